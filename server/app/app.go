@@ -0,0 +1,304 @@
+// Package app wires the services, handlers and routes of the server. Every
+// dependency outside of the process is passed in, so main runs it on
+// firebase, mongo, redis and the object store while the tests run the same
+// routes on in-memory fakes.
+package app
+
+import (
+	"server/handler"
+	"server/job"
+	"server/model"
+	"server/repository"
+	"server/service"
+	"server/ws-handler"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+type Repositories struct {
+	User            repository.UserRepository
+	Stock           repository.StockRepository
+	Ledger          repository.LedgerRepository
+	Payment         repository.PaymentRepository
+	CorporateAction repository.CorporateActionRepository
+	Dividend        repository.DividendRepository
+	QueuedOrder     repository.QueuedOrderRepository
+}
+
+type Dependencies struct {
+	Authenticator   handler.Authenticator
+	ObjectStore     service.ObjectStore
+	Cache           service.Cache
+	Repositories    Repositories
+	FxRateProvider  service.FxRateProvider
+	PaymentProvider service.PaymentProvider
+	FeeSchedule     model.FeeSchedule
+	PaymentLimit    model.PaymentLimit
+	MarginConfig    model.MarginConfig
+	MarketCalendar  model.MarketCalendar
+	AdminUids       []string
+}
+
+type App struct {
+	Router *gin.Engine
+	Hub    *wshandler.Hub
+
+	cache                  service.Cache
+	marginConfig           model.MarginConfig
+	stockStatusService     service.StockStatusService
+	marginService          service.MarginService
+	corporateActionService service.CorporateActionService
+	dividendService        service.DividendService
+	marketSessionService   service.MarketSessionService
+}
+
+func New(deps Dependencies) *App {
+	repos := deps.Repositories
+
+	userService := service.NewUserService(
+		repos.User,
+		repos.Stock,
+		repos.Ledger,
+		deps.FxRateProvider,
+		deps.FeeSchedule,
+		deps.Cache,
+	)
+	stockService := service.NewStockService(repos.Stock, deps.Cache, deps.ObjectStore)
+	profileService := service.NewProfileService(repos.User, deps.ObjectStore, deps.Cache)
+	stockStatusService := service.NewStockStatusService(
+		repos.Stock,
+		repos.User,
+		repos.Ledger,
+		deps.Cache,
+	)
+	ledgerService := service.NewLedgerService(repos.Ledger, repos.User)
+	paymentService := service.NewPaymentService(
+		repos.Payment,
+		userService,
+		deps.PaymentProvider,
+		deps.FxRateProvider,
+		deps.PaymentLimit,
+	)
+	corporateActionService := service.NewCorporateActionService(
+		repos.CorporateAction,
+		repos.Stock,
+		repos.User,
+		repos.QueuedOrder,
+		repos.Ledger,
+		deps.Cache,
+	)
+	marketSessionService := service.NewMarketSessionService(
+		repos.QueuedOrder,
+		repos.Stock,
+		userService,
+		deps.MarketCalendar,
+		deps.Cache,
+	)
+
+	sessionUserService := service.NewSessionUserService(userService, marketSessionService)
+
+	// orders placed for the user by the jobs follow the market session as well
+	marginService := service.NewMarginService(
+		repos.User,
+		repos.Stock,
+		repos.Ledger,
+		marketSessionService,
+		deps.FxRateProvider,
+		deps.MarginConfig.CallGrace,
+		deps.Cache,
+	)
+	dividendService := service.NewDividendService(
+		repos.Dividend,
+		repos.Stock,
+		repos.User,
+		repos.Ledger,
+		sessionUserService,
+		deps.Cache,
+	)
+
+	userHandler := handler.NewUserHandler(sessionUserService, stockService)
+	stockHandler := handler.NewStockHandler(stockService)
+	stockStatusHandler := handler.NewStockStatusHandler(stockStatusService)
+	ledgerHandler := handler.NewLedgerHandler(ledgerService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	marginHandler := handler.NewMarginHandler(marginService)
+	corporateActionHandler := handler.NewCorporateActionHandler(corporateActionService)
+	dividendHandler := handler.NewDividendHandler(dividendService)
+	marketSessionHandler := handler.NewMarketSessionHandler(marketSessionService)
+	imageHandler := handler.NewImageHandler(deps.ObjectStore)
+	profileHandler := handler.NewProfileHandler(profileService)
+	stockWebsocket := wshandler.NewStockWebsocket(stockService)
+
+	hub := wshandler.NewHub()
+	router := gin.Default()
+
+	router.Use(cors.Default())
+	router.Use(gin.Logger())
+
+	// images are loaded by the browser and callbacks are sent by the payment
+	// provider, neither of them carry the credentials of a user
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "ok",
+		})
+	})
+	router.GET("/api/v1/image/*key", imageHandler.GetImage)
+	router.POST("/api/v1/payment/callback", paymentHandler.HandleCallback)
+
+	router.Use(handler.Authenticate(deps.Authenticator))
+
+	apiV1 := router.Group("/api/v1")
+	adminOnly := handler.AdminOnly(deps.AdminUids)
+
+	userGroup := apiV1.Group("/user")
+	stockGroup := apiV1.Group("/stock")
+	stockAdminGroup := stockGroup.Group("/admin", adminOnly)
+	ledgerGroup := apiV1.Group("/ledger")
+	paymentGroup := apiV1.Group("/payment")
+	paymentAdminGroup := paymentGroup.Group("/admin", adminOnly)
+	marginGroup := apiV1.Group("/margin")
+	marginAdminGroup := marginGroup.Group("/admin", adminOnly)
+	corporateActionGroup := apiV1.Group("/corporate-action")
+	corporateActionAdminGroup := corporateActionGroup.Group("/admin", adminOnly)
+	dividendGroup := apiV1.Group("/dividend")
+	dividendAdminGroup := dividendGroup.Group("/admin", adminOnly)
+	marketGroup := apiV1.Group("/market")
+
+	websocketGroup := router.Group("/ws/v1")
+
+	websocketGroup.GET("/price", func(c *gin.Context) {
+		stockWebsocket.ServePriceWs(hub, c.Writer, c.Request)
+	})
+	websocketGroup.GET("/transaction", func(c *gin.Context) {
+		stockWebsocket.ServeTransactionWs(hub, c.Writer, c.Request)
+	})
+	websocketGroup.GET("/graph", func(c *gin.Context) {
+		stockWebsocket.ServeGraphWs(hub, c.Writer, c.Request)
+	})
+
+	userGroup.POST("/signup", userHandler.SignUp)
+	userGroup.POST("/buy", userHandler.BuyStock)
+	userGroup.POST("/sale", userHandler.SaleStock)
+	userGroup.POST("/set-favorite", userHandler.SetFavoriteStock)
+	userGroup.GET("/balance-transaction", userHandler.GetUserBalanceHistory)
+	userGroup.GET("/balance", userHandler.GetUserBalance)
+	userGroup.GET("/balances", userHandler.GetUserCurrencyBalances)
+	userGroup.GET("/get-favorite", userHandler.GetUserFavoriteStock)
+	userGroup.POST("/signin", userHandler.SignIn)
+	userGroup.GET("/trade-transaction", userHandler.GetUserTradingHistories)
+	userGroup.GET("/stock-transaction", userHandler.GetUserStockHistory)
+	userGroup.GET("/stock-ratio", userHandler.GetUserStockAmount)
+	userGroup.GET("/fee-summary", userHandler.GetUserFeeSummary)
+	userGroup.GET("/portfolio", userHandler.GetUserPortfolio)
+	userGroup.POST("/edit-profile", profileHandler.EditProfile)
+	userGroup.POST("/edit-avatar", profileHandler.EditAvatar)
+	userGroup.DELETE("/delete-favorite", userHandler.DeleteFavoriteStock)
+	userGroup.DELETE("/delete-account", userHandler.DeleteUserAccount)
+
+	stockGroup.GET("/collections", stockHandler.GetAllStockCollections)
+	stockGroup.GET("/top-stocks", stockHandler.GetTop10Stocks)
+	stockGroup.GET("/collection/:stockId", stockHandler.GetStockCollection)
+	stockGroup.GET("/transaction/:stockId", stockHandler.GetStockHistory)
+	stockGroup.GET("/price/:stockId", stockHandler.GetStockPrice)
+	stockGroup.GET("/graph/:stockId", stockHandler.GetStockGraph)
+	stockGroup.GET("/trading-rule/:stockId", stockHandler.GetStockTradingRule)
+	stockAdminGroup.POST("/create-stock", stockHandler.CreateStockCollection)
+	stockAdminGroup.POST("/set-price/:stockId", stockHandler.SetStockPrice)
+	stockAdminGroup.POST("/set-trading-rule/:stockId", stockHandler.SetStockTradingRule)
+	stockAdminGroup.POST("/edit-name/:stockId", stockHandler.EditStockName)
+	stockAdminGroup.POST("/edit-sign/:stockId", corporateActionHandler.EditStockSign)
+	stockAdminGroup.POST("/edit-image/:stockId", stockHandler.EditStockImage)
+	stockAdminGroup.POST("/set-status/:stockId", stockStatusHandler.SetStockStatus)
+	stockAdminGroup.POST("/delist/:stockId", stockStatusHandler.DelistStock)
+
+	ledgerGroup.GET("/transaction", ledgerHandler.GetUserLedger)
+
+	paymentGroup.POST("/deposit", paymentHandler.RequestDeposit)
+	paymentGroup.POST("/withdraw", paymentHandler.RequestWithdraw)
+	paymentGroup.GET("/transaction", paymentHandler.GetUserPayments)
+	paymentGroup.GET("/detail/:paymentId", paymentHandler.GetPayment)
+	paymentAdminGroup.GET("/pending-approval", paymentHandler.GetPendingApprovals)
+	paymentAdminGroup.POST("/approve/:paymentId", paymentHandler.ApprovePayment)
+	paymentAdminGroup.POST("/reject/:paymentId", paymentHandler.RejectPayment)
+
+	marginGroup.GET("/summary", marginHandler.GetMarginSummary)
+	marginAdminGroup.POST("/set-enabled/:uid", marginHandler.SetMarginEnabled)
+
+	corporateActionGroup.GET("/stock/:stockId", corporateActionHandler.GetStockCorporateActions)
+	corporateActionAdminGroup.POST("/create/:stockId", corporateActionHandler.CreateCorporateAction)
+	corporateActionAdminGroup.POST("/cancel/:actionId", corporateActionHandler.CancelCorporateAction)
+
+	dividendGroup.GET("/stock/:stockId", dividendHandler.GetStockDividends)
+	dividendGroup.POST("/reinvest", dividendHandler.SetDividendReinvest)
+	dividendAdminGroup.POST("/declare/:stockId", dividendHandler.DeclareDividend)
+	dividendAdminGroup.POST("/cancel/:dividendId", dividendHandler.CancelDividend)
+
+	marketGroup.GET("/session/:stockId", marketSessionHandler.GetMarketSession)
+	marketGroup.GET("/queued-order", marketSessionHandler.GetUserQueuedOrders)
+	marketGroup.POST("/cancel-order/:orderId", marketSessionHandler.CancelQueuedOrder)
+
+	return &App{
+		Router: router,
+		Hub:    hub,
+
+		cache:                  deps.Cache,
+		marginConfig:           deps.MarginConfig,
+		stockStatusService:     stockStatusService,
+		marginService:          marginService,
+		corporateActionService: corporateActionService,
+		dividendService:        dividendService,
+		marketSessionService:   marketSessionService,
+	}
+}
+
+// the websocket hub and the stock events it forwards run until the process
+// exits
+func (a *App) Start() {
+	go a.Hub.Run()
+	go a.Hub.SubscribeStockEvents(a.cache)
+}
+
+// the scheduled jobs run until stop is called
+func (a *App) StartJobs() (stop func()) {
+	stops := []chan struct{}{
+		job.Every("circuit-breaker", 10*time.Second, func() error {
+			_, err := a.stockStatusService.ResumeHaltedStocks()
+			return err
+		}),
+		job.Every("margin-call", a.marginConfig.CheckInterval, func() error {
+			_, err := a.marginService.CheckMarginCalls()
+			return err
+		}),
+		job.Every("borrow-fee", 24*time.Hour, func() error {
+			_, err := a.marginService.AccrueBorrowFees()
+			return err
+		}),
+		job.Every("corporate-action", time.Minute, func() error {
+			_, err := a.corporateActionService.ApplyDueCorporateActions()
+			return err
+		}),
+		job.Every("dividend", time.Minute, func() error {
+			_, err := a.dividendService.ProcessDueDividends()
+			return err
+		}),
+		// the call auction of a session change is uncrossed before the
+		// queued orders of the continuous session are sent
+		job.Every("market-session", 10*time.Second, func() error {
+			_, err := a.marketSessionService.PublishSessionChanges()
+			if err != nil {
+				return err
+			}
+
+			_, err = a.marketSessionService.ProcessQueuedOrders()
+			return err
+		}),
+	}
+
+	return func() {
+		for _, stop := range stops {
+			close(stop)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"server/app"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/service"
+	"server/util"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the uid is the bearer token
+type bearerAuthenticator struct{}
+
+func (bearerAuthenticator) Authenticate(r *http.Request) (string, error) {
+	uid, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", errs.ErrUnauthorized
+	}
+
+	return uid, nil
+}
+
+type ledgerRepositoryFake struct {
+	mu           sync.Mutex
+	transactions []model.LedgerTransaction
+}
+
+func (r *ledgerRepositoryFake) Append(transaction model.LedgerTransaction) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.transactions = append(r.transactions, transaction)
+
+	return "Successfully appended ledger transaction", nil
+}
+
+func (r *ledgerRepositoryFake) GetTransactions(userId string, skip uint) ([]model.LedgerTransaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transactions := []model.LedgerTransaction{}
+	for _, transaction := range r.transactions {
+		if transaction.UID == userId {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	return transactions, nil
+}
+
+func (r *ledgerRepositoryFake) GetBalances() (map[string]float64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	balances := map[string]float64{}
+	for _, transaction := range r.transactions {
+		for _, entry := range transaction.Entries {
+			balances[entry.Account] += entry.Credit - entry.Debit
+		}
+	}
+
+	return balances, nil
+}
+
+type paymentRepositoryFake struct {
+	mu       sync.Mutex
+	payments map[string]model.Payment
+}
+
+func (r *paymentRepositoryFake) Create(payment model.Payment) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.payments[payment.ID.Hex()] = payment
+
+	return "Successfully created payment", nil
+}
+
+func (r *paymentRepositoryFake) Get(paymentId string) (model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment, ok := r.payments[paymentId]
+	if !ok {
+		return model.Payment{}, errs.ErrPayment
+	}
+
+	return payment, nil
+}
+
+func (r *paymentRepositoryFake) GetUserPayments(userId string, skip uint) ([]model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payments := []model.Payment{}
+	for _, payment := range r.payments {
+		if payment.UID == userId {
+			payments = append(payments, payment)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].CreatedAt > payments[j].CreatedAt
+	})
+
+	return payments, nil
+}
+
+func (r *paymentRepositoryFake) GetPendingApprovals() ([]model.Payment, error) {
+	return []model.Payment{}, nil
+}
+
+func (r *paymentRepositoryFake) GetDailyTotal(userId string, method string, since int64) (float64, error) {
+	return 0, nil
+}
+
+func (r *paymentRepositoryFake) UpdateStatus(paymentId string, from string, event model.PaymentEvent) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment, ok := r.payments[paymentId]
+	if !ok || payment.Status != from || !util.CanTransitPayment(from, event.Status) {
+		return "", errs.ErrPaymentStatus
+	}
+
+	payment.Status = event.Status
+	payment.History = append(payment.History, event)
+	r.payments[paymentId] = payment
+
+	return "Successfully updated payment status", nil
+}
+
+func (r *paymentRepositoryFake) SetReference(paymentId string, reference string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	payment, ok := r.payments[paymentId]
+	if !ok {
+		return "", errs.ErrPayment
+	}
+
+	payment.Reference = reference
+	r.payments[paymentId] = payment
+
+	return "Successfully set payment reference", nil
+}
+
+const adminUid = "e2e-admin"
+
+// the server is started before the app is built so the payment provider
+// can call back to its address
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	server := httptest.NewUnstartedServer(nil)
+	url := "http://" + server.Listener.Addr().String()
+
+	a := app.New(app.Dependencies{
+		Authenticator: bearerAuthenticator{},
+		ObjectStore:   service.NewMemoryObjectStore(url + "/api/v1/image"),
+		Cache:         service.NewMemoryCache(1000),
+		Repositories: app.Repositories{
+			User:            repository.NewUserRepositoryMemory(),
+			Stock:           repository.NewStockRepositoryMemory(),
+			Ledger:          &ledgerRepositoryFake{},
+			Payment:         &paymentRepositoryFake{payments: map[string]model.Payment{}},
+			CorporateAction: repository.NewCorporateActionRepositoryDBMock(),
+			Dividend:        repository.NewDividendRepositoryDBMock(),
+			QueuedOrder:     repository.NewQueuedOrderRepositoryDBMock(),
+		},
+		FxRateProvider:  service.NewStaticFxRateProvider(service.FxRates{}),
+		PaymentProvider: service.NewLocalPaymentProvider(url+"/api/v1/payment/callback", "secret", 0),
+		MarginConfig:    model.MarginConfig{CheckInterval: time.Minute},
+		AdminUids:       []string{adminUid},
+	})
+	a.Start()
+
+	server.Config.Handler = a.Router
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+type client struct {
+	t      *testing.T
+	server *httptest.Server
+	uid    string
+}
+
+func (c client) do(method string, path string, contentType string, body io.Reader) (int, map[string]interface{}) {
+	c.t.Helper()
+
+	req, err := http.NewRequest(method, c.server.URL+path, body)
+	require.NoError(c.t, err)
+
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if len(c.uid) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.uid)
+	}
+
+	res, err := c.server.Client().Do(req)
+	require.NoError(c.t, err)
+	defer res.Body.Close()
+
+	var resBody map[string]interface{}
+	require.NoError(c.t, json.NewDecoder(res.Body).Decode(&resBody))
+
+	return res.StatusCode, resBody
+}
+
+func (c client) get(path string) map[string]interface{} {
+	c.t.Helper()
+
+	status, resBody := c.do("GET", path, "", nil)
+	require.Equal(c.t, 200, status, resBody)
+
+	return resBody
+}
+
+func (c client) post(path string, body interface{}) map[string]interface{} {
+	c.t.Helper()
+
+	reqBody, err := json.Marshal(body)
+	require.NoError(c.t, err)
+
+	status, resBody := c.do("POST", path, "application/json", bytes.NewReader(reqBody))
+	require.Equal(c.t, 200, status, resBody)
+
+	return resBody
+}
+
+func (c client) createStock(name string, sign string, price float64) {
+	c.t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("name", name)
+	writer.WriteField("sign", sign)
+	writer.WriteField("price", fmt.Sprint(price))
+
+	file, err := writer.CreateFormFile("stock_image", "stock.png")
+	require.NoError(c.t, err)
+	require.NoError(c.t, png.Encode(file, image.NewRGBA(image.Rect(0, 0, model.MinImageDimension, model.MinImageDimension))))
+	require.NoError(c.t, writer.Close())
+
+	status, resBody := c.do("POST", "/api/v1/stock/admin/create-stock", writer.FormDataContentType(), &body)
+	require.Equal(c.t, 200, status, resBody)
+}
+
+func (c client) balance() float64 {
+	c.t.Helper()
+
+	return c.get("/api/v1/user/balance")["balance"].(float64)
+}
+
+func TestTradingFlow(t *testing.T) {
+	server := newTestServer(t)
+	user := client{t, server, "e2e-user"}
+	admin := client{t, server, adminUid}
+
+	user.post("/api/v1/user/signup", model.CreateAccount{
+		UID:          user.uid,
+		Name:         "e2e",
+		ProfileImage: "https://example.com/e2e.png",
+		Email:        "e2e@example.com",
+	})
+
+	admin.createStock("End To End", "E2E", 100)
+	stocks := user.get("/api/v1/stock/collections")["stocks"].([]interface{})
+	require.Len(t, stocks, 1)
+	stock := stocks[0].(map[string]interface{})
+	stockId := stock["id"].(string)
+
+	// the stock image is served by the app itself
+	images := stock["images"].(map[string]interface{})
+	require.NotEmpty(t, images)
+	res, err := server.Client().Get(images["64"].(string))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "image/png", res.Header.Get("Content-Type"))
+
+	// the deposit is credited by the callback of the payment provider
+	payment := user.post("/api/v1/payment/deposit", model.PaymentRequest{Amount: 1000})["payment"].(map[string]interface{})
+	assert.Equal(t, model.PaymentPending, payment["status"])
+	require.Eventually(t, func() bool {
+		return user.balance() == 1000
+	}, 5*time.Second, 20*time.Millisecond)
+
+	user.post("/api/v1/user/buy", model.OrderRequest{
+		StockId:     stockId,
+		Price:       100,
+		Amount:      3,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	})
+	assert.Equal(t, 700.0, user.balance())
+
+	user.post("/api/v1/user/sale", model.OrderRequest{
+		StockId:     stockId,
+		Price:       100,
+		Amount:      1,
+		OrderType:   "auto",
+		OrderMethod: "sale",
+	})
+	assert.Equal(t, 800.0, user.balance())
+
+	ratio := user.get("/api/v1/user/stock-ratio?stockId=" + stockId)
+	assert.Equal(t, 2.0, ratio["stockRatio"].(map[string]interface{})["amount"])
+
+	transactions := user.get("/api/v1/user/trade-transaction?startPage=0")["transactions"].([]interface{})
+	require.Len(t, transactions, 2)
+	methods := []string{}
+	for _, transaction := range transactions {
+		methods = append(methods, transaction.(map[string]interface{})["orderMethod"].(string))
+	}
+	assert.ElementsMatch(t, []string{"buy", "sale"}, methods)
+
+	payments := user.get("/api/v1/payment/transaction?startPage=0")["payments"].([]interface{})
+	require.Len(t, payments, 1)
+	assert.Equal(t, model.PaymentCompleted, payments[0].(map[string]interface{})["status"])
+
+	// a message sent to the room is answered with the trades of the stock
+	wsUrl := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/v1/transaction?stockId=" + stockId
+	conn, _, err := websocket.DefaultDialer.Dial(wsUrl, http.Header{"Authorization": {"Bearer " + user.uid}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("trades")))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var message struct {
+		Room string                       `json:"room"`
+		Tx   []model.StockHistoryResponse `json:"tx"`
+	}
+	require.NoError(t, conn.ReadJSON(&message))
+	assert.Equal(t, stockId, message.Room)
+	assert.ElementsMatch(t, []model.StockHistoryResponse{
+		{Amount: 3, Price: 100},
+		{Amount: 1, Price: 100},
+	}, message.Tx)
+}
+
+func TestUnauthorized(t *testing.T) {
+	server := newTestServer(t)
+	anonymous := client{t, server, ""}
+
+	status, resBody := anonymous.do("GET", "/api/v1/user/balance", "", nil)
+	assert.Equal(t, 401, status)
+	assert.Equal(t, errs.ErrUnauthorized.Error(), resBody["message"])
+
+	_, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(server.URL, "http")+"/ws/v1/transaction?stockId=1",
+		nil,
+	)
+	assert.ErrorIs(t, err, websocket.ErrBadHandshake)
+
+	// stocks are only changed by admins
+	user := client{t, server, "e2e-user"}
+	status, resBody = user.do("POST", "/api/v1/stock/admin/set-price/1", "application/json", strings.NewReader(`{"price": 1}`))
+	assert.Equal(t, 403, status)
+	assert.Equal(t, errs.ErrAdmin.Error(), resBody["message"])
+
+	status, resBody = anonymous.do("GET", "/", "", nil)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, "ok", resBody["message"])
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"server/config"
+	"server/repository"
+	"server/service"
+)
+
+// recompute user balances from the ledger and report every account that
+// does not match, exit with status 1 when a mismatch is found
+func main() {
+	cfg, err := config.Load(os.Args[1:], os.Environ())
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := repository.InitMongoDB(cfg.Mongo.URI)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db := client.Database(cfg.Mongo.Database)
+	userCollection := db.Collection(cfg.Mongo.Collections.User)
+	ledgerCollection := db.Collection(cfg.Mongo.Collections.Ledger)
+
+	userRepositoryDB := repository.NewUserRepositoryDB(userCollection)
+	ledgerRepositoryDB := repository.NewLedgerRepositoryDB(ledgerCollection)
+	ledgerService := service.NewLedgerService(ledgerRepositoryDB, userRepositoryDB)
+
+	mismatches, err := ledgerService.Reconcile()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(mismatches) == 0 {
+		fmt.Println("all balances match the ledger")
+		return
+	}
+
+	fmt.Printf("%-30s %-8s %15s %15s %15s\n", "uid", "currency", "balance", "ledger", "difference")
+	for _, mismatch := range mismatches {
+		fmt.Printf(
+			"%-30s %-8s %15.2f %15.2f %15.2f\n",
+			mismatch.UID,
+			mismatch.Currency,
+			mismatch.Balance,
+			mismatch.LedgerBalance,
+			mismatch.Difference,
+		)
+	}
+
+	os.Exit(1)
+}
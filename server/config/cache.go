@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"server/model"
+	"strconv"
+	"time"
+)
+
+// redis on localhost is used by default and the in-process cache takes over
+// while it is down, CACHE=memory runs without redis
+func LoadCacheConfig(getenv func(string) string) (model.CacheConfig, error) {
+	cacheConfig := model.CacheConfig{
+		Backend: getenv("CACHE"),
+		Redis: model.RedisConfig{
+			Addr:     getenv("REDIS_ADDR"),
+			Password: getenv("REDIS_PASSWORD"),
+		},
+		Size:          10000,
+		RetryInterval: 5 * time.Second,
+	}
+
+	if len(cacheConfig.Backend) == 0 {
+		cacheConfig.Backend = model.CacheRedis
+	}
+
+	if len(cacheConfig.Redis.Addr) == 0 {
+		cacheConfig.Redis.Addr = "localhost:6379"
+	}
+
+	integers := map[string]*int{
+		"REDIS_DB":   &cacheConfig.Redis.DB,
+		"CACHE_SIZE": &cacheConfig.Size,
+	}
+	for key, integer := range integers {
+		value := getenv(key)
+		if len(value) == 0 {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return model.CacheConfig{}, fmt.Errorf("error parsing %s: %v", key, err)
+		}
+
+		*integer = parsed
+	}
+
+	value := getenv("CACHE_RETRY_INTERVAL")
+	if len(value) > 0 {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return model.CacheConfig{}, fmt.Errorf("error parsing CACHE_RETRY_INTERVAL: %v", err)
+		}
+
+		cacheConfig.RetryInterval = parsed
+	}
+
+	switch cacheConfig.Backend {
+	case model.CacheRedis, model.CacheMemory:
+	default:
+		return model.CacheConfig{}, fmt.Errorf("error parsing CACHE: unknown backend %s", cacheConfig.Backend)
+	}
+
+	if cacheConfig.Size <= 0 {
+		return model.CacheConfig{}, fmt.Errorf("error parsing CACHE_SIZE: must be positive")
+	}
+
+	if cacheConfig.RetryInterval <= 0 {
+		return model.CacheConfig{}, fmt.Errorf("error parsing CACHE_RETRY_INTERVAL: must be positive")
+	}
+
+	return cacheConfig, nil
+}
@@ -0,0 +1,466 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"server/model"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// every variable the server reads, a variable of the env file that is not
+// here is an error, one of the environment that looks like a typo of one of
+// them is only logged since the environment is shared with everything else
+var variables = []string{
+	"APP_PROFILE",
+	"CONFIG_FILE",
+	"PORT",
+	"TIMEZONE",
+	"MONGO_URI",
+	"MONGO_DATABASE",
+	"MONGO_COLLECTION_USER",
+	"MONGO_COLLECTION_STOCK",
+	"MONGO_COLLECTION_LEDGER",
+	"MONGO_COLLECTION_PAYMENT",
+	"MONGO_COLLECTION_CORPORATE_ACTION",
+	"MONGO_COLLECTION_DIVIDEND",
+	"MONGO_COLLECTION_QUEUED_ORDER",
+	"FIREBASE_PROJECT_ID",
+	"FIREBASE_PRIVATE_KEY_ID",
+	"FIREBASE_PRIVATE_KEY",
+	"FIREBASE_CLIENT_EMAIL",
+	"FIREBASE_CLIENT_ID",
+	"FIREBASE_CERT_URL",
+	"CACHE",
+	"CACHE_SIZE",
+	"CACHE_RETRY_INTERVAL",
+	"REDIS_ADDR",
+	"REDIS_PASSWORD",
+	"REDIS_DB",
+	"OBJECT_STORE",
+	"OBJECT_STORE_DIR",
+	"OBJECT_STORE_URL",
+	"GOOGLE_STORAGE_PROJECT_ID",
+	"GOOGLE_STORAGE_BUCKET_NAME",
+	"GOOGLE_STORAGE_FOLDER",
+	"GOOGLE_STORAGE_PRIVATE_KEY_ID",
+	"GOOGLE_STORAGE_PRIVATE_KEY",
+	"GOOGLE_STORAGE_CLIENT_EMAIL",
+	"GOOGLE_STORAGE_CLIENT_ID",
+	"GOOGLE_STORAGE_CERT_URI",
+	"PAYMENT_PROVIDER",
+	"PAYMENT_CALLBACK_URL",
+	"PAYMENT_WEBHOOK_SECRET",
+	"DAILY_DEPOSIT_LIMIT",
+	"DAILY_WITHDRAW_LIMIT",
+	"WITHDRAW_APPROVAL_THRESHOLD",
+	"MARGIN_CHECK_INTERVAL",
+	"MARGIN_CALL_GRACE",
+	"ADMIN_UIDS",
+	"FEE_SCHEDULE_FILE",
+	"FX_RATE_FILE",
+	"MARKET_CALENDAR_FILE",
+}
+
+var secrets = []string{
+	"FIREBASE_PRIVATE_KEY_ID",
+	"FIREBASE_PRIVATE_KEY",
+	"REDIS_PASSWORD",
+	"GOOGLE_STORAGE_PRIVATE_KEY_ID",
+	"GOOGLE_STORAGE_PRIVATE_KEY",
+	"PAYMENT_WEBHOOK_SECRET",
+}
+
+var localDefaults = map[string]string{
+	"PORT":                              "4000",
+	"TIMEZONE":                          "Asia/Bangkok",
+	"MONGO_URI":                         "mongodb://localhost:27017",
+	"MONGO_DATABASE":                    "trading-system",
+	"MONGO_COLLECTION_USER":             "user",
+	"MONGO_COLLECTION_STOCK":            "stock",
+	"MONGO_COLLECTION_LEDGER":           "ledger",
+	"MONGO_COLLECTION_PAYMENT":          "payment",
+	"MONGO_COLLECTION_CORPORATE_ACTION": "corporate_action",
+	"MONGO_COLLECTION_DIVIDEND":         "dividend",
+	"MONGO_COLLECTION_QUEUED_ORDER":     "queued_order",
+	"PAYMENT_PROVIDER":                  model.PaymentProviderLocal,
+}
+
+// dev runs on a local mongo and redis, test keeps the cache and the images in
+// the process and prod has no default for anything it connects to
+var profiles = map[string]map[string]string{
+	model.ProfileDev: localDefaults,
+	model.ProfileTest: withDefaults(localDefaults, map[string]string{
+		"MONGO_DATABASE": "trading-system-test",
+		"CACHE":          model.CacheMemory,
+		"OBJECT_STORE":   model.ObjectStoreMemory,
+	}),
+	model.ProfileProd: {
+		"PORT":     "4000",
+		"TIMEZONE": "Asia/Bangkok",
+	},
+}
+
+var required = []string{
+	"MONGO_URI",
+	"MONGO_DATABASE",
+	"MONGO_COLLECTION_USER",
+	"MONGO_COLLECTION_STOCK",
+	"MONGO_COLLECTION_LEDGER",
+	"MONGO_COLLECTION_PAYMENT",
+	"MONGO_COLLECTION_CORPORATE_ACTION",
+	"MONGO_COLLECTION_DIVIDEND",
+	"MONGO_COLLECTION_QUEUED_ORDER",
+}
+
+var requiredInProd = []string{
+	"FIREBASE_PROJECT_ID",
+	"FIREBASE_PRIVATE_KEY_ID",
+	"FIREBASE_PRIVATE_KEY",
+	"FIREBASE_CLIENT_EMAIL",
+	"PAYMENT_WEBHOOK_SECRET",
+}
+
+// the defaults of the profile are overridden by the env file, the env file
+// by the environment and the environment by the flags, an empty value is
+// the same as an unset one. the env file is .env unless -config or
+// CONFIG_FILE names another one, only .env may be missing
+func Load(args []string, environ []string) (model.Config, error) {
+	env := map[string]string{}
+	for _, variable := range environ {
+		key, value, _ := strings.Cut(variable, "=")
+		env[key] = value
+	}
+
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	profileFlag := flags.String("profile", "", "dev, test or prod, APP_PROFILE when empty")
+	fileFlag := flags.String("config", "", "env file, CONFIG_FILE or .env when empty")
+	portFlag := flags.String("port", "", "port to listen on, PORT when empty")
+	timezoneFlag := flags.String("timezone", "", "time zone of the server, TIMEZONE when empty")
+	if err := flags.Parse(args); err != nil {
+		return model.Config{}, err
+	}
+
+	file := firstOf(*fileFlag, env["CONFIG_FILE"])
+	fileValues, err := readEnvFile(file)
+	if err != nil {
+		return model.Config{}, err
+	}
+
+	for _, key := range sortedKeys(fileValues) {
+		if !known(key) {
+			return model.Config{}, fmt.Errorf("error parsing %s: %s", firstOf(file, ".env"), unknownVariable(key))
+		}
+	}
+
+	for _, key := range sortedKeys(env) {
+		if !known(key) && len(suggest(key)) > 0 {
+			log.Printf("config environment: %s", unknownVariable(key))
+		}
+	}
+
+	profile := firstOf(*profileFlag, env["APP_PROFILE"], fileValues["APP_PROFILE"], model.ProfileDev)
+	defaults, ok := profiles[profile]
+	if !ok {
+		return model.Config{}, fmt.Errorf("error parsing APP_PROFILE: unknown profile %s", profile)
+	}
+
+	values := withDefaults(defaults, fileValues, env, map[string]string{
+		"PORT":     *portFlag,
+		"TIMEZONE": *timezoneFlag,
+	})
+	getenv := func(key string) string {
+		return values[key]
+	}
+
+	missing := []string{}
+	requiredVariables := slices.Clone(required)
+	if profile == model.ProfileProd {
+		requiredVariables = append(requiredVariables, requiredInProd...)
+	}
+	for _, key := range requiredVariables {
+		if len(getenv(key)) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return model.Config{}, fmt.Errorf("missing required variables of profile %s: %s", profile, strings.Join(missing, ", "))
+	}
+
+	config := model.Config{
+		Profile:  profile,
+		Timezone: getenv("TIMEZONE"),
+		Mongo: model.MongoConfig{
+			URI:      getenv("MONGO_URI"),
+			Database: getenv("MONGO_DATABASE"),
+			Collections: model.MongoCollections{
+				User:            getenv("MONGO_COLLECTION_USER"),
+				Stock:           getenv("MONGO_COLLECTION_STOCK"),
+				Ledger:          getenv("MONGO_COLLECTION_LEDGER"),
+				Payment:         getenv("MONGO_COLLECTION_PAYMENT"),
+				CorporateAction: getenv("MONGO_COLLECTION_CORPORATE_ACTION"),
+				Dividend:        getenv("MONGO_COLLECTION_DIVIDEND"),
+				QueuedOrder:     getenv("MONGO_COLLECTION_QUEUED_ORDER"),
+			},
+		},
+		Firebase: model.FirebaseConfig{
+			ProjectID:    getenv("FIREBASE_PROJECT_ID"),
+			PrivateKeyID: getenv("FIREBASE_PRIVATE_KEY_ID"),
+			PrivateKey:   getenv("FIREBASE_PRIVATE_KEY"),
+			ClientEmail:  getenv("FIREBASE_CLIENT_EMAIL"),
+			ClientID:     getenv("FIREBASE_CLIENT_ID"),
+			CertURL:      getenv("FIREBASE_CERT_URL"),
+		},
+		Payment: model.PaymentConfig{
+			Provider:      getenv("PAYMENT_PROVIDER"),
+			CallbackURL:   getenv("PAYMENT_CALLBACK_URL"),
+			WebhookSecret: getenv("PAYMENT_WEBHOOK_SECRET"),
+		},
+		AdminUids:          splitList(getenv("ADMIN_UIDS")),
+		FeeScheduleFile:    getenv("FEE_SCHEDULE_FILE"),
+		FxRateFile:         getenv("FX_RATE_FILE"),
+		MarketCalendarFile: getenv("MARKET_CALENDAR_FILE"),
+	}
+
+	config.Port, err = strconv.Atoi(getenv("PORT"))
+	if err != nil {
+		return model.Config{}, fmt.Errorf("error parsing PORT: %v", err)
+	}
+
+	if config.Port <= 0 || config.Port > 65535 {
+		return model.Config{}, fmt.Errorf("error parsing PORT: must be between 1 and 65535")
+	}
+
+	if len(config.Timezone) > 0 {
+		if _, err := time.LoadLocation(config.Timezone); err != nil {
+			return model.Config{}, fmt.Errorf("error parsing TIMEZONE: %v", err)
+		}
+	}
+
+	config.Cache, err = LoadCacheConfig(getenv)
+	if err != nil {
+		return model.Config{}, err
+	}
+
+	config.ObjectStore, err = LoadObjectStoreConfig(getenv)
+	if err != nil {
+		return model.Config{}, err
+	}
+
+	config.Payment.Provider, err = loadPaymentProvider(config.Payment.Provider, profile)
+	if err != nil {
+		return model.Config{}, err
+	}
+
+	config.Payment.Limit, err = LoadPaymentLimit(getenv)
+	if err != nil {
+		return model.Config{}, err
+	}
+
+	config.Margin, err = LoadMarginConfig(getenv)
+	if err != nil {
+		return model.Config{}, err
+	}
+
+	return config, nil
+}
+
+// one variable a line with the secrets and the password of the mongo uri
+// left out, for the startup log
+func Format(config model.Config) string {
+	mongoURI := config.Mongo.URI
+	if parsed, err := url.Parse(mongoURI); err == nil {
+		mongoURI = parsed.Redacted()
+	}
+
+	lines := [][2]string{
+		{"APP_PROFILE", config.Profile},
+		{"PORT", strconv.Itoa(config.Port)},
+		{"TIMEZONE", config.Timezone},
+		{"MONGO_URI", mongoURI},
+		{"MONGO_DATABASE", config.Mongo.Database},
+		{"MONGO_COLLECTION_USER", config.Mongo.Collections.User},
+		{"MONGO_COLLECTION_STOCK", config.Mongo.Collections.Stock},
+		{"MONGO_COLLECTION_LEDGER", config.Mongo.Collections.Ledger},
+		{"MONGO_COLLECTION_PAYMENT", config.Mongo.Collections.Payment},
+		{"MONGO_COLLECTION_CORPORATE_ACTION", config.Mongo.Collections.CorporateAction},
+		{"MONGO_COLLECTION_DIVIDEND", config.Mongo.Collections.Dividend},
+		{"MONGO_COLLECTION_QUEUED_ORDER", config.Mongo.Collections.QueuedOrder},
+		{"FIREBASE_PROJECT_ID", config.Firebase.ProjectID},
+		{"FIREBASE_PRIVATE_KEY_ID", config.Firebase.PrivateKeyID},
+		{"FIREBASE_PRIVATE_KEY", config.Firebase.PrivateKey},
+		{"FIREBASE_CLIENT_EMAIL", config.Firebase.ClientEmail},
+		{"FIREBASE_CLIENT_ID", config.Firebase.ClientID},
+		{"FIREBASE_CERT_URL", config.Firebase.CertURL},
+		{"CACHE", config.Cache.Backend},
+		{"CACHE_SIZE", strconv.Itoa(config.Cache.Size)},
+		{"CACHE_RETRY_INTERVAL", config.Cache.RetryInterval.String()},
+		{"REDIS_ADDR", config.Cache.Redis.Addr},
+		{"REDIS_PASSWORD", config.Cache.Redis.Password},
+		{"REDIS_DB", strconv.Itoa(config.Cache.Redis.DB)},
+		{"OBJECT_STORE", config.ObjectStore.Backend},
+		{"OBJECT_STORE_DIR", config.ObjectStore.Dir},
+		{"OBJECT_STORE_URL", config.ObjectStore.BaseURL},
+		{"GOOGLE_STORAGE_PROJECT_ID", config.ObjectStore.GCS.ProjectID},
+		{"GOOGLE_STORAGE_BUCKET_NAME", config.ObjectStore.GCS.BucketName},
+		{"GOOGLE_STORAGE_FOLDER", config.ObjectStore.GCS.UploadPath},
+		{"PAYMENT_PROVIDER", config.Payment.Provider},
+		{"PAYMENT_CALLBACK_URL", config.Payment.CallbackURL},
+		{"PAYMENT_WEBHOOK_SECRET", config.Payment.WebhookSecret},
+		{"DAILY_DEPOSIT_LIMIT", strconv.FormatFloat(config.Payment.Limit.DailyDeposit, 'f', -1, 64)},
+		{"DAILY_WITHDRAW_LIMIT", strconv.FormatFloat(config.Payment.Limit.DailyWithdraw, 'f', -1, 64)},
+		{"WITHDRAW_APPROVAL_THRESHOLD", strconv.FormatFloat(config.Payment.Limit.WithdrawApprovalThreshold, 'f', -1, 64)},
+		{"MARGIN_CHECK_INTERVAL", config.Margin.CheckInterval.String()},
+		{"MARGIN_CALL_GRACE", config.Margin.CallGrace.String()},
+		{"ADMIN_UIDS", strings.Join(config.AdminUids, ",")},
+		{"FEE_SCHEDULE_FILE", config.FeeScheduleFile},
+		{"FX_RATE_FILE", config.FxRateFile},
+		{"MARKET_CALENDAR_FILE", config.MarketCalendarFile},
+	}
+
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	for _, line := range lines {
+		key, value := line[0], line[1]
+		if slices.Contains(secrets, key) && len(value) > 0 {
+			value = "<redacted>"
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\n", key, value)
+	}
+	writer.Flush()
+
+	return builder.String()
+}
+
+func readEnvFile(path string) (map[string]string, error) {
+	if len(path) == 0 {
+		values, err := godotenv.Read()
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading .env: %v", err)
+		}
+
+		return values, nil
+	}
+
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	return values, nil
+}
+
+func unknownVariable(key string) string {
+	suggestion := suggest(key)
+	if len(suggestion) == 0 {
+		return fmt.Sprintf("unknown variable %s", key)
+	}
+
+	return fmt.Sprintf("unknown variable %s, did you mean %s?", key, suggestion)
+}
+
+// the known variable closest to key when it is at most two edits away,
+// short names are not matched so that PWD or HOME are not taken for typos
+func suggest(key string) string {
+	if len(key) < 5 {
+		return ""
+	}
+
+	upper := strings.ToUpper(key)
+	suggestion, best := "", 3
+	for _, variable := range variables {
+		distance := editDistance(upper, variable)
+		if distance < best {
+			suggestion, best = variable, distance
+		}
+	}
+
+	return suggestion
+}
+
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func known(key string) bool {
+	return slices.Contains(variables, key)
+}
+
+// later maps override earlier ones, empty values are skipped
+func withDefaults(maps ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, values := range maps {
+		for key, value := range values {
+			if len(value) > 0 {
+				merged[key] = value
+			}
+		}
+	}
+
+	return merged
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if len(value) > 0 {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) > 0 {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"server/model"
+)
+
+// no fee is charged when path is empty
+func LoadFeeSchedule(path string) (model.FeeSchedule, error) {
+	var feeSchedule model.FeeSchedule
+	if len(path) == 0 {
+		return feeSchedule, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return feeSchedule, fmt.Errorf("error reading fee schedule: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &feeSchedule); err != nil {
+		return feeSchedule, fmt.Errorf("error parsing fee schedule: %v", err)
+	}
+
+	return feeSchedule, nil
+}
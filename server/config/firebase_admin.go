@@ -4,24 +4,24 @@ import (
 	"context"
 	"fmt"
 	"encoding/json"
-	"os"
+	"server/model"
 
 	firebase "firebase.google.com/go"
 	"google.golang.org/api/option"
 )
 
-func InitializeFirebase() (*firebase.App, error) {
+func InitializeFirebase(firebaseConfig model.FirebaseConfig) (*firebase.App, error) {
 	serviceAccountKey := map[string]interface{}{
 		"type":                        "service_account",
-		"project_id":                  os.Getenv("FIREBASE_PROJECT_ID"),
-		"private_key_id":              os.Getenv("FIREBASE_PRIVATE_KEY_ID"),
-		"private_key":                 os.Getenv("FIREBASE_PRIVATE_KEY") ,
-		"client_email":                os.Getenv("FIREBASE_CLIENT_EMAIL"),
-		"client_id":                   os.Getenv("FIREBASE_CLIENT_ID"),
+		"project_id":                  firebaseConfig.ProjectID,
+		"private_key_id":              firebaseConfig.PrivateKeyID,
+		"private_key":                 firebaseConfig.PrivateKey,
+		"client_email":                firebaseConfig.ClientEmail,
+		"client_id":                   firebaseConfig.ClientID,
 		"auth_uri":                    "https://accounts.google.com/o/oauth2/auth",
 		"token_uri":                   "https://oauth2.googleapis.com/token",
 		"auth_provider_x509_cert_url": "https://www.googleapis.com/oauth2/v1/certs",
-		"client_x509_cert_url":        os.Getenv("FIREBASE_CERT_URL"),
+		"client_x509_cert_url":        firebaseConfig.CertURL,
 		"universe_domain":             "googleapis.com",
 	}
 
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"server/model"
+	"time"
+)
+
+// the margin check runs every minute and liquidates right away by default
+func LoadMarginConfig(getenv func(string) string) (model.MarginConfig, error) {
+	marginConfig := model.MarginConfig{
+		CheckInterval: time.Minute,
+	}
+
+	durations := map[string]*time.Duration{
+		"MARGIN_CHECK_INTERVAL": &marginConfig.CheckInterval,
+		"MARGIN_CALL_GRACE":     &marginConfig.CallGrace,
+	}
+	for key, duration := range durations {
+		value := getenv(key)
+		if len(value) == 0 {
+			continue
+		}
+
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return model.MarginConfig{}, fmt.Errorf("error parsing %s: %v", key, err)
+		}
+
+		*duration = parsed
+	}
+
+	if marginConfig.CheckInterval <= 0 {
+		return model.MarginConfig{}, fmt.Errorf("error parsing MARGIN_CHECK_INTERVAL: must be positive")
+	}
+
+	return marginConfig, nil
+}
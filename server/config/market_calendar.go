@@ -0,0 +1,52 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"server/model"
+	"server/util"
+	"time"
+)
+
+// the market is open all day every day when path is empty, orders outside
+// of the continuous session are rejected unless offHours is queue
+func LoadMarketCalendar(path string) (model.MarketCalendar, error) {
+	marketCalendar := model.MarketCalendar{
+		OffHours: model.OffHoursReject,
+	}
+	if len(path) == 0 {
+		return marketCalendar, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return marketCalendar, fmt.Errorf("error reading market calendar: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &marketCalendar); err != nil {
+		return marketCalendar, fmt.Errorf("error parsing market calendar: %v", err)
+	}
+
+	if len(marketCalendar.Timezone) > 0 {
+		if _, err := time.LoadLocation(marketCalendar.Timezone); err != nil {
+			return marketCalendar, fmt.Errorf("error parsing market calendar: %v", err)
+		}
+	}
+
+	if marketCalendar.OffHours != model.OffHoursReject && marketCalendar.OffHours != model.OffHoursQueue {
+		return marketCalendar, fmt.Errorf("error parsing market calendar: invalid offHours %s", marketCalendar.OffHours)
+	}
+
+	if !util.ValidMarketSchedule(marketCalendar.MarketSchedule) {
+		return marketCalendar, fmt.Errorf("error parsing market calendar: invalid schedule")
+	}
+
+	for stockId, schedule := range marketCalendar.Stocks {
+		if !util.ValidMarketSchedule(schedule) {
+			return marketCalendar, fmt.Errorf("error parsing market calendar: invalid schedule of %s", stockId)
+		}
+	}
+
+	return marketCalendar, nil
+}
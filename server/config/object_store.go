@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"server/model"
+)
+
+// images are stored in google cloud storage when a bucket is set and on the
+// local disk otherwise, OBJECT_STORE picks the backend explicitly
+func LoadObjectStoreConfig(getenv func(string) string) (model.ObjectStoreConfig, error) {
+	objectStoreConfig := model.ObjectStoreConfig{
+		Backend: getenv("OBJECT_STORE"),
+		Dir:     getenv("OBJECT_STORE_DIR"),
+		BaseURL: getenv("OBJECT_STORE_URL"),
+		GCS: model.GCSConfig{
+			ProjectID:  getenv("GOOGLE_STORAGE_PROJECT_ID"),
+			BucketName: getenv("GOOGLE_STORAGE_BUCKET_NAME"),
+			UploadPath: getenv("GOOGLE_STORAGE_FOLDER"),
+		},
+	}
+
+	if len(objectStoreConfig.Backend) == 0 {
+		objectStoreConfig.Backend = model.ObjectStoreLocal
+		if len(objectStoreConfig.GCS.BucketName) > 0 {
+			objectStoreConfig.Backend = model.ObjectStoreGCS
+		}
+	}
+
+	if len(objectStoreConfig.Dir) == 0 {
+		objectStoreConfig.Dir = "uploads"
+	}
+
+	if len(objectStoreConfig.BaseURL) == 0 {
+		objectStoreConfig.BaseURL = "/api/v1/image"
+	}
+
+	switch objectStoreConfig.Backend {
+	case model.ObjectStoreLocal, model.ObjectStoreMemory:
+		return objectStoreConfig, nil
+	case model.ObjectStoreGCS:
+	default:
+		return model.ObjectStoreConfig{}, fmt.Errorf("error parsing OBJECT_STORE: unknown backend %s", objectStoreConfig.Backend)
+	}
+
+	if len(objectStoreConfig.GCS.BucketName) == 0 {
+		return model.ObjectStoreConfig{}, fmt.Errorf("error parsing GOOGLE_STORAGE_BUCKET_NAME: must be set for gcs")
+	}
+
+	keyFile := map[string]interface{}{
+		"type":                        "service_account",
+		"project_id":                  objectStoreConfig.GCS.ProjectID,
+		"private_key_id":              getenv("GOOGLE_STORAGE_PRIVATE_KEY_ID"),
+		"private_key":                 getenv("GOOGLE_STORAGE_PRIVATE_KEY"),
+		"client_email":                getenv("GOOGLE_STORAGE_CLIENT_EMAIL"),
+		"client_id":                   getenv("GOOGLE_STORAGE_CLIENT_ID"),
+		"auth_uri":                    "https://accounts.google.com/o/oauth2/auth",
+		"token_uri":                   "https://oauth2.googleapis.com/token",
+		"auth_provider_x509_cert_url": "https://www.googleapis.com/oauth2/v1/certs",
+		"client_x509_cert_url":        getenv("GOOGLE_STORAGE_CERT_URI"),
+		"universe_domain":             "googleapis.com",
+	}
+
+	jsonBytes, err := json.Marshal(keyFile)
+	if err != nil {
+		return model.ObjectStoreConfig{}, fmt.Errorf("error marshaling credentials: %v", err)
+	}
+
+	objectStoreConfig.GCS.CredentialsJSON = jsonBytes
+
+	return objectStoreConfig, nil
+}
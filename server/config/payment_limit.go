@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"server/model"
+	"strconv"
+)
+
+// the local provider completes every payment by itself, so it cannot move
+// real money in prod where payments are off until a provider is set
+func loadPaymentProvider(provider string, profile string) (string, error) {
+	if len(provider) == 0 {
+		provider = model.PaymentProviderNone
+	}
+
+	switch provider {
+	case model.PaymentProviderNone:
+		return provider, nil
+	case model.PaymentProviderLocal:
+		if profile == model.ProfileProd {
+			return "", fmt.Errorf("error parsing PAYMENT_PROVIDER: local cannot be used in profile prod")
+		}
+
+		return provider, nil
+	default:
+		return "", fmt.Errorf("error parsing PAYMENT_PROVIDER: unknown provider %s", provider)
+	}
+}
+
+// unset limits are disabled
+func LoadPaymentLimit(getenv func(string) string) (model.PaymentLimit, error) {
+	var paymentLimit model.PaymentLimit
+
+	limits := map[string]*float64{
+		"DAILY_DEPOSIT_LIMIT":         &paymentLimit.DailyDeposit,
+		"DAILY_WITHDRAW_LIMIT":        &paymentLimit.DailyWithdraw,
+		"WITHDRAW_APPROVAL_THRESHOLD": &paymentLimit.WithdrawApprovalThreshold,
+	}
+	for key, limit := range limits {
+		value := getenv(key)
+		if len(value) == 0 {
+			continue
+		}
+
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return model.PaymentLimit{}, fmt.Errorf("error parsing %s: %v", key, err)
+		}
+
+		*limit = parsed
+	}
+
+	return paymentLimit, nil
+}
@@ -0,0 +1,7 @@
+package errs
+
+import "errors"
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+)
@@ -0,0 +1,8 @@
+package errs
+
+import "errors"
+
+var (
+	ErrCache     = errors.New("invalid cache")
+	ErrCacheMiss = errors.New("cache miss")
+)
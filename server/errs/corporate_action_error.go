@@ -0,0 +1,9 @@
+package errs
+
+import "errors"
+
+var (
+	ErrCorporateAction       = errors.New("invalid corporate action")
+	ErrCorporateActionStatus = errors.New("invalid corporate action status")
+	ErrSplitRatio            = errors.New("invalid split ratio")
+)
@@ -0,0 +1,9 @@
+package errs
+
+import "errors"
+
+var (
+	ErrDividend       = errors.New("invalid dividend")
+	ErrDividendDate   = errors.New("invalid dividend date")
+	ErrDividendStatus = errors.New("invalid dividend status")
+)
@@ -0,0 +1,10 @@
+package errs
+
+import "errors"
+
+var (
+	ErrImageType      = errors.New("invalid image type")
+	ErrImageSize      = errors.New("image is too large")
+	ErrImageDimension = errors.New("invalid image dimension")
+	ErrImageChanged   = errors.New("image was changed by another request")
+)
@@ -0,0 +1,8 @@
+package errs
+
+import "errors"
+
+var (
+	ErrLedgerUnbalanced = errors.New("ledger transaction is not balanced")
+	ErrLedgerMethod     = errors.New("invalid ledger method")
+)
@@ -0,0 +1,10 @@
+package errs
+
+import "errors"
+
+var (
+	ErrMarketClosed      = errors.New("market is closed for trading")
+	ErrMarketSession     = errors.New("invalid market session")
+	ErrQueuedOrder       = errors.New("invalid queued order")
+	ErrQueuedOrderStatus = errors.New("invalid queued order status")
+)
@@ -0,0 +1,9 @@
+package errs
+
+import "errors"
+
+var (
+	ErrObjectStore    = errors.New("invalid object store")
+	ErrObjectKey      = errors.New("invalid object key")
+	ErrObjectNotFound = errors.New("object not found")
+)
@@ -0,0 +1,13 @@
+package errs
+
+import "errors"
+
+var (
+	ErrPayment          = errors.New("invalid payment")
+	ErrPaymentStatus    = errors.New("invalid payment status")
+	ErrPaymentSignature = errors.New("invalid payment signature")
+	ErrPaymentProvider  = errors.New("payment provider is not available")
+	ErrDailyLimit       = errors.New("daily limit exceeded")
+	ErrApproval         = errors.New("payment does not require approval")
+	ErrAdmin            = errors.New("admin only")
+)
@@ -6,4 +6,13 @@ var (
 	ErrName = errors.New("invalid name")
 	ErrSign = errors.New("invalid sign")
 	ErrPrice = errors.New("invalid price")
+	ErrTradingRule = errors.New("invalid trading rule")
+	ErrTickSize = errors.New("price is not a multiple of tick size")
+	ErrLotSize = errors.New("amount is not a multiple of lot size")
+	ErrMinQuantity = errors.New("amount is below minimum order quantity")
+	ErrMaxQuantity = errors.New("amount is above maximum order quantity")
+	ErrMinNotional = errors.New("order value is below minimum notional")
+	ErrMaxSlippage = errors.New("invalid max slippage")
+	ErrSlippage = errors.New("price moved beyond max slippage")
+	ErrLimitPrice = errors.New("limit price is not reached")
 )
\ No newline at end of file
@@ -0,0 +1,10 @@
+package errs
+
+import "errors"
+
+var (
+	ErrStockStatus    = errors.New("invalid stock status")
+	ErrStockNotActive = errors.New("stock is not open for trading")
+	ErrSettlement     = errors.New("invalid settlement")
+	ErrPriceBand      = errors.New("price is outside of the price band")
+)
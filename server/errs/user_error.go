@@ -14,4 +14,10 @@ var (
 	ErrOrderType = errors.New("invalid order type")
 	ErrOrderMethod = errors.New("invalid order method")
 	ErrFavoriteStock = errors.New("already set favorite stock")
+	ErrFee = errors.New("fee exceeds order value")
+	ErrCurrency = errors.New("invalid currency")
+	ErrFxRate = errors.New("fx rate not available")
+	ErrBuyingPower = errors.New("buying power not enough")
+	ErrShort = errors.New("stock is not shortable")
+	ErrEmail = errors.New("invalid email")
 )
\ No newline at end of file
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"server/errs"
+
+	"github.com/gin-gonic/gin"
+)
+
+func AdminOnly(adminUids []string) gin.HandlerFunc {
+	admins := map[string]bool{}
+	for _, uid := range adminUids {
+		admins[uid] = true
+	}
+
+	return func(c *gin.Context) {
+		if !admins[c.MustGet("uid").(string)] {
+			c.AbortWithStatusJSON(403, gin.H{
+				"message": errs.ErrAdmin.Error(),
+			})
+
+			return
+		}
+
+		c.Next()
+	}
+}
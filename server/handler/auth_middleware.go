@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"server/errs"
+	"strings"
+
+	"firebase.google.com/go/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// resolves the uid of the user making the request
+type Authenticator interface {
+	Authenticate(*http.Request) (string, error)
+}
+
+type staticAuthenticator struct {
+	uid string
+}
+
+// every request is made by the same user, for development
+func NewStaticAuthenticator(uid string) Authenticator {
+	return staticAuthenticator{uid}
+}
+
+func (a staticAuthenticator) Authenticate(r *http.Request) (string, error) {
+	return a.uid, nil
+}
+
+// *auth.Client of firebase
+type IDTokenVerifier interface {
+	VerifyIDToken(context.Context, string) (*auth.Token, error)
+}
+
+type firebaseAuthenticator struct {
+	verifier IDTokenVerifier
+}
+
+// the user is the uid of the firebase ID token sent as a Bearer token
+func NewFirebaseAuthenticator(verifier IDTokenVerifier) Authenticator {
+	return firebaseAuthenticator{verifier}
+}
+
+func (a firebaseAuthenticator) Authenticate(r *http.Request) (string, error) {
+	idToken, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || len(idToken) == 0 {
+		return "", errs.ErrUnauthorized
+	}
+
+	token, err := a.verifier.VerifyIDToken(r.Context(), idToken)
+	if err != nil {
+		return "", err
+	}
+
+	return token.UID, nil
+}
+
+func Authenticate(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, err := authenticator.Authenticate(c.Request)
+		if err != nil || len(uid) == 0 {
+			c.AbortWithStatusJSON(401, gin.H{
+				"message": errs.ErrUnauthorized.Error(),
+			})
+
+			return
+		}
+
+		c.Set("uid", uid)
+		c.Next()
+	}
+}
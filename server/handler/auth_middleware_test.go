@@ -0,0 +1,98 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/handler"
+	"testing"
+
+	"firebase.google.com/go/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// the uid of "valid-token" is the user, any other token is rejected
+type idTokenVerifierFake struct{}
+
+func (idTokenVerifierFake) VerifyIDToken(ctx context.Context, idToken string) (*auth.Token, error) {
+	if idToken != "valid-token" {
+		return nil, errors.New("invalid ID token")
+	}
+
+	return &auth.Token{UID: userId}, nil
+}
+
+func TestFirebaseAuthenticator(t *testing.T) {
+	cases := []struct {
+		name          string
+		authorization string
+		expectedCode  int
+		expectedBody  string
+	}{
+		{
+			"Successfully authenticate",
+			"Bearer valid-token",
+			http.StatusOK,
+			fmt.Sprintf(`{"uid":"%s"}`, userId),
+		},
+		{
+			"Error invalid token",
+			"Bearer other-token",
+			http.StatusUnauthorized,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrUnauthorized.Error()),
+		},
+		{
+			"Error not a bearer token",
+			"valid-token",
+			http.StatusUnauthorized,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrUnauthorized.Error()),
+		},
+		{
+			"Error without token",
+			"",
+			http.StatusUnauthorized,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrUnauthorized.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			router.Use(handler.Authenticate(handler.NewFirebaseAuthenticator(idTokenVerifierFake{})))
+			router.GET("/uid", func(c *gin.Context) {
+				c.JSON(200, gin.H{"uid": c.GetString("uid")})
+			})
+
+			req, err := http.NewRequest("GET", "/uid", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			if len(c.authorization) > 0 {
+				req.Header.Set("Authorization", c.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
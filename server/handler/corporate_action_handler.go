@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"server/model"
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type corporateActionHandler struct {
+	corporateActionService service.CorporateActionService
+}
+
+type CorporateActionRequest = model.CorporateActionRequest
+type EditSignRequest = model.EditSignRequest
+
+func NewCorporateActionHandler(corporateActionService service.CorporateActionService) corporateActionHandler {
+	return corporateActionHandler{corporateActionService}
+}
+
+func (h corporateActionHandler) CreateCorporateAction(c *gin.Context) {
+	stockId := c.Param("stockId")
+	body := CorporateActionRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	corporateAction, err := h.corporateActionService.CreateCorporateAction(stockId, body, uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":         "Successfully created corporate action",
+		"corporateAction": corporateAction,
+	})
+}
+
+// the sign is changed by a symbol change that takes effect right away so the
+// old sign is kept in the history of the stock
+func (h corporateActionHandler) EditStockSign(c *gin.Context) {
+	stockId := c.Param("stockId")
+	body := EditSignRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	corporateAction, err := h.corporateActionService.CreateCorporateAction(
+		stockId,
+		CorporateActionRequest{Type: model.CorporateActionSymbolChange, Sign: body.Sign},
+		uid,
+	)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":         "Successfully updated sign",
+		"corporateAction": corporateAction,
+	})
+}
+
+func (h corporateActionHandler) CancelCorporateAction(c *gin.Context) {
+	actionId := c.Param("actionId")
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.corporateActionService.CancelCorporateAction(actionId, uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
+
+func (h corporateActionHandler) GetStockCorporateActions(c *gin.Context) {
+	stockId := c.Param("stockId")
+
+	corporateActions, err := h.corporateActionService.GetStockCorporateActions(stockId)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":          "Successfully fetched corporate actions",
+		"corporateActions": corporateActions,
+	})
+}
@@ -0,0 +1,296 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/handler"
+	"server/model"
+	"server/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CorporateAction = model.CorporateAction
+type CorporateActionRequest = model.CorporateActionRequest
+
+func corporateActionPath(route string) string {
+	return fmt.Sprintf("/api/v1/corporate-action/%s", route)
+}
+
+func TestCreateCorporateAction(t *testing.T) {
+	expectedMessage := "Successfully created corporate action"
+	stockId := "65c39a03dfb8060d99995934"
+	testBody := CorporateActionRequest{
+		Type:      model.CorporateActionSplit,
+		NewShares: 2,
+		OldShares: 1,
+	}
+	expectedAction := CorporateAction{
+		ID:        primitive.NewObjectID(),
+		StockId:   stockId,
+		Type:      model.CorporateActionSplit,
+		NewShares: 2,
+		OldShares: 1,
+		OldSign:   "AAA",
+		Status:    model.CorporateActionApplied,
+		History:   []model.CorporateActionEvent{},
+	}
+
+	cases := []struct {
+		name         string
+		uid          string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully create corporate action",
+			"admin",
+			nil,
+			http.StatusOK,
+			func() string {
+				expectedJsonAction, _ := json.Marshal(expectedAction)
+				return fmt.Sprintf(`{"corporateAction":%s,"message":"%s"}`, expectedJsonAction, expectedMessage)
+			}(),
+		},
+		{
+			"Error split ratio",
+			"admin",
+			errs.ErrSplitRatio,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrSplitRatio.Error()),
+		},
+		{
+			"Error not admin",
+			userId,
+			nil,
+			http.StatusForbidden,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrAdmin.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			corporateActionService := service.NewCorporateActionServiceMock()
+
+			corporateActionService.
+				On("CreateCorporateAction", stockId, testBody, "admin").
+				Return(expectedAction, c.err)
+
+			corporateActionHandler := handler.NewCorporateActionHandler(corporateActionService)
+
+			reqBody, _ := json.Marshal(testBody)
+			req, err := http.NewRequest(
+				"POST",
+				corporateActionPath("admin/create/"+stockId),
+				bytes.NewBuffer(reqBody),
+			)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", c.uid)
+			})
+
+			router.POST(
+				corporateActionPath("admin/create/:stockId"),
+				handler.AdminOnly([]string{"admin"}),
+				corporateActionHandler.CreateCorporateAction,
+			)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestEditStockSign(t *testing.T) {
+	expectedMessage := "Successfully updated sign"
+	stockId := "65c39a03dfb8060d99995934"
+	testBody := model.EditSignRequest{Sign: "BBB"}
+	expectedAction := CorporateAction{
+		ID:      primitive.NewObjectID(),
+		StockId: stockId,
+		Type:    model.CorporateActionSymbolChange,
+		OldSign: "AAA",
+		NewSign: "BBB",
+		Status:  model.CorporateActionApplied,
+		History: []model.CorporateActionEvent{},
+	}
+
+	cases := []struct {
+		name         string
+		uid          string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully edit stock sign",
+			"admin",
+			nil,
+			http.StatusOK,
+			func() string {
+				expectedJsonAction, _ := json.Marshal(expectedAction)
+				return fmt.Sprintf(`{"corporateAction":%s,"message":"%s"}`, expectedJsonAction, expectedMessage)
+			}(),
+		},
+		{
+			"Error sign",
+			"admin",
+			errs.ErrSign,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrSign.Error()),
+		},
+		{
+			"Error not admin",
+			userId,
+			nil,
+			http.StatusForbidden,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrAdmin.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			corporateActionService := service.NewCorporateActionServiceMock()
+
+			corporateActionService.
+				On("CreateCorporateAction", stockId, CorporateActionRequest{
+					Type: model.CorporateActionSymbolChange,
+					Sign: "BBB",
+				}, "admin").
+				Return(expectedAction, c.err)
+
+			corporateActionHandler := handler.NewCorporateActionHandler(corporateActionService)
+
+			reqBody, _ := json.Marshal(testBody)
+			req, err := http.NewRequest(
+				"POST",
+				stockPath("admin/edit-sign/"+stockId),
+				bytes.NewBuffer(reqBody),
+			)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", c.uid)
+			})
+
+			router.POST(
+				stockPath("admin/edit-sign/:stockId"),
+				handler.AdminOnly([]string{"admin"}),
+				corporateActionHandler.EditStockSign,
+			)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestGetStockCorporateActions(t *testing.T) {
+	expectedMessage := "Successfully fetched corporate actions"
+	stockId := "65c39a03dfb8060d99995934"
+	expectedActions := []CorporateAction{
+		{
+			ID:          primitive.NewObjectID(),
+			StockId:     stockId,
+			Type:        model.CorporateActionSymbolChange,
+			OldSign:     "AAA",
+			NewSign:     "BBB",
+			EffectiveAt: 1708855336,
+			Status:      model.CorporateActionApplied,
+			History:     []model.CorporateActionEvent{},
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	corporateActionService := service.NewCorporateActionServiceMock()
+
+	corporateActionService.
+		On("GetStockCorporateActions", stockId).
+		Return(expectedActions, nil)
+
+	corporateActionHandler := handler.NewCorporateActionHandler(corporateActionService)
+
+	req, err := http.NewRequest("GET", corporateActionPath("stock/"+stockId), nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.GET(corporateActionPath("stock/:stockId"), corporateActionHandler.GetStockCorporateActions)
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf(
+			"Expected status code %d, got %d",
+			http.StatusOK,
+			recorder.Code,
+		)
+	}
+
+	expectedJsonActions, _ := json.Marshal(expectedActions)
+	expectedResponseBody := fmt.Sprintf(
+		`{"corporateActions":%s,"message":"%s"}`,
+		expectedJsonActions,
+		expectedMessage,
+	)
+
+	if recorder.Body.String() != expectedResponseBody {
+		t.Errorf(
+			"Expected response body %s, got %s",
+			expectedResponseBody,
+			recorder.Body.String(),
+		)
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"server/model"
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type dividendHandler struct {
+	dividendService service.DividendService
+}
+
+type DividendRequest = model.DividendRequest
+type DividendReinvestRequest = model.DividendReinvestRequest
+
+func NewDividendHandler(dividendService service.DividendService) dividendHandler {
+	return dividendHandler{dividendService}
+}
+
+func (h dividendHandler) DeclareDividend(c *gin.Context) {
+	stockId := c.Param("stockId")
+	body := DividendRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	dividend, err := h.dividendService.DeclareDividend(stockId, body, uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":  "Successfully declared dividend",
+		"dividend": dividend,
+	})
+}
+
+func (h dividendHandler) CancelDividend(c *gin.Context) {
+	dividendId := c.Param("dividendId")
+
+	message, err := h.dividendService.CancelDividend(dividendId)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
+
+func (h dividendHandler) GetStockDividends(c *gin.Context) {
+	stockId := c.Param("stockId")
+
+	dividends, err := h.dividendService.GetStockDividends(stockId)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":   "Successfully fetched dividends",
+		"dividends": dividends,
+	})
+}
+
+func (h dividendHandler) SetDividendReinvest(c *gin.Context) {
+	body := DividendReinvestRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.dividendService.SetDividendReinvest(uid, body.Enabled)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
@@ -0,0 +1,203 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/handler"
+	"server/model"
+	"server/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Dividend = model.Dividend
+type DividendRequest = model.DividendRequest
+type DividendReinvestRequest = model.DividendReinvestRequest
+
+func dividendPath(route string) string {
+	return fmt.Sprintf("/api/v1/dividend/%s", route)
+}
+
+func TestDeclareDividend(t *testing.T) {
+	expectedMessage := "Successfully declared dividend"
+	stockId := "65c39a03dfb8060d99995934"
+	testBody := DividendRequest{
+		AmountPerShare: 0.5,
+		RecordDate:     1708855336,
+		PayDate:        1708941736,
+	}
+	expectedDividend := Dividend{
+		ID:             primitive.NewObjectID(),
+		StockId:        stockId,
+		AmountPerShare: 0.5,
+		Currency:       model.BaseCurrency,
+		RecordDate:     1708855336,
+		PayDate:        1708941736,
+		Status:         model.DividendDeclared,
+		DeclaredBy:     "admin",
+		Holders:        []model.DividendHolder{},
+	}
+
+	cases := []struct {
+		name         string
+		uid          string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully declare dividend",
+			"admin",
+			nil,
+			http.StatusOK,
+			func() string {
+				expectedJsonDividend, _ := json.Marshal(expectedDividend)
+				return fmt.Sprintf(`{"dividend":%s,"message":"%s"}`, expectedJsonDividend, expectedMessage)
+			}(),
+		},
+		{
+			"Error dividend date",
+			"admin",
+			errs.ErrDividendDate,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrDividendDate.Error()),
+		},
+		{
+			"Error not admin",
+			userId,
+			nil,
+			http.StatusForbidden,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrAdmin.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			dividendService := service.NewDividendServiceMock()
+
+			dividendService.
+				On("DeclareDividend", stockId, testBody, "admin").
+				Return(expectedDividend, c.err)
+
+			dividendHandler := handler.NewDividendHandler(dividendService)
+
+			reqBody, _ := json.Marshal(testBody)
+			req, err := http.NewRequest(
+				"POST",
+				dividendPath("admin/declare/"+stockId),
+				bytes.NewBuffer(reqBody),
+			)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", c.uid)
+			})
+
+			router.POST(
+				dividendPath("admin/declare/:stockId"),
+				handler.AdminOnly([]string{"admin"}),
+				dividendHandler.DeclareDividend,
+			)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestSetDividendReinvest(t *testing.T) {
+	expectedMessage := "Successfully set dividend reinvestment"
+
+	cases := []struct {
+		name         string
+		body         interface{}
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully set dividend reinvestment",
+			DividendReinvestRequest{Enabled: true},
+			http.StatusOK,
+			fmt.Sprintf(`{"message":"%s"}`, expectedMessage),
+		},
+		{
+			"Error invalid body",
+			"enabled",
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrData.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			dividendService := service.NewDividendServiceMock()
+
+			dividendService.
+				On("SetDividendReinvest", userId, true).
+				Return(expectedMessage, nil)
+
+			dividendHandler := handler.NewDividendHandler(dividendService)
+
+			reqBody, _ := json.Marshal(c.body)
+			req, err := http.NewRequest("POST", dividendPath("reinvest"), bytes.NewBuffer(reqBody))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", userId)
+			})
+
+			router.POST(dividendPath("reinvest"), dividendHandler.SetDividendReinvest)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"strings"
+
+	"server/errs"
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type imageHandler struct {
+	objectStore service.ObjectStore
+}
+
+func NewImageHandler(objectStore service.ObjectStore) imageHandler {
+	return imageHandler{objectStore}
+}
+
+func (h imageHandler) GetImage(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	body, contentType, err := h.objectStore.Get(key)
+	if errors.Is(err, errs.ErrObjectNotFound) {
+		c.JSON(404, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+	defer body.Close()
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.DataFromReader(200, -1, contentType, body, nil)
+}
@@ -0,0 +1,90 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/handler"
+	"server/service"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetImage(t *testing.T) {
+	objectStore := service.NewMemoryObjectStore("/api/v1/image")
+	objectStore.Put("stock/test", strings.NewReader("image"), "image/png")
+
+	cases := []struct {
+		name                string
+		key                 string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			"Successfully get image",
+			"stock/test",
+			http.StatusOK,
+			"image/png",
+			"image",
+		},
+		{
+			"Error object not found",
+			"stock/missing",
+			http.StatusNotFound,
+			"application/json; charset=utf-8",
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrObjectNotFound.Error()),
+		},
+		{
+			"Error invalid key",
+			"stock/./test",
+			http.StatusBadRequest,
+			"application/json; charset=utf-8",
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrObjectKey.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			imageHandler := handler.NewImageHandler(objectStore)
+
+			req, err := http.NewRequest("GET", "/api/v1/image/"+c.key, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.GET("/api/v1/image/*key", imageHandler.GetImage)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != c.expectedContentType {
+				t.Errorf(
+					"Expected content type %s, got %s",
+					c.expectedContentType,
+					contentType,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
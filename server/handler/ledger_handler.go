@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"server/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ledgerHandler struct {
+	ledgerService service.LedgerService
+}
+
+func NewLedgerHandler(ledgerService service.LedgerService) ledgerHandler {
+	return ledgerHandler{ledgerService}
+}
+
+func (h ledgerHandler) GetUserLedger(c *gin.Context) {
+	startPage, err := strconv.Atoi(c.Query("startPage"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	transactions, err := h.ledgerService.GetUserLedger(uid, uint(startPage))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":      "Successfully fetched ledger transactions",
+		"transactions": transactions,
+	})
+}
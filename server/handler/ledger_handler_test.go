@@ -0,0 +1,142 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"server/handler"
+	"server/model"
+	"server/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LedgerTransaction = model.LedgerTransaction
+type LedgerEntry = model.LedgerEntry
+
+func ledgerPath(route string) string {
+	return fmt.Sprintf("/api/v1/ledger/%s", route)
+}
+
+func TestGetUserLedger(t *testing.T) {
+	expectedMessage := "Successfully fetched ledger transactions"
+	expectedTransactions := []LedgerTransaction{
+		{
+			UID:       "test12345",
+			Timestamp: 1708855336,
+			Method:    "BUY",
+			Reference: "65cc5fd45aa71b64fbb551a9",
+			Entries: []LedgerEntry{
+				{Account: "user:test12345:cash", Debit: 10},
+				{Account: model.LedgerMarketAccount, Credit: 10},
+			},
+		},
+	}
+	path := ledgerPath("transaction")
+
+	t.Run("Successfully get user ledger", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		ledgerService := service.NewLedgerServiceMock()
+
+		ledgerService.
+			On("GetUserLedger", userId, uint(0)).
+			Return(expectedTransactions, nil)
+
+		ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+		req, err := http.NewRequest(
+			"GET",
+			path,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		queryParams := url.Values{}
+		queryParams.Set("startPage", "0")
+		req.URL.RawQuery = queryParams.Encode()
+		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Set("uid", userId)
+		})
+
+		router.GET(path, ledgerHandler.GetUserLedger)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusOK,
+				recorder.Code,
+			)
+		}
+
+		expectedJsonTransactions, _ := json.Marshal(expectedTransactions)
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s","transactions":%s}`,
+			expectedMessage,
+			expectedJsonTransactions,
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+
+	t.Run("Error on handler query", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		ledgerService := service.NewLedgerServiceMock()
+		ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+		req, err := http.NewRequest(
+			"GET",
+			path,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Set("uid", userId)
+		})
+
+		router.GET(path, ledgerHandler.GetUserLedger)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusBadRequest,
+				recorder.Code,
+			)
+		}
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s"}`,
+			ErrData.Error(),
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"server/model"
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type marginHandler struct {
+	marginService service.MarginService
+}
+
+type MarginEnabledRequest = model.MarginEnabledRequest
+
+func NewMarginHandler(marginService service.MarginService) marginHandler {
+	return marginHandler{marginService}
+}
+
+func (h marginHandler) GetMarginSummary(c *gin.Context) {
+	uid := c.MustGet("uid").(string)
+
+	marginSummary, err := h.marginService.GetMarginSummary(uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully fetched margin summary",
+		"margin":  marginSummary,
+	})
+}
+
+func (h marginHandler) SetMarginEnabled(c *gin.Context) {
+	userId := c.Param("uid")
+	body := MarginEnabledRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	message, err := h.marginService.SetMarginEnabled(userId, body.Enabled)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
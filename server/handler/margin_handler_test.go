@@ -0,0 +1,183 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/handler"
+	"server/model"
+	"server/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MarginSummary = model.MarginSummary
+type MarginEnabledRequest = model.MarginEnabledRequest
+
+func marginPath(route string) string {
+	return fmt.Sprintf("/api/v1/margin/%s", route)
+}
+
+func TestGetMarginSummary(t *testing.T) {
+	expectedMessage := "Successfully fetched margin summary"
+	expectedSummary := MarginSummary{
+		MarginEnabled:          true,
+		Cash:                   -500,
+		MarketValue:            1000,
+		CollateralValue:        500,
+		Equity:                 500,
+		InitialRequirement:     500,
+		MaintenanceRequirement: 250,
+		Positions:              []model.MarginPosition{},
+	}
+	path := marginPath("summary")
+
+	cases := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully get margin summary",
+			nil,
+			http.StatusOK,
+			func() string {
+				expectedJsonSummary, _ := json.Marshal(expectedSummary)
+				return fmt.Sprintf(`{"margin":%s,"message":"%s"}`, expectedJsonSummary, expectedMessage)
+			}(),
+		},
+		{
+			"Error invalid user",
+			errs.ErrUser,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrUser.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			marginService := service.NewMarginServiceMock()
+
+			marginService.
+				On("GetMarginSummary", userId).
+				Return(expectedSummary, c.err)
+
+			marginHandler := handler.NewMarginHandler(marginService)
+
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", userId)
+			})
+
+			router.GET(path, marginHandler.GetMarginSummary)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestSetMarginEnabled(t *testing.T) {
+	expectedMessage := "Successfully set margin account"
+
+	cases := []struct {
+		name         string
+		uid          string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully set margin enabled",
+			"admin",
+			http.StatusOK,
+			fmt.Sprintf(`{"message":"%s"}`, expectedMessage),
+		},
+		{
+			"Error not admin",
+			userId,
+			http.StatusForbidden,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrAdmin.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			marginService := service.NewMarginServiceMock()
+
+			marginService.
+				On("SetMarginEnabled", userId, true).
+				Return(expectedMessage, nil)
+
+			marginHandler := handler.NewMarginHandler(marginService)
+
+			reqBody, _ := json.Marshal(MarginEnabledRequest{Enabled: true})
+			req, err := http.NewRequest(
+				"POST",
+				marginPath("admin/set-enabled/"+userId),
+				bytes.NewBuffer(reqBody),
+			)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", c.uid)
+			})
+
+			router.POST(
+				marginPath("admin/set-enabled/:uid"),
+				handler.AdminOnly([]string{"admin"}),
+				marginHandler.SetMarginEnabled,
+			)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type marketSessionHandler struct {
+	marketSessionService service.MarketSessionService
+}
+
+func NewMarketSessionHandler(marketSessionService service.MarketSessionService) marketSessionHandler {
+	return marketSessionHandler{marketSessionService}
+}
+
+func (h marketSessionHandler) GetMarketSession(c *gin.Context) {
+	stockId := c.Param("stockId")
+
+	marketSession, err := h.marketSessionService.GetMarketSession(stockId)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully fetched market session",
+		"session": marketSession,
+	})
+}
+
+func (h marketSessionHandler) GetUserQueuedOrders(c *gin.Context) {
+	uid := c.MustGet("uid").(string)
+
+	queuedOrders, err := h.marketSessionService.GetUserQueuedOrders(uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully fetched queued orders",
+		"orders":  queuedOrders,
+	})
+}
+
+func (h marketSessionHandler) CancelQueuedOrder(c *gin.Context) {
+	orderId := c.Param("orderId")
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.marketSessionService.CancelQueuedOrder(uid, orderId)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
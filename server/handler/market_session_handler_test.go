@@ -0,0 +1,216 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/handler"
+	"server/model"
+	"server/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MarketSession = model.MarketSession
+type QueuedOrder = model.QueuedOrder
+
+func marketPath(route string) string {
+	return fmt.Sprintf("/api/v1/market/%s", route)
+}
+
+func TestGetMarketSession(t *testing.T) {
+	stockId := "65c39a03dfb8060d99995936"
+	expectedSession := MarketSession{
+		StockId:  stockId,
+		Session:  model.SessionPreOpen,
+		OffHours: model.OffHoursQueue,
+	}
+
+	cases := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully get market session",
+			nil,
+			http.StatusOK,
+			func() string {
+				expectedJsonSession, _ := json.Marshal(expectedSession)
+				return fmt.Sprintf(`{"message":"Successfully fetched market session","session":%s}`, expectedJsonSession)
+			}(),
+		},
+		{
+			"Error invalid stock",
+			errs.ErrInvalidStock,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrInvalidStock.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			marketSessionService := service.NewMarketSessionServiceMock()
+
+			marketSessionService.
+				On("GetMarketSession", stockId).
+				Return(expectedSession, c.err)
+
+			marketSessionHandler := handler.NewMarketSessionHandler(marketSessionService)
+
+			req, err := http.NewRequest("GET", marketPath("session/"+stockId), nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.GET(marketPath("session/:stockId"), marketSessionHandler.GetMarketSession)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestGetUserQueuedOrders(t *testing.T) {
+	expectedOrders := []QueuedOrder{{
+		ID:          primitive.NewObjectID(),
+		UserId:      userId,
+		StockId:     "65c39a03dfb8060d99995936",
+		Price:       10,
+		Amount:      100,
+		OrderType:   "order",
+		OrderMethod: "buy",
+		Status:      model.QueuedOrderQueued,
+	}}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	marketSessionService := service.NewMarketSessionServiceMock()
+
+	marketSessionService.
+		On("GetUserQueuedOrders", userId).
+		Return(expectedOrders, nil)
+
+	marketSessionHandler := handler.NewMarketSessionHandler(marketSessionService)
+
+	req, err := http.NewRequest("GET", marketPath("queued-order"), nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("uid", userId)
+	})
+
+	router.GET(marketPath("queued-order"), marketSessionHandler.GetUserQueuedOrders)
+	router.ServeHTTP(recorder, req)
+
+	expectedJsonOrders, _ := json.Marshal(expectedOrders)
+	expectedBody := fmt.Sprintf(`{"message":"Successfully fetched queued orders","orders":%s}`, expectedJsonOrders)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	if recorder.Body.String() != expectedBody {
+		t.Errorf(
+			"Expected response body %s, got %s",
+			expectedBody,
+			recorder.Body.String(),
+		)
+	}
+}
+
+func TestCancelQueuedOrder(t *testing.T) {
+	expectedMessage := "Successfully cancelled queued order"
+	orderId := primitive.NewObjectID().Hex()
+
+	cases := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully cancel queued order",
+			nil,
+			http.StatusOK,
+			fmt.Sprintf(`{"message":"%s"}`, expectedMessage),
+		},
+		{
+			"Error already sent",
+			errs.ErrQueuedOrderStatus,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrQueuedOrderStatus.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			marketSessionService := service.NewMarketSessionServiceMock()
+
+			marketSessionService.
+				On("CancelQueuedOrder", userId, orderId).
+				Return(expectedMessage, c.err)
+
+			marketSessionHandler := handler.NewMarketSessionHandler(marketSessionService)
+
+			req, err := http.NewRequest("POST", marketPath("cancel-order/"+orderId), nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", userId)
+			})
+
+			router.POST(marketPath("cancel-order/:orderId"), marketSessionHandler.CancelQueuedOrder)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"io"
+	"server/model"
+	"server/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type paymentHandler struct {
+	paymentService service.PaymentService
+}
+
+type PaymentRequest = model.PaymentRequest
+
+func NewPaymentHandler(paymentService service.PaymentService) paymentHandler {
+	return paymentHandler{paymentService}
+}
+
+func (h paymentHandler) RequestDeposit(c *gin.Context) {
+	body := PaymentRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	payment, err := h.paymentService.RequestDeposit(uid, body)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully requested deposit",
+		"payment": payment,
+	})
+}
+
+func (h paymentHandler) RequestWithdraw(c *gin.Context) {
+	body := PaymentRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	payment, err := h.paymentService.RequestWithdraw(uid, body)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully requested withdrawal",
+		"payment": payment,
+	})
+}
+
+// called by the payment provider, the body is passed as is so the provider
+// can verify its signature
+func (h paymentHandler) HandleCallback(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	signature := c.GetHeader(service.PaymentSignatureHeader)
+
+	message, err := h.paymentService.HandleCallback(body, signature)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
+
+func (h paymentHandler) ApprovePayment(c *gin.Context) {
+	paymentId := c.Param("paymentId")
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.paymentService.ApprovePayment(paymentId, uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
+
+func (h paymentHandler) RejectPayment(c *gin.Context) {
+	paymentId := c.Param("paymentId")
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.paymentService.RejectPayment(paymentId, uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
+
+func (h paymentHandler) GetPayment(c *gin.Context) {
+	paymentId := c.Param("paymentId")
+	uid := c.MustGet("uid").(string)
+
+	payment, err := h.paymentService.GetPayment(uid, paymentId)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully fetched payment",
+		"payment": payment,
+	})
+}
+
+func (h paymentHandler) GetUserPayments(c *gin.Context) {
+	startPage, err := strconv.Atoi(c.Query("startPage"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	payments, err := h.paymentService.GetUserPayments(uid, uint(startPage))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":  "Successfully fetched payments",
+		"payments": payments,
+	})
+}
+
+func (h paymentHandler) GetPendingApprovals(c *gin.Context) {
+	payments, err := h.paymentService.GetPendingApprovals()
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":  "Successfully fetched pending approvals",
+		"payments": payments,
+	})
+}
@@ -0,0 +1,279 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/handler"
+	"server/model"
+	"server/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Payment = model.Payment
+type PaymentRequest = model.PaymentRequest
+
+func paymentPath(route string) string {
+	return fmt.Sprintf("/api/v1/payment/%s", route)
+}
+
+func TestRequestDeposit(t *testing.T) {
+	expectedMessage := "Successfully requested deposit"
+	expectedPayment := Payment{
+		ID:        primitive.NewObjectID(),
+		UID:       userId,
+		Method:    "DEPOSIT",
+		Currency:  "THB",
+		Amount:    100,
+		Status:    model.PaymentPending,
+		Reference: "local_1",
+	}
+	path := paymentPath("deposit")
+
+	t.Run("Successfully request deposit", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		paymentService := service.NewPaymentServiceMock()
+		testBody := PaymentRequest{Amount: 100}
+
+		paymentService.
+			On("RequestDeposit", userId, testBody).
+			Return(expectedPayment, nil)
+
+		paymentHandler := handler.NewPaymentHandler(paymentService)
+
+		reqBody, _ := json.Marshal(testBody)
+		req, err := http.NewRequest(
+			"POST",
+			path,
+			bytes.NewBuffer(reqBody),
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Set("uid", userId)
+		})
+
+		router.POST(path, paymentHandler.RequestDeposit)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusOK,
+				recorder.Code,
+			)
+		}
+
+		expectedJsonPayment, _ := json.Marshal(expectedPayment)
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s","payment":%v}`,
+			expectedMessage,
+			string(expectedJsonPayment),
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+
+	t.Run("Error daily limit", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		paymentService := service.NewPaymentServiceMock()
+		testBody := PaymentRequest{Amount: 100000}
+
+		paymentService.
+			On("RequestDeposit", userId, testBody).
+			Return(Payment{}, errs.ErrDailyLimit)
+
+		paymentHandler := handler.NewPaymentHandler(paymentService)
+
+		reqBody, _ := json.Marshal(testBody)
+		req, err := http.NewRequest(
+			"POST",
+			path,
+			bytes.NewBuffer(reqBody),
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Set("uid", userId)
+		})
+
+		router.POST(path, paymentHandler.RequestDeposit)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusBadRequest,
+				recorder.Code,
+			)
+		}
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s"}`,
+			errs.ErrDailyLimit.Error(),
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+}
+
+func TestHandlePaymentCallback(t *testing.T) {
+	expectedMessage := "Successfully handled payment callback"
+	path := paymentPath("callback")
+
+	t.Run("Successfully handle callback", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		paymentService := service.NewPaymentServiceMock()
+		reqBody := []byte(`{"paymentId":"1","status":"completed"}`)
+
+		paymentService.
+			On("HandleCallback", reqBody, "signature").
+			Return(expectedMessage, nil)
+
+		paymentHandler := handler.NewPaymentHandler(paymentService)
+
+		req, err := http.NewRequest(
+			"POST",
+			path,
+			bytes.NewBuffer(reqBody),
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(service.PaymentSignatureHeader, "signature")
+		recorder := httptest.NewRecorder()
+
+		router.POST(path, paymentHandler.HandleCallback)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusOK,
+				recorder.Code,
+			)
+		}
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s"}`,
+			expectedMessage,
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+}
+
+func TestApprovePayment(t *testing.T) {
+	expectedMessage := "Successfully approved payment"
+	paymentId := primitive.NewObjectID().Hex()
+	path := paymentPath("admin/approve/:paymentId")
+
+	cases := []struct {
+		name         string
+		uid          string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully approve payment",
+			"admin",
+			http.StatusOK,
+			fmt.Sprintf(`{"message":"%s"}`, expectedMessage),
+		},
+		{
+			"Error not admin",
+			userId,
+			http.StatusForbidden,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrAdmin.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			paymentService := service.NewPaymentServiceMock()
+
+			paymentService.
+				On("ApprovePayment", paymentId, "admin").
+				Return(expectedMessage, nil)
+
+			paymentHandler := handler.NewPaymentHandler(paymentService)
+
+			req, err := http.NewRequest(
+				"POST",
+				paymentPath("admin/approve/"+paymentId),
+				nil,
+			)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", c.uid)
+			})
+
+			router.POST(path, handler.AdminOnly([]string{"admin"}), paymentHandler.ApprovePayment)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"server/model"
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type profileHandler struct {
+	profileService service.ProfileService
+}
+
+type EditProfileRequest = model.EditProfileRequest
+
+func NewProfileHandler(profileService service.ProfileService) profileHandler {
+	return profileHandler{profileService}
+}
+
+func (h profileHandler) EditProfile(c *gin.Context) {
+	body := EditProfileRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.profileService.EditProfile(uid, body)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
+
+func (h profileHandler) EditAvatar(c *gin.Context) {
+	file, err := c.FormFile("profile_image")
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": "invalid file",
+		})
+
+		return
+	}
+
+	blobFile, err := file.Open()
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": "invalid file",
+		})
+
+		return
+	}
+	defer blobFile.Close()
+
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.profileService.EditAvatar(uid, blobFile)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
@@ -0,0 +1,183 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"server/errs"
+	"server/handler"
+	"server/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+type EditProfileRequest = handler.EditProfileRequest
+
+func TestEditProfile(t *testing.T) {
+	expectedMessage := "Successfully updated profile"
+	testBody := EditProfileRequest{Name: "test", Email: "test@example.com"}
+
+	cases := []struct {
+		name         string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully edit profile",
+			nil,
+			http.StatusOK,
+			fmt.Sprintf(`{"message":"%s"}`, expectedMessage),
+		},
+		{
+			"Error invalid email",
+			errs.ErrEmail,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrEmail.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			profileService := service.NewProfileServiceMock()
+
+			profileService.
+				On("EditProfile", userId, testBody).
+				Return(expectedMessage, c.err)
+
+			profileHandler := handler.NewProfileHandler(profileService)
+
+			reqBody, _ := json.Marshal(testBody)
+			req, err := http.NewRequest(
+				"POST",
+				userPath("edit-profile"),
+				bytes.NewBuffer(reqBody),
+			)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", userId)
+			})
+
+			router.POST(userPath("edit-profile"), profileHandler.EditProfile)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestEditAvatar(t *testing.T) {
+	expectedMessage := "Successfully updated avatar"
+
+	cases := []struct {
+		name         string
+		withFile     bool
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully edit avatar",
+			true,
+			nil,
+			http.StatusOK,
+			fmt.Sprintf(`{"message":"%s"}`, expectedMessage),
+		},
+		{
+			"Error image dimension",
+			true,
+			errs.ErrImageDimension,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrImageDimension.Error()),
+		},
+		{
+			"Error invalid file",
+			false,
+			nil,
+			http.StatusBadRequest,
+			`{"message":"invalid file"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			profileService := service.NewProfileServiceMock()
+
+			profileService.
+				On("EditAvatar", userId, mock.Anything).
+				Return(expectedMessage, c.err)
+
+			profileHandler := handler.NewProfileHandler(profileService)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			if c.withFile {
+				part, _ := writer.CreateFormFile("profile_image", "test.png")
+				image, _ := os.ReadFile("test.png")
+				part.Write(image)
+			}
+			writer.Close()
+
+			req, err := http.NewRequest("POST", userPath("edit-avatar"), body)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", userId)
+			})
+
+			router.POST(userPath("edit-avatar"), profileHandler.EditAvatar)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
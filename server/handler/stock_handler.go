@@ -6,7 +6,6 @@ import (
 	"server/service"
 
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,9 +18,8 @@ type StockCollectionRequest = model.StockCollectionRequest
 type StockHistory = model.StockHistory
 type CreateStockRequest = model.CreateStockRequest
 type SetPriceRequest = model.SetPriceRequest
-type CreateOrderRequest = model.CreateOrderRequest
 type EditNameRequest = model.EditNameRequest
-type EditSignRequest = model.EditSignRequest
+type TradingRule = model.TradingRule
 
 var (
 	ErrData  = errs.ErrData
@@ -67,6 +65,7 @@ func (h stockHandler) CreateStockCollection(c *gin.Context) {
 		Name:       c.PostForm("name"),
 		Sign:       c.PostForm("sign"),
 		Price:      price,
+		Currency:   c.PostForm("currency"),
 	}
 
 	message, err := h.stockService.CreateStockCollection(stock)
@@ -83,39 +82,6 @@ func (h stockHandler) CreateStockCollection(c *gin.Context) {
 	})
 }
 
-func (h stockHandler) CreateStockOrder(c *gin.Context) {
-	stockId := c.Param("stockId")
-	body := CreateOrderRequest{}
-
-	if err := c.ShouldBind(&body); err != nil {
-		c.JSON(400, gin.H{
-			"message": ErrData.Error(),
-		})
-
-		return
-	}
-
-	order := StockHistory{
-		ID:        c.MustGet("uid").(string),
-		Timestamp: int64(time.Now().Unix()),
-		Price:     body.Price,
-		Amount:    body.Amount,
-	}
-
-	message, err := h.stockService.CreateStockOrder(stockId, order)
-	if err != nil {
-		c.JSON(400, gin.H{
-			"message": err.Error(),
-		})
-
-		return
-	}
-
-	c.JSON(200, gin.H{
-		"message": message,
-	})
-}
-
 func (h stockHandler) GetAllStockCollections(c *gin.Context) {
 	stocks, err := h.stockService.GetAllStockCollections()
 	if err != nil {
@@ -220,6 +186,24 @@ func (h stockHandler) GetStockGraph(c *gin.Context) {
 	})
 }
 
+func (h stockHandler) GetStockTradingRule(c *gin.Context) {
+	stockId := c.Param("stockId")
+
+	tradingRule, err := h.stockService.GetStockTradingRule(stockId)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":     "Successfully fetched trading rule",
+		"tradingRule": tradingRule,
+	})
+}
+
 func (h stockHandler) SetStockPrice(c *gin.Context) {
 	stockId := c.Param("stockId")
 	body := SetPriceRequest{}
@@ -246,9 +230,9 @@ func (h stockHandler) SetStockPrice(c *gin.Context) {
 	})
 }
 
-func (h stockHandler) EditStockName(c *gin.Context) {
+func (h stockHandler) SetStockTradingRule(c *gin.Context) {
 	stockId := c.Param("stockId")
-	body := EditNameRequest{}
+	body := TradingRule{}
 
 	if err := c.ShouldBind(&body); err != nil {
 		c.JSON(400, gin.H{
@@ -258,7 +242,7 @@ func (h stockHandler) EditStockName(c *gin.Context) {
 		return
 	}
 
-	message, err := h.stockService.EditStockName(stockId, body.Name)
+	message, err := h.stockService.SetStockTradingRule(stockId, body)
 	if err != nil {
 		c.JSON(400, gin.H{
 			"message": err.Error(),
@@ -272,9 +256,9 @@ func (h stockHandler) EditStockName(c *gin.Context) {
 	})
 }
 
-func (h stockHandler) EditStockSign(c *gin.Context) {
+func (h stockHandler) EditStockName(c *gin.Context) {
 	stockId := c.Param("stockId")
-	body := EditSignRequest{}
+	body := EditNameRequest{}
 
 	if err := c.ShouldBind(&body); err != nil {
 		c.JSON(400, gin.H{
@@ -284,7 +268,7 @@ func (h stockHandler) EditStockSign(c *gin.Context) {
 		return
 	}
 
-	message, err := h.stockService.EditStockSign(stockId, body.Sign)
+	message, err := h.stockService.EditStockName(stockId, body.Name)
 	if err != nil {
 		c.JSON(400, gin.H{
 			"message": err.Error(),
@@ -298,10 +282,28 @@ func (h stockHandler) EditStockSign(c *gin.Context) {
 	})
 }
 
-func (h stockHandler) DeleteStockCollection(c *gin.Context) {
+func (h stockHandler) EditStockImage(c *gin.Context) {
 	stockId := c.Param("stockId")
+	file, err := c.FormFile("stock_image")
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": "invalid file",
+		})
+
+		return
+	}
+
+	blobFile, err := file.Open()
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": "invalid file",
+		})
+
+		return
+	}
+	defer blobFile.Close()
 
-	message, err := h.stockService.DeleteStockCollection(stockId)
+	message, err := h.stockService.EditStockImage(stockId, blobFile)
 	if err != nil {
 		c.JSON(400, gin.H{
 			"message": err.Error(),
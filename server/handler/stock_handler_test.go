@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"time"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -21,14 +20,13 @@ import (
 )
 
 type StockCollectionRequest = model.StockCollectionRequest
-type CreateOrderRequest = model.CreateOrderRequest
 type StockHistory = model.StockHistory
 type TopStock = model.TopStock
 type StockHistoryResponse = model.StockHistoryResponse
 type Graph = model.Graph
 type SetPriceRequest = model.SetPriceRequest
 type EditNameRequest = model.EditNameRequest
-type EditSignRequest = model.EditSignRequest
+type TradingRule = model.TradingRule
 
 var (
 	ErrPrice = errs.ErrPrice
@@ -218,150 +216,6 @@ func TestCreateStockCollection(t *testing.T) {
 	})
 }
 
-func TestCreateStockOrder(t *testing.T) {
-	expectedMessage := "Successfully created stock order"
-
-	t.Run("Successfully create stock order", func(t *testing.T) {
-		gin.SetMode(gin.TestMode)
-		router := gin.Default()
-		url := stockPath("create-order/12345")
-
-		stockService := service.NewStockServiceMock()
-
-		testBody := CreateOrderRequest{
-			Amount: 1,
-			Price:  1,
-		}
-
-		order := StockHistory{
-			ID:        userId,
-			Timestamp: int64(time.Now().Unix()),
-			Price:     1,
-			Amount:    1,
-		}
-
-		stockService.
-			On("CreateStockOrder", "12345", order).
-			Return(expectedMessage, nil)
-
-		stockHandler := handler.NewStockHandler(stockService)
-
-		jsonBody, _ := json.Marshal(testBody)
-
-		req, err := http.NewRequest(
-			"POST",
-			url,
-			bytes.NewBuffer(jsonBody),
-		)
-		if err != nil {
-			t.Fatalf("failed to create request: %v", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		recorder := httptest.NewRecorder()
-		router.Use(func(c *gin.Context) {
-			c.Set("uid", userId)
-			c.Params = gin.Params{
-				{
-					Key:   "stockId",
-					Value: "12345",
-				},
-			}
-		})
-
-		router.POST(url, stockHandler.CreateStockOrder)
-		router.ServeHTTP(recorder, req)
-
-		if recorder.Code != http.StatusOK {
-			t.Errorf(
-				"Expected status code %d, got %d",
-				http.StatusOK,
-				recorder.Code,
-			)
-		}
-
-		expectedResponseBody := fmt.Sprintf(
-			`{"message":"%s"}`, 
-			expectedMessage,
-		)
-
-		if recorder.Body.String() != expectedResponseBody {
-			t.Errorf(
-				"Expected response body %s, got %s",
-				expectedResponseBody,
-				recorder.Body.String(),
-			)
-		}
-	})
-
-	t.Run("Error on handler param", func(t *testing.T) {
-		gin.SetMode(gin.TestMode)
-		router := gin.Default()
-		url := stockPath("create-order/")
-
-		stockService := service.NewStockServiceMock()
-
-		testBody := CreateOrderRequest{
-			Amount: 1,
-			Price:  1,
-		}
-
-		order := StockHistory{
-			ID:        userId,
-			Timestamp: int64(time.Now().Unix()),
-			Price:     1,
-			Amount:    1,
-		}
-
-		stockService.
-			On("CreateStockOrder", "", order).
-			Return(expectedMessage, ErrData)
-
-		stockHandler := handler.NewStockHandler(stockService)
-
-		jsonBody, _ := json.Marshal(testBody)
-
-		req, err := http.NewRequest(
-			"POST",
-			url,
-			bytes.NewBuffer(jsonBody),
-		)
-		if err != nil {
-			t.Fatalf("failed to create request: %v", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		recorder := httptest.NewRecorder()
-		router.Use(func(c *gin.Context) {
-			c.Set("uid", userId)
-		})
-
-		router.POST(url, stockHandler.CreateStockOrder)
-		router.ServeHTTP(recorder, req)
-
-		if recorder.Code != http.StatusBadRequest {
-			t.Errorf(
-				"Expected status code %d, got %d",
-				http.StatusBadRequest,
-				recorder.Code,
-			)
-		}
-
-		expectedResponseBody := fmt.Sprintf(
-			`{"message":"%s"}`, 
-			ErrData.Error(),
-		)
-
-		if recorder.Body.String() != expectedResponseBody {
-			t.Errorf(
-				"Expected response body %s, got %s",
-				expectedResponseBody,
-				recorder.Body.String(),
-			)
-		}
-	})
-}
-
 func TestGetAllStockCollections(t *testing.T) {
 	expectedMessage := "Successfully fetched all stocks"
 	expectedStockCollections := []StockCollectionResponse{
@@ -566,7 +420,7 @@ func TestGetStockCollection(t *testing.T) {
 	t.Run("Error on handler param", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("create-order/")
+		url := stockPath("collection/")
 
 		stockService := service.NewStockServiceMock()
 
@@ -966,38 +820,36 @@ func TestGetStockGraph(t *testing.T) {
 	})
 }
 
-func TestSetStockPrice(t *testing.T) {
-	expectedMessage := "Successfully set price"
+func TestGetStockTradingRule(t *testing.T) {
+	expectedMessage := "Successfully fetched trading rule"
+	expectedTradingRule := TradingRule{
+		TickSize:    0.5,
+		LotSize:     1,
+		MinQuantity: 1,
+	}
 
-	t.Run("Successfully set stock price", func(t *testing.T) {
+	t.Run("Successfully get trading rule", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("set-price/12345")
-
-		testBody := SetPriceRequest{
-			Price: 1.1,
-		}
+		url := stockPath("trading-rule/12345")
 
 		stockService := service.NewStockServiceMock()
 
 		stockService.
-			On("SetStockPrice", "12345", testBody.Price).
-			Return(expectedMessage, nil)
+			On("GetStockTradingRule", "12345").
+			Return(expectedTradingRule, nil)
 
 		stockHandler := handler.NewStockHandler(stockService)
 
-		jsonBody, _ := json.Marshal(testBody)
-
 		req, err := http.NewRequest(
-			"POST",
+			"GET",
 			url,
-			bytes.NewBuffer(jsonBody),
+			nil,
 		)
 		if err != nil {
 			t.Fatalf("failed to create request: %v", err)
 		}
 
-		req.Header.Set("Content-Type", "application/json")
 		recorder := httptest.NewRecorder()
 		router.Use(func(c *gin.Context) {
 			c.Params = []gin.Param{
@@ -1008,7 +860,7 @@ func TestSetStockPrice(t *testing.T) {
 			}
 		})
 
-		router.POST(url, stockHandler.SetStockPrice)
+		router.GET(url, stockHandler.GetStockTradingRule)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -1019,9 +871,11 @@ func TestSetStockPrice(t *testing.T) {
 			)
 		}
 
+		jsonTradingRule, _ := json.Marshal(expectedTradingRule)
 		expectedResponseBody := fmt.Sprintf(
-			`{"message":"%s"}`,
+			`{"message":"%s","tradingRule":%s}`,
 			expectedMessage,
+			jsonTradingRule,
 		)
 
 		if recorder.Body.String() != expectedResponseBody {
@@ -1036,35 +890,28 @@ func TestSetStockPrice(t *testing.T) {
 	t.Run("Error on handler param", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("set-price/")
-
-		testBody := SetPriceRequest{
-			Price: 1.1,
-		}
+		url := stockPath("trading-rule/")
 
 		stockService := service.NewStockServiceMock()
 
 		stockService.
-			On("SetStockPrice", "", testBody.Price).
-			Return(expectedMessage, ErrInvalidStock)
+			On("GetStockTradingRule", "").
+			Return(TradingRule{}, ErrInvalidStock)
 
 		stockHandler := handler.NewStockHandler(stockService)
 
-		jsonBody, _ := json.Marshal(testBody)
-
 		req, err := http.NewRequest(
-			"POST",
+			"GET",
 			url,
-			bytes.NewBuffer(jsonBody),
+			nil,
 		)
 		if err != nil {
 			t.Fatalf("failed to create request: %v", err)
 		}
 
-		req.Header.Set("Content-Type", "application/json")
 		recorder := httptest.NewRecorder()
 
-		router.POST(url, stockHandler.SetStockPrice)
+		router.GET(url, stockHandler.GetStockTradingRule)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
@@ -1077,7 +924,7 @@ func TestSetStockPrice(t *testing.T) {
 
 		expectedResponseBody := fmt.Sprintf(
 			`{"message":"%s"}`,
-			ErrInvalidStock,
+			ErrInvalidStock.Error(),
 		)
 
 		if recorder.Body.String() != expectedResponseBody {
@@ -1090,22 +937,22 @@ func TestSetStockPrice(t *testing.T) {
 	})
 }
 
-func TestEditStockName(t *testing.T) {
-	expectedMessage := "Successfully updated name"
+func TestSetStockPrice(t *testing.T) {
+	expectedMessage := "Successfully set price"
 
-	t.Run("Successfully edit stock name", func(t *testing.T) {
+	t.Run("Successfully set stock price", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("edit-name/12345")
-
-		stockService := service.NewStockServiceMock()
+		url := stockPath("set-price/12345")
 
-		testBody := EditNameRequest{
-			Name: "test",
+		testBody := SetPriceRequest{
+			Price: 1.1,
 		}
 
+		stockService := service.NewStockServiceMock()
+
 		stockService.
-			On("EditStockName", "12345", testBody.Name).
+			On("SetStockPrice", "12345", testBody.Price).
 			Return(expectedMessage, nil)
 
 		stockHandler := handler.NewStockHandler(stockService)
@@ -1132,7 +979,7 @@ func TestEditStockName(t *testing.T) {
 			}
 		})
 
-		router.POST(url, stockHandler.EditStockName)
+		router.POST(url, stockHandler.SetStockPrice)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -1160,16 +1007,16 @@ func TestEditStockName(t *testing.T) {
 	t.Run("Error on handler param", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("edit-name/")
-
-		stockService := service.NewStockServiceMock()
+		url := stockPath("set-price/")
 
-		testBody := EditNameRequest{
-			Name: "test",
+		testBody := SetPriceRequest{
+			Price: 1.1,
 		}
 
+		stockService := service.NewStockServiceMock()
+
 		stockService.
-			On("EditStockName", "", testBody.Name).
+			On("SetStockPrice", "", testBody.Price).
 			Return(expectedMessage, ErrInvalidStock)
 
 		stockHandler := handler.NewStockHandler(stockService)
@@ -1188,7 +1035,7 @@ func TestEditStockName(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		recorder := httptest.NewRecorder()
 
-		router.POST(url, stockHandler.EditStockName)
+		router.POST(url, stockHandler.SetStockPrice)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
@@ -1201,7 +1048,7 @@ func TestEditStockName(t *testing.T) {
 
 		expectedResponseBody := fmt.Sprintf(
 			`{"message":"%s"}`,
-			ErrInvalidStock.Error(),
+			ErrInvalidStock,
 		)
 
 		if recorder.Body.String() != expectedResponseBody {
@@ -1214,22 +1061,26 @@ func TestEditStockName(t *testing.T) {
 	})
 }
 
-func TestEditStockSign(t *testing.T) {
-	expectedMessage := "Successfully updated sign"
+func TestSetStockTradingRule(t *testing.T) {
+	expectedMessage := "Successfully set trading rule"
 
-	t.Run("Successfully edit stock sign", func(t *testing.T) {
+	t.Run("Successfully set trading rule", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("edit-sign/12345")
+		url := stockPath("set-trading-rule/12345")
 
-		stockService := service.NewStockServiceMock()
-
-		testBody := EditSignRequest{
-			Sign: "test",
+		testBody := TradingRule{
+			TickSize:    0.05,
+			LotSize:     100,
+			MinQuantity: 100,
+			MaxQuantity: 10000,
+			MinNotional: 500,
 		}
 
+		stockService := service.NewStockServiceMock()
+
 		stockService.
-			On("EditStockSign", "12345", testBody.Sign).
+			On("SetStockTradingRule", "12345", testBody).
 			Return(expectedMessage, nil)
 
 		stockHandler := handler.NewStockHandler(stockService)
@@ -1256,7 +1107,7 @@ func TestEditStockSign(t *testing.T) {
 			}
 		})
 
-		router.POST(url, stockHandler.EditStockSign)
+		router.POST(url, stockHandler.SetStockTradingRule)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -1281,20 +1132,21 @@ func TestEditStockSign(t *testing.T) {
 		}
 	})
 
-	t.Run("Error on handler param", func(t *testing.T) {
+	t.Run("Error on service invalid trading rule", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("edit-sign/")
-
-		stockService := service.NewStockServiceMock()
+		url := stockPath("set-trading-rule/12345")
 
-		testBody := EditSignRequest{
-			Sign: "test",
+		testBody := TradingRule{
+			MinQuantity: 100,
+			MaxQuantity: 10,
 		}
 
+		stockService := service.NewStockServiceMock()
+
 		stockService.
-			On("EditStockSign", "", testBody.Sign).
-			Return(expectedMessage, ErrInvalidStock)
+			On("SetStockTradingRule", "12345", testBody).
+			Return("", errs.ErrTradingRule)
 
 		stockHandler := handler.NewStockHandler(stockService)
 
@@ -1311,8 +1163,16 @@ func TestEditStockSign(t *testing.T) {
 
 		req.Header.Set("Content-Type", "application/json")
 		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Params = []gin.Param{
+				{
+					Key: "stockId",
+					Value: "12345",
+				},
+			}
+		})
 
-		router.POST(url, stockHandler.EditStockSign)
+		router.POST(url, stockHandler.SetStockTradingRule)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
@@ -1325,7 +1185,7 @@ func TestEditStockSign(t *testing.T) {
 
 		expectedResponseBody := fmt.Sprintf(
 			`{"message":"%s"}`,
-			ErrInvalidStock.Error(),
+			errs.ErrTradingRule.Error(),
 		)
 
 		if recorder.Body.String() != expectedResponseBody {
@@ -1338,31 +1198,38 @@ func TestEditStockSign(t *testing.T) {
 	})
 }
 
-func TestDeleteStockCollection(t *testing.T) {
-	expectedMessage := "Successfully deleted stock"
+func TestEditStockName(t *testing.T) {
+	expectedMessage := "Successfully updated name"
 
-	t.Run("Successfully deleted stock", func(t *testing.T) {
+	t.Run("Successfully edit stock name", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("delete/12345")
+		url := stockPath("edit-name/12345")
 
 		stockService := service.NewStockServiceMock()
 
+		testBody := EditNameRequest{
+			Name: "test",
+		}
+
 		stockService.
-			On("DeleteStockCollection", "12345").
+			On("EditStockName", "12345", testBody.Name).
 			Return(expectedMessage, nil)
 
 		stockHandler := handler.NewStockHandler(stockService)
 
+		jsonBody, _ := json.Marshal(testBody)
+
 		req, err := http.NewRequest(
-			"DELETE",
+			"POST",
 			url,
-			nil,
+			bytes.NewBuffer(jsonBody),
 		)
 		if err != nil {
 			t.Fatalf("failed to create request: %v", err)
 		}
 
+		req.Header.Set("Content-Type", "application/json")
 		recorder := httptest.NewRecorder()
 		router.Use(func(c *gin.Context) {
 			c.Params = []gin.Param{
@@ -1373,7 +1240,7 @@ func TestDeleteStockCollection(t *testing.T) {
 			}
 		})
 
-		router.DELETE(url, stockHandler.DeleteStockCollection)
+		router.POST(url, stockHandler.EditStockName)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -1398,39 +1265,38 @@ func TestDeleteStockCollection(t *testing.T) {
 		}
 	})
 
-	t.Run("Successfully deleted stock", func(t *testing.T) {
+	t.Run("Error on handler param", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
-		url := stockPath("delete/")
+		url := stockPath("edit-name/")
 
 		stockService := service.NewStockServiceMock()
 
+		testBody := EditNameRequest{
+			Name: "test",
+		}
+
 		stockService.
-			On("DeleteStockCollection", "").
+			On("EditStockName", "", testBody.Name).
 			Return(expectedMessage, ErrInvalidStock)
 
 		stockHandler := handler.NewStockHandler(stockService)
 
+		jsonBody, _ := json.Marshal(testBody)
+
 		req, err := http.NewRequest(
-			"DELETE",
+			"POST",
 			url,
-			nil,
+			bytes.NewBuffer(jsonBody),
 		)
 		if err != nil {
 			t.Fatalf("failed to create request: %v", err)
 		}
 
+		req.Header.Set("Content-Type", "application/json")
 		recorder := httptest.NewRecorder()
-		router.Use(func(c *gin.Context) {
-			c.Params = []gin.Param{
-				{
-					Key: "stockId",
-					Value: "",
-				},
-			}
-		})
 
-		router.DELETE(url, stockHandler.DeleteStockCollection)
+		router.POST(url, stockHandler.EditStockName)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
@@ -1455,4 +1321,91 @@ func TestDeleteStockCollection(t *testing.T) {
 		}
 	})
 }
+
  
+
+func TestEditStockImage(t *testing.T) {
+	stockId := "65c39a03dfb8060d99995936"
+	url := stockPath("edit-image/" + stockId)
+
+	cases := []struct {
+		name         string
+		withFile     bool
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully edit stock image",
+			true,
+			nil,
+			http.StatusOK,
+			`{"message":"Successfully updated stock image"}`,
+		},
+		{
+			"Error image type",
+			true,
+			errs.ErrImageType,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrImageType.Error()),
+		},
+		{
+			"Error invalid file",
+			false,
+			nil,
+			http.StatusBadRequest,
+			`{"message":"invalid file"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			stockService := service.NewStockServiceMock()
+
+			stockService.
+				On("EditStockImage", stockId, mock.Anything).
+				Return("Successfully updated stock image", c.err)
+
+			stockHandler := handler.NewStockHandler(stockService)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			if c.withFile {
+				part, _ := writer.CreateFormFile("stock_image", "test.png")
+				image, _ := os.ReadFile("test.png")
+				part.Write(image)
+			}
+			writer.Close()
+
+			req, err := http.NewRequest("POST", url, body)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			recorder := httptest.NewRecorder()
+
+			router.POST(stockPath("edit-image/:stockId"), stockHandler.EditStockImage)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"server/model"
+	"server/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type stockStatusHandler struct {
+	stockStatusService service.StockStatusService
+}
+
+type StockStatusRequest = model.StockStatusRequest
+type DelistRequest = model.DelistRequest
+
+func NewStockStatusHandler(stockStatusService service.StockStatusService) stockStatusHandler {
+	return stockStatusHandler{stockStatusService}
+}
+
+func (h stockStatusHandler) SetStockStatus(c *gin.Context) {
+	stockId := c.Param("stockId")
+	body := StockStatusRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.stockStatusService.SetStockStatus(stockId, body, uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
+
+func (h stockStatusHandler) DelistStock(c *gin.Context) {
+	stockId := c.Param("stockId")
+	body := DelistRequest{}
+
+	if err := c.ShouldBind(&body); err != nil {
+		c.JSON(400, gin.H{
+			"message": ErrData.Error(),
+		})
+
+		return
+	}
+
+	uid := c.MustGet("uid").(string)
+
+	message, err := h.stockStatusService.DelistStock(stockId, body, uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": message,
+	})
+}
@@ -0,0 +1,162 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/handler"
+	"server/model"
+	"server/service"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockStatusRequest = model.StockStatusRequest
+type DelistRequest = model.DelistRequest
+
+func TestSetStockStatus(t *testing.T) {
+	expectedMessage := "Successfully set stock status"
+	stockId := "65c39a03dfb8060d99995934"
+	testBody := StockStatusRequest{Status: model.StockHalted, Reason: "pending news"}
+
+	cases := []struct {
+		name         string
+		uid          string
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			"Successfully set stock status",
+			"admin",
+			nil,
+			http.StatusOK,
+			fmt.Sprintf(`{"message":"%s"}`, expectedMessage),
+		},
+		{
+			"Error stock status",
+			"admin",
+			errs.ErrStockStatus,
+			http.StatusBadRequest,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrStockStatus.Error()),
+		},
+		{
+			"Error not admin",
+			userId,
+			nil,
+			http.StatusForbidden,
+			fmt.Sprintf(`{"message":"%s"}`, errs.ErrAdmin.Error()),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			stockStatusService := service.NewStockStatusServiceMock()
+
+			stockStatusService.
+				On("SetStockStatus", stockId, testBody, "admin").
+				Return(expectedMessage, c.err)
+
+			stockStatusHandler := handler.NewStockStatusHandler(stockStatusService)
+
+			reqBody, _ := json.Marshal(testBody)
+			req, err := http.NewRequest(
+				"POST",
+				stockPath("admin/set-status/"+stockId),
+				bytes.NewBuffer(reqBody),
+			)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			req.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.Use(func(ctx *gin.Context) {
+				ctx.Set("uid", c.uid)
+			})
+
+			router.POST(
+				stockPath("admin/set-status/:stockId"),
+				handler.AdminOnly([]string{"admin"}),
+				stockStatusHandler.SetStockStatus,
+			)
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != c.expectedCode {
+				t.Errorf(
+					"Expected status code %d, got %d",
+					c.expectedCode,
+					recorder.Code,
+				)
+			}
+
+			if recorder.Body.String() != c.expectedBody {
+				t.Errorf(
+					"Expected response body %s, got %s",
+					c.expectedBody,
+					recorder.Body.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestDelistStock(t *testing.T) {
+	expectedMessage := "Successfully delisted stock"
+	stockId := "65c39a03dfb8060d99995934"
+	testBody := DelistRequest{Reason: "merged", Settlement: model.SettlementCash, Price: 25}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	stockStatusService := service.NewStockStatusServiceMock()
+
+	stockStatusService.
+		On("DelistStock", stockId, testBody, "admin").
+		Return(expectedMessage, nil)
+
+	stockStatusHandler := handler.NewStockStatusHandler(stockStatusService)
+
+	reqBody, _ := json.Marshal(testBody)
+	req, err := http.NewRequest("POST", stockPath("admin/delist/"+stockId), bytes.NewBuffer(reqBody))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set("uid", "admin")
+	})
+
+	router.POST(
+		stockPath("admin/delist/:stockId"),
+		handler.AdminOnly([]string{"admin"}),
+		stockStatusHandler.DelistStock,
+	)
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Errorf(
+			"Expected status code %d, got %d",
+			http.StatusOK,
+			recorder.Code,
+		)
+	}
+
+	expectedResponseBody := fmt.Sprintf(`{"message":"%s"}`, expectedMessage)
+	if recorder.Body.String() != expectedResponseBody {
+		t.Errorf(
+			"Expected response body %s, got %s",
+			expectedResponseBody,
+			recorder.Body.String(),
+		)
+	}
+}
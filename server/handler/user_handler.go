@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"fmt"
 	"server/errs"
 	"server/model"
 	"server/service"
@@ -20,7 +19,6 @@ type OrderRequest = model.OrderRequest
 
 type UserIdRequest = model.UserIdRequest
 
-type UserBalanceRequest = model.UserBalanceRequest
 type UserSetFavoriteRequest = model.UserSetFavoriteRequest
 
 type FilterBalanceRequest struct {
@@ -60,62 +58,6 @@ func (h userHandler) SignUp(c *gin.Context) {
 	})	
 }
 
-func (h userHandler) DepositBalance(c *gin.Context) {
-	body := UserBalanceRequest{}
-
-	if err := c.ShouldBind(&body); err != nil {
-		c.JSON(400, gin.H{
-			"message": ErrData.Error(),
-		})
-
-		return
-	}
-
-	uid := c.MustGet("uid").(string)
-	deposit := body.Balance
-	fmt.Println("test", uid, deposit)
-	
-	message, err := h.userService.DepositBalance(uid, deposit)
-	if err != nil {
-		c.JSON(400, gin.H{
-			"message": err.Error(),
-		})
-
-		return
-	}
-	
-	c.JSON(200, gin.H{
-		"message": message,
-	})
-}
-
-func (h userHandler) WithdrawBalance(c *gin.Context) {
-	body := UserBalanceRequest{}
-	
-	if err := c.ShouldBind(&body); err != nil {
-		c.JSON(400, gin.H{
-			"message": ErrData.Error(),
-		})
-
-		return
-	}
-
-	uid := c.MustGet("uid").(string)
-	withdraw := body.Balance
-	message, err := h.userService.WithdrawBalance(uid, withdraw)
-	if err != nil {
-		c.JSON(400, gin.H{
-			"message": err.Error(),
-		})
-
-		return 
-	}
-
-	c.JSON(200, gin.H{
-		"message": message,
-	})
-}
-
 func (h userHandler) BuyStock(c *gin.Context) {
 	body := OrderRequest{}
 
@@ -214,6 +156,24 @@ func (h userHandler) GetUserBalance(c *gin.Context) {
 	})
 }
 
+func (h userHandler) GetUserCurrencyBalances(c *gin.Context) {
+	uid := c.MustGet("uid").(string)
+
+	balances, err := h.userService.GetUserCurrencyBalances(uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully fetched user balances",
+		"balances": balances,
+	})
+}
+
 func (h userHandler) GetUserBalanceHistory(c *gin.Context) {
 	startPage, err := strconv.Atoi(c.Query("startPage"))
 	if err != nil {
@@ -223,7 +183,6 @@ func (h userHandler) GetUserBalanceHistory(c *gin.Context) {
 
 		return
 	}
-	fmt.Println(startPage)
 	uid := c.MustGet("uid").(string)
 	method := c.Query("method")
 
@@ -366,6 +325,42 @@ func (h userHandler) GetUserStockAmount(c *gin.Context) {
 	})
 }
 
+func (h userHandler) GetUserFeeSummary(c *gin.Context) {
+	uid := c.MustGet("uid").(string)
+
+	feeSummary, err := h.userService.GetUserFeeSummary(uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully fetched fee summary",
+		"feeSummary": feeSummary,
+	})
+}
+
+func (h userHandler) GetUserPortfolio(c *gin.Context) {
+	uid := c.MustGet("uid").(string)
+
+	portfolio, err := h.userService.GetUserPortfolio(uid)
+	if err != nil {
+		c.JSON(400, gin.H{
+			"message": err.Error(),
+		})
+
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Successfully fetched portfolio",
+		"portfolio": portfolio,
+	})
+}
+
 func (h userHandler) DeleteFavoriteStock(c *gin.Context) {
 	uid := c.MustGet("uid").(string)
 	stockId := c.Query("stockId")
@@ -19,7 +19,6 @@ import (
 type UserAccount = model.UserAccount
 type CreateAccount = model.CreateAccount
 type UserStock = model.UserStock
-type UserBalanceRequest = model.UserBalanceRequest
 type UserSetFavoriteRequest = model.UserSetFavoriteRequest
 type OrderRequest = model.OrderRequest
 type BalanceHistory = model.BalanceHistory
@@ -218,146 +217,28 @@ func TestSignUp(t *testing.T) {
 	})
 }
 
-func TestDepositBalance(t *testing.T) {
-	expectedMessage := "Successfully deposited money"
-	url := userPath("deposit")
-
-	t.Run("Successfully deposit", func(t *testing.T) {
-		gin.SetMode(gin.TestMode)
-		router := gin.Default()
-
-		userService := service.NewUserServiceMock()
-		stockService := service.NewStockServiceMock()
-
-		testBody := UserBalanceRequest{
-			Balance: 1000,
-		}
-
-		userService.
-			On("DepositBalance", userId, testBody.Balance).
-			Return(expectedMessage, nil)
-
-		userHandler := handler.NewUserHandler(userService, stockService)
-
-		jsonBody, _ := json.Marshal(testBody)
-
-		req, err := http.NewRequest(
-			"POST",
-			url,
-			bytes.NewBuffer(jsonBody),
-		)
-		if err != nil {
-			t.Fatalf("failed to create request: %v", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		recorder := httptest.NewRecorder()
-		router.Use(func(c *gin.Context) {
-			c.Set("uid", userId)
-		})
-
-		router.POST(url, userHandler.DepositBalance)
-		router.ServeHTTP(recorder, req)
-
-		if recorder.Code != http.StatusOK {
-			t.Errorf(
-				"Expected status code %d, got %d",
-				http.StatusOK,
-				recorder.Code,
-			)
-		}
-
-		expectedResponseBody := fmt.Sprintf(
-			`{"message":"%s"}`, 
-			expectedMessage,
-		)
-
-		if recorder.Body.String() != expectedResponseBody {
-			t.Errorf(
-				"Expected response body %s, got %s",
-				expectedResponseBody,
-				recorder.Body.String(),
-			)
-		}
-	})
-
-	t.Run("Error on service deposit balance", func(t *testing.T) {
-		gin.SetMode(gin.TestMode)
-		router := gin.Default()
-
-		userService := service.NewUserServiceMock()
-		stockService := service.NewStockServiceMock()
-
-		testBody := UserBalanceRequest{
-			Balance: -1,
-		}
-
-		userService.
-			On("DepositBalance", userId, testBody.Balance).
-			Return(expectedMessage, ErrMoney)
-
-		userHandler := handler.NewUserHandler(userService, stockService)
-
-		jsonBody, _ := json.Marshal(testBody)
-
-		req, err := http.NewRequest(
-			"POST",
-			url,
-			bytes.NewBuffer(jsonBody),
-		)
-		if err != nil {
-			t.Fatalf("failed to create request: %v", err)
-		}
-
-		req.Header.Set("Content-Type", "application/json")
-		recorder := httptest.NewRecorder()
-		router.Use(func(c *gin.Context) {
-			c.Set("uid", userId)
-		})
-
-		router.POST(url, userHandler.DepositBalance)
-		router.ServeHTTP(recorder, req)
-
-		if recorder.Code != http.StatusBadRequest {
-			t.Errorf(
-				"Expected status code %d, got %d",
-				http.StatusBadRequest,
-				recorder.Code,
-			)
-		}
-
-		expectedResponseBody := fmt.Sprintf(
-			`{"message":"%s"}`, 
-			ErrMoney.Error(),
-		)
-
-		if recorder.Body.String() != expectedResponseBody {
-			t.Errorf(
-				"Expected response body %s, got %s",
-				expectedResponseBody,
-				recorder.Body.String(),
-			)
-		}
-	})
-}
-
-func TestWithdrawBalance(t *testing.T) {
-	expectedMessage := "Successfully withdrawed money"
-	url := userPath("withdraw")
+func TestBuyStock(t *testing.T) {
+	expectedMessage := "Successfully bought stock"
+	url := userPath("buy")
 
-	t.Run("Successfully withdraw", func(t *testing.T) {
+	t.Run("Successfully buy stock", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
 		userService := service.NewUserServiceMock()
 		stockService := service.NewStockServiceMock()
 
-		testBody := UserBalanceRequest{
-			Balance: 1000,
+		testBody := OrderRequest{
+			StockId:     "65c39a03dfb8060d99995934",
+			UserId:      "test12345",
+			Price:       60,
+			Amount:      8,
+			OrderType:   "auto",
+			OrderMethod: "buy",
 		}
 
 		userService.
-			On("WithdrawBalance", userId, testBody.Balance).
+			On("BuyStock", testBody).
 			Return(expectedMessage, nil)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
@@ -379,7 +260,7 @@ func TestWithdrawBalance(t *testing.T) {
 			c.Set("uid", userId)
 		})
 
-		router.POST(url, userHandler.WithdrawBalance)
+		router.POST(url, userHandler.BuyStock)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -404,20 +285,25 @@ func TestWithdrawBalance(t *testing.T) {
 		}
 	})
 
-	t.Run("Error on service withdraw balance", func(t *testing.T) {
+	t.Run("Error on service buy stock", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
 		userService := service.NewUserServiceMock()
 		stockService := service.NewStockServiceMock()
 
-		testBody := UserBalanceRequest{
-			Balance: -1,
+		testBody := OrderRequest{
+			StockId:     "65c39a03dfb8060d99995934",
+			UserId:      "test12345",
+			Price:       60,
+			Amount:      8,
+			OrderType:   "auto",
+			OrderMethod: "sale",
 		}
 
 		userService.
-			On("WithdrawBalance", userId, testBody.Balance).
-			Return(expectedMessage, ErrMoney)
+			On("BuyStock", testBody).
+			Return(expectedMessage, ErrOrderMethod)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
 
@@ -438,7 +324,7 @@ func TestWithdrawBalance(t *testing.T) {
 			c.Set("uid", userId)
 		})
 
-		router.POST(url, userHandler.WithdrawBalance)
+		router.POST(url, userHandler.BuyStock)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
@@ -449,10 +335,7 @@ func TestWithdrawBalance(t *testing.T) {
 			)
 		}
 
-		expectedResponseBody := fmt.Sprintf(
-			`{"message":"%s"}`, 
-			ErrMoney.Error(),
-		)
+		expectedResponseBody := fmt.Sprintf(`{"message":"%s"}`, ErrOrderMethod.Error())
 
 		if recorder.Body.String() != expectedResponseBody {
 			t.Errorf(
@@ -464,11 +347,11 @@ func TestWithdrawBalance(t *testing.T) {
 	})
 }
 
-func TestBuyStock(t *testing.T) {
-	expectedMessage := "Successfully bought stock"
-	url := userPath("buy")
+func TestSaleStock(t *testing.T) {
+	expectedMessage := "Successfully sold stock"
+	url := userPath("sale")
 
-	t.Run("Successfully buy stock", func(t *testing.T) {
+	t.Run("Successfully sale stock", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
@@ -481,11 +364,11 @@ func TestBuyStock(t *testing.T) {
 			Price:       60,
 			Amount:      8,
 			OrderType:   "auto",
-			OrderMethod: "buy",
+			OrderMethod: "sale",
 		}
 
 		userService.
-			On("BuyStock", testBody).
+			On("SaleStock", testBody).
 			Return(expectedMessage, nil)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
@@ -507,7 +390,7 @@ func TestBuyStock(t *testing.T) {
 			c.Set("uid", userId)
 		})
 
-		router.POST(url, userHandler.BuyStock)
+		router.POST(url, userHandler.SaleStock)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -532,7 +415,7 @@ func TestBuyStock(t *testing.T) {
 		}
 	})
 
-	t.Run("Error on service buy stock", func(t *testing.T) {
+	t.Run("Error on service sale stock", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
@@ -545,11 +428,11 @@ func TestBuyStock(t *testing.T) {
 			Price:       60,
 			Amount:      8,
 			OrderType:   "auto",
-			OrderMethod: "sale",
+			OrderMethod: "buy",
 		}
 
 		userService.
-			On("BuyStock", testBody).
+			On("SaleStock", testBody).
 			Return(expectedMessage, ErrOrderMethod)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
@@ -571,7 +454,7 @@ func TestBuyStock(t *testing.T) {
 			c.Set("uid", userId)
 		})
 
-		router.POST(url, userHandler.BuyStock)
+		router.POST(url, userHandler.SaleStock)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
@@ -582,7 +465,10 @@ func TestBuyStock(t *testing.T) {
 			)
 		}
 
-		expectedResponseBody := fmt.Sprintf(`{"message":"%s"}`, ErrOrderMethod.Error())
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s"}`, 
+			ErrOrderMethod.Error(),
+		)
 
 		if recorder.Body.String() != expectedResponseBody {
 			t.Errorf(
@@ -594,28 +480,23 @@ func TestBuyStock(t *testing.T) {
 	})
 }
 
-func TestSaleStock(t *testing.T) {
-	expectedMessage := "Successfully sold stock"
-	url := userPath("sale")
+func TestSetFavoriteStock(t *testing.T) {
+	expectedMessage := "Successfully set favorite stock"
+	url := userPath("set-favorite")
 
-	t.Run("Successfully sale stock", func(t *testing.T) {
+	t.Run("Successfully set favorite stock", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
 		userService := service.NewUserServiceMock()
 		stockService := service.NewStockServiceMock()
 
-		testBody := OrderRequest{
-			StockId:     "65c39a03dfb8060d99995934",
-			UserId:      "test12345",
-			Price:       60,
-			Amount:      8,
-			OrderType:   "auto",
-			OrderMethod: "sale",
+		testBody := UserSetFavoriteRequest{
+			StockId: "65c39a03dfb8060d99995934",
 		}
 
 		userService.
-			On("SaleStock", testBody).
+			On("SetFavoriteStock", userId, testBody.StockId).
 			Return(expectedMessage, nil)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
@@ -637,7 +518,7 @@ func TestSaleStock(t *testing.T) {
 			c.Set("uid", userId)
 		})
 
-		router.POST(url, userHandler.SaleStock)
+		router.POST(url, userHandler.SetFavoriteStock)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -662,25 +543,20 @@ func TestSaleStock(t *testing.T) {
 		}
 	})
 
-	t.Run("Error on service sale stock", func(t *testing.T) {
+	t.Run("Error on service set favorite stock", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
 		userService := service.NewUserServiceMock()
 		stockService := service.NewStockServiceMock()
 
-		testBody := OrderRequest{
-			StockId:     "65c39a03dfb8060d99995934",
-			UserId:      "test12345",
-			Price:       60,
-			Amount:      8,
-			OrderType:   "auto",
-			OrderMethod: "buy",
+		testBody := UserSetFavoriteRequest{
+			StockId: "",
 		}
 
 		userService.
-			On("SaleStock", testBody).
-			Return(expectedMessage, ErrOrderMethod)
+			On("SetFavoriteStock", userId, testBody.StockId).
+			Return(expectedMessage, ErrInvalidStock)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
 
@@ -701,7 +577,7 @@ func TestSaleStock(t *testing.T) {
 			c.Set("uid", userId)
 		})
 
-		router.POST(url, userHandler.SaleStock)
+		router.POST(url, userHandler.SetFavoriteStock)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
@@ -714,7 +590,7 @@ func TestSaleStock(t *testing.T) {
 
 		expectedResponseBody := fmt.Sprintf(
 			`{"message":"%s"}`, 
-			ErrOrderMethod.Error(),
+			ErrInvalidStock.Error(),
 		)
 
 		if recorder.Body.String() != expectedResponseBody {
@@ -727,33 +603,28 @@ func TestSaleStock(t *testing.T) {
 	})
 }
 
-func TestSetFavoriteStock(t *testing.T) {
-	expectedMessage := "Successfully set favorite stock"
-	url := userPath("set-favorite")
+func TestGetUserBalance(t *testing.T) {
+	expectedMessage := "Successfully fetched user balance"
+	expectedBalance := 1001
+	url := userPath("balance")
 
-	t.Run("Successfully set favorite stock", func(t *testing.T) {
+	t.Run("Successfully buy stock", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
 		userService := service.NewUserServiceMock()
 		stockService := service.NewStockServiceMock()
 
-		testBody := UserSetFavoriteRequest{
-			StockId: "65c39a03dfb8060d99995934",
-		}
-
 		userService.
-			On("SetFavoriteStock", userId, testBody.StockId).
-			Return(expectedMessage, nil)
+			On("GetUserBalance", userId).
+			Return(expectedBalance, nil)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
 
-		jsonBody, _ := json.Marshal(testBody)
-
 		req, err := http.NewRequest(
-			"POST",
+			"GET",
 			url,
-			bytes.NewBuffer(jsonBody),
+			nil,
 		)
 		if err != nil {
 			t.Fatalf("failed to create request: %v", err)
@@ -765,7 +636,7 @@ func TestSetFavoriteStock(t *testing.T) {
 			c.Set("uid", userId)
 		})
 
-		router.POST(url, userHandler.SetFavoriteStock)
+		router.GET(url, userHandler.GetUserBalance)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -777,7 +648,8 @@ func TestSetFavoriteStock(t *testing.T) {
 		}
 
 		expectedResponseBody := fmt.Sprintf(
-			`{"message":"%s"}`, 
+			`{"balance":%d,"message":"%s"}`,
+			expectedBalance,
 			expectedMessage,
 		)
 
@@ -790,29 +662,23 @@ func TestSetFavoriteStock(t *testing.T) {
 		}
 	})
 
-	t.Run("Error on service set favorite stock", func(t *testing.T) {
+	t.Run("Error on service buy stock", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
 		userService := service.NewUserServiceMock()
 		stockService := service.NewStockServiceMock()
 
-		testBody := UserSetFavoriteRequest{
-			StockId: "",
-		}
-
 		userService.
-			On("SetFavoriteStock", userId, testBody.StockId).
-			Return(expectedMessage, ErrInvalidStock)
+			On("GetUserBalance", "").
+			Return(expectedBalance, ErrUser)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
 
-		jsonBody, _ := json.Marshal(testBody)
-
 		req, err := http.NewRequest(
-			"POST",
+			"GET",
 			url,
-			bytes.NewBuffer(jsonBody),
+			nil,
 		)
 		if err != nil {
 			t.Fatalf("failed to create request: %v", err)
@@ -821,23 +687,23 @@ func TestSetFavoriteStock(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		recorder := httptest.NewRecorder()
 		router.Use(func(c *gin.Context) {
-			c.Set("uid", userId)
+			c.Set("uid", "")
 		})
 
-		router.POST(url, userHandler.SetFavoriteStock)
+		router.GET(url, userHandler.GetUserBalance)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
 			t.Errorf(
 				"Expected status code %d, got %d",
-				http.StatusBadRequest,
+				http.StatusOK,
 				recorder.Code,
 			)
 		}
 
 		expectedResponseBody := fmt.Sprintf(
 			`{"message":"%s"}`, 
-			ErrInvalidStock.Error(),
+			ErrUser.Error(),
 		)
 
 		if recorder.Body.String() != expectedResponseBody {
@@ -850,12 +716,15 @@ func TestSetFavoriteStock(t *testing.T) {
 	})
 }
 
-func TestGetUserBalance(t *testing.T) {
-	expectedMessage := "Successfully fetched user balance"
-	expectedBalance := 1001
-	url := userPath("balance")
+func TestGetUserCurrencyBalances(t *testing.T) {
+	expectedMessage := "Successfully fetched user balances"
+	expectedBalances := map[string]float64{
+		"THB": 1001,
+		"USD": 25.5,
+	}
+	url := userPath("balances")
 
-	t.Run("Successfully buy stock", func(t *testing.T) {
+	t.Run("Successfully get user balances", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
@@ -863,8 +732,8 @@ func TestGetUserBalance(t *testing.T) {
 		stockService := service.NewStockServiceMock()
 
 		userService.
-			On("GetUserBalance", userId).
-			Return(expectedBalance, nil)
+			On("GetUserCurrencyBalances", userId).
+			Return(expectedBalances, nil)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
 
@@ -883,7 +752,7 @@ func TestGetUserBalance(t *testing.T) {
 			c.Set("uid", userId)
 		})
 
-		router.GET(url, userHandler.GetUserBalance)
+		router.GET(url, userHandler.GetUserCurrencyBalances)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusOK {
@@ -895,8 +764,7 @@ func TestGetUserBalance(t *testing.T) {
 		}
 
 		expectedResponseBody := fmt.Sprintf(
-			`{"balance":%d,"message":"%s"}`,
-			expectedBalance,
+			`{"balances":{"THB":1001,"USD":25.5},"message":"%s"}`,
 			expectedMessage,
 		)
 
@@ -909,7 +777,7 @@ func TestGetUserBalance(t *testing.T) {
 		}
 	})
 
-	t.Run("Error on service buy stock", func(t *testing.T) {
+	t.Run("Error invalid user", func(t *testing.T) {
 		gin.SetMode(gin.TestMode)
 		router := gin.Default()
 
@@ -917,8 +785,8 @@ func TestGetUserBalance(t *testing.T) {
 		stockService := service.NewStockServiceMock()
 
 		userService.
-			On("GetUserBalance", "").
-			Return(expectedBalance, ErrUser)
+			On("GetUserCurrencyBalances", "").
+			Return(map[string]float64{}, ErrUser)
 
 		userHandler := handler.NewUserHandler(userService, stockService)
 
@@ -937,19 +805,19 @@ func TestGetUserBalance(t *testing.T) {
 			c.Set("uid", "")
 		})
 
-		router.GET(url, userHandler.GetUserBalance)
+		router.GET(url, userHandler.GetUserCurrencyBalances)
 		router.ServeHTTP(recorder, req)
 
 		if recorder.Code != http.StatusBadRequest {
 			t.Errorf(
 				"Expected status code %d, got %d",
-				http.StatusOK,
+				http.StatusBadRequest,
 				recorder.Code,
 			)
 		}
 
 		expectedResponseBody := fmt.Sprintf(
-			`{"message":"%s"}`, 
+			`{"message":"%s"}`,
 			ErrUser.Error(),
 		)
 
@@ -1903,6 +1771,259 @@ func TestGetUserStockAmount(t *testing.T) {
 	})
 }
 
+func TestGetUserFeeSummary(t *testing.T) {
+	expectedMessage := "Successfully fetched fee summary"
+	expectedFeeSummary := model.FeeSummary{
+		TotalFee:    12.5,
+		TradeCount:  3,
+		Volume30Day: 8000,
+		Percent:     0.0015,
+	}
+	path := userPath("fee-summary")
+
+	t.Run("Successfully get user fee summary", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		userService := service.NewUserServiceMock()
+		stockService := service.NewStockServiceMock()
+
+		userService.
+			On("GetUserFeeSummary", userId).
+			Return(expectedFeeSummary, nil)
+
+		userHandler := handler.NewUserHandler(userService, stockService)
+
+		req, err := http.NewRequest(
+			"GET",
+			path,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Set("uid", "test12345")
+		})
+
+		router.GET(path, userHandler.GetUserFeeSummary)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusOK,
+				recorder.Code,
+			)
+		}
+
+		expectedJsonFeeSummary, _ := json.Marshal(expectedFeeSummary)
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"feeSummary":%v,"message":"%s"}`,
+			string(expectedJsonFeeSummary),
+			expectedMessage,
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+
+	t.Run("Error invalid user", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		userService := service.NewUserServiceMock()
+		stockService := service.NewStockServiceMock()
+
+		userService.
+			On("GetUserFeeSummary", "").
+			Return(model.FeeSummary{}, ErrUser)
+
+		userHandler := handler.NewUserHandler(userService, stockService)
+
+		req, err := http.NewRequest(
+			"GET",
+			path,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Set("uid", "")
+		})
+
+		router.GET(path, userHandler.GetUserFeeSummary)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusBadRequest,
+				recorder.Code,
+			)
+		}
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s"}`,
+			ErrUser.Error(),
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+}
+
+func TestGetUserPortfolio(t *testing.T) {
+	expectedMessage := "Successfully fetched portfolio"
+	expectedPortfolio := model.Portfolio{
+		Cash:          2000,
+		MarketValue:   -900,
+		CostBasis:     -1000,
+		UnrealizedPnl: 100,
+		Equity:        1100,
+		Positions: []model.PortfolioPosition{
+			{
+				StockId:       "65c39a03dfb8060d99995934",
+				Amount:        -10,
+				Currency:      "THB",
+				Price:         90,
+				AveragePrice:  100,
+				Value:         -900,
+				CostBasis:     -1000,
+				UnrealizedPnl: 100,
+			},
+		},
+	}
+	path := userPath("portfolio")
+
+	t.Run("Successfully get user portfolio", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		userService := service.NewUserServiceMock()
+		stockService := service.NewStockServiceMock()
+
+		userService.
+			On("GetUserPortfolio", userId).
+			Return(expectedPortfolio, nil)
+
+		userHandler := handler.NewUserHandler(userService, stockService)
+
+		req, err := http.NewRequest(
+			"GET",
+			path,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Set("uid", "test12345")
+		})
+
+		router.GET(path, userHandler.GetUserPortfolio)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusOK {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusOK,
+				recorder.Code,
+			)
+		}
+
+		expectedJsonPortfolio, _ := json.Marshal(expectedPortfolio)
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s","portfolio":%v}`,
+			expectedMessage,
+			string(expectedJsonPortfolio),
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+
+	t.Run("Error invalid user", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.Default()
+
+		userService := service.NewUserServiceMock()
+		stockService := service.NewStockServiceMock()
+
+		userService.
+			On("GetUserPortfolio", "").
+			Return(model.Portfolio{}, ErrUser)
+
+		userHandler := handler.NewUserHandler(userService, stockService)
+
+		req, err := http.NewRequest(
+			"GET",
+			path,
+			nil,
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.Use(func(c *gin.Context) {
+			c.Set("uid", "")
+		})
+
+		router.GET(path, userHandler.GetUserPortfolio)
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf(
+				"Expected status code %d, got %d",
+				http.StatusBadRequest,
+				recorder.Code,
+			)
+		}
+
+		expectedResponseBody := fmt.Sprintf(
+			`{"message":"%s"}`,
+			ErrUser.Error(),
+		)
+
+		if recorder.Body.String() != expectedResponseBody {
+			t.Errorf(
+				"Expected response body %s, got %s",
+				expectedResponseBody,
+				recorder.Body.String(),
+			)
+		}
+	})
+}
+
 func TestDeleteFavoriteStock(t *testing.T) {
 	expectedMessage := "Successfully deleted favorite stock"
 	path := userPath("delete-favorite")
@@ -0,0 +1,31 @@
+package job
+
+import (
+	"log"
+	"time"
+)
+
+// Every runs fn on its own goroutine each interval until stop is closed,
+// errors are logged and the next run goes ahead
+func Every(name string, interval time.Duration, fn func() error) (stop chan struct{}) {
+	stop = make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := fn()
+				if err != nil {
+					log.Printf("job %s: %v", name, err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop
+}
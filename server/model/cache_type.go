@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+const (
+	CacheRedis  = "redis"
+	CacheMemory = "memory"
+)
+
+type CacheConfig struct {
+	Backend string
+	Redis   RedisConfig
+	// entries kept by the in-process cache, it is also the fallback of redis
+	Size int
+	// how often redis is tried again while it is down
+	RetryInterval time.Duration
+}
+
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+type CacheMessage struct {
+	Channel string
+	Payload string
+}
@@ -0,0 +1,63 @@
+package model
+
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+type Config struct {
+	Profile     string
+	Port        int
+	Timezone    string
+	Mongo       MongoConfig
+	Firebase    FirebaseConfig
+	Cache       CacheConfig
+	ObjectStore ObjectStoreConfig
+	Payment     PaymentConfig
+	Margin      MarginConfig
+	AdminUids   []string
+	// json files, the feature keeps its default when the path is empty
+	FeeScheduleFile    string
+	FxRateFile         string
+	MarketCalendarFile string
+}
+
+type MongoConfig struct {
+	URI         string
+	Database    string
+	Collections MongoCollections
+}
+
+type MongoCollections struct {
+	User            string
+	Stock           string
+	Ledger          string
+	Payment         string
+	CorporateAction string
+	Dividend        string
+	QueuedOrder     string
+}
+
+// firebase is not initialized when the project id is empty
+type FirebaseConfig struct {
+	ProjectID    string
+	PrivateKeyID string
+	PrivateKey   string
+	ClientEmail  string
+	ClientID     string
+	CertURL      string
+}
+
+const (
+	PaymentProviderLocal = "local"
+	PaymentProviderNone  = "none"
+)
+
+// payments fail when there is no provider
+type PaymentConfig struct {
+	Provider      string
+	CallbackURL   string
+	WebhookSecret string
+	Limit         PaymentLimit
+}
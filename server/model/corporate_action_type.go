@@ -0,0 +1,51 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	CorporateActionSplit        = "SPLIT"
+	CorporateActionReverseSplit = "REVERSE_SPLIT"
+	CorporateActionSymbolChange = "SYMBOL_CHANGE"
+)
+
+// scheduled -> applying -> applied, failed
+// scheduled -> cancelled
+const (
+	CorporateActionScheduled = "scheduled"
+	CorporateActionApplying  = "applying"
+	CorporateActionApplied   = "applied"
+	CorporateActionFailed    = "failed"
+	CorporateActionCancelled = "cancelled"
+)
+
+type CorporateActionEvent struct {
+	Status    string `bson:"status" json:"status"`
+	Timestamp int64  `bson:"timestamp" json:"timestamp"`
+	Actor     string `bson:"actor,omitempty" json:"actor,omitempty"` // admin uid or system
+	Note      string `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+// a split of NewShares for every OldShares, 2 for 1 is a split and 1 for
+// 10 a reverse split
+type CorporateAction struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	StockId     string                 `bson:"stockId" json:"stockId"`
+	Type        string                 `bson:"type" json:"type"`
+	NewShares   float64                `bson:"newShares,omitempty" json:"newShares,omitempty"`
+	OldShares   float64                `bson:"oldShares,omitempty" json:"oldShares,omitempty"`
+	OldSign     string                 `bson:"oldSign" json:"oldSign"`
+	NewSign     string                 `bson:"newSign,omitempty" json:"newSign,omitempty"`
+	EffectiveAt int64                  `bson:"effectiveAt" json:"effectiveAt"`
+	Status      string                 `bson:"status" json:"status"`
+	CreatedAt   int64                  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   int64                  `bson:"updatedAt" json:"updatedAt"`
+	History     []CorporateActionEvent `bson:"history" json:"history"`
+}
+
+type CorporateActionRequest struct {
+	Type        string  `json:"type"`
+	NewShares   float64 `json:"newShares"`
+	OldShares   float64 `json:"oldShares"`
+	Sign        string  `json:"sign"`
+	EffectiveAt int64   `json:"effectiveAt"` // applied right away when not in the future
+}
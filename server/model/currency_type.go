@@ -0,0 +1,7 @@
+package model
+
+// balance, ledger and reconciliation without a currency are in base currency
+const BaseCurrency = "THB"
+
+// value of one unit of each currency in base currency
+type FxRates map[string]float64
@@ -0,0 +1,46 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// declared -> recorded -> paid
+// declared -> cancelled
+const (
+	DividendDeclared  = "declared"
+	DividendRecorded  = "recorded"
+	DividendPaid      = "paid"
+	DividendCancelled = "cancelled"
+)
+
+// a holder of the stock at the record date, the reinvest choice is taken
+// at the record date too
+type DividendHolder struct {
+	UID      string  `bson:"uid" json:"uid"`
+	Amount   float64 `bson:"amount" json:"amount"`
+	Cash     float64 `bson:"cash" json:"cash"` // dividend currency
+	Reinvest bool    `bson:"reinvest" json:"reinvest"`
+	Paid     bool    `bson:"paid" json:"paid"`
+}
+
+type Dividend struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StockId        string             `bson:"stockId" json:"stockId"`
+	AmountPerShare float64            `bson:"amountPerShare" json:"amountPerShare"`
+	Currency       string             `bson:"currency" json:"currency"` // quote currency of the stock
+	RecordDate     int64              `bson:"recordDate" json:"recordDate"`
+	PayDate        int64              `bson:"payDate" json:"payDate"`
+	Status         string             `bson:"status" json:"status"`
+	DeclaredBy     string             `bson:"declaredBy" json:"declaredBy"`
+	Holders        []DividendHolder   `bson:"holders" json:"holders"`
+	CreatedAt      int64              `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      int64              `bson:"updatedAt" json:"updatedAt"`
+}
+
+type DividendRequest struct {
+	AmountPerShare float64 `json:"amountPerShare"`
+	RecordDate     int64   `json:"recordDate"`
+	PayDate        int64   `json:"payDate"`
+}
+
+type DividendReinvestRequest struct {
+	Enabled bool `json:"enabled"`
+}
@@ -0,0 +1,26 @@
+package model
+
+type FeeTier struct {
+	MinVolume float64 `json:"minVolume"`
+	Percent   float64 `json:"percent"`
+}
+
+// fee of a fill is flat + value * (percent + maker or taker rate), when
+// tiers are set the tier of the 30-day trading volume replaces percent
+type FeeSchedule struct {
+	Flat      float64   `json:"flat"`
+	Percent   float64   `json:"percent"`
+	Tiers     []FeeTier `json:"tiers"`
+	MakerRate float64   `json:"makerRate"` // order
+	TakerRate float64   `json:"takerRate"` // auto
+}
+
+type FeeSummary struct {
+	TotalFee    float64 `bson:"totalFee" json:"totalFee"`
+	TradeCount  int64   `bson:"tradeCount" json:"tradeCount"`
+	Volume30Day float64 `bson:"-" json:"volume30Day"`
+	FlatFee     float64 `bson:"-" json:"flatFee"`
+	Percent     float64 `bson:"-" json:"percent"`
+	MakerRate   float64 `bson:"-" json:"makerRate"`
+	TakerRate   float64 `bson:"-" json:"takerRate"`
+}
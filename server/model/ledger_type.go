@@ -0,0 +1,34 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+const (
+	LedgerBankAccount   = "bank"
+	LedgerMarketAccount = "market"
+	LedgerFeeAccount    = "fee"
+	LedgerIssuerAccount = "issuer" // pays the dividends
+)
+
+type LedgerEntry struct {
+	Account string  `bson:"account" json:"account"`
+	Debit   float64 `bson:"debit" json:"debit"`
+	Credit  float64 `bson:"credit" json:"credit"`
+}
+
+type LedgerTransaction struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UID       string             `bson:"uid" json:"uid"`
+	Timestamp int64              `bson:"timestamp" json:"timestamp"`
+	Method    string             `bson:"method" json:"method"` // DEPOSIT, WITHDRAW, BUY, SALE, BORROW_FEE, DIVIDEND, REVERSAL
+	Reference string             `bson:"reference,omitempty" json:"reference,omitempty"`
+	Currency  string             `bson:"currency,omitempty" json:"currency,omitempty"`
+	Entries   []LedgerEntry      `bson:"entries" json:"entries"`
+}
+
+type Reconciliation struct {
+	UID           string  `json:"uid"`
+	Currency      string  `json:"currency"`
+	Balance       float64 `json:"balance"`
+	LedgerBalance float64 `json:"ledgerBalance"`
+	Difference    float64 `json:"difference"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+const (
+	MarginCallIssued     = "issued"
+	MarginCallMet        = "met"
+	MarginCallLiquidated = "liquidated"
+)
+
+type MarginCall struct {
+	Timestamp              int64   `bson:"timestamp" json:"timestamp"`
+	Equity                 float64 `bson:"equity" json:"equity"`
+	MaintenanceRequirement float64 `bson:"maintenanceRequirement" json:"maintenanceRequirement"`
+	Deficit                float64 `bson:"deficit" json:"deficit"`
+	Status                 string  `bson:"status" json:"status"`
+}
+
+type MarginPosition struct {
+	StockId           string  `json:"stockId"`
+	Amount            float64 `json:"amount"`
+	Value             float64 `json:"value"`
+	InitialMargin     float64 `json:"initialMargin"`
+	MaintenanceMargin float64 `json:"maintenanceMargin"`
+}
+
+// every value is in base currency
+type MarginSummary struct {
+	MarginEnabled          bool             `json:"marginEnabled"`
+	Cash                   float64          `json:"cash"`
+	MarketValue            float64          `json:"marketValue"`
+	CollateralValue        float64          `json:"collateralValue"`
+	Equity                 float64          `json:"equity"`
+	InitialRequirement     float64          `json:"initialRequirement"`
+	MaintenanceRequirement float64          `json:"maintenanceRequirement"`
+	BuyingPower            float64          `json:"buyingPower"`
+	Positions              []MarginPosition `json:"positions"`
+	MarginCall             *MarginCall      `json:"marginCall,omitempty"`
+}
+
+type MarginEnabledRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type MarginConfig struct {
+	CheckInterval time.Duration
+	CallGrace     time.Duration
+}
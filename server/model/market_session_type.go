@@ -0,0 +1,104 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// only the continuous session fills orders right away
+const (
+	SessionPreOpen    = "pre-open"
+	SessionContinuous = "continuous"
+	SessionClosing    = "closing"
+	SessionClosed     = "closed"
+)
+
+// what happens to an order entered outside of the continuous session
+const (
+	OffHoursReject = "reject"
+	OffHoursQueue  = "queue"
+)
+
+// queued -> filling -> filled
+// queued -> filling -> failed
+// queued -> cancelled
+const (
+	QueuedOrderQueued    = "queued"
+	QueuedOrderFilling   = "filling"
+	QueuedOrderFilled    = "filled"
+	QueuedOrderFailed    = "failed"
+	QueuedOrderCancelled = "cancelled"
+)
+
+// redis channel every session change of a stock is published on
+const MarketSessionChannel = "marketSession"
+
+type TradingSession struct {
+	Session string `json:"session"` // pre-open, continuous, closing
+	Start   string `json:"start"`   // 15:04 market time
+	End     string `json:"end"`     // exclusive
+}
+
+// the time outside of every session is closed
+type MarketSchedule struct {
+	Weekdays []time.Weekday   `json:"weekdays"` // 0 is sunday, every day when empty
+	Sessions []TradingSession `json:"sessions"` // continuous all day when empty
+	Holidays []string         `json:"holidays"` // 2006-01-02
+}
+
+type MarketCalendar struct {
+	Timezone string `json:"timezone"` // local time zone when empty
+	OffHours string `json:"offHours"` // reject, queue
+	// orders of the pre-open and closing sessions are queued and uncrossed
+	// in a call auction when the session ends, whatever offHours is
+	Auction bool `json:"auction"`
+	MarketSchedule
+	// by stock id, the weekdays and sessions of an override replace those
+	// of the market when set, its holidays are added to the market holidays
+	Stocks map[string]MarketSchedule `json:"stocks"`
+}
+
+type MarketSession struct {
+	StockId  string `json:"stockId"`
+	Session  string `json:"session"`
+	OffHours string `json:"offHours"`
+}
+
+type MarketSessionMessage struct {
+	StockId   string         `json:"stockId"`
+	Session   string         `json:"session"`
+	Previous  string         `json:"previous"`
+	Timestamp int64          `json:"timestamp"`
+	Auction   *AuctionResult `json:"auction,omitempty"` // when the previous session was uncrossed
+}
+
+// the orders of the session are filled at price up to volume on each side,
+// a zero volume leaves every order queued
+type AuctionResult struct {
+	StockId   string  `json:"stockId"`
+	Session   string  `json:"session"` // pre-open for the open, closing for the close
+	Price     float64 `json:"price"`
+	Volume    float64 `json:"volume"`
+	Filled    int     `json:"filled"` // trades between a buy and a sale order
+	Timestamp int64   `json:"timestamp"`
+}
+
+// an order entered outside of the continuous session, it is sent to buy or
+// sale once the session of the stock is continuous
+type QueuedOrder struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserId      string             `bson:"userId" json:"userId"`
+	StockId     string             `bson:"stockId" json:"stockId"`
+	Price       float64            `bson:"price" json:"price"`
+	Amount      float64            `bson:"amount" json:"amount"`
+	OrderType   string             `bson:"orderType" json:"orderType"`
+	OrderMethod string             `bson:"orderMethod" json:"orderMethod"` // buy, sale
+	MaxSlippage *float64           `bson:"maxSlippage,omitempty" json:"maxSlippage,omitempty"`
+	Currency    string             `bson:"currency" json:"currency"`
+	Status      string             `bson:"status" json:"status"`
+	Note        string             `bson:"note,omitempty" json:"note,omitempty"` // why the order failed
+	CreatedAt   int64              `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   int64              `bson:"updatedAt" json:"updatedAt"`
+	Splits      []string           `bson:"splits,omitempty" json:"-"` // corporate actions applied to the order
+}
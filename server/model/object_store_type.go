@@ -0,0 +1,33 @@
+package model
+
+const (
+	ObjectStoreGCS    = "gcs"
+	ObjectStoreLocal  = "local"
+	ObjectStoreMemory = "memory"
+)
+
+type ObjectStoreConfig struct {
+	Backend string
+	Dir     string // root directory of the local store
+	BaseURL string // url the local and memory objects are served under
+	GCS     GCSConfig
+}
+
+type GCSConfig struct {
+	ProjectID       string
+	BucketName      string
+	UploadPath      string
+	CredentialsJSON []byte
+}
+
+// uploaded images are checked against these limits and stored as square
+// png thumbnails of every size
+const (
+	MaxImageBytes     = 5 << 20
+	MinImageDimension = 64
+	MaxImageDimension = 4096
+)
+
+var ImageSizes = []int{64, 128, 256, 512}
+
+var ImageTypes = []string{"image/png", "image/jpeg", "image/gif"}
@@ -0,0 +1,54 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// requested -> pending -> completed -> reversed
+// requested, pending -> failed
+const (
+	PaymentRequested = "requested"
+	PaymentPending   = "pending"
+	PaymentCompleted = "completed"
+	PaymentFailed    = "failed"
+	PaymentReversed  = "reversed"
+)
+
+type PaymentEvent struct {
+	Status    string `bson:"status" json:"status"`
+	Timestamp int64  `bson:"timestamp" json:"timestamp"`
+	Actor     string `bson:"actor,omitempty" json:"actor,omitempty"` // user, admin uid or provider
+	Note      string `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+type Payment struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UID              string             `bson:"uid" json:"uid"`
+	Method           string             `bson:"method" json:"method"` // DEPOSIT, WITHDRAW
+	Currency         string             `bson:"currency" json:"currency"`
+	Amount           float64            `bson:"amount" json:"amount"`
+	BaseAmount       float64            `bson:"baseAmount" json:"baseAmount"` // in base currency for daily limits
+	Status           string             `bson:"status" json:"status"`
+	Reference        string             `bson:"reference,omitempty" json:"reference,omitempty"` // payment provider reference
+	ApprovalRequired bool               `bson:"approvalRequired" json:"approvalRequired"`
+	CreatedAt        int64              `bson:"createdAt" json:"createdAt"`
+	UpdatedAt        int64              `bson:"updatedAt" json:"updatedAt"`
+	History          []PaymentEvent     `bson:"history" json:"history"`
+}
+
+type PaymentRequest struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+type PaymentCallback struct {
+	PaymentId string `json:"paymentId"`
+	Reference string `json:"reference"`
+	Status    string `json:"status"` // completed, failed, reversed
+	Reason    string `json:"reason"`
+}
+
+// zero disables the limit, amounts are in base currency
+type PaymentLimit struct {
+	DailyDeposit              float64
+	DailyWithdraw             float64
+	WithdrawApprovalThreshold float64
+}
@@ -0,0 +1,23 @@
+package model
+
+// values are in base currency, a short position has a negative amount,
+// value and cost basis
+type PortfolioPosition struct {
+	StockId       string  `json:"stockId"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	Price         float64 `json:"price"`
+	AveragePrice  float64 `json:"averagePrice"`
+	Value         float64 `json:"value"`
+	CostBasis     float64 `json:"costBasis"`
+	UnrealizedPnl float64 `json:"unrealizedPnl"`
+}
+
+type Portfolio struct {
+	Cash          float64             `json:"cash"`
+	MarketValue   float64             `json:"marketValue"`
+	CostBasis     float64             `json:"costBasis"`
+	UnrealizedPnl float64             `json:"unrealizedPnl"`
+	Equity        float64             `json:"equity"`
+	Positions     []PortfolioPosition `json:"positions"`
+}
@@ -11,23 +11,95 @@ type StockCollectionRequest struct {
 	Name       string         `bson:"name"`
 	Sign       string         `bson:"sign"`
 	Price      float64        `bson:"price"`
+	Currency   string         `bson:"currency"`
+}
+
+// counterparty of every trade that is not matched with another user
+const MarketCounterparty = "market"
+
+// active <-> halted, active <-> suspended, halted <-> suspended
+// active, halted, suspended -> delisted
+const (
+	StockActive    = "active"
+	StockHalted    = "halted"    // trading paused for a short time
+	StockSuspended = "suspended" // trading stopped until further notice
+	StockDelisted  = "delisted"  // every position is settled or cancelled
+)
+
+// actor of the status changes made by the circuit breaker
+const StockSystemActor = "system"
+
+// redis channel every stock status change is published on
+const StockStatusChannel = "stockStatus"
+
+// how positions are closed when a stock is delisted
+const (
+	SettlementCash   = "cash"   // at the settlement price
+	SettlementCancel = "cancel" // without value
+)
+
+type StockStatusEvent struct {
+	Status    string `bson:"status" json:"status"`
+	Timestamp int64  `bson:"timestamp" json:"timestamp"`
+	Actor     string `bson:"actor,omitempty" json:"actor,omitempty"` // admin uid or system
+	Reason    string `bson:"reason,omitempty" json:"reason,omitempty"`
+	Until     int64  `bson:"until,omitempty" json:"until,omitempty"` // halt is lifted at, zero until an admin does
+}
+
+type StockStatusMessage struct {
+	StockId string `json:"stockId"`
+	StockStatusEvent
 }
 
 type StockHistory struct {
 	ID        string  `bson:"userId,omitempty" json:"userId"`
+	TradeId   string  `bson:"tradeId,omitempty" json:"tradeId,omitempty"`
+	BuyerId   string  `bson:"buyerId,omitempty" json:"buyerId,omitempty"`
+	SellerId  string  `bson:"sellerId,omitempty" json:"sellerId,omitempty"`
 	Timestamp int64   `bson:"timestamp" json:"timestamp"`
 	Amount    float64 `bson:"amount" json:"amount"`
 	Price     float64 `bson:"price" json:"price"`
 }
 
+type TradingRule struct {
+	TickSize        float64 `bson:"tickSize" json:"tickSize"`
+	LotSize         float64 `bson:"lotSize" json:"lotSize"`
+	FractionalShare bool    `bson:"fractionalShare" json:"fractionalShare"` // ignore lot size when enabled
+	MinQuantity     float64 `bson:"minQuantity" json:"minQuantity"`
+	MaxQuantity     float64 `bson:"maxQuantity" json:"maxQuantity"`
+	MinNotional     float64 `bson:"minNotional" json:"minNotional"`
+	// share of the value paid in cash when bought and kept as equity while
+	// held, 0.5 = 50%, zero is 1 so the stock cannot be bought on margin
+	InitialMargin     float64 `bson:"initialMargin" json:"initialMargin"`
+	MaintenanceMargin float64 `bson:"maintenanceMargin" json:"maintenanceMargin"`
+	Shortable         bool    `bson:"shortable" json:"shortable"`
+	BorrowRate        float64 `bson:"borrowRate" json:"borrowRate"` // yearly, charged daily on short value
+	// daily price band around the previous close, 0.1 = 10%, zero disables
+	// that side, a price outside of it halts trading for the halt duration
+	LimitUp      float64 `bson:"limitUp" json:"limitUp"`
+	LimitDown    float64 `bson:"limitDown" json:"limitDown"`
+	HaltDuration int64   `bson:"haltDuration" json:"haltDuration"` // seconds, zero is 5 minutes
+}
+
 type StockCollection struct {
-	ID          primitive.ObjectID  `bson:"_id,omitempty"`
-	StockImage  string              `bson:"stockImage"`
-	Name        string              `bson:"name"`
-	Sign        string              `bson:"sign"`
-	Price       float64             `bson:"price"`
-	CreatedDate primitive.Timestamp `bson:"createdDate" json:"createdDate"`
-	History     []StockHistory      `bson:"stockHistory"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty"`
+	StockImage    string              `bson:"stockImage"`
+	Name          string              `bson:"name"`
+	Sign          string              `bson:"sign"`
+	Price         float64             `bson:"price"`
+	Currency      string              `bson:"currency" json:"currency"` // quote currency
+	CreatedDate   primitive.Timestamp `bson:"createdDate" json:"createdDate"`
+	TradingRule   TradingRule         `bson:"tradingRule" json:"tradingRule"`
+	Status        string              `bson:"status" json:"status"` // active when empty
+	StatusHistory []StockStatusEvent  `bson:"statusHistory" json:"statusHistory"`
+	HaltUntil     int64               `bson:"haltUntil" json:"haltUntil"` // zero when not halted by the circuit breaker
+	PreviousClose float64             `bson:"previousClose" json:"previousClose"`
+	CloseDate     string              `bson:"closeDate" json:"closeDate"` // day the previous close was taken on
+	OpenPrice     float64             `bson:"openPrice" json:"openPrice"` // official open of the open date
+	OpenDate      string              `bson:"openDate" json:"openDate"`
+	OfficialClose float64             `bson:"officialClose,omitempty" json:"officialClose,omitempty"` // taken as the next previous close
+	History       []StockHistory      `bson:"stockHistory"`
+	Splits        []string            `bson:"splits,omitempty" json:"-"` // corporate actions applied to the prices
 }
 
 type TopStock struct {
@@ -48,6 +120,16 @@ type StockCollectionResponse struct {
 	Name       string  `json:"name"`
 	Sign       string  `json:"sign"`
 	Price      float64 `json:"price"`
+	Currency   string  `json:"currency"`
+	Status     string  `json:"status"`
+	// official open of the day and the change from the previous close, zero
+	// until they are known
+	Open          float64 `bson:"openPrice" json:"open"`
+	OpenDate      string  `bson:"openDate" json:"-"`
+	PreviousClose float64 `bson:"previousClose" json:"previousClose"`
+	Change        float64 `bson:"-" json:"change"`
+	// thumbnail urls by size
+	Images map[string]string `bson:"-" json:"images"`
 }
 
 type StockHistoryResponse struct {
@@ -84,15 +166,21 @@ type SetPriceRequest struct {
 	Price float64 `json:"price"`
 }
 
-type CreateOrderRequest struct {
-	Amount float64 `json:"amount"`
-	Price  float64 `json:"price"`
-}
-
 type EditNameRequest struct {
 	Name string `json:"name"`
 }
 
 type EditSignRequest struct {
 	Sign string `json:"sign"`
+}
+
+type StockStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+type DelistRequest struct {
+	Reason     string  `json:"reason"`
+	Settlement string  `json:"settlement"` // cash, cancel
+	Price      float64 `json:"price"`      // last price when zero
 }
\ No newline at end of file
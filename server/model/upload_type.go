@@ -1,10 +0,0 @@
-package model
-
-import "cloud.google.com/go/storage"
-
-type ClientUploader struct {
-	Cl         *storage.Client
-	ProjectID  string
-	BucketName string
-	UploadPath string
-}
\ No newline at end of file
@@ -6,22 +6,29 @@ import (
 )
 
 type UserStock struct {
-	StockId string  `bson:"stockId" json:"stockId"`
-	Amount  float64 `bson:"amount" json:"amount"`
+	StockId      string  `bson:"stockId" json:"stockId"`
+	Amount       float64 `bson:"amount" json:"amount"`             // below zero for a short position
+	AveragePrice float64 `bson:"averagePrice" json:"averagePrice"` // quote currency
 }
 
 type UserAccount struct {
-	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"_id"`
-	UID            string              `bson:"uid" json:"uid"`
-	Name           string              `bson:"name" json:"name"`
-	ProfileImage   string              `bson:"profileImage" json:"profileImage"`
-	Email          string              `bson:"email" json:"email"`
-	RegisterDate   primitive.Timestamp `bson:"registerDate" json:"registerDate"`
-	Balance        float64             `bson:"balance" json:"balance"`
-	BalanceHistory []BalanceHistory    `bson:"balanceHistory" json:"balanceHistory"`
-	Favorite       []string            `bson:"favorite" json:"favorite"`
-	History        []UserHistory       `bson:"userHistory" json:"userHistory"`
-	Stock          []UserStock         `bson:"userStock" json:"userStock"`
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"_id"`
+	UID              string              `bson:"uid" json:"uid"`
+	Name             string              `bson:"name" json:"name"`
+	ProfileImage     string              `bson:"profileImage" json:"profileImage"`
+	Avatar           string              `bson:"avatar,omitempty" json:"-"` // key of the uploaded profile image
+	Email            string              `bson:"email" json:"email"`
+	RegisterDate     primitive.Timestamp `bson:"registerDate" json:"registerDate"`
+	Balance          float64             `bson:"balance" json:"balance"`   // base currency
+	Balances         map[string]float64  `bson:"balances" json:"balances"` // other currencies
+	BalanceHistory   []BalanceHistory    `bson:"balanceHistory" json:"balanceHistory"`
+	Favorite         []string            `bson:"favorite" json:"favorite"`
+	History          []UserHistory       `bson:"userHistory" json:"userHistory"`
+	Stock            []UserStock         `bson:"userStock" json:"userStock"`
+	MarginEnabled    bool                `bson:"marginEnabled" json:"marginEnabled"`
+	MarginCall       *MarginCall         `bson:"marginCall,omitempty" json:"marginCall,omitempty"` // open margin call
+	DividendReinvest bool                `bson:"dividendReinvest" json:"dividendReinvest"`
+	Splits           []string            `bson:"splits,omitempty" json:"-"` // corporate actions applied to the positions
 }
 
 type CreateAccount struct {
@@ -38,18 +45,36 @@ type OrderRequest struct {
 	Amount      float64 `json:"amount"`
 	OrderType   string  `json:"orderType"`   // auto, order
 	OrderMethod string  `json:"orderMethod"` // buy, sale
+	MaxSlippage *float64 `json:"maxSlippage"` // 0.01 = 1%, auto order only, 0.01 when omitted
+	Currency    string  `json:"currency"`    // settlement currency, base currency when empty
+	TradeId     string  `json:"-"`
+	Fee         float64 `json:"-"`
+	FxRate      float64 `json:"-"`
+	Margin      bool    `json:"-"` // balance may go below zero
+	Short       bool    `json:"-"` // stock amount may go below zero
+	Counterparty string `json:"-"` // user matched in a call auction, the trade is recorded by the auction
 }
 
 type UserHistory struct {
 	Timestamp   int64   `bson:"timestamp" json:"timestamp"`
+	TradeId     string  `bson:"tradeId,omitempty" json:"tradeId,omitempty"`
 	StockId     string  `bson:"stockId" json:"stockId"`
 	Price       float64 `bson:"price" json:"price"`
 	Amount      float64 `bson:"amount" json:"amount"`
+	Fee         float64 `bson:"fee" json:"fee"`
+	Currency    string  `bson:"currency,omitempty" json:"currency,omitempty"`
+	FxRate      float64 `bson:"fxRate,omitempty" json:"fxRate,omitempty"` // quote to settlement currency
 	Status      string  `bson:"status" json:"status"`           // pending, success, cancle
 	OrderType   string  `bson:"orderType" json:"orderType"`     // auto, order
 	OrderMethod string  `bson:"orderMethod" json:"orderMethod"` // buy, sale
 }
 
+// empty fields are kept
+type EditProfileRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
 type UserResponse struct {
 	Name         string `json:"name"`
 	ProfileImage string `json:"profileImage"`
@@ -68,13 +93,10 @@ type OrderOperation struct {
 type BalanceHistory struct {
 	Timestamp int64   `bson:"timestamp" json:"timestamp"`
 	Balance   float64 `bson:"balance" json:"balance"`
+	Currency  string  `bson:"currency,omitempty" json:"currency,omitempty"`
 	Method    string  `bson:"method" json:"method"`
 }
 
-type UserBalanceRequest struct {
-	Balance float64 `json:"balance"`
-}
-
 type UserSetFavoriteRequest struct {
 	StockId string `json:"stockId"`
 }
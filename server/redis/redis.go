@@ -1,9 +0,0 @@
-package redis
-
-import "github.com/redis/go-redis/v9"
-
-func InitRedis() *redis.Client {
-	return redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
-}
\ No newline at end of file
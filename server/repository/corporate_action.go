@@ -0,0 +1,14 @@
+package repository
+
+import "server/model"
+
+type CorporateAction = model.CorporateAction
+type CorporateActionEvent = model.CorporateActionEvent
+
+type CorporateActionRepository interface {
+	Create(CorporateAction) (string, error)
+	Get(string) (CorporateAction, error)
+	GetStockActions(string) ([]CorporateAction, error)
+	GetDueActions(int64) ([]CorporateAction, error)
+	UpdateStatus(string, string, CorporateActionEvent) (string, error)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"server/errs"
+	"server/model"
+	"server/util"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type corporateActionRepositoryDB struct {
+	db *mongo.Collection
+}
+
+var (
+	ErrCorporateAction       = errs.ErrCorporateAction
+	ErrCorporateActionStatus = errs.ErrCorporateActionStatus
+)
+
+func NewCorporateActionRepositoryDB(db *mongo.Collection) CorporateActionRepository {
+	return corporateActionRepositoryDB{db}
+}
+
+func (r corporateActionRepositoryDB) Create(corporateAction CorporateAction) (string, error) {
+	if len(corporateAction.StockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if len(corporateAction.Type) == 0 ||
+		corporateAction.Status != model.CorporateActionScheduled {
+		return "", ErrCorporateAction
+	}
+
+	now := time.Now().Unix()
+	corporateAction.CreatedAt = now
+	corporateAction.UpdatedAt = now
+	if corporateAction.History == nil {
+		corporateAction.History = []CorporateActionEvent{}
+	}
+
+	_, err := r.db.InsertOne(ctx, corporateAction)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully created corporate action", nil
+}
+
+func (r corporateActionRepositoryDB) Get(actionId string) (CorporateAction, error) {
+	objectActionId, err := primitive.ObjectIDFromHex(actionId)
+	if err != nil {
+		return CorporateAction{}, ErrCorporateAction
+	}
+
+	var corporateAction CorporateAction
+	err = r.db.FindOne(ctx, bson.M{"_id": objectActionId}).Decode(&corporateAction)
+	if err == mongo.ErrNoDocuments {
+		return CorporateAction{}, ErrCorporateAction
+	}
+	if err != nil {
+		return CorporateAction{}, err
+	}
+
+	return corporateAction, nil
+}
+
+func (r corporateActionRepositoryDB) GetStockActions(stockId string) ([]CorporateAction, error) {
+	if len(stockId) == 0 {
+		return []CorporateAction{}, ErrInvalidStock
+	}
+
+	filter := bson.M{
+		"stockId": stockId,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "effectiveAt", Value: -1}})
+
+	return r.find(filter, opts)
+}
+
+// scheduled and failed actions effective at or before now, oldest first so actions
+// of the same stock are applied in order
+func (r corporateActionRepositoryDB) GetDueActions(now int64) ([]CorporateAction, error) {
+	filter := bson.M{
+		"status":      bson.M{"$in": bson.A{model.CorporateActionScheduled, model.CorporateActionFailed}},
+		"effectiveAt": bson.M{"$lte": now},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "effectiveAt", Value: 1}})
+
+	return r.find(filter, opts)
+}
+
+// the status only moves along the allowed transitions, a concurrent update
+// of the same action matches nothing and fails with ErrCorporateActionStatus
+func (r corporateActionRepositoryDB) UpdateStatus(actionId string, from string, event CorporateActionEvent) (string, error) {
+	if !util.CanTransitCorporateAction(from, event.Status) {
+		return "", ErrCorporateActionStatus
+	}
+
+	objectActionId, err := primitive.ObjectIDFromHex(actionId)
+	if err != nil {
+		return "", ErrCorporateAction
+	}
+
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+
+	filter := bson.M{
+		"_id":    objectActionId,
+		"status": from,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    event.Status,
+			"updatedAt": event.Timestamp,
+		},
+		"$push": bson.M{
+			"history": event,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrCorporateActionStatus
+	}
+
+	return "Successfully updated corporate action status", nil
+}
+
+func (r corporateActionRepositoryDB) find(filter bson.M, opts *options.FindOptions) ([]CorporateAction, error) {
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return []CorporateAction{}, err
+	}
+	defer cursor.Close(ctx)
+
+	corporateActions := []CorporateAction{}
+	if err := cursor.All(ctx, &corporateActions); err != nil {
+		return []CorporateAction{}, err
+	}
+
+	return corporateActions, nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type corporateActionRepositoryDBMock struct {
+	mock.Mock
+}
+
+func NewCorporateActionRepositoryDBMock() *corporateActionRepositoryDBMock {
+	return &corporateActionRepositoryDBMock{}
+}
+
+func (m *corporateActionRepositoryDBMock) Create(corporateAction CorporateAction) (string, error) {
+	arge := m.Called(corporateAction)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *corporateActionRepositoryDBMock) Get(actionId string) (CorporateAction, error) {
+	arge := m.Called(actionId)
+	return arge.Get(0).(CorporateAction), arge.Error(1)
+}
+
+func (m *corporateActionRepositoryDBMock) GetStockActions(stockId string) ([]CorporateAction, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).([]CorporateAction), arge.Error(1)
+}
+
+func (m *corporateActionRepositoryDBMock) GetDueActions(now int64) ([]CorporateAction, error) {
+	arge := m.Called(now)
+	return arge.Get(0).([]CorporateAction), arge.Error(1)
+}
+
+func (m *corporateActionRepositoryDBMock) UpdateStatus(actionId string, from string, event CorporateActionEvent) (string, error) {
+	arge := m.Called(actionId, from, event)
+	return arge.String(0), arge.Error(1)
+}
@@ -0,0 +1,138 @@
+package repository_test
+
+import (
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CorporateAction = repository.CorporateAction
+type CorporateActionEvent = repository.CorporateActionEvent
+
+func InitCorporateActionRepo() repository.CorporateActionRepository {
+	client, _ := repository.InitMongoDB("mongodb://localhost:27017/trading-system")
+	db := client.Database("trading-system")
+	collection := db.Collection("corporateAction")
+	corporateActionRepo := repository.NewCorporateActionRepositoryDB(collection)
+
+	return corporateActionRepo
+}
+
+var corporateActionRepo = InitCorporateActionRepo()
+
+func TestCorporateActionLifecycle(t *testing.T) {
+	corporateAction := CorporateAction{
+		ID:          primitive.NewObjectID(),
+		StockId:     stockIdTesting,
+		Type:        model.CorporateActionSplit,
+		NewShares:   2,
+		OldShares:   1,
+		EffectiveAt: time.Now().Unix(),
+		Status:      model.CorporateActionScheduled,
+	}
+	actionId := corporateAction.ID.Hex()
+
+	t.Run("Error invalid corporate action", func(t *testing.T) {
+		_, err := corporateActionRepo.Create(CorporateAction{StockId: stockIdTesting})
+
+		assert.ErrorIs(t, err, errs.ErrCorporateAction)
+	})
+
+	t.Run("Create corporate action", func(t *testing.T) {
+		actual, err := corporateActionRepo.Create(corporateAction)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully created corporate action", actual)
+	})
+
+	t.Run("Get due actions", func(t *testing.T) {
+		actual, err := corporateActionRepo.GetDueActions(time.Now().Unix())
+
+		assert.Empty(t, err)
+		assert.Contains(t, actionIds(actual), actionId)
+	})
+
+	t.Run("Error invalid transition", func(t *testing.T) {
+		_, err := corporateActionRepo.UpdateStatus(
+			actionId,
+			model.CorporateActionScheduled,
+			CorporateActionEvent{Status: model.CorporateActionApplied},
+		)
+
+		assert.ErrorIs(t, err, errs.ErrCorporateActionStatus)
+	})
+
+	t.Run("Apply corporate action", func(t *testing.T) {
+		_, err := corporateActionRepo.UpdateStatus(
+			actionId,
+			model.CorporateActionScheduled,
+			CorporateActionEvent{Status: model.CorporateActionApplying, Actor: "admin"},
+		)
+		assert.Empty(t, err)
+
+		_, err = corporateActionRepo.UpdateStatus(
+			actionId,
+			model.CorporateActionScheduled,
+			CorporateActionEvent{Status: model.CorporateActionApplying, Actor: "admin"},
+		)
+		assert.ErrorIs(t, err, errs.ErrCorporateActionStatus)
+
+		actual, _ := corporateActionRepo.Get(actionId)
+		assert.Equal(t, model.CorporateActionApplying, actual.Status)
+		assert.Len(t, actual.History, 1)
+	})
+
+	t.Run("Get stock actions", func(t *testing.T) {
+		actual, err := corporateActionRepo.GetStockActions(stockIdTesting)
+
+		assert.Empty(t, err)
+		assert.Contains(t, actionIds(actual), actionId)
+	})
+}
+
+func TestApplySplit(t *testing.T) {
+	t.Run("Error split ratio", func(t *testing.T) {
+		_, err := userRepo.ApplySplit(stockIdTesting, primitive.NewObjectID().Hex(), 0)
+
+		assert.ErrorIs(t, err, errs.ErrSplitRatio)
+	})
+
+	t.Run("Apply split to holders", func(t *testing.T) {
+		before, _ := userRepo.GetStockAmount(userIdTesting, stockIdTesting)
+
+		actionId := primitive.NewObjectID().Hex()
+		_, err := userRepo.ApplySplit(stockIdTesting, actionId, 2)
+		assert.Empty(t, err)
+
+		after, _ := userRepo.GetStockAmount(userIdTesting, stockIdTesting)
+		assert.Equal(t, before.Amount*2, after.Amount)
+		assert.Equal(t, before.AveragePrice/2, after.AveragePrice)
+	})
+
+	t.Run("Apply split once per action", func(t *testing.T) {
+		actionId := primitive.NewObjectID().Hex()
+		userRepo.ApplySplit(stockIdTesting, actionId, 2)
+		before, _ := userRepo.GetStockAmount(userIdTesting, stockIdTesting)
+
+		holders, err := userRepo.ApplySplit(stockIdTesting, actionId, 2)
+		assert.Empty(t, err)
+		assert.Equal(t, int64(0), holders)
+
+		after, _ := userRepo.GetStockAmount(userIdTesting, stockIdTesting)
+		assert.Equal(t, before, after)
+	})
+}
+
+func actionIds(corporateActions []CorporateAction) []string {
+	ids := []string{}
+	for _, corporateAction := range corporateActions {
+		ids = append(ids, corporateAction.ID.Hex())
+	}
+
+	return ids
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "server/model"
+
+type Dividend = model.Dividend
+
+type DividendRepository interface {
+	Create(Dividend) (string, error)
+	Get(string) (Dividend, error)
+	GetStockDividends(string) ([]Dividend, error)
+	GetDueDividends(int64) ([]Dividend, error)
+	Record(string, []DividendHolder) (string, error)
+	SetHolderPaid(string, string, bool) (string, error)
+	UpdateStatus(string, string, string) (string, error)
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"server/errs"
+	"server/model"
+	"server/util"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type dividendRepositoryDB struct {
+	db *mongo.Collection
+}
+
+var (
+	ErrDividend       = errs.ErrDividend
+	ErrDividendStatus = errs.ErrDividendStatus
+)
+
+func NewDividendRepositoryDB(db *mongo.Collection) DividendRepository {
+	return dividendRepositoryDB{db}
+}
+
+func (r dividendRepositoryDB) Create(dividend Dividend) (string, error) {
+	if len(dividend.StockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if dividend.AmountPerShare <= 0 ||
+		dividend.Status != model.DividendDeclared {
+		return "", ErrDividend
+	}
+
+	now := time.Now().Unix()
+	dividend.CreatedAt = now
+	dividend.UpdatedAt = now
+	dividend.Holders = []DividendHolder{}
+
+	_, err := r.db.InsertOne(ctx, dividend)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully declared dividend", nil
+}
+
+func (r dividendRepositoryDB) Get(dividendId string) (Dividend, error) {
+	objectDividendId, err := primitive.ObjectIDFromHex(dividendId)
+	if err != nil {
+		return Dividend{}, ErrDividend
+	}
+
+	var dividend Dividend
+	err = r.db.FindOne(ctx, bson.M{"_id": objectDividendId}).Decode(&dividend)
+	if err == mongo.ErrNoDocuments {
+		return Dividend{}, ErrDividend
+	}
+	if err != nil {
+		return Dividend{}, err
+	}
+
+	return dividend, nil
+}
+
+func (r dividendRepositoryDB) GetStockDividends(stockId string) ([]Dividend, error) {
+	if len(stockId) == 0 {
+		return []Dividend{}, ErrInvalidStock
+	}
+
+	filter := bson.M{
+		"stockId": stockId,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "recordDate", Value: -1}})
+
+	return r.find(filter, opts)
+}
+
+// declared dividends past the record date and recorded dividends past the
+// pay date, oldest first
+func (r dividendRepositoryDB) GetDueDividends(now int64) ([]Dividend, error) {
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{
+				"status":     model.DividendDeclared,
+				"recordDate": bson.M{"$lte": now},
+			},
+			bson.M{
+				"status":  model.DividendRecorded,
+				"payDate": bson.M{"$lte": now},
+			},
+		},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "recordDate", Value: 1}})
+
+	return r.find(filter, opts)
+}
+
+// the holders are kept with the dividend so the payment does not depend on
+// trades after the record date
+func (r dividendRepositoryDB) Record(dividendId string, holders []DividendHolder) (string, error) {
+	objectDividendId, err := primitive.ObjectIDFromHex(dividendId)
+	if err != nil {
+		return "", ErrDividend
+	}
+
+	filter := bson.M{
+		"_id":    objectDividendId,
+		"status": model.DividendDeclared,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    model.DividendRecorded,
+			"holders":   holders,
+			"updatedAt": time.Now().Unix(),
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrDividendStatus
+	}
+
+	return "Successfully recorded dividend holders", nil
+}
+
+// the paid flag of a holder only flips from the other value, so a holder
+// marked by another run matches nothing and fails with ErrDividendStatus
+func (r dividendRepositoryDB) SetHolderPaid(dividendId string, userId string, paid bool) (string, error) {
+	objectDividendId, err := primitive.ObjectIDFromHex(dividendId)
+	if err != nil {
+		return "", ErrDividend
+	}
+
+	filter := bson.M{
+		"_id":    objectDividendId,
+		"status": model.DividendRecorded,
+		"holders": bson.M{"$elemMatch": bson.M{
+			"uid":  userId,
+			"paid": !paid,
+		}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"holders.$.paid": paid,
+			"updatedAt":      time.Now().Unix(),
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrDividendStatus
+	}
+
+	return "Successfully updated dividend holder", nil
+}
+
+func (r dividendRepositoryDB) UpdateStatus(dividendId string, from string, to string) (string, error) {
+	if !util.CanTransitDividend(from, to) {
+		return "", ErrDividendStatus
+	}
+
+	objectDividendId, err := primitive.ObjectIDFromHex(dividendId)
+	if err != nil {
+		return "", ErrDividend
+	}
+
+	filter := bson.M{
+		"_id":    objectDividendId,
+		"status": from,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    to,
+			"updatedAt": time.Now().Unix(),
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrDividendStatus
+	}
+
+	return "Successfully updated dividend status", nil
+}
+
+func (r dividendRepositoryDB) find(filter bson.M, opts *options.FindOptions) ([]Dividend, error) {
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return []Dividend{}, err
+	}
+	defer cursor.Close(ctx)
+
+	dividends := []Dividend{}
+	if err := cursor.All(ctx, &dividends); err != nil {
+		return []Dividend{}, err
+	}
+
+	return dividends, nil
+}
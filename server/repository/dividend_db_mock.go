@@ -0,0 +1,46 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type dividendRepositoryDBMock struct {
+	mock.Mock
+}
+
+func NewDividendRepositoryDBMock() *dividendRepositoryDBMock {
+	return &dividendRepositoryDBMock{}
+}
+
+func (m *dividendRepositoryDBMock) Create(dividend Dividend) (string, error) {
+	arge := m.Called(dividend)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *dividendRepositoryDBMock) Get(dividendId string) (Dividend, error) {
+	arge := m.Called(dividendId)
+	return arge.Get(0).(Dividend), arge.Error(1)
+}
+
+func (m *dividendRepositoryDBMock) GetStockDividends(stockId string) ([]Dividend, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).([]Dividend), arge.Error(1)
+}
+
+func (m *dividendRepositoryDBMock) GetDueDividends(now int64) ([]Dividend, error) {
+	arge := m.Called(now)
+	return arge.Get(0).([]Dividend), arge.Error(1)
+}
+
+func (m *dividendRepositoryDBMock) Record(dividendId string, holders []DividendHolder) (string, error) {
+	arge := m.Called(dividendId, holders)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *dividendRepositoryDBMock) SetHolderPaid(dividendId string, userId string, paid bool) (string, error) {
+	arge := m.Called(dividendId, userId, paid)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *dividendRepositoryDBMock) UpdateStatus(dividendId string, from string, to string) (string, error) {
+	arge := m.Called(dividendId, from, to)
+	return arge.String(0), arge.Error(1)
+}
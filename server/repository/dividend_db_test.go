@@ -0,0 +1,142 @@
+package repository_test
+
+import (
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Dividend = repository.Dividend
+type DividendHolder = repository.DividendHolder
+
+func InitDividendRepo() repository.DividendRepository {
+	client, _ := repository.InitMongoDB("mongodb://localhost:27017/trading-system")
+	db := client.Database("trading-system")
+	collection := db.Collection("dividend")
+	dividendRepo := repository.NewDividendRepositoryDB(collection)
+
+	return dividendRepo
+}
+
+var dividendRepo = InitDividendRepo()
+
+func TestDividendLifecycle(t *testing.T) {
+	now := time.Now().Unix()
+	dividend := Dividend{
+		ID:             primitive.NewObjectID(),
+		StockId:        stockIdTesting,
+		AmountPerShare: 1.5,
+		Currency:       model.BaseCurrency,
+		RecordDate:     now,
+		PayDate:        now,
+		Status:         model.DividendDeclared,
+	}
+	dividendId := dividend.ID.Hex()
+
+	t.Run("Error invalid dividend", func(t *testing.T) {
+		_, err := dividendRepo.Create(Dividend{StockId: stockIdTesting, Status: model.DividendDeclared})
+
+		assert.ErrorIs(t, err, errs.ErrDividend)
+	})
+
+	t.Run("Declare dividend", func(t *testing.T) {
+		actual, err := dividendRepo.Create(dividend)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully declared dividend", actual)
+	})
+
+	t.Run("Get due dividends", func(t *testing.T) {
+		actual, err := dividendRepo.GetDueDividends(now)
+
+		assert.Empty(t, err)
+		assert.Contains(t, dividendIds(actual), dividendId)
+	})
+
+	t.Run("Record holders", func(t *testing.T) {
+		holders := []DividendHolder{{UID: userIdTesting, Amount: 10, Cash: 15}}
+
+		_, err := dividendRepo.Record(dividendId, holders)
+		assert.Empty(t, err)
+
+		_, err = dividendRepo.Record(dividendId, holders)
+		assert.ErrorIs(t, err, errs.ErrDividendStatus)
+	})
+
+	t.Run("Set holder paid", func(t *testing.T) {
+		_, err := dividendRepo.SetHolderPaid(dividendId, userIdTesting, true)
+		assert.Empty(t, err)
+
+		_, err = dividendRepo.SetHolderPaid(dividendId, userIdTesting, true)
+		assert.ErrorIs(t, err, errs.ErrDividendStatus)
+
+		actual, _ := dividendRepo.Get(dividendId)
+		assert.True(t, actual.Holders[0].Paid)
+	})
+
+	t.Run("Error invalid transition", func(t *testing.T) {
+		_, err := dividendRepo.UpdateStatus(dividendId, model.DividendRecorded, model.DividendCancelled)
+
+		assert.ErrorIs(t, err, errs.ErrDividendStatus)
+	})
+
+	t.Run("Pay dividend", func(t *testing.T) {
+		_, err := dividendRepo.UpdateStatus(dividendId, model.DividendRecorded, model.DividendPaid)
+		assert.Empty(t, err)
+
+		actual, err := dividendRepo.GetStockDividends(stockIdTesting)
+		assert.Empty(t, err)
+		assert.Contains(t, dividendIds(actual), dividendId)
+	})
+}
+
+func TestGetStockHolders(t *testing.T) {
+	t.Run("Error invalid stock", func(t *testing.T) {
+		_, err := userRepo.GetStockHolders("")
+
+		assert.ErrorIs(t, err, ErrInvalidStock)
+	})
+
+	t.Run("Get holders with reinvest choice", func(t *testing.T) {
+		_, err := userRepo.SetDividendReinvest(userIdTesting, true)
+		assert.Empty(t, err)
+
+		actual, err := userRepo.GetStockHolders(stockIdTesting)
+		assert.Empty(t, err)
+		for _, holder := range actual {
+			assert.Greater(t, holder.Amount, float64(0))
+			if holder.UID == userIdTesting {
+				assert.True(t, holder.Reinvest)
+			}
+		}
+	})
+}
+
+func TestCredit(t *testing.T) {
+	t.Run("Error invalid money", func(t *testing.T) {
+		_, err := userRepo.Credit(userIdTesting, model.BaseCurrency, "DIVIDEND", 0)
+
+		assert.ErrorIs(t, err, ErrMoney)
+	})
+
+	t.Run("Credit dividend", func(t *testing.T) {
+		actual, err := userRepo.Credit(userIdTesting, model.BaseCurrency, "DIVIDEND", 15)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully credited money", actual)
+	})
+}
+
+func dividendIds(dividends []Dividend) []string {
+	ids := []string{}
+	for _, dividend := range dividends {
+		ids = append(ids, dividend.ID.Hex())
+	}
+
+	return ids
+}
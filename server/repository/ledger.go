@@ -0,0 +1,13 @@
+package repository
+
+import "server/model"
+
+type LedgerEntry = model.LedgerEntry
+type LedgerTransaction = model.LedgerTransaction
+
+// append only, a wrong transaction is corrected by a reversing one
+type LedgerRepository interface {
+	Append(LedgerTransaction) (string, error)
+	GetTransactions(string, uint) ([]LedgerTransaction, error)
+	GetBalances() (map[string]float64, error)
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"math"
+	"server/errs"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ledgerRepositoryDB struct {
+	db *mongo.Collection
+}
+
+var ErrLedgerUnbalanced = errs.ErrLedgerUnbalanced
+
+func NewLedgerRepositoryDB(db *mongo.Collection) LedgerRepository {
+	return ledgerRepositoryDB{db}
+}
+
+func (r ledgerRepositoryDB) Append(transaction LedgerTransaction) (string, error) {
+	if len(transaction.Method) == 0 || len(transaction.Entries) < 2 {
+		return "", ErrData
+	}
+
+	var debit, credit float64
+	for _, entry := range transaction.Entries {
+		if len(entry.Account) == 0 ||
+			entry.Debit < 0 ||
+			entry.Credit < 0 ||
+			(entry.Debit > 0) == (entry.Credit > 0) {
+			return "", ErrData
+		}
+
+		debit += entry.Debit
+		credit += entry.Credit
+	}
+
+	if math.Abs(debit-credit) > 1e-9 {
+		return "", ErrLedgerUnbalanced
+	}
+
+	if transaction.Timestamp == 0 {
+		transaction.Timestamp = time.Now().Unix()
+	}
+
+	_, err := r.db.InsertOne(ctx, transaction)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully appended ledger transaction", nil
+}
+
+func (r ledgerRepositoryDB) GetTransactions(userId string, skip uint) ([]LedgerTransaction, error) {
+	if len(userId) == 0 {
+		return []LedgerTransaction{}, ErrUser
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(10)
+
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return []LedgerTransaction{}, err
+	}
+	defer cursor.Close(ctx)
+
+	transactions := []LedgerTransaction{}
+	if err := cursor.All(ctx, &transactions); err != nil {
+		return []LedgerTransaction{}, err
+	}
+
+	return transactions, nil
+}
+
+// balance of an account is credit minus debit, user cash accounts are
+// what the platform owes to the user
+func (r ledgerRepositoryDB) GetBalances() (map[string]float64, error) {
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$unwind", Value: "$entries"}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":    "$entries.account",
+			"debit":  bson.M{"$sum": "$entries.debit"},
+			"credit": bson.M{"$sum": "$entries.credit"},
+		}}},
+	}
+
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return map[string]float64{}, err
+	}
+	defer cursor.Close(ctx)
+
+	balances := map[string]float64{}
+	for cursor.Next(ctx) {
+		var result struct {
+			Account string  `bson:"_id"`
+			Debit   float64 `bson:"debit"`
+			Credit  float64 `bson:"credit"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return map[string]float64{}, err
+		}
+
+		balances[result.Account] = result.Credit - result.Debit
+	}
+
+	if err := cursor.Err(); err != nil {
+		return map[string]float64{}, err
+	}
+
+	return balances, nil
+}
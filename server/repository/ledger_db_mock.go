@@ -0,0 +1,26 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type ledgerRepositoryDBMock struct {
+	mock.Mock
+}
+
+func NewLedgerRepositoryDBMock() *ledgerRepositoryDBMock {
+	return &ledgerRepositoryDBMock{}
+}
+
+func (m *ledgerRepositoryDBMock) Append(transaction LedgerTransaction) (string, error) {
+	arge := m.Called(transaction)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *ledgerRepositoryDBMock) GetTransactions(userId string, skip uint) ([]LedgerTransaction, error) {
+	arge := m.Called(userId, skip)
+	return arge.Get(0).([]LedgerTransaction), arge.Error(1)
+}
+
+func (m *ledgerRepositoryDBMock) GetBalances() (map[string]float64, error) {
+	arge := m.Called()
+	return arge.Get(0).(map[string]float64), arge.Error(1)
+}
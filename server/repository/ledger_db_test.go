@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"server/errs"
+	"server/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type LedgerTransaction = repository.LedgerTransaction
+type LedgerEntry = repository.LedgerEntry
+
+func InitLedgerRepo() repository.LedgerRepository {
+	client, _ := repository.InitMongoDB("mongodb://localhost:27017/trading-system")
+	db := client.Database("trading-system")
+	collection := db.Collection("ledger")
+	ledgerRepo := repository.NewLedgerRepositoryDB(collection)
+
+	return ledgerRepo
+}
+
+var ledgerRepo = InitLedgerRepo()
+
+func TestAppend(t *testing.T) {
+	t.Run("Error invalid data", func(t *testing.T) {
+		_, err := ledgerRepo.Append(LedgerTransaction{})
+
+		assert.ErrorIs(t, err, ErrData)
+	})
+
+	t.Run("Error entry with debit and credit", func(t *testing.T) {
+		transaction := LedgerTransaction{
+			UID:    "test12345",
+			Method: "DEPOSIT",
+			Entries: []LedgerEntry{
+				{Account: "bank", Debit: 10, Credit: 10},
+				{Account: "user:test12345:cash", Credit: 10},
+			},
+		}
+		_, err := ledgerRepo.Append(transaction)
+
+		assert.ErrorIs(t, err, ErrData)
+	})
+
+	t.Run("Error unbalanced transaction", func(t *testing.T) {
+		transaction := LedgerTransaction{
+			UID:    "test12345",
+			Method: "DEPOSIT",
+			Entries: []LedgerEntry{
+				{Account: "bank", Debit: 10},
+				{Account: "user:test12345:cash", Credit: 9},
+			},
+		}
+		_, err := ledgerRepo.Append(transaction)
+
+		assert.ErrorIs(t, err, errs.ErrLedgerUnbalanced)
+	})
+
+	t.Run("Append transaction", func(t *testing.T) {
+		transaction := LedgerTransaction{
+			UID:    "test12345",
+			Method: "DEPOSIT",
+			Entries: []LedgerEntry{
+				{Account: "bank", Debit: 10},
+				{Account: "user:test12345:cash", Credit: 10},
+			},
+		}
+		actual, _ := ledgerRepo.Append(transaction)
+		expected := "Successfully appended ledger transaction"
+
+		assert.Equal(t, expected, actual)
+	})
+}
+
+func TestGetTransactions(t *testing.T) {
+	t.Run("Error invalid user", func(t *testing.T) {
+		_, err := ledgerRepo.GetTransactions("", 0)
+
+		assert.ErrorIs(t, err, ErrUser)
+	})
+
+	t.Run("Get transactions", func(t *testing.T) {
+		_, err := ledgerRepo.GetTransactions("test12345", 0)
+
+		assert.Empty(t, err)
+	})
+}
+
+func TestGetBalances(t *testing.T) {
+	t.Run("Get balances", func(t *testing.T) {
+		actual, err := ledgerRepo.GetBalances()
+
+		assert.Empty(t, err)
+		assert.NotNil(t, actual)
+	})
+}
@@ -0,0 +1,16 @@
+package repository
+
+import "server/model"
+
+type Payment = model.Payment
+type PaymentEvent = model.PaymentEvent
+
+type PaymentRepository interface {
+	Create(Payment) (string, error)
+	Get(string) (Payment, error)
+	GetUserPayments(string, uint) ([]Payment, error)
+	GetPendingApprovals() ([]Payment, error)
+	GetDailyTotal(string, string, int64) (float64, error)
+	UpdateStatus(string, string, PaymentEvent) (string, error)
+	SetReference(string, string) (string, error)
+}
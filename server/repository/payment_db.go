@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"server/errs"
+	"server/model"
+	"server/util"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type paymentRepositoryDB struct {
+	db *mongo.Collection
+}
+
+var (
+	ErrPayment       = errs.ErrPayment
+	ErrPaymentStatus = errs.ErrPaymentStatus
+)
+
+func NewPaymentRepositoryDB(db *mongo.Collection) PaymentRepository {
+	return paymentRepositoryDB{db}
+}
+
+func (r paymentRepositoryDB) Create(payment Payment) (string, error) {
+	if len(payment.UID) == 0 {
+		return "", ErrUser
+	}
+
+	if (payment.Method != "DEPOSIT" && payment.Method != "WITHDRAW") ||
+		payment.Amount <= 0 ||
+		payment.Status != model.PaymentRequested {
+		return "", ErrPayment
+	}
+
+	now := time.Now().Unix()
+	payment.CreatedAt = now
+	payment.UpdatedAt = now
+	if payment.History == nil {
+		payment.History = []PaymentEvent{}
+	}
+
+	_, err := r.db.InsertOne(ctx, payment)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully created payment", nil
+}
+
+func (r paymentRepositoryDB) Get(paymentId string) (Payment, error) {
+	objectPaymentId, err := primitive.ObjectIDFromHex(paymentId)
+	if err != nil {
+		return Payment{}, ErrPayment
+	}
+
+	var payment Payment
+	err = r.db.FindOne(ctx, bson.M{"_id": objectPaymentId}).Decode(&payment)
+	if err == mongo.ErrNoDocuments {
+		return Payment{}, ErrPayment
+	}
+	if err != nil {
+		return Payment{}, err
+	}
+
+	return payment, nil
+}
+
+func (r paymentRepositoryDB) GetUserPayments(userId string, skip uint) ([]Payment, error) {
+	if len(userId) == 0 {
+		return []Payment{}, ErrUser
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64(skip)).
+		SetLimit(10)
+
+	return r.find(filter, opts)
+}
+
+func (r paymentRepositoryDB) GetPendingApprovals() ([]Payment, error) {
+	filter := bson.M{
+		"status":           model.PaymentRequested,
+		"approvalRequired": true,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	return r.find(filter, opts)
+}
+
+// failed payments do not count toward the limit
+func (r paymentRepositoryDB) GetDailyTotal(userId string, method string, since int64) (float64, error) {
+	if len(userId) == 0 {
+		return 0, ErrUser
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"uid":       userId,
+			"method":    method,
+			"createdAt": bson.M{"$gte": since},
+			"status":    bson.M{"$ne": model.PaymentFailed},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": "$baseAmount"},
+		}}},
+	}
+
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Total float64 `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	return result.Total, nil
+}
+
+// the status only moves along the allowed transitions, a concurrent update
+// of the same payment matches nothing and fails with ErrPaymentStatus
+func (r paymentRepositoryDB) UpdateStatus(paymentId string, from string, event PaymentEvent) (string, error) {
+	if !util.CanTransitPayment(from, event.Status) {
+		return "", ErrPaymentStatus
+	}
+
+	objectPaymentId, err := primitive.ObjectIDFromHex(paymentId)
+	if err != nil {
+		return "", ErrPayment
+	}
+
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+
+	filter := bson.M{
+		"_id":    objectPaymentId,
+		"status": from,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    event.Status,
+			"updatedAt": event.Timestamp,
+		},
+		"$push": bson.M{
+			"history": event,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrPaymentStatus
+	}
+
+	return "Successfully updated payment status", nil
+}
+
+func (r paymentRepositoryDB) SetReference(paymentId string, reference string) (string, error) {
+	objectPaymentId, err := primitive.ObjectIDFromHex(paymentId)
+	if err != nil {
+		return "", ErrPayment
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"reference": reference,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, bson.M{"_id": objectPaymentId}, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrPayment
+	}
+
+	return "Successfully set payment reference", nil
+}
+
+func (r paymentRepositoryDB) find(filter bson.M, opts *options.FindOptions) ([]Payment, error) {
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return []Payment{}, err
+	}
+	defer cursor.Close(ctx)
+
+	payments := []Payment{}
+	if err := cursor.All(ctx, &payments); err != nil {
+		return []Payment{}, err
+	}
+
+	return payments, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type paymentRepositoryDBMock struct {
+	mock.Mock
+}
+
+func NewPaymentRepositoryDBMock() *paymentRepositoryDBMock {
+	return &paymentRepositoryDBMock{}
+}
+
+func (m *paymentRepositoryDBMock) Create(payment Payment) (string, error) {
+	arge := m.Called(payment)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *paymentRepositoryDBMock) Get(paymentId string) (Payment, error) {
+	arge := m.Called(paymentId)
+	return arge.Get(0).(Payment), arge.Error(1)
+}
+
+func (m *paymentRepositoryDBMock) GetUserPayments(userId string, skip uint) ([]Payment, error) {
+	arge := m.Called(userId, skip)
+	return arge.Get(0).([]Payment), arge.Error(1)
+}
+
+func (m *paymentRepositoryDBMock) GetPendingApprovals() ([]Payment, error) {
+	arge := m.Called()
+	return arge.Get(0).([]Payment), arge.Error(1)
+}
+
+func (m *paymentRepositoryDBMock) GetDailyTotal(userId string, method string, since int64) (float64, error) {
+	arge := m.Called(userId, method, since)
+	return arge.Get(0).(float64), arge.Error(1)
+}
+
+func (m *paymentRepositoryDBMock) UpdateStatus(paymentId string, from string, event PaymentEvent) (string, error) {
+	arge := m.Called(paymentId, from, event)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *paymentRepositoryDBMock) SetReference(paymentId string, reference string) (string, error) {
+	arge := m.Called(paymentId, reference)
+	return arge.String(0), arge.Error(1)
+}
@@ -0,0 +1,92 @@
+package repository_test
+
+import (
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Payment = repository.Payment
+type PaymentEvent = repository.PaymentEvent
+
+func InitPaymentRepo() repository.PaymentRepository {
+	client, _ := repository.InitMongoDB("mongodb://localhost:27017/trading-system")
+	db := client.Database("trading-system")
+	collection := db.Collection("payment")
+	paymentRepo := repository.NewPaymentRepositoryDB(collection)
+
+	return paymentRepo
+}
+
+var paymentRepo = InitPaymentRepo()
+
+func TestPaymentLifecycle(t *testing.T) {
+	payment := Payment{
+		ID:       primitive.NewObjectID(),
+		UID:      "test12345",
+		Method:   "DEPOSIT",
+		Currency: "THB",
+		Amount:   100,
+		Status:   model.PaymentRequested,
+	}
+	paymentId := payment.ID.Hex()
+
+	t.Run("Error invalid payment", func(t *testing.T) {
+		_, err := paymentRepo.Create(Payment{UID: "test12345"})
+
+		assert.ErrorIs(t, err, errs.ErrPayment)
+	})
+
+	t.Run("Create payment", func(t *testing.T) {
+		actual, err := paymentRepo.Create(payment)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully created payment", actual)
+	})
+
+	t.Run("Error invalid transition", func(t *testing.T) {
+		_, err := paymentRepo.UpdateStatus(
+			paymentId,
+			model.PaymentRequested,
+			PaymentEvent{Status: model.PaymentCompleted},
+		)
+
+		assert.ErrorIs(t, err, errs.ErrPaymentStatus)
+	})
+
+	t.Run("Update status", func(t *testing.T) {
+		_, err := paymentRepo.UpdateStatus(
+			paymentId,
+			model.PaymentRequested,
+			PaymentEvent{Status: model.PaymentPending},
+		)
+		assert.Empty(t, err)
+
+		actual, err := paymentRepo.Get(paymentId)
+
+		assert.Empty(t, err)
+		assert.Equal(t, model.PaymentPending, actual.Status)
+		assert.Len(t, actual.History, 1)
+	})
+
+	t.Run("Error stale status", func(t *testing.T) {
+		_, err := paymentRepo.UpdateStatus(
+			paymentId,
+			model.PaymentRequested,
+			PaymentEvent{Status: model.PaymentPending},
+		)
+
+		assert.ErrorIs(t, err, errs.ErrPaymentStatus)
+	})
+
+	t.Run("Get daily total", func(t *testing.T) {
+		actual, err := paymentRepo.GetDailyTotal("test12345", "DEPOSIT", 0)
+
+		assert.Empty(t, err)
+		assert.GreaterOrEqual(t, actual, float64(0))
+	})
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "server/model"
+
+type QueuedOrder = model.QueuedOrder
+
+type QueuedOrderRepository interface {
+	Create(QueuedOrder) (string, error)
+	GetUserQueuedOrders(string) ([]QueuedOrder, error)
+	GetQueuedOrders() ([]QueuedOrder, error)
+	UpdateStatus(string, string, string, string) (string, error)
+	Cancel(string, string) (string, error)
+	ReduceAmount(string, float64) (string, error)
+	ApplySplit(string, string, float64) (int64, error)
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"server/errs"
+	"server/model"
+	"server/util"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type queuedOrderRepositoryDB struct {
+	db *mongo.Collection
+}
+
+var (
+	ErrQueuedOrder       = errs.ErrQueuedOrder
+	ErrQueuedOrderStatus = errs.ErrQueuedOrderStatus
+)
+
+func NewQueuedOrderRepositoryDB(db *mongo.Collection) QueuedOrderRepository {
+	return queuedOrderRepositoryDB{db}
+}
+
+func (r queuedOrderRepositoryDB) Create(queuedOrder QueuedOrder) (string, error) {
+	if len(queuedOrder.UserId) == 0 {
+		return "", ErrUser
+	}
+
+	if len(queuedOrder.StockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if queuedOrder.Amount <= 0 ||
+		queuedOrder.Price <= 0 ||
+		queuedOrder.Status != model.QueuedOrderQueued {
+		return "", ErrQueuedOrder
+	}
+
+	now := time.Now().Unix()
+	queuedOrder.CreatedAt = now
+	queuedOrder.UpdatedAt = now
+
+	_, err := r.db.InsertOne(ctx, queuedOrder)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully queued order", nil
+}
+
+func (r queuedOrderRepositoryDB) GetUserQueuedOrders(userId string) ([]QueuedOrder, error) {
+	if len(userId) == 0 {
+		return []QueuedOrder{}, ErrUser
+	}
+
+	filter := bson.M{
+		"userId": userId,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+
+	return r.find(filter, opts)
+}
+
+// orders waiting for the continuous session, oldest first
+func (r queuedOrderRepositoryDB) GetQueuedOrders() ([]QueuedOrder, error) {
+	filter := bson.M{
+		"status": model.QueuedOrderQueued,
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	return r.find(filter, opts)
+}
+
+func (r queuedOrderRepositoryDB) UpdateStatus(orderId string, from string, to string, note string) (string, error) {
+	if !util.CanTransitQueuedOrder(from, to) {
+		return "", ErrQueuedOrderStatus
+	}
+
+	objectOrderId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return "", ErrQueuedOrder
+	}
+
+	filter := bson.M{
+		"_id":    objectOrderId,
+		"status": from,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    to,
+			"note":      note,
+			"updatedAt": time.Now().Unix(),
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrQueuedOrderStatus
+	}
+
+	return "Successfully updated queued order status", nil
+}
+
+// only the user who queued the order can cancel it, and only before it is
+// sent
+func (r queuedOrderRepositoryDB) Cancel(orderId string, userId string) (string, error) {
+	objectOrderId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return "", ErrQueuedOrder
+	}
+
+	filter := bson.M{
+		"_id":    objectOrderId,
+		"userId": userId,
+		"status": model.QueuedOrderQueued,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    model.QueuedOrderCancelled,
+			"updatedAt": time.Now().Unix(),
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrQueuedOrderStatus
+	}
+
+	return "Successfully cancelled queued order", nil
+}
+
+// the part of an order filled in a call auction, the rest stays queued. a
+// negative amount gives back a part that could not be filled
+func (r queuedOrderRepositoryDB) ReduceAmount(orderId string, amount float64) (string, error) {
+	objectOrderId, err := primitive.ObjectIDFromHex(orderId)
+	if err != nil {
+		return "", ErrQueuedOrder
+	}
+
+	filter := bson.M{
+		"_id":    objectOrderId,
+		"status": model.QueuedOrderQueued,
+		"amount": bson.M{"$gt": amount},
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			"amount": -amount,
+		},
+		"$set": bson.M{
+			"updatedAt": time.Now().Unix(),
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrQueuedOrderStatus
+	}
+
+	return "Successfully reduced queued order", nil
+}
+
+// a queued order keeps its value through a split, the amount is multiplied
+// and the price divided by the ratio
+func (r queuedOrderRepositoryDB) ApplySplit(stockId string, actionId string, ratio float64) (int64, error) {
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	if len(actionId) == 0 {
+		return 0, errs.ErrCorporateAction
+	}
+
+	if ratio <= 0 {
+		return 0, errs.ErrSplitRatio
+	}
+
+	filter := bson.M{
+		"stockId": stockId,
+		"status":  model.QueuedOrderQueued,
+		"splits":  bson.M{"$ne": actionId},
+	}
+	update := bson.M{
+		"$mul": bson.M{
+			"amount": ratio,
+			"price":  1 / ratio,
+		},
+		"$set": bson.M{
+			"updatedAt": time.Now().Unix(),
+		},
+		"$push": bson.M{
+			"splits": actionId,
+		},
+	}
+
+	result, err := r.db.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r queuedOrderRepositoryDB) find(filter bson.M, opts *options.FindOptions) ([]QueuedOrder, error) {
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return []QueuedOrder{}, err
+	}
+	defer cursor.Close(ctx)
+
+	queuedOrders := []QueuedOrder{}
+	if err := cursor.All(ctx, &queuedOrders); err != nil {
+		return []QueuedOrder{}, err
+	}
+
+	return queuedOrders, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type queuedOrderRepositoryDBMock struct {
+	mock.Mock
+}
+
+func NewQueuedOrderRepositoryDBMock() *queuedOrderRepositoryDBMock {
+	return &queuedOrderRepositoryDBMock{}
+}
+
+func (m *queuedOrderRepositoryDBMock) Create(queuedOrder QueuedOrder) (string, error) {
+	arge := m.Called(queuedOrder)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *queuedOrderRepositoryDBMock) GetUserQueuedOrders(userId string) ([]QueuedOrder, error) {
+	arge := m.Called(userId)
+	return arge.Get(0).([]QueuedOrder), arge.Error(1)
+}
+
+func (m *queuedOrderRepositoryDBMock) GetQueuedOrders() ([]QueuedOrder, error) {
+	arge := m.Called()
+	return arge.Get(0).([]QueuedOrder), arge.Error(1)
+}
+
+func (m *queuedOrderRepositoryDBMock) UpdateStatus(orderId string, from string, to string, note string) (string, error) {
+	arge := m.Called(orderId, from, to, note)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *queuedOrderRepositoryDBMock) Cancel(orderId string, userId string) (string, error) {
+	arge := m.Called(orderId, userId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *queuedOrderRepositoryDBMock) ApplySplit(stockId string, actionId string, ratio float64) (int64, error) {
+	arge := m.Called(stockId, actionId, ratio)
+	return arge.Get(0).(int64), arge.Error(1)
+}
+
+func (m *queuedOrderRepositoryDBMock) ReduceAmount(orderId string, amount float64) (string, error) {
+	arge := m.Called(orderId, amount)
+	return arge.String(0), arge.Error(1)
+}
@@ -0,0 +1,100 @@
+package repository_test
+
+import (
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type QueuedOrder = repository.QueuedOrder
+
+func InitQueuedOrderRepo() repository.QueuedOrderRepository {
+	client, _ := repository.InitMongoDB("mongodb://localhost:27017/trading-system")
+	db := client.Database("trading-system")
+	collection := db.Collection("queuedOrder")
+	queuedOrderRepo := repository.NewQueuedOrderRepositoryDB(collection)
+
+	return queuedOrderRepo
+}
+
+var queuedOrderRepo = InitQueuedOrderRepo()
+
+func TestQueuedOrderLifecycle(t *testing.T) {
+	queuedOrder := QueuedOrder{
+		ID:          primitive.NewObjectID(),
+		UserId:      userIdTesting,
+		StockId:     stockIdTesting,
+		Price:       10,
+		Amount:      100,
+		OrderType:   "order",
+		OrderMethod: "buy",
+		Status:      model.QueuedOrderQueued,
+	}
+	orderId := queuedOrder.ID.Hex()
+
+	t.Run("Error invalid queued order", func(t *testing.T) {
+		_, err := queuedOrderRepo.Create(QueuedOrder{UserId: userIdTesting, StockId: stockIdTesting})
+
+		assert.ErrorIs(t, err, errs.ErrQueuedOrder)
+	})
+
+	t.Run("Queue order", func(t *testing.T) {
+		actual, err := queuedOrderRepo.Create(queuedOrder)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully queued order", actual)
+	})
+
+	t.Run("Apply split to queued order", func(t *testing.T) {
+		_, err := queuedOrderRepo.ApplySplit(stockIdTesting, primitive.NewObjectID().Hex(), 2)
+		assert.Empty(t, err)
+
+		queuedOrders, _ := queuedOrderRepo.GetUserQueuedOrders(userIdTesting)
+		for _, actual := range queuedOrders {
+			if actual.ID == queuedOrder.ID {
+				assert.Equal(t, float64(5), actual.Price)
+				assert.Equal(t, float64(200), actual.Amount)
+			}
+		}
+	})
+
+	t.Run("Error cancel by other user", func(t *testing.T) {
+		_, err := queuedOrderRepo.Cancel(orderId, "other")
+
+		assert.ErrorIs(t, err, errs.ErrQueuedOrderStatus)
+	})
+
+	t.Run("Reduce queued order", func(t *testing.T) {
+		_, err := queuedOrderRepo.ReduceAmount(orderId, 100)
+		assert.ErrorIs(t, err, errs.ErrQueuedOrderStatus)
+
+		actual, err := queuedOrderRepo.ReduceAmount(orderId, 40)
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully reduced queued order", actual)
+
+		_, err = queuedOrderRepo.ReduceAmount(orderId, -40)
+		assert.Empty(t, err)
+	})
+
+	t.Run("Fill queued order", func(t *testing.T) {
+		_, err := queuedOrderRepo.UpdateStatus(orderId, model.QueuedOrderQueued, model.QueuedOrderFilling, "")
+		assert.Empty(t, err)
+
+		_, err = queuedOrderRepo.Cancel(orderId, userIdTesting)
+		assert.ErrorIs(t, err, errs.ErrQueuedOrderStatus)
+
+		actual, err := queuedOrderRepo.UpdateStatus(orderId, model.QueuedOrderFilling, model.QueuedOrderFilled, "")
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully updated queued order status", actual)
+	})
+
+	t.Run("Error invalid transition", func(t *testing.T) {
+		_, err := queuedOrderRepo.UpdateStatus(orderId, model.QueuedOrderFilled, model.QueuedOrderQueued, "")
+
+		assert.ErrorIs(t, err, errs.ErrQueuedOrderStatus)
+	})
+}
@@ -0,0 +1,67 @@
+package repositorytest_test
+
+import (
+	"context"
+	"os"
+	"server/repository"
+	"server/repository/repositorytest"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	mongoOnce   sync.Once
+	mongoClient *mongo.Client
+)
+
+// every case gets its own collection that is dropped afterwards, the mongo
+// suites are skipped when there is no database to run them on
+func mongoCollection(t *testing.T) *mongo.Collection {
+	t.Helper()
+
+	mongoOnce.Do(func() {
+		uri := os.Getenv("MONGO_URI")
+		if len(uri) == 0 {
+			uri = "mongodb://localhost:27017"
+		}
+		mongoClient, _ = repository.InitMongoDB(uri)
+	})
+
+	if mongoClient == nil {
+		t.Skip("mongo is not reachable")
+	}
+
+	collection := mongoClient.Database("trading-system-test").Collection("conformance_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		collection.Drop(context.Background())
+	})
+
+	return collection
+}
+
+func TestStockRepositoryMemory(t *testing.T) {
+	repositorytest.TestStockRepository(t, func(t *testing.T) repository.StockRepository {
+		return repository.NewStockRepositoryMemory()
+	})
+}
+
+func TestStockRepositoryDB(t *testing.T) {
+	repositorytest.TestStockRepository(t, func(t *testing.T) repository.StockRepository {
+		return repository.NewStockRepositoryDB(mongoCollection(t))
+	})
+}
+
+func TestUserRepositoryMemory(t *testing.T) {
+	repositorytest.TestUserRepository(t, func(t *testing.T) repository.UserRepository {
+		return repository.NewUserRepositoryMemory()
+	})
+}
+
+func TestUserRepositoryDB(t *testing.T) {
+	repositorytest.TestUserRepository(t, func(t *testing.T) repository.UserRepository {
+		return repository.NewUserRepositoryDB(mongoCollection(t))
+	})
+}
@@ -0,0 +1,337 @@
+// Package repositorytest holds the behavior every implementation of the
+// repositories has to share, the suites are run against the mongo and the
+// in-memory repositories so they cannot drift apart.
+package repositorytest
+
+import (
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type StockCollection = repository.StockCollection
+type StockHistory = repository.StockHistory
+type StockStatusEvent = repository.StockStatusEvent
+type TradingRule = repository.TradingRule
+
+// newRepo returns an empty repository for every case
+func TestStockRepository(t *testing.T, newRepo func(t *testing.T) repository.StockRepository) {
+	t.Run("Create stock", func(t *testing.T) {
+		stockRepo := newRepo(t)
+
+		_, err := stockRepo.CreateStock(StockCollection{Name: "test", Sign: "test", StockImage: "test"})
+		assert.ErrorIs(t, err, errs.ErrData)
+
+		actual, err := stockRepo.CreateStock(newStock("AAA"))
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully created stock collection", actual)
+
+		stocks, err := stockRepo.GetAllStocks()
+		assert.Empty(t, err)
+		assert.Len(t, stocks, 1)
+		assert.Equal(t, "AAA", stocks[0].Sign)
+		assert.Equal(t, float64(10), stocks[0].Price)
+		assert.Equal(t, model.StockActive, stocks[0].Status)
+	})
+
+	t.Run("Get stock", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+
+		_, err := stockRepo.GetStock("")
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+		_, err = stockRepo.GetStock("invalid")
+		assert.Error(t, err)
+
+		missing, err := stockRepo.GetStock(primitive.NewObjectID().Hex())
+		assert.Empty(t, err)
+		assert.Empty(t, missing)
+
+		actual, err := stockRepo.GetStock(stockId)
+		assert.Empty(t, err)
+		assert.Equal(t, stockId, actual.ID)
+		assert.Equal(t, "AAA", actual.Name)
+		assert.Equal(t, model.StockActive, actual.Status)
+		assert.Zero(t, actual.Open)
+		assert.Zero(t, actual.Change)
+	})
+
+	t.Run("Create stock order", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+		order := StockHistory{ID: "user", Amount: 2, Price: 12}
+
+		_, err := stockRepo.CreateStockOrder("", order)
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+		_, err = stockRepo.CreateStockOrder(stockId, StockHistory{})
+		assert.ErrorIs(t, err, errs.ErrData)
+		_, err = stockRepo.CreateStockOrder(primitive.NewObjectID().Hex(), order)
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+		actual, err := stockRepo.CreateStockOrder(stockId, order)
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully created stock order", actual)
+		stockRepo.CreateStockOrder(stockId, StockHistory{ID: "user", Amount: 1, Price: 14})
+
+		price, err := stockRepo.GetPrice(stockId)
+		assert.Empty(t, err)
+		assert.Equal(t, float64(14), price)
+
+		histories, err := stockRepo.GetStockHistory(stockId)
+		assert.Empty(t, err)
+		assert.ElementsMatch(t, []repository.StockHistoryResponse{
+			{Amount: 2, Price: 12},
+			{Amount: 1, Price: 14},
+		}, histories)
+
+		graph, err := stockRepo.GetGraph(stockId)
+		assert.Empty(t, err)
+		assert.Len(t, graph, 2)
+	})
+
+	t.Run("Get top stocks", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		lowId := createStock(t, stockRepo, "LOW")
+		highId := createStock(t, stockRepo, "HIGH")
+		delistedId := createStock(t, stockRepo, "DEL")
+		createStock(t, stockRepo, "NONE")
+		stockRepo.CreateStockOrder(lowId, StockHistory{ID: "user", Amount: 1, Price: 10})
+		stockRepo.CreateStockOrder(highId, StockHistory{ID: "user", Amount: 5, Price: 10})
+		stockRepo.CreateStockOrder(delistedId, StockHistory{ID: "user", Amount: 9, Price: 10})
+		stockRepo.SetStatus(delistedId, model.StockActive, StockStatusEvent{Status: model.StockDelisted})
+
+		actual, err := stockRepo.GetTopStocks()
+
+		assert.Empty(t, err)
+		assert.Len(t, actual, 2)
+		assert.Equal(t, highId, actual[0].ID)
+		assert.Equal(t, float64(50), actual[0].Volume)
+		assert.Equal(t, lowId, actual[1].ID)
+	})
+
+	t.Run("Get favorite stock", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+		createStock(t, stockRepo, "BBB")
+
+		_, err := stockRepo.GetFavoriteStock([]string{""})
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+		_, err = stockRepo.GetFavoriteStock([]string{"invalid"})
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+
+		actual, err := stockRepo.GetFavoriteStock([]string{stockId, primitive.NewObjectID().Hex()})
+		assert.Empty(t, err)
+		assert.Len(t, actual, 1)
+		assert.Equal(t, stockId, actual[0].ID)
+	})
+
+	t.Run("Set price", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+
+		_, err := stockRepo.SetPrice("", 20)
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+		_, err = stockRepo.SetPrice(stockId, 0)
+		assert.ErrorIs(t, err, errs.ErrPrice)
+		_, err = stockRepo.GetPrice(primitive.NewObjectID().Hex())
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+		actual, err := stockRepo.SetPrice(stockId, 20)
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully set price", actual)
+		price, _ := stockRepo.GetPrice(stockId)
+		assert.Equal(t, float64(20), price)
+	})
+
+	t.Run("Set trading rule", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+		tradingRule := TradingRule{TickSize: 0.5, LotSize: 10}
+
+		_, err := stockRepo.SetTradingRule(stockId, TradingRule{TickSize: -1})
+		assert.ErrorIs(t, err, errs.ErrTradingRule)
+		_, err = stockRepo.SetTradingRule(primitive.NewObjectID().Hex(), tradingRule)
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+		_, err = stockRepo.GetTradingRule(primitive.NewObjectID().Hex())
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+
+		_, err = stockRepo.SetTradingRule(stockId, tradingRule)
+		assert.Empty(t, err)
+		actual, err := stockRepo.GetTradingRule(stockId)
+		assert.Empty(t, err)
+		assert.Equal(t, tradingRule, actual)
+	})
+
+	t.Run("Get currency", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+
+		_, err := stockRepo.GetCurrency(primitive.NewObjectID().Hex())
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+
+		actual, err := stockRepo.GetCurrency(stockId)
+		assert.Empty(t, err)
+		assert.Equal(t, model.BaseCurrency, actual)
+	})
+
+	t.Run("Edit name and sign", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+
+		_, err := stockRepo.EditName(stockId, "")
+		assert.ErrorIs(t, err, errs.ErrName)
+		_, err = stockRepo.EditSign(stockId, "")
+		assert.ErrorIs(t, err, errs.ErrSign)
+
+		_, err = stockRepo.EditName(stockId, "name")
+		assert.Empty(t, err)
+		_, err = stockRepo.EditSign(stockId, "BBB")
+		assert.Empty(t, err)
+
+		actual, _ := stockRepo.GetStock(stockId)
+		assert.Equal(t, "name", actual.Name)
+		assert.Equal(t, "BBB", actual.Sign)
+	})
+
+	t.Run("Apply split", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+		stockRepo.CreateStockOrder(stockId, StockHistory{ID: "user", Amount: 3, Price: 10})
+		stockRepo.GetPreviousClose(stockId, "2024-01-01")
+
+		_, err := stockRepo.ApplySplit(stockId, "action", 0)
+		assert.ErrorIs(t, err, errs.ErrSplitRatio)
+		_, err = stockRepo.ApplySplit(primitive.NewObjectID().Hex(), "action", 2)
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+
+		_, err = stockRepo.ApplySplit(stockId, "action", 2)
+		assert.Empty(t, err)
+		_, err = stockRepo.ApplySplit(stockId, "action", 2)
+		assert.Empty(t, err)
+
+		price, _ := stockRepo.GetPrice(stockId)
+		assert.Equal(t, float64(5), price)
+		previousClose, _ := stockRepo.GetPreviousClose(stockId, "2024-01-01")
+		assert.Equal(t, float64(5), previousClose)
+		histories, _ := stockRepo.GetStockHistory(stockId)
+		assert.Equal(t, []repository.StockHistoryResponse{{Amount: 6, Price: 5}}, histories)
+	})
+
+	t.Run("Set status", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+		now := time.Now().Unix()
+
+		_, err := stockRepo.SetStatus(stockId, model.StockActive, StockStatusEvent{Status: "unknown"})
+		assert.ErrorIs(t, err, errs.ErrStockStatus)
+		_, err = stockRepo.SetStatus(stockId, model.StockHalted, StockStatusEvent{Status: model.StockActive})
+		assert.ErrorIs(t, err, errs.ErrStockStatus)
+
+		_, err = stockRepo.SetStatus(stockId, model.StockActive, StockStatusEvent{Status: model.StockHalted, Until: now})
+		assert.Empty(t, err)
+		status, _ := stockRepo.GetStatus(stockId)
+		assert.Equal(t, model.StockHalted, status)
+
+		expired, err := stockRepo.GetExpiredHalts(now)
+		assert.Empty(t, err)
+		assert.Equal(t, []string{stockId}, expired)
+		expired, _ = stockRepo.GetExpiredHalts(now - 1)
+		assert.Empty(t, expired)
+
+		_, err = stockRepo.SetStatus(stockId, model.StockHalted, StockStatusEvent{Status: model.StockDelisted})
+		assert.Empty(t, err)
+		stocks, _ := stockRepo.GetAllStocks()
+		assert.Empty(t, stocks)
+	})
+
+	t.Run("Previous close and auction price", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+		today := time.Now().Format(time.DateOnly)
+
+		_, err := stockRepo.SetAuctionPrice(stockId, model.SessionContinuous, 12, today)
+		assert.ErrorIs(t, err, errs.ErrMarketSession)
+		_, err = stockRepo.SetAuctionPrice(stockId, model.SessionClosing, 0, today)
+		assert.ErrorIs(t, err, errs.ErrPrice)
+		_, err = stockRepo.SetAuctionPrice(primitive.NewObjectID().Hex(), model.SessionClosing, 12, today)
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+		_, err = stockRepo.GetPreviousClose(primitive.NewObjectID().Hex(), today)
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+
+		previousClose, err := stockRepo.GetPreviousClose(stockId, "2024-01-01")
+		assert.Empty(t, err)
+		assert.Equal(t, float64(10), previousClose)
+
+		stockRepo.SetAuctionPrice(stockId, model.SessionClosing, 12, "2024-01-01")
+		previousClose, _ = stockRepo.GetPreviousClose(stockId, "2024-01-01")
+		assert.Equal(t, float64(10), previousClose)
+		previousClose, _ = stockRepo.GetPreviousClose(stockId, "2024-01-02")
+		assert.Equal(t, float64(12), previousClose)
+
+		stockRepo.SetPrice(stockId, 15)
+		previousClose, _ = stockRepo.GetPreviousClose(stockId, "2024-01-03")
+		assert.Equal(t, float64(15), previousClose)
+
+		_, err = stockRepo.SetAuctionPrice(stockId, model.SessionPreOpen, 16, today)
+		assert.Empty(t, err)
+		actual, _ := stockRepo.GetStock(stockId)
+		assert.Equal(t, float64(16), actual.Open)
+		assert.Equal(t, float64(0), actual.Change)
+	})
+
+	t.Run("Set image", func(t *testing.T) {
+		stockRepo := newRepo(t)
+		stockId := createStock(t, stockRepo, "AAA")
+
+		_, err := stockRepo.SetImage(stockId, "image", "")
+		assert.ErrorIs(t, err, errs.ErrImageType)
+		_, err = stockRepo.SetImage(stockId, "other", "next")
+		assert.ErrorIs(t, err, errs.ErrImageChanged)
+		_, err = stockRepo.GetImage(primitive.NewObjectID().Hex())
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+
+		_, err = stockRepo.SetImage(stockId, "image", "next")
+		assert.Empty(t, err)
+
+		image, _ := stockRepo.GetImage(stockId)
+		assert.Equal(t, "next", image)
+		used, _ := stockRepo.IsImageUsed("next")
+		assert.True(t, used)
+		used, _ = stockRepo.IsImageUsed("image")
+		assert.False(t, used)
+	})
+}
+
+func newStock(sign string) StockCollection {
+	return StockCollection{
+		StockImage: "image",
+		Name:       sign,
+		Sign:       sign,
+		Price:      10,
+		History:    []StockHistory{},
+	}
+}
+
+// the id of a stock is only known from the listing
+func createStock(t *testing.T, stockRepo repository.StockRepository, sign string) string {
+	t.Helper()
+
+	_, err := stockRepo.CreateStock(newStock(sign))
+	assert.Empty(t, err)
+
+	stocks, _ := stockRepo.GetAllStocks()
+	for _, stock := range stocks {
+		if stock.Sign == sign {
+			return stock.ID
+		}
+	}
+
+	t.Fatalf("stock %s is not listed", sign)
+	return ""
+}
@@ -0,0 +1,456 @@
+package repositorytest
+
+import (
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CreateAccount = repository.CreateAccount
+type OrderRequest = repository.OrderRequest
+type UserStock = repository.UserStock
+
+const (
+	testUserId  = "user"
+	testStockId = "65c39a12c4e3672bcbf15b0f"
+)
+
+// newRepo returns an empty repository for every case
+func TestUserRepository(t *testing.T, newRepo func(t *testing.T) repository.UserRepository) {
+	t.Run("Create account", func(t *testing.T) {
+		userRepo := newRepo(t)
+
+		_, err := userRepo.Create(CreateAccount{UID: testUserId})
+		assert.ErrorIs(t, err, errs.ErrData)
+
+		actual, err := userRepo.Create(newAccount(testUserId))
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully created account", actual)
+
+		_, err = userRepo.Create(newAccount(testUserId))
+		assert.ErrorIs(t, err, errs.ErrUser)
+
+		account, err := userRepo.GetAccount(testUserId)
+		assert.Empty(t, err)
+		assert.Equal(t, testUserId, account.UID)
+		assert.Equal(t, "name", account.Name)
+		assert.Equal(t, "email", account.Email)
+		assert.Zero(t, account.Balance)
+		assert.Empty(t, account.Stock)
+
+		_, err = userRepo.GetAccount("missing")
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	})
+
+	t.Run("Deposit and withdraw", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+
+		_, err := userRepo.Deposit(testUserId, "", 0)
+		assert.ErrorIs(t, err, errs.ErrMoney)
+		_, err = userRepo.Deposit("", "", 100)
+		assert.ErrorIs(t, err, errs.ErrUser)
+		_, err = userRepo.Deposit(testUserId, "invalid", 100)
+		assert.ErrorIs(t, err, errs.ErrCurrency)
+
+		actual, err := userRepo.Deposit(testUserId, "", 100)
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully deposited money", actual)
+		userRepo.Deposit(testUserId, "usd", 20)
+
+		_, err = userRepo.Withdraw(testUserId, "", 200)
+		assert.ErrorIs(t, err, errs.ErrBalance)
+		_, err = userRepo.Withdraw("missing", "", 10)
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+		_, err = userRepo.Withdraw(testUserId, "", 30)
+		assert.Empty(t, err)
+
+		balance, err := userRepo.GetBalance(testUserId)
+		assert.Empty(t, err)
+		assert.Equal(t, float64(70), balance)
+
+		balances, err := userRepo.GetCurrencyBalances(testUserId)
+		assert.Empty(t, err)
+		assert.Equal(t, map[string]float64{model.BaseCurrency: 70, "USD": 20}, balances)
+
+		allBalances, err := userRepo.GetBalances()
+		assert.Empty(t, err)
+		assert.Equal(t, map[string]map[string]float64{testUserId: {model.BaseCurrency: 70, "USD": 20}}, allBalances)
+
+		_, err = userRepo.GetBalance("missing")
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	})
+
+	t.Run("Get balance history", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+		userRepo.Deposit(testUserId, "", 100)
+		userRepo.Withdraw(testUserId, "", 30)
+
+		_, err := userRepo.GetBalanceHistory(testUserId, "", 0)
+		assert.ErrorIs(t, err, errs.ErrOrderMethod)
+		_, err = userRepo.GetBalanceHistory(testUserId, "unknown", 0)
+		assert.ErrorIs(t, err, errs.ErrOrderMethod)
+
+		all, err := userRepo.GetBalanceHistory(testUserId, "ALL", 0)
+		assert.Empty(t, err)
+		assert.Len(t, all, 2)
+
+		deposits, err := userRepo.GetBalanceHistory(testUserId, "DEPOSIT", 0)
+		assert.Empty(t, err)
+		assert.Len(t, deposits, 1)
+		assert.Equal(t, float64(100), deposits[0].Balance)
+		assert.Equal(t, model.BaseCurrency, deposits[0].Currency)
+
+		skipped, _ := userRepo.GetBalanceHistory(testUserId, "ALL", 2)
+		assert.Empty(t, skipped)
+	})
+
+	t.Run("Charge and credit", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+
+		_, err := userRepo.Charge(testUserId, "BORROW_FEE", 0)
+		assert.ErrorIs(t, err, errs.ErrMoney)
+		_, err = userRepo.Credit("missing", "", "DIVIDEND", 10)
+		assert.ErrorIs(t, err, errs.ErrUser)
+
+		_, err = userRepo.Charge(testUserId, "BORROW_FEE", 10)
+		assert.Empty(t, err)
+		balance, _ := userRepo.GetBalance(testUserId)
+		assert.Equal(t, float64(-10), balance)
+
+		_, err = userRepo.Credit(testUserId, "", "DIVIDEND", 25)
+		assert.Empty(t, err)
+		balance, _ = userRepo.GetBalance(testUserId)
+		assert.Equal(t, float64(15), balance)
+
+		dividends, _ := userRepo.GetBalanceHistory(testUserId, "DIVIDEND", 0)
+		assert.Len(t, dividends, 1)
+	})
+
+	t.Run("Buy", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+		userRepo.Deposit(testUserId, "", 100)
+
+		_, err := userRepo.Buy(newOrder("", "buy", 1, 10))
+		assert.ErrorIs(t, err, errs.ErrUser)
+		_, err = userRepo.Buy(newOrder(testUserId, "buy", 0, 10))
+		assert.ErrorIs(t, err, errs.ErrData)
+		_, err = userRepo.Buy(newOrder(testUserId, "sale", 1, 10))
+		assert.ErrorIs(t, err, errs.ErrOrderMethod)
+		order := newOrder(testUserId, "buy", 1, 10)
+		order.OrderType = "limit"
+		_, err = userRepo.Buy(order)
+		assert.ErrorIs(t, err, errs.ErrOrderType)
+		_, err = userRepo.Buy(newOrder("missing", "buy", 1, 10))
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+		_, err = userRepo.Buy(newOrder(testUserId, "buy", 20, 10))
+		assert.ErrorIs(t, err, errs.ErrBalance)
+
+		actual, err := userRepo.Buy(newOrder(testUserId, "buy", 2, 10))
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully bought stock", actual)
+		_, err = userRepo.Buy(newOrder(testUserId, "buy", 2, 20))
+		assert.Empty(t, err)
+
+		userStock, err := userRepo.GetStockAmount(testUserId, testStockId)
+		assert.Empty(t, err)
+		assert.Equal(t, UserStock{StockId: testStockId, Amount: 4, AveragePrice: 15}, userStock)
+		balance, _ := userRepo.GetBalance(testUserId)
+		assert.Equal(t, float64(40), balance)
+
+		margin := newOrder(testUserId, "buy", 10, 10)
+		margin.Margin = true
+		_, err = userRepo.Buy(margin)
+		assert.Empty(t, err)
+		balance, _ = userRepo.GetBalance(testUserId)
+		assert.Equal(t, float64(-60), balance)
+	})
+
+	t.Run("Sale", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+		userRepo.Deposit(testUserId, "", 100)
+		userRepo.Buy(newOrder(testUserId, "buy", 4, 10))
+
+		_, err := userRepo.Sale(newOrder(testUserId, "buy", 1, 10))
+		assert.ErrorIs(t, err, errs.ErrOrderMethod)
+		_, err = userRepo.Sale(newOrder(testUserId, "sale", 5, 10))
+		assert.ErrorIs(t, err, errs.ErrNotEnoughStock)
+
+		actual, err := userRepo.Sale(newOrder(testUserId, "sale", 1, 20))
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully sold stock", actual)
+		userStock, _ := userRepo.GetStockAmount(testUserId, testStockId)
+		assert.Equal(t, UserStock{StockId: testStockId, Amount: 3, AveragePrice: 10}, userStock)
+
+		_, err = userRepo.Sale(newOrder(testUserId, "sale", 3, 20))
+		assert.Empty(t, err)
+		userStock, _ = userRepo.GetStockAmount(testUserId, testStockId)
+		assert.Equal(t, UserStock{}, userStock)
+		balance, _ := userRepo.GetBalance(testUserId)
+		assert.Equal(t, float64(140), balance)
+	})
+
+	t.Run("Sale short and cover", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+
+		short := newOrder(testUserId, "sale", 2, 10)
+		short.Short = true
+		_, err := userRepo.Sale(short)
+		assert.Empty(t, err)
+
+		positions, err := userRepo.GetStockPositions(testStockId)
+		assert.Empty(t, err)
+		assert.Equal(t, map[string]float64{testUserId: -2}, positions)
+		holders, _ := userRepo.GetStockHolders(testStockId)
+		assert.Empty(t, holders)
+
+		cover := newOrder(testUserId, "buy", 2, 5)
+		cover.Margin = true
+		_, err = userRepo.Buy(cover)
+		assert.Empty(t, err)
+
+		userStock, _ := userRepo.GetStockAmount(testUserId, testStockId)
+		assert.Equal(t, UserStock{}, userStock)
+		balance, _ := userRepo.GetBalance(testUserId)
+		assert.Equal(t, float64(10), balance)
+	})
+
+	t.Run("Trade histories", func(t *testing.T) {
+		userRepo := newRepo(t)
+		since := time.Now().Unix()
+		userRepo.Create(newAccount(testUserId))
+		userRepo.Deposit(testUserId, "", 1000)
+		order := newOrder(testUserId, "buy", 2, 10)
+		order.Fee = 1
+		userRepo.Buy(order)
+		other := newOrder(testUserId, "buy", 1, 30)
+		other.StockId = "other"
+		userRepo.Buy(other)
+
+		_, err := userRepo.GetUserStockHistory(testUserId, "", 0)
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+
+		all, err := userRepo.GetAllHistories(testUserId, 0)
+		assert.Empty(t, err)
+		assert.Len(t, all, 2)
+
+		histories, err := userRepo.GetUserStockHistory(testUserId, testStockId, 0)
+		assert.Empty(t, err)
+		assert.Len(t, histories, 1)
+		assert.Equal(t, float64(2), histories[0].Amount)
+		assert.Equal(t, float64(1), histories[0].Fee)
+		assert.Equal(t, "success", histories[0].Status)
+
+		volume, err := userRepo.GetTradingVolume(testUserId, since)
+		assert.Empty(t, err)
+		assert.Equal(t, float64(50), volume)
+
+		feeSummary, err := userRepo.GetFeeSummary(testUserId)
+		assert.Empty(t, err)
+		assert.Equal(t, float64(1), feeSummary.TotalFee)
+		assert.Equal(t, int64(2), feeSummary.TradeCount)
+	})
+
+	t.Run("Favorite", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+
+		_, err := userRepo.SetFavorite(testUserId, "")
+		assert.ErrorIs(t, err, errs.ErrInvalidStock)
+
+		actual, err := userRepo.SetFavorite(testUserId, testStockId)
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully set favorite stock", actual)
+		_, err = userRepo.SetFavorite(testUserId, testStockId)
+		assert.ErrorIs(t, err, errs.ErrFavoriteStock)
+
+		favorite, err := userRepo.GetFavorite(testUserId)
+		assert.Empty(t, err)
+		assert.Equal(t, []string{testStockId}, favorite)
+
+		_, err = userRepo.DeleteFavorite(testUserId, testStockId)
+		assert.Empty(t, err)
+		favorite, _ = userRepo.GetFavorite(testUserId)
+		assert.Empty(t, favorite)
+
+		_, err = userRepo.GetFavorite("missing")
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	})
+
+	t.Run("Margin account", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+		userRepo.Create(newAccount("other"))
+
+		_, err := userRepo.SetMarginEnabled("missing", true)
+		assert.ErrorIs(t, err, errs.ErrUser)
+
+		_, err = userRepo.SetMarginEnabled(testUserId, true)
+		assert.Empty(t, err)
+		accounts, err := userRepo.GetMarginAccounts()
+		assert.Empty(t, err)
+		assert.Equal(t, []string{testUserId}, accounts)
+
+		marginCall := &repository.MarginCall{Timestamp: 1, Deficit: 10, Status: "open"}
+		_, err = userRepo.SetMarginCall(testUserId, marginCall)
+		assert.Empty(t, err)
+		account, _ := userRepo.GetAccount(testUserId)
+		assert.Equal(t, marginCall, account.MarginCall)
+
+		_, err = userRepo.SetMarginCall(testUserId, nil)
+		assert.Empty(t, err)
+		account, _ = userRepo.GetAccount(testUserId)
+		assert.Nil(t, account.MarginCall)
+	})
+
+	t.Run("Apply split and dividend holders", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+		userRepo.Create(newAccount("other"))
+		userRepo.Deposit(testUserId, "", 100)
+		userRepo.Buy(newOrder(testUserId, "buy", 2, 10))
+
+		_, err := userRepo.ApplySplit(testStockId, "action", 0)
+		assert.ErrorIs(t, err, errs.ErrSplitRatio)
+		_, err = userRepo.ApplySplit(testStockId, "", 2)
+		assert.ErrorIs(t, err, errs.ErrCorporateAction)
+
+		holders, err := userRepo.ApplySplit(testStockId, "action", 2)
+		assert.Empty(t, err)
+		assert.Equal(t, int64(1), holders)
+		userStock, _ := userRepo.GetStockAmount(testUserId, testStockId)
+		assert.Equal(t, UserStock{StockId: testStockId, Amount: 4, AveragePrice: 5}, userStock)
+
+		holders, err = userRepo.ApplySplit(testStockId, "action", 2)
+		assert.Empty(t, err)
+		assert.Equal(t, int64(0), holders)
+		userStock, _ = userRepo.GetStockAmount(testUserId, testStockId)
+		assert.Equal(t, float64(4), userStock.Amount)
+
+		_, err = userRepo.SetDividendReinvest("missing", true)
+		assert.ErrorIs(t, err, errs.ErrUser)
+		_, err = userRepo.SetDividendReinvest(testUserId, true)
+		assert.Empty(t, err)
+
+		dividendHolders, err := userRepo.GetStockHolders(testStockId)
+		assert.Empty(t, err)
+		assert.Equal(t, []repository.DividendHolder{{UID: testUserId, Amount: 4, Reinvest: true}}, dividendHolders)
+	})
+
+	t.Run("Settle fraction", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+		userRepo.Deposit(testUserId, "", 100)
+		userRepo.Buy(newOrder(testUserId, "buy", 5, 10))
+		userRepo.ApplySplit(testStockId, "action", 0.5)
+
+		_, err := userRepo.SettleFraction(testUserId, testStockId, 3.5, 20, "")
+		assert.ErrorIs(t, err, errs.ErrNotEnoughStock)
+
+		fraction, err := userRepo.SettleFraction(testUserId, testStockId, 2.5, 20, "")
+		assert.Empty(t, err)
+		assert.Equal(t, 0.5, fraction)
+		userStock, _ := userRepo.GetStockAmount(testUserId, testStockId)
+		assert.Equal(t, float64(2), userStock.Amount)
+		balance, _ := userRepo.GetBalance(testUserId)
+		assert.Equal(t, float64(60), balance)
+
+		fraction, err = userRepo.SettleFraction(testUserId, testStockId, 2, 20, "")
+		assert.Empty(t, err)
+		assert.Equal(t, float64(0), fraction)
+	})
+
+	t.Run("Settle position", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+		userRepo.Deposit(testUserId, "", 100)
+		userRepo.Buy(newOrder(testUserId, "buy", 2, 10))
+
+		_, err := userRepo.SettlePosition(testUserId, testStockId, 2, -1, "")
+		assert.ErrorIs(t, err, errs.ErrPrice)
+
+		_, err = userRepo.SettlePosition(testUserId, testStockId, 3, 15, "")
+		assert.ErrorIs(t, err, errs.ErrNotEnoughStock)
+
+		amount, err := userRepo.SettlePosition(testUserId, testStockId, 2, 15, "")
+		assert.Empty(t, err)
+		assert.Equal(t, float64(2), amount)
+		balance, _ := userRepo.GetBalance(testUserId)
+		assert.Equal(t, float64(110), balance)
+
+		_, err = userRepo.SettlePosition(testUserId, testStockId, 2, 15, "")
+		assert.ErrorIs(t, err, errs.ErrNotEnoughStock)
+	})
+
+	t.Run("Edit profile and avatar", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+
+		_, err := userRepo.EditProfile(testUserId, repository.EditProfileRequest{})
+		assert.ErrorIs(t, err, errs.ErrData)
+		_, err = userRepo.EditProfile("missing", repository.EditProfileRequest{Name: "next"})
+		assert.ErrorIs(t, err, errs.ErrUser)
+
+		_, err = userRepo.EditProfile(testUserId, repository.EditProfileRequest{Name: "next"})
+		assert.Empty(t, err)
+		account, _ := userRepo.GetAccount(testUserId)
+		assert.Equal(t, "next", account.Name)
+		assert.Equal(t, "email", account.Email)
+
+		_, err = userRepo.SetAvatar(testUserId, "", "", "url")
+		assert.ErrorIs(t, err, errs.ErrImageType)
+		_, err = userRepo.SetAvatar(testUserId, "other", "avatar", "url")
+		assert.ErrorIs(t, err, errs.ErrImageChanged)
+
+		_, err = userRepo.SetAvatar(testUserId, "", "avatar", "url")
+		assert.Empty(t, err)
+		account, _ = userRepo.GetAccount(testUserId)
+		assert.Equal(t, "url", account.ProfileImage)
+		used, _ := userRepo.IsAvatarUsed("avatar")
+		assert.True(t, used)
+	})
+
+	t.Run("Delete account", func(t *testing.T) {
+		userRepo := newRepo(t)
+		userRepo.Create(newAccount(testUserId))
+
+		actual, err := userRepo.DeleteAccount(testUserId)
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully deleted account", actual)
+
+		_, err = userRepo.GetAccount(testUserId)
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+		_, err = userRepo.Create(newAccount(testUserId))
+		assert.Empty(t, err)
+	})
+}
+
+func newAccount(userId string) CreateAccount {
+	return CreateAccount{
+		UID:          userId,
+		Name:         "name",
+		ProfileImage: "image",
+		Email:        "email",
+	}
+}
+
+func newOrder(userId string, orderMethod string, amount float64, price float64) OrderRequest {
+	return OrderRequest{
+		UserId:      userId,
+		StockId:     testStockId,
+		Amount:      amount,
+		Price:       price,
+		OrderType:   "order",
+		OrderMethod: orderMethod,
+	}
+}
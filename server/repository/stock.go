@@ -9,6 +9,8 @@ type TopStock = model.TopStock
 type StockHistory = model.StockHistory
 type StockCollectionResponse = model.StockCollectionResponse
 type StockHistoryResponse = model.StockHistoryResponse
+type TradingRule = model.TradingRule
+type StockStatusEvent = model.StockStatusEvent
 
 
 type StockRepository interface {
@@ -21,8 +23,19 @@ type StockRepository interface {
 	GetStockHistory(string) ([]StockHistoryResponse, error) 
 	GetPrice(string) (float64, error)
 	GetGraph(string) ([]StockGraph, error)
+	GetTradingRule(string) (TradingRule, error)
+	GetCurrency(string) (string, error)
+	GetStatus(string) (string, error)
+	GetPreviousClose(string, string) (float64, error)
 	SetPrice(string, float64) (string, error)
+	SetTradingRule(string, TradingRule) (string, error)
 	EditName(string, string) (string, error)
 	EditSign(string, string) (string, error)
-	DeleteStock(string) (string, error)
+	ApplySplit(string, string, float64) (string, error)
+	SetStatus(string, string, StockStatusEvent) (string, error)
+	GetExpiredHalts(int64) ([]string, error)
+	SetAuctionPrice(string, string, float64, string) (string, error)
+	GetImage(string) (string, error)
+	SetImage(string, string, string) (string, error)
+	IsImageUsed(string) (bool, error)
 }
@@ -3,6 +3,7 @@ package repository
 import (
 	"server/errs"
 	"server/model"
+	"server/util"
 	"sort"
 	"time"
 
@@ -20,6 +21,26 @@ type StockPrice struct {
 	Price float64 `bson:"price"`
 }
 
+type StockTradingRule struct {
+	TradingRule TradingRule `bson:"tradingRule"`
+}
+
+type StockCurrency struct {
+	Currency string `bson:"currency"`
+}
+
+type StockStatus struct {
+	Status string `bson:"status"`
+}
+
+type StockImage struct {
+	StockImage string `bson:"stockImage"`
+}
+
+type StockPreviousClose struct {
+	PreviousClose float64 `bson:"previousClose"`
+}
+
 // type StockGraph struct {
 // 	Price float64 `json:"price"`
 // 	Timestamp int64 `json:"timestamp"`
@@ -30,10 +51,17 @@ type Graph = model.Graph
 type StockGraph = model.StockGraph
 
 var (
-	ErrPrice = errs.ErrPrice
-	ErrSign  = errs.ErrSign
+	ErrPrice       = errs.ErrPrice
+	ErrSign        = errs.ErrSign
+	ErrTradingRule = errs.ErrTradingRule
+	ErrStockStatus = errs.ErrStockStatus
 )
 
+// delisted stocks are kept for history but left out of the listings
+var listedFilter = bson.M{
+	"status": bson.M{"$ne": model.StockDelisted},
+}
+
 func NewStockRepositoryDB(db *mongo.Collection) StockRepository {
 	return stockRepositoryDB{db}
 }
@@ -86,12 +114,13 @@ func (r stockRepositoryDB) CreateStockOrder(stockId string, stockOrder StockHist
 		return "", err
 	}
 
-	// stockOrder.Price = stockPrice.Price
-
 	update := bson.M{
 		"$push": bson.M{
 			"stockHistory": stockOrder,
 		},
+		"$set": bson.M{
+			"price": stockOrder.Price,
+		},
 	}
 
 	_, err = r.db.UpdateOne(ctx, filter, update)
@@ -103,13 +132,14 @@ func (r stockRepositoryDB) CreateStockOrder(stockId string, stockOrder StockHist
 }
 
 func (r stockRepositoryDB) GetAllStocks() ([]StockCollectionResponse, error) {
-	filter := bson.M{}
+	filter := listedFilter
 	projection := bson.M{
 		"_id":        1,
 		"stockImage": 1,
 		"name":       1,
 		"sign":       1,
 		"price":      1,
+		"status":     1,
 	}
 
 	opts := options.Find().SetProjection(projection)
@@ -125,12 +155,14 @@ func (r stockRepositoryDB) GetAllStocks() ([]StockCollectionResponse, error) {
 			return []StockCollectionResponse{}, err
 		}
 
+		status, _ := result["status"].(string)
 		stockCollection := StockCollectionResponse{
 			ID:         result["_id"].(primitive.ObjectID).Hex(),
 			StockImage: result["stockImage"].(string),
 			Name:       result["name"].(string),
 			Sign:       result["sign"].(string),
 			Price:      result["price"].(float64),
+			Status:     util.StockStatus(status),
 		}
 
 		stockCollections = append(stockCollections, stockCollection)
@@ -141,6 +173,7 @@ func (r stockRepositoryDB) GetAllStocks() ([]StockCollectionResponse, error) {
 
 func (r stockRepositoryDB) GetTopStocks() ([]StockGroup, error) {
 	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: listedFilter}},
 		bson.D{{Key: "$unwind", Value: "$stockHistory"}},
 		bson.D{{Key: "$sort", Value: bson.D{{
 			Key: "stockHistory.timestamp", Value: -1,
@@ -231,11 +264,16 @@ func (r stockRepositoryDB) GetStock(stockId string) (StockCollectionResponse, er
 		"_id": objectStockId,
 	}
 	projection := bson.M{
-		"_id":        1,
-		"stockImage": 1,
-		"name":       1,
-		"sign":       1,
-		"price":      1,
+		"_id":           1,
+		"stockImage":    1,
+		"name":          1,
+		"sign":          1,
+		"price":         1,
+		"currency":      1,
+		"status":        1,
+		"openPrice":     1,
+		"openDate":      1,
+		"previousClose": 1,
 	}
 
 	var stockCollection StockCollectionResponse
@@ -246,6 +284,15 @@ func (r stockRepositoryDB) GetStock(stockId string) (StockCollectionResponse, er
 	}
 
 	stockCollection.ID = stockId
+	stockCollection.Status = util.StockStatus(stockCollection.Status)
+
+	if stockCollection.OpenDate != time.Now().Format(time.DateOnly) {
+		stockCollection.Open = 0
+	}
+
+	if stockCollection.PreviousClose > 0 {
+		stockCollection.Change = stockCollection.Price - stockCollection.PreviousClose
+	}
 
 	return stockCollection, nil
 }
@@ -275,6 +322,7 @@ func (r stockRepositoryDB) GetFavoriteStock(favoriteStockIds []string) ([]StockC
 		"sign":       1,
 		"price":      1,
 		"stockImage": 1,
+		"status":     1,
 	}
 
 	opts := options.Find().SetProjection(projection)
@@ -291,12 +339,14 @@ func (r stockRepositoryDB) GetFavoriteStock(favoriteStockIds []string) ([]StockC
 			return []StockCollectionResponse{}, err
 		}
 
+		status, _ := result["status"].(string)
 		favoriteStock := StockCollectionResponse{
 			ID:         result["_id"].(primitive.ObjectID).Hex(),
 			Name:       result["name"].(string),
 			Sign:       result["sign"].(string),
 			StockImage: result["stockImage"].(string),
 			Price:      result["price"].(float64),
+			Status:     util.StockStatus(status),
 		}
 
 		favoriteStocks = append(favoriteStocks, favoriteStock)
@@ -434,6 +484,155 @@ func (r stockRepositoryDB) GetGraph(stockId string) ([]StockGraph, error) {
 	return groups, nil
 }
 
+func (r stockRepositoryDB) GetTradingRule(stockId string) (TradingRule, error) {
+	if len(stockId) == 0 {
+		return TradingRule{}, ErrInvalidStock
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return TradingRule{}, err
+	}
+
+	filter := bson.M{
+		"_id": objectStockId,
+	}
+	projection := bson.M{
+		"tradingRule": 1,
+	}
+
+	var stockTradingRule StockTradingRule
+	opts := options.FindOne().SetProjection(projection)
+	err = r.db.FindOne(ctx, filter, opts).Decode(&stockTradingRule)
+	if err == mongo.ErrNoDocuments {
+		return TradingRule{}, ErrInvalidStock
+	}
+	if err != nil {
+		return TradingRule{}, err
+	}
+
+	return stockTradingRule.TradingRule, nil
+}
+
+// stocks created before quote currencies are quoted in base currency
+func (r stockRepositoryDB) GetCurrency(stockId string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	filter := bson.M{
+		"_id": objectStockId,
+	}
+	projection := bson.M{
+		"currency": 1,
+	}
+
+	var stockCurrency StockCurrency
+	opts := options.FindOne().SetProjection(projection)
+	err = r.db.FindOne(ctx, filter, opts).Decode(&stockCurrency)
+	if err == mongo.ErrNoDocuments {
+		return "", ErrInvalidStock
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(stockCurrency.Currency) == 0 {
+		return model.BaseCurrency, nil
+	}
+
+	return stockCurrency.Currency, nil
+}
+
+func (r stockRepositoryDB) GetStatus(stockId string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	filter := bson.M{
+		"_id": objectStockId,
+	}
+	projection := bson.M{
+		"status": 1,
+	}
+
+	var stockStatus StockStatus
+	opts := options.FindOne().SetProjection(projection)
+	err = r.db.FindOne(ctx, filter, opts).Decode(&stockStatus)
+	if err == mongo.ErrNoDocuments {
+		return "", ErrInvalidStock
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return util.StockStatus(stockStatus.Status), nil
+}
+
+// the first call of the day takes the official close of the closing auction
+// or else the price as the previous close, so the band stays the same until
+// the next day
+func (r stockRepositoryDB) GetPreviousClose(stockId string, date string) (float64, error) {
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return 0, err
+	}
+
+	rollFilter := bson.M{
+		"_id":       objectStockId,
+		"closeDate": bson.M{"$ne": date},
+	}
+	rollUpdate := bson.A{
+		bson.M{
+			"$set": bson.M{
+				"previousClose": bson.M{"$ifNull": bson.A{"$officialClose", "$price"}},
+				"closeDate":     date,
+			},
+		},
+		bson.M{
+			"$unset": "officialClose",
+		},
+	}
+
+	_, err = r.db.UpdateOne(ctx, rollFilter, rollUpdate)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{
+		"_id": objectStockId,
+	}
+	projection := bson.M{
+		"previousClose": 1,
+	}
+
+	var stockPreviousClose StockPreviousClose
+	opts := options.FindOne().SetProjection(projection)
+	err = r.db.FindOne(ctx, filter, opts).Decode(&stockPreviousClose)
+	if err == mongo.ErrNoDocuments {
+		return 0, ErrInvalidStock
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return stockPreviousClose.PreviousClose, nil
+}
+
 func (r stockRepositoryDB) SetPrice(stockId string, price float64) (string, error) {
 	if len(stockId) == 0 {
 		return "", ErrInvalidStock
@@ -465,6 +664,41 @@ func (r stockRepositoryDB) SetPrice(stockId string, price float64) (string, erro
 	return "Successfully set price", nil
 }
 
+func (r stockRepositoryDB) SetTradingRule(stockId string, tradingRule TradingRule) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if !util.ValidTradingRule(tradingRule) {
+		return "", ErrTradingRule
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	filter := bson.M{
+		"_id": objectStockId,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"tradingRule": tradingRule,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrInvalidStock
+	}
+
+	return "Successfully set trading rule", nil
+}
+
 func (r stockRepositoryDB) EditName(stockId string, name string) (string, error) {
 	if len(stockId) == 0 {
 		return "", ErrInvalidStock
@@ -527,7 +761,210 @@ func (r stockRepositoryDB) EditSign(stockId string, sign string) (string, error)
 	return "Successfully updated sign", nil
 }
 
-func (r stockRepositoryDB) DeleteStock(stockId string) (string, error) {
+// the price and every trade are adjusted so the graph stays continuous
+func (r stockRepositoryDB) ApplySplit(stockId string, actionId string, ratio float64) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if len(actionId) == 0 {
+		return "", errs.ErrCorporateAction
+	}
+
+	if ratio <= 0 {
+		return "", errs.ErrSplitRatio
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	filter := bson.M{
+		"_id":    objectStockId,
+		"splits": bson.M{"$ne": actionId},
+	}
+	update := mongo.Pipeline{
+		bson.D{{Key: "$set", Value: bson.M{
+			"splits": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$splits", bson.A{}}},
+				bson.A{actionId},
+			}},
+			"price":         bson.M{"$divide": bson.A{"$price", ratio}},
+			"previousClose": splitPrice("previousClose", ratio),
+			"openPrice":     splitPrice("openPrice", ratio),
+			"officialClose": splitPrice("officialClose", ratio),
+			"stockHistory": bson.M{"$map": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$stockHistory", bson.A{}}},
+				"as":    "history",
+				"in": bson.M{"$mergeObjects": bson.A{
+					"$$history",
+					bson.M{
+						"price":  bson.M{"$divide": bson.A{"$$history.price", ratio}},
+						"amount": bson.M{"$multiply": bson.A{"$$history.amount", ratio}},
+					},
+				}},
+			}},
+		}}},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		// already applied by an earlier try of the action
+		count, err := r.db.CountDocuments(ctx, bson.M{"_id": objectStockId})
+		if err != nil {
+			return "", err
+		}
+
+		if count == 0 {
+			return "", ErrInvalidStock
+		}
+	}
+
+	return "Successfully applied split", nil
+}
+
+// the price field divided by the ratio, a missing field stays missing
+func splitPrice(field string, ratio float64) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": "$" + field}, "missing"}},
+		"$$REMOVE",
+		bson.M{"$divide": bson.A{"$" + field, ratio}},
+	}}
+}
+
+// the status only moves along the allowed transitions, a concurrent update
+// of the same stock matches nothing and fails with ErrStockStatus
+func (r stockRepositoryDB) SetStatus(stockId string, from string, event StockStatusEvent) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if !util.CanTransitStock(from, event.Status) {
+		return "", ErrStockStatus
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+
+	statuses := bson.A{from}
+	if util.StockStatus(from) == model.StockActive {
+		statuses = bson.A{model.StockActive, "", nil}
+	}
+
+	filter := bson.M{
+		"_id":    objectStockId,
+		"status": bson.M{"$in": statuses},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    event.Status,
+			"haltUntil": event.Until,
+		},
+		"$push": bson.M{
+			"statusHistory": event,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrStockStatus
+	}
+
+	return "Successfully set stock status", nil
+}
+
+// halts of the circuit breaker that are due to be lifted
+func (r stockRepositoryDB) GetExpiredHalts(now int64) ([]string, error) {
+	filter := bson.M{
+		"status":    model.StockHalted,
+		"haltUntil": bson.M{"$gt": 0, "$lte": now},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return []string{}, err
+	}
+	defer cursor.Close(ctx)
+
+	stockIds := []string{}
+	for cursor.Next(ctx) {
+		var stock StockCollection
+		if err := cursor.Decode(&stock); err != nil {
+			return []string{}, err
+		}
+
+		stockIds = append(stockIds, stock.ID.Hex())
+	}
+
+	if err := cursor.Err(); err != nil {
+		return []string{}, err
+	}
+
+	return stockIds, nil
+}
+
+// the opening auction sets the open of the day, the closing auction the
+// close taken as the previous close on the next day
+func (r stockRepositoryDB) SetAuctionPrice(stockId string, session string, price float64, date string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if price <= 0 {
+		return "", ErrPrice
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	var set bson.M
+	switch session {
+	case model.SessionPreOpen:
+		set = bson.M{"openPrice": price, "openDate": date}
+	case model.SessionClosing:
+		set = bson.M{"officialClose": price}
+	default:
+		return "", errs.ErrMarketSession
+	}
+
+	filter := bson.M{
+		"_id": objectStockId,
+	}
+	update := bson.M{
+		"$set": set,
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrInvalidStock
+	}
+
+	return "Successfully set auction price", nil
+}
+
+func (r stockRepositoryDB) GetImage(stockId string) (string, error) {
 	if len(stockId) == 0 {
 		return "", ErrInvalidStock
 	}
@@ -540,11 +977,71 @@ func (r stockRepositoryDB) DeleteStock(stockId string) (string, error) {
 	filter := bson.M{
 		"_id": objectStockId,
 	}
+	projection := bson.M{
+		"stockImage": 1,
+	}
+
+	var stockImage StockImage
+	opts := options.FindOne().SetProjection(projection)
+	err = r.db.FindOne(ctx, filter, opts).Decode(&stockImage)
+	if err == mongo.ErrNoDocuments {
+		return "", ErrInvalidStock
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return stockImage.StockImage, nil
+}
+
+// the image is only swapped while it is still from, so the image replaced by
+// a concurrent request is never lost track of
+func (r stockRepositoryDB) SetImage(stockId string, from string, to string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if len(to) == 0 {
+		return "", errs.ErrImageType
+	}
+
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	filter := bson.M{
+		"_id":        objectStockId,
+		"stockImage": from,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"stockImage": to,
+		},
+	}
 
-	_, err = r.db.DeleteOne(ctx, filter)
+	result, err := r.db.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return "", err
 	}
 
-	return "Successfully deleted stock", nil
+	if result.MatchedCount == 0 {
+		return "", errs.ErrImageChanged
+	}
+
+	return "Successfully updated stock image", nil
+}
+
+// images are stored by content so stocks with the same image share it
+func (r stockRepositoryDB) IsImageUsed(stockImage string) (bool, error) {
+	filter := bson.M{
+		"stockImage": stockImage,
+	}
+
+	count, err := r.db.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
 }
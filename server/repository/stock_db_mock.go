@@ -55,11 +55,36 @@ func (m *stockRepositoryDBMock) GetGraph(stockId string) ([]StockGraph, error) {
 	return arge.Get(0).([]StockGraph), arge.Error(1)
 }
 
+func (m *stockRepositoryDBMock) GetTradingRule(stockId string) (TradingRule, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).(TradingRule), arge.Error(1)
+}
+
+func (m *stockRepositoryDBMock) GetCurrency(stockId string) (string, error) {
+	arge := m.Called(stockId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *stockRepositoryDBMock) GetStatus(stockId string) (string, error) {
+	arge := m.Called(stockId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *stockRepositoryDBMock) GetPreviousClose(stockId string, date string) (float64, error) {
+	arge := m.Called(stockId, date)
+	return arge.Get(0).(float64), arge.Error(1)
+}
+
 func (m *stockRepositoryDBMock) SetPrice(stockId string, price float64) (string, error) {
 	arge := m.Called(stockId, price)
 	return arge.String(0), arge.Error(1)
 }
 
+func (m *stockRepositoryDBMock) SetTradingRule(stockId string, tradingRule TradingRule) (string, error) {
+	arge := m.Called(stockId, tradingRule)
+	return arge.String(0), arge.Error(1)
+}
+
 func (m *stockRepositoryDBMock) EditName(stockId string, name string) (string, error) {
 	arge := m.Called(stockId, name)
 	return arge.String(0), arge.Error(1)
@@ -70,7 +95,37 @@ func (m *stockRepositoryDBMock) EditSign(stockId string, sign string) (string, e
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *stockRepositoryDBMock) DeleteStock(stockId string) (string, error) {
+func (m *stockRepositoryDBMock) ApplySplit(stockId string, actionId string, ratio float64) (string, error) {
+	arge := m.Called(stockId, actionId, ratio)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *stockRepositoryDBMock) SetStatus(stockId string, from string, event StockStatusEvent) (string, error) {
+	arge := m.Called(stockId, from, event)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *stockRepositoryDBMock) GetExpiredHalts(now int64) ([]string, error) {
+	arge := m.Called(now)
+	return arge.Get(0).([]string), arge.Error(1)
+}
+
+func (m *stockRepositoryDBMock) SetAuctionPrice(stockId string, session string, price float64, date string) (string, error) {
+	arge := m.Called(stockId, session, price, date)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *stockRepositoryDBMock) GetImage(stockId string) (string, error) {
 	arge := m.Called(stockId)
 	return arge.String(0), arge.Error(1)
 }
+
+func (m *stockRepositoryDBMock) SetImage(stockId string, from string, to string) (string, error) {
+	arge := m.Called(stockId, from, to)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *stockRepositoryDBMock) IsImageUsed(stockImage string) (bool, error) {
+	arge := m.Called(stockImage)
+	return arge.Bool(0), arge.Error(1)
+}
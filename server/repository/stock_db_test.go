@@ -2,14 +2,17 @@ package repository_test
 
 import (
 	"server/errs"
+	"server/model"
 	"server/repository"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type StockCollection = repository.StockCollection
 type StockHistory = repository.StockHistory
+type StockStatusEvent = repository.StockStatusEvent
 
 func InitStockRepo() repository.StockRepository {
 	client, _ := repository.InitMongoDB("mongodb://localhost:27017/trading-system")
@@ -222,6 +225,104 @@ func TestSetPrice(t *testing.T) {
 	})
 }
 
+func TestStockApplySplit(t *testing.T) {
+	t.Run("Error invalid stock", func(t *testing.T) {
+		_, err := stockRepo.ApplySplit("", primitive.NewObjectID().Hex(), 2)
+
+		assert.ErrorIs(t, err, ErrInvalidStock)
+	})
+
+	t.Run("Apply split", func(t *testing.T) {
+		before, _ := stockRepo.GetPrice("65c99e67b244d2f0231ed667")
+
+		actual, err := stockRepo.ApplySplit("65c99e67b244d2f0231ed667", primitive.NewObjectID().Hex(), 2)
+		expected := "Successfully applied split"
+
+		assert.Empty(t, err)
+		assert.Equal(t, expected, actual)
+
+		after, _ := stockRepo.GetPrice("65c99e67b244d2f0231ed667")
+		assert.Equal(t, before/2, after)
+	})
+}
+
+func TestGetTradingRule(t *testing.T) {
+	t.Run("Error invalid stock", func(t *testing.T) {
+		_, err := stockRepo.GetTradingRule("")
+
+		assert.ErrorIs(t, err, ErrInvalidStock)
+	})
+
+	t.Run("Error convert userId to objectId", func(t *testing.T) {
+		_, err := stockRepo.GetTradingRule("test")
+
+		assert.Equal(t, err.Error(), "the provided hex string is not a valid ObjectID")
+	})
+
+	t.Run("Get trading rule", func(t *testing.T) {
+		_, err := stockRepo.GetTradingRule("65c99e67b244d2f0231ed667")
+
+		assert.Empty(t, err)
+	})
+}
+
+func TestGetCurrency(t *testing.T) {
+	t.Run("Error invalid stock", func(t *testing.T) {
+		_, err := stockRepo.GetCurrency("")
+
+		assert.ErrorIs(t, err, ErrInvalidStock)
+	})
+
+	t.Run("Error convert userId to objectId", func(t *testing.T) {
+		_, err := stockRepo.GetCurrency("test")
+
+		assert.Equal(t, err.Error(), "the provided hex string is not a valid ObjectID")
+	})
+
+	t.Run("Get currency", func(t *testing.T) {
+		actual, err := stockRepo.GetCurrency("65c99e67b244d2f0231ed667")
+
+		assert.Empty(t, err)
+		assert.NotEmpty(t, actual)
+	})
+}
+
+func TestSetTradingRule(t *testing.T) {
+	t.Run("Error invalid stock", func(t *testing.T) {
+		_, err := stockRepo.SetTradingRule("", repository.TradingRule{})
+
+		assert.ErrorIs(t, err, ErrInvalidStock)
+	})
+
+	t.Run("Error invalid trading rule", func(t *testing.T) {
+		tradingRule := repository.TradingRule{
+			MinQuantity: 10,
+			MaxQuantity: 1,
+		}
+		_, err := stockRepo.SetTradingRule("test", tradingRule)
+
+		assert.ErrorIs(t, err, errs.ErrTradingRule)
+	})
+
+	t.Run("Error convert userId to objectId", func(t *testing.T) {
+		_, err := stockRepo.SetTradingRule("test", repository.TradingRule{})
+
+		assert.Equal(t, err.Error(), "the provided hex string is not a valid ObjectID")
+	})
+
+	t.Run("Set trading rule", func(t *testing.T) {
+		tradingRule := repository.TradingRule{
+			TickSize:    0.01,
+			LotSize:     1,
+			MinQuantity: 1,
+		}
+		actual, _ := stockRepo.SetTradingRule("65c99e67b244d2f0231ed667", tradingRule)
+		expected := "Successfully set trading rule"
+
+		assert.Equal(t, expected, actual)
+	})
+}
+
 func TestEditName(t *testing.T) {
 // 65c99e67b244d2f0231ed667
 	t.Run("Error invalid stock", func(t *testing.T) {
@@ -277,23 +378,127 @@ func TestEditSign(t *testing.T) {
 	})
 }
 
-func TestDeleteStock(t *testing.T) {
+func TestSetStatus(t *testing.T) {
+	haltedStockId := "65c99e6c02a43e12a634f777"
+
 	t.Run("Error invalid stock", func(t *testing.T) {
-		_, err := stockRepo.DeleteStock("")
+		_, err := stockRepo.SetStatus("", model.StockActive, StockStatusEvent{Status: model.StockHalted})
 
 		assert.ErrorIs(t, err, ErrInvalidStock)
 	})
 
-	t.Run("Error convert userId to objectId", func(t *testing.T) {
-		_, err := stockRepo.DeleteStock("test")
+	t.Run("Error invalid transition", func(t *testing.T) {
+		_, err := stockRepo.SetStatus(haltedStockId, model.StockDelisted, StockStatusEvent{Status: model.StockActive})
 
-		assert.Equal(t, err.Error(), "the provided hex string is not a valid ObjectID")
+		assert.ErrorIs(t, err, errs.ErrStockStatus)
 	})
 
-	t.Run("Delete stock", func(t *testing.T) {
-		actual, _ := stockRepo.DeleteStock("65c99e6c02a43e12a634f777")
-		expected := "Successfully deleted stock"
+	t.Run("Halt and resume stock", func(t *testing.T) {
+		actual, err := stockRepo.SetStatus(haltedStockId, model.StockActive, StockStatusEvent{Status: model.StockHalted, Actor: "admin"})
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully set stock status", actual)
 
-		assert.Equal(t, expected, actual)
+		_, err = stockRepo.SetStatus(haltedStockId, model.StockActive, StockStatusEvent{Status: model.StockHalted, Actor: "admin"})
+		assert.ErrorIs(t, err, errs.ErrStockStatus)
+
+		status, _ := stockRepo.GetStatus(haltedStockId)
+		assert.Equal(t, model.StockHalted, status)
+
+		_, err = stockRepo.SetStatus(haltedStockId, model.StockHalted, StockStatusEvent{Status: model.StockActive, Actor: "admin"})
+		assert.Empty(t, err)
 	})
-}
\ No newline at end of file
+}
+func TestGetPreviousClose(t *testing.T) {
+	t.Run("Error invalid stock", func(t *testing.T) {
+		_, err := stockRepo.GetPreviousClose("", "2024-02-12")
+
+		assert.ErrorIs(t, err, ErrInvalidStock)
+	})
+
+	t.Run("Keep previous close for the day", func(t *testing.T) {
+		stockId := "65c99e6c02a43e12a634f777"
+		price, _ := stockRepo.GetPrice(stockId)
+
+		actual, err := stockRepo.GetPreviousClose(stockId, "2024-02-12")
+		assert.Empty(t, err)
+		assert.Equal(t, price, actual)
+
+		stockRepo.SetPrice(stockId, price+1)
+		actual, err = stockRepo.GetPreviousClose(stockId, "2024-02-12")
+		assert.Empty(t, err)
+		assert.Equal(t, price, actual)
+
+		stockRepo.SetPrice(stockId, price)
+	})
+}
+
+func TestSetAuctionPrice(t *testing.T) {
+	stockId := "65c99e6c02a43e12a634f777"
+
+	t.Run("Error market session", func(t *testing.T) {
+		_, err := stockRepo.SetAuctionPrice(stockId, model.SessionContinuous, 10, "2024-02-12")
+
+		assert.ErrorIs(t, err, errs.ErrMarketSession)
+	})
+
+	t.Run("Set official open and close", func(t *testing.T) {
+		price, _ := stockRepo.GetPrice(stockId)
+
+		actual, err := stockRepo.SetAuctionPrice(stockId, model.SessionPreOpen, price, "2024-02-12")
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully set auction price", actual)
+
+		_, err = stockRepo.SetAuctionPrice(stockId, model.SessionClosing, price+1, "2024-02-12")
+		assert.Empty(t, err)
+
+		previousClose, err := stockRepo.GetPreviousClose(stockId, "2024-02-13")
+		assert.Empty(t, err)
+		assert.Equal(t, price+1, previousClose)
+	})
+}
+
+func TestGetExpiredHalts(t *testing.T) {
+	haltedStockId := "65c99e6c02a43e12a634f777"
+
+	_, err := stockRepo.SetStatus(haltedStockId, model.StockActive, StockStatusEvent{
+		Status:    model.StockHalted,
+		Actor:     model.StockSystemActor,
+		Timestamp: 100,
+		Until:     160,
+	})
+	assert.Empty(t, err)
+
+	actual, err := stockRepo.GetExpiredHalts(159)
+	assert.Empty(t, err)
+	assert.NotContains(t, actual, haltedStockId)
+
+	actual, err = stockRepo.GetExpiredHalts(160)
+	assert.Empty(t, err)
+	assert.Contains(t, actual, haltedStockId)
+
+	stockRepo.SetStatus(haltedStockId, model.StockHalted, StockStatusEvent{Status: model.StockActive})
+}
+
+func TestSetImage(t *testing.T) {
+	stockId := "65c99e6c02a43e12a634f777"
+	oldImage, err := stockRepo.GetImage(stockId)
+	assert.Empty(t, err)
+
+	t.Run("Error image changed", func(t *testing.T) {
+		_, err := stockRepo.SetImage(stockId, oldImage+"-changed", "stock/new")
+
+		assert.ErrorIs(t, err, errs.ErrImageChanged)
+	})
+
+	t.Run("Swap image", func(t *testing.T) {
+		actual, err := stockRepo.SetImage(stockId, oldImage, "stock/new")
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully updated stock image", actual)
+
+		used, err := stockRepo.IsImageUsed("stock/new")
+		assert.Empty(t, err)
+		assert.True(t, used)
+
+		stockRepo.SetImage(stockId, "stock/new", oldImage)
+	})
+}
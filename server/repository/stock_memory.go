@@ -0,0 +1,728 @@
+package repository
+
+import (
+	"server/errs"
+	"server/model"
+	"server/util"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// stocks kept in the process with the validation and errors of the mongo
+// repository, for tests and running without a database. stocks are returned
+// in the order they were created like the natural order of the collection
+type stockRepositoryMemory struct {
+	mu     sync.RWMutex
+	stocks map[primitive.ObjectID]*StockCollection
+	order  []primitive.ObjectID
+}
+
+func NewStockRepositoryMemory() StockRepository {
+	return &stockRepositoryMemory{
+		stocks: map[primitive.ObjectID]*StockCollection{},
+	}
+}
+
+func (r *stockRepositoryMemory) CreateStock(stockCollection StockCollection) (string, error) {
+	if len(stockCollection.StockImage) == 0 ||
+		len(stockCollection.Sign) == 0 ||
+		len(stockCollection.Name) == 0 ||
+		stockCollection.Price < 1 {
+		return "", ErrData
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stockCollection.ID.IsZero() {
+		stockCollection.ID = primitive.NewObjectID()
+	}
+
+	if _, ok := r.stocks[stockCollection.ID]; ok {
+		return "", duplicateKeyError()
+	}
+
+	stock := cloneStock(stockCollection)
+	r.stocks[stock.ID] = &stock
+	r.order = append(r.order, stock.ID)
+
+	return "Successfully created stock collection", nil
+}
+
+func (r *stockRepositoryMemory) CreateStockOrder(stockId string, stockOrder StockHistory) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if len(stockOrder.ID) == 0 ||
+		stockOrder.Amount == 0 ||
+		stockOrder.Price == 0 {
+		return "", ErrData
+	}
+
+	stockOrder.Timestamp = time.Now().Unix()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if stock == nil {
+		return "", mongo.ErrNoDocuments
+	}
+
+	stock.History = append(stock.History, stockOrder)
+	stock.Price = stockOrder.Price
+
+	return "Successfully created stock order", nil
+}
+
+func (r *stockRepositoryMemory) GetAllStocks() ([]StockCollectionResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stockCollections = []StockCollectionResponse{}
+	for _, stock := range r.listed() {
+		stockCollections = append(stockCollections, StockCollectionResponse{
+			ID:         stock.ID.Hex(),
+			StockImage: stock.StockImage,
+			Name:       stock.Name,
+			Sign:       stock.Sign,
+			Price:      stock.Price,
+			Status:     util.StockStatus(stock.Status),
+		})
+	}
+
+	return stockCollections, nil
+}
+
+func (r *stockRepositoryMemory) GetTopStocks() ([]StockGroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var stocks []StockGroup
+	for _, stock := range r.listed() {
+		if len(stock.History) == 0 {
+			continue
+		}
+
+		var volume float64 = 0
+		for _, history := range latestTrades(stock.History, 10) {
+			volume += history.Amount * history.Price
+		}
+
+		stocks = append(stocks, StockGroup{
+			ID:         stock.ID.Hex(),
+			Name:       stock.Name,
+			Price:      stock.Price,
+			Sign:       stock.Sign,
+			StockImage: stock.StockImage,
+			Volume:     volume,
+		})
+	}
+
+	sort.SliceStable(stocks, func(i, j int) bool {
+		return stocks[i].Volume > stocks[j].Volume
+	})
+
+	amountOfStock := len(stocks)
+	if amountOfStock > 10 {
+		amountOfStock = 10
+	}
+
+	return stocks[0:amountOfStock], nil
+}
+
+func (r *stockRepositoryMemory) GetStock(stockId string) (StockCollectionResponse, error) {
+	if len(stockId) == 0 {
+		return StockCollectionResponse{}, ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return StockCollectionResponse{}, err
+	}
+
+	if stock == nil {
+		return StockCollectionResponse{}, nil
+	}
+
+	stockCollection := StockCollectionResponse{
+		ID:            stockId,
+		StockImage:    stock.StockImage,
+		Name:          stock.Name,
+		Sign:          stock.Sign,
+		Price:         stock.Price,
+		Currency:      stock.Currency,
+		Status:        util.StockStatus(stock.Status),
+		Open:          stock.OpenPrice,
+		OpenDate:      stock.OpenDate,
+		PreviousClose: stock.PreviousClose,
+	}
+
+	if stockCollection.OpenDate != time.Now().Format(time.DateOnly) {
+		stockCollection.Open = 0
+	}
+
+	if stockCollection.PreviousClose > 0 {
+		stockCollection.Change = stockCollection.Price - stockCollection.PreviousClose
+	}
+
+	return stockCollection, nil
+}
+
+func (r *stockRepositoryMemory) GetFavoriteStock(favoriteStockIds []string) ([]StockCollectionResponse, error) {
+	objectFavoriteStocks := map[primitive.ObjectID]bool{}
+	for _, stock := range favoriteStockIds {
+		if len(stock) == 0 {
+			return []StockCollectionResponse{}, ErrInvalidStock
+		}
+
+		objectStockId, err := primitive.ObjectIDFromHex(stock)
+		if err == primitive.ErrInvalidHex {
+			return []StockCollectionResponse{}, ErrInvalidStock
+		}
+		objectFavoriteStocks[objectStockId] = true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var favoriteStocks []StockCollectionResponse
+	for _, id := range r.order {
+		if !objectFavoriteStocks[id] {
+			continue
+		}
+
+		stock := r.stocks[id]
+		favoriteStocks = append(favoriteStocks, StockCollectionResponse{
+			ID:         stock.ID.Hex(),
+			Name:       stock.Name,
+			Sign:       stock.Sign,
+			StockImage: stock.StockImage,
+			Price:      stock.Price,
+			Status:     util.StockStatus(stock.Status),
+		})
+	}
+
+	return favoriteStocks, nil
+}
+
+func (r *stockRepositoryMemory) GetStockHistory(stockId string) ([]StockHistoryResponse, error) {
+	if len(stockId) == 0 {
+		return []StockHistoryResponse{}, ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return []StockHistoryResponse{}, err
+	}
+
+	if stock == nil {
+		return nil, nil
+	}
+
+	var stockHistories []StockHistoryResponse
+	for _, history := range latestTrades(stock.History, 2) {
+		stockHistories = append(stockHistories, StockHistoryResponse{
+			Price:  history.Price,
+			Amount: history.Amount,
+		})
+	}
+
+	return stockHistories, nil
+}
+
+func (r *stockRepositoryMemory) GetPrice(stockId string) (float64, error) {
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return 0, err
+	}
+
+	if stock == nil {
+		return 0, mongo.ErrNoDocuments
+	}
+
+	return stock.Price, nil
+}
+
+func (r *stockRepositoryMemory) GetGraph(stockId string) ([]StockGraph, error) {
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return []StockGraph{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, ok := r.stocks[objectStockId]
+	if !ok {
+		return nil, nil
+	}
+
+	var groups []StockGraph
+	for _, history := range latestTrades(stock.History, len(stock.History)) {
+		if history.Timestamp <= 1709004689 {
+			continue
+		}
+
+		groups = append(groups, StockGraph{
+			Price:     history.Price,
+			Timestamp: history.Timestamp,
+		})
+	}
+
+	return groups, nil
+}
+
+func (r *stockRepositoryMemory) GetTradingRule(stockId string) (TradingRule, error) {
+	if len(stockId) == 0 {
+		return TradingRule{}, ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, err := r.findExisting(stockId)
+	if err != nil {
+		return TradingRule{}, err
+	}
+
+	return stock.TradingRule, nil
+}
+
+// stocks created before quote currencies are quoted in base currency
+func (r *stockRepositoryMemory) GetCurrency(stockId string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, err := r.findExisting(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if len(stock.Currency) == 0 {
+		return model.BaseCurrency, nil
+	}
+
+	return stock.Currency, nil
+}
+
+func (r *stockRepositoryMemory) GetStatus(stockId string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, err := r.findExisting(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	return util.StockStatus(stock.Status), nil
+}
+
+// the first call of the day takes the official close of the closing auction
+// or else the price as the previous close, so the band stays the same until
+// the next day
+func (r *stockRepositoryMemory) GetPreviousClose(stockId string, date string) (float64, error) {
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.findExisting(stockId)
+	if err != nil {
+		return 0, err
+	}
+
+	if stock.CloseDate != date {
+		stock.PreviousClose = stock.Price
+		if stock.OfficialClose != 0 {
+			stock.PreviousClose = stock.OfficialClose
+		}
+		stock.CloseDate = date
+		stock.OfficialClose = 0
+	}
+
+	return stock.PreviousClose, nil
+}
+
+func (r *stockRepositoryMemory) SetPrice(stockId string, price float64) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if price < 1 {
+		return "", ErrPrice
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if stock != nil {
+		stock.Price = price
+	}
+
+	return "Successfully set price", nil
+}
+
+func (r *stockRepositoryMemory) SetTradingRule(stockId string, tradingRule TradingRule) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if !util.ValidTradingRule(tradingRule) {
+		return "", ErrTradingRule
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.findExisting(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	stock.TradingRule = tradingRule
+
+	return "Successfully set trading rule", nil
+}
+
+func (r *stockRepositoryMemory) EditName(stockId string, name string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if len(name) == 0 {
+		return "", errs.ErrName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if stock != nil {
+		stock.Name = name
+	}
+
+	return "Successfully updated name", nil
+}
+
+func (r *stockRepositoryMemory) EditSign(stockId string, sign string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if len(sign) == 0 {
+		return "", ErrSign
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if stock != nil {
+		stock.Sign = sign
+	}
+
+	return "Successfully updated sign", nil
+}
+
+// the price and every trade are adjusted so the graph stays continuous
+func (r *stockRepositoryMemory) ApplySplit(stockId string, actionId string, ratio float64) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if len(actionId) == 0 {
+		return "", errs.ErrCorporateAction
+	}
+
+	if ratio <= 0 {
+		return "", errs.ErrSplitRatio
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.findExisting(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if slices.Contains(stock.Splits, actionId) {
+		return "Successfully applied split", nil
+	}
+
+	stock.Splits = append(stock.Splits, actionId)
+	stock.Price /= ratio
+	stock.PreviousClose /= ratio
+	stock.OpenPrice /= ratio
+	stock.OfficialClose /= ratio
+	for i := range stock.History {
+		stock.History[i].Price /= ratio
+		stock.History[i].Amount *= ratio
+	}
+
+	return "Successfully applied split", nil
+}
+
+// the status only moves along the allowed transitions, a concurrent update
+// of the same stock matches nothing and fails with ErrStockStatus
+func (r *stockRepositoryMemory) SetStatus(stockId string, from string, event StockStatusEvent) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if !util.CanTransitStock(from, event.Status) {
+		return "", ErrStockStatus
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+
+	matched := stock != nil && stock.Status == from
+	if stock != nil && util.StockStatus(from) == model.StockActive {
+		matched = util.StockStatus(stock.Status) == model.StockActive
+	}
+
+	if !matched {
+		return "", ErrStockStatus
+	}
+
+	stock.Status = event.Status
+	stock.HaltUntil = event.Until
+	stock.StatusHistory = append(stock.StatusHistory, event)
+
+	return "Successfully set stock status", nil
+}
+
+// halts of the circuit breaker that are due to be lifted
+func (r *stockRepositoryMemory) GetExpiredHalts(now int64) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stockIds := []string{}
+	for _, id := range r.order {
+		stock := r.stocks[id]
+		if stock.Status == model.StockHalted && stock.HaltUntil > 0 && stock.HaltUntil <= now {
+			stockIds = append(stockIds, id.Hex())
+		}
+	}
+
+	return stockIds, nil
+}
+
+// the opening auction sets the open of the day, the closing auction the
+// close taken as the previous close on the next day
+func (r *stockRepositoryMemory) SetAuctionPrice(stockId string, session string, price float64, date string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if price <= 0 {
+		return "", ErrPrice
+	}
+
+	if _, err := primitive.ObjectIDFromHex(stockId); err != nil {
+		return "", err
+	}
+
+	if session != model.SessionPreOpen && session != model.SessionClosing {
+		return "", errs.ErrMarketSession
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.findExisting(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if session == model.SessionPreOpen {
+		stock.OpenPrice = price
+		stock.OpenDate = date
+	} else {
+		stock.OfficialClose = price
+	}
+
+	return "Successfully set auction price", nil
+}
+
+func (r *stockRepositoryMemory) GetImage(stockId string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stock, err := r.findExisting(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	return stock.StockImage, nil
+}
+
+// the image is only swapped while it is still from, so the image replaced by
+// a concurrent request is never lost track of
+func (r *stockRepositoryMemory) SetImage(stockId string, from string, to string) (string, error) {
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	if len(to) == 0 {
+		return "", errs.ErrImageType
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stock, err := r.find(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if stock == nil || stock.StockImage != from {
+		return "", errs.ErrImageChanged
+	}
+
+	stock.StockImage = to
+
+	return "Successfully updated stock image", nil
+}
+
+// images are stored by content so stocks with the same image share it
+func (r *stockRepositoryMemory) IsImageUsed(stockImage string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stock := range r.stocks {
+		if stock.StockImage == stockImage {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// the stock is nil when no stock has the id, an id that is not an object id
+// fails like it does in mongo
+func (r *stockRepositoryMemory) find(stockId string) (*StockCollection, error) {
+	objectStockId, err := primitive.ObjectIDFromHex(stockId)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.stocks[objectStockId], nil
+}
+
+func (r *stockRepositoryMemory) findExisting(stockId string) (*StockCollection, error) {
+	stock, err := r.find(stockId)
+	if err != nil {
+		return nil, err
+	}
+
+	if stock == nil {
+		return nil, ErrInvalidStock
+	}
+
+	return stock, nil
+}
+
+// delisted stocks are kept for history but left out of the listings
+func (r *stockRepositoryMemory) listed() []*StockCollection {
+	var stocks []*StockCollection
+	for _, id := range r.order {
+		if stock := r.stocks[id]; stock.Status != model.StockDelisted {
+			stocks = append(stocks, stock)
+		}
+	}
+
+	return stocks
+}
+
+// the limit latest trades, newest first
+func latestTrades(histories []StockHistory, limit int) []StockHistory {
+	trades := make([]StockHistory, len(histories))
+	copy(trades, histories)
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp > trades[j].Timestamp
+	})
+
+	if len(trades) > limit {
+		trades = trades[:limit]
+	}
+
+	return trades
+}
+
+func cloneStock(stock StockCollection) StockCollection {
+	stock.StatusHistory = append([]StockStatusEvent(nil), stock.StatusHistory...)
+	stock.History = append([]StockHistory(nil), stock.History...)
+	stock.Splits = append([]string(nil), stock.Splits...)
+
+	return stock
+}
+
+// the error mongo returns for a document with an id that is already taken
+func duplicateKeyError() error {
+	return mongo.WriteException{
+		WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}},
+	}
+}
@@ -8,16 +8,24 @@ type UserHistory = model.UserHistory
 type UserStock = model.UserStock
 type OrderRequest = model.OrderRequest
 type BalanceHistory = model.BalanceHistory
+type FeeSummary = model.FeeSummary
+type MarginCall = model.MarginCall
+type DividendHolder = model.DividendHolder
+type EditProfileRequest = model.EditProfileRequest
 
 type UserRepository interface {
 	Create(CreateAccount) (string, error)
-	Deposit(string, float64) (string, error)
-	Withdraw(string, float64) (string, error)
+	Deposit(string, string, float64) (string, error)
+	Withdraw(string, string, float64) (string, error)
+	Charge(string, string, float64) (string, error)
+	Credit(string, string, string, float64) (string, error)
 	Buy(OrderRequest) (string, error)
 	Sale(OrderRequest) (string, error)
 	SetFavorite(string, string) (string, error)
 	GetBalanceHistory(string, string, uint) ([]BalanceHistory, error)
 	GetBalance(string) (float64, error)
+	GetCurrencyBalances(string) (map[string]float64, error)
+	GetBalances() (map[string]map[string]float64, error)
 	// GetStockBalance(string)
 	// GetStockValueRatio(string)
 	// GetStockAmountRatio(string) ()
@@ -26,6 +34,20 @@ type UserRepository interface {
 	GetAllHistories(string, uint) ([]UserHistory, error)
 	GetUserStockHistory(string, string, uint) ([]UserHistory, error)
 	GetStockAmount(string, string) (UserStock, error) 
+	GetTradingVolume(string, int64) (float64, error)
+	GetFeeSummary(string) (FeeSummary, error)
+	SetMarginEnabled(string, bool) (string, error)
+	SetMarginCall(string, *MarginCall) (string, error)
+	GetMarginAccounts() ([]string, error)
+	ApplySplit(string, string, float64) (int64, error)
+	SetDividendReinvest(string, bool) (string, error)
+	GetStockHolders(string) ([]DividendHolder, error)
+	GetStockPositions(string) (map[string]float64, error)
+	SettlePosition(string, string, float64, float64, string) (float64, error)
+	SettleFraction(string, string, float64, float64, string) (float64, error)
+	EditProfile(string, EditProfileRequest) (string, error)
+	SetAvatar(string, string, string, string) (string, error)
+	IsAvatarUsed(string) (bool, error)
 	DeleteFavorite(string, string) (string, error)
 	DeleteAccount(string) (string, error)
 }
\ No newline at end of file
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"math"
 	"server/errs"
+	"server/model"
 	"server/util"
 	"time"
 
@@ -31,17 +33,14 @@ type Stock struct {
 }
 
 type UserBalance struct {
-	Balance float64 `bson:"balance"`
+	Balance  float64            `bson:"balance"`
+	Balances map[string]float64 `bson:"balances"`
 }
 
 type UserFavorite struct {
 	Favorite []string `bson:"favorite"`
 }
 
-type ValidStock struct {
-	Stock []string `bson:"favorite"`
-}
-
 type FavoriteStock struct {
 	Favorite []string `bson:"favorite"`
 }
@@ -57,6 +56,7 @@ var (
 	ErrInvalidStock   = errs.ErrInvalidStock
 	ErrFavoriteStock  = errs.ErrFavoriteStock
 	ErrNotEnoughStock = errs.ErrNotEnoughStock
+	ErrCurrency       = errs.ErrCurrency
 )
 
 func NewUserRepositoryDB(db *mongo.Collection) UserRepository {
@@ -90,6 +90,7 @@ func (r userRepositoryDB) Create(data CreateAccount) (string, error) {
 		ProfileImage:   profileImage,
 		Email:          email,
 		Balance:        0,
+		Balances:       map[string]float64{},
 		BalanceHistory: []BalanceHistory{},
 		Favorite:       []string{},
 		History:        []UserHistory{},
@@ -132,32 +133,66 @@ func (r userRepositoryDB) Buy(orderRequest OrderRequest) (string, error) {
 		return "", ErrOrderMethod
 	}
 
+	currency := util.NormalizeCurrency(orderRequest.Currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
+	fxRate := orderRequest.FxRate
+	if fxRate == 0 {
+		fxRate = 1
+	}
+
 	// objectUserId, err := primitive.ObjectIDFromHex(userId)
 	// if err != nil {
 	// 	return "", err
 	// }
 
 	userHistory := UserHistory{
+		TradeId:     orderRequest.TradeId,
 		StockId:     stockId,
 		Price:       price,
 		Amount:      amount,
-		Status:      "pending",
+		Fee:         orderRequest.Fee,
+		Currency:    currency,
+		FxRate:      fxRate,
+		Status:      "success",
 		Timestamp:   int64(time.Now().Unix()),
 		OrderType:   orderRequest.OrderType,
 		OrderMethod: orderRequest.OrderMethod,
 	}
 
-	validStock, _, balance, err := util.CheckValidStock(r.db, userId, stockId)
+	validStock, userStock, balance, err := util.CheckValidStock(r.db, userId, stockId, currency)
 	if err != nil {
 		return "", err
 	}
 
-	stockValue := price * amount
-	if stockValue > balance {
+	stockValue := price*amount*fxRate + orderRequest.Fee
+	if !orderRequest.Margin && stockValue > balance {
 		return "", ErrBalance
 	}
 
-	if validStock {
+	if validStock && userStock.Amount+amount == 0 {
+		filter := bson.M{
+			"uid": userId,
+		}
+		update := bson.M{
+			"$push": bson.M{
+				"userHistory": userHistory,
+			},
+			"$pull": bson.M{
+				"userStock": bson.M{"stockId": stockId},
+			},
+			"$inc": bson.M{
+				util.BalanceField(currency): -stockValue,
+			},
+		}
+
+		_, err := r.db.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return "", err
+		}
+	} else if validStock {
 		filter := bson.M{
 			"uid": bson.M{
 				"$eq": userId,
@@ -171,8 +206,16 @@ func (r userRepositoryDB) Buy(orderRequest OrderRequest) (string, error) {
 				"userHistory": userHistory,
 			},
 			"$inc": bson.M{
-				"userStock.$.amount": amount,
-				"balance":            -stockValue,
+				"userStock.$.amount":        amount,
+				util.BalanceField(currency): -stockValue,
+			},
+			"$set": bson.M{
+				"userStock.$.averagePrice": util.AveragePrice(
+					userStock.Amount,
+					userStock.AveragePrice,
+					amount,
+					price,
+				),
 			},
 		}
 
@@ -182,8 +225,9 @@ func (r userRepositoryDB) Buy(orderRequest OrderRequest) (string, error) {
 		}
 	} else {
 		userStock := UserStock{
-			StockId: stockId,
-			Amount:  amount,
+			StockId:      stockId,
+			Amount:       amount,
+			AveragePrice: price,
 		}
 
 		update := bson.M{
@@ -192,7 +236,7 @@ func (r userRepositoryDB) Buy(orderRequest OrderRequest) (string, error) {
 				"userStock":   userStock,
 			},
 			"$inc": bson.M{
-				"balance": -stockValue,
+				util.BalanceField(currency): -stockValue,
 			},
 		}
 
@@ -239,32 +283,70 @@ func (r userRepositoryDB) Sale(orderRequest OrderRequest) (string, error) {
 		return "", ErrOrderMethod
 	}
 
+	currency := util.NormalizeCurrency(orderRequest.Currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
+	fxRate := orderRequest.FxRate
+	if fxRate == 0 {
+		fxRate = 1
+	}
+
 	// objectUserId, err := primitive.ObjectIDFromHex(userId)
 	// if err != nil {
 	// 	return "", err
 	// }
 
 	userHistory := UserHistory{
+		TradeId:     orderRequest.TradeId,
 		StockId:     stockId,
 		Price:       price,
 		Amount:      amount,
-		Status:      "pending",
+		Fee:         orderRequest.Fee,
+		Currency:    currency,
+		FxRate:      fxRate,
+		Status:      "success",
 		Timestamp:   int64(time.Now().Unix()),
 		OrderType:   orderRequest.OrderType,
 		OrderMethod: orderRequest.OrderMethod,
 	}
 
-	validStock, userStock, _, err := util.CheckValidStock(r.db, userId, stockId)
+	validStock, userStock, _, err := util.CheckValidStock(r.db, userId, stockId, currency)
 	if err != nil {
 		return "", err
 	}
 
-	if amount > userStock.Amount {
+	if !orderRequest.Short && amount > userStock.Amount {
 		return "", errs.ErrNotEnoughStock
 	}
 
-	stockValue := price * amount
-	if validStock {
+	stockValue := price*amount*fxRate - orderRequest.Fee
+	if !validStock && orderRequest.Short {
+		shortStock := UserStock{
+			StockId:      stockId,
+			Amount:       -amount,
+			AveragePrice: price,
+		}
+
+		filter := bson.M{
+			"uid": userId,
+		}
+		update := bson.M{
+			"$push": bson.M{
+				"userHistory": userHistory,
+				"userStock":   shortStock,
+			},
+			"$inc": bson.M{
+				util.BalanceField(currency): stockValue,
+			},
+		}
+
+		_, err := r.db.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return "", err
+		}
+	} else if validStock {
 		if userStock.Amount == amount {
 			filter := bson.M{
 				"uid": userId,
@@ -277,7 +359,7 @@ func (r userRepositoryDB) Sale(orderRequest OrderRequest) (string, error) {
 					"userStock": bson.M{"stockId": stockId},
 				},
 				"$inc": bson.M{
-					"balance": stockValue,
+					util.BalanceField(currency): stockValue,
 				},
 			}
 
@@ -285,7 +367,7 @@ func (r userRepositoryDB) Sale(orderRequest OrderRequest) (string, error) {
 			if err != nil {
 				return "", err
 			}
-		} else if userStock.Amount > amount {
+		} else if userStock.Amount > amount || orderRequest.Short {
 			filter := bson.M{
 				"uid": bson.M{
 					"$eq": userId,
@@ -299,8 +381,16 @@ func (r userRepositoryDB) Sale(orderRequest OrderRequest) (string, error) {
 					"userHistory": userHistory,
 				},
 				"$inc": bson.M{
-					"userStock.$.amount": -amount,
-					"balance":            stockValue,
+					"userStock.$.amount":        -amount,
+					util.BalanceField(currency): stockValue,
+				},
+				"$set": bson.M{
+					"userStock.$.averagePrice": util.AveragePrice(
+						userStock.Amount,
+						userStock.AveragePrice,
+						-amount,
+						price,
+					),
 				},
 			}
 
@@ -391,7 +481,7 @@ func (r userRepositoryDB) GetBalanceHistory(userId string, method string, skip u
 				"balanceHistory": 1,
 			}}},
 		}
-	} else if method == "DEPOSIT" || method == "WITHDRAW" {
+	} else if method == "DEPOSIT" || method == "WITHDRAW" || method == "BORROW_FEE" || method == "DIVIDEND" {
 		pipeline = mongo.Pipeline{
 			bson.D{{Key: "$match", Value: filter}},
 			bson.D{{Key: "$unwind", Value: "$balanceHistory"}},
@@ -427,9 +517,11 @@ func (r userRepositoryDB) GetBalanceHistory(userId string, method string, skip u
 		}
 
 		balanceHistoryMap := result["balanceHistory"].(bson.M)
+		currency, _ := balanceHistoryMap["currency"].(string)
 		balanceHistory := BalanceHistory{
 			Timestamp: balanceHistoryMap["timestamp"].(int64),
 			Balance:   balanceHistoryMap["balance"].(float64),
+			Currency:  currency,
 			Method:    balanceHistoryMap["method"].(string),
 		}
 
@@ -466,6 +558,73 @@ func (r userRepositoryDB) GetBalance(userId string) (float64, error) {
 	return userBalance.Balance, nil
 }
 
+func (r userRepositoryDB) GetCurrencyBalances(userId string) (map[string]float64, error) {
+	if len(userId) == 0 {
+		return map[string]float64{}, ErrUser
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	projection := bson.M{
+		"balance":  1,
+		"balances": 1,
+	}
+
+	var userBalance UserBalance
+	opts := options.FindOne().SetProjection(projection)
+	err := r.db.FindOne(ctx, filter, opts).Decode(&userBalance)
+	if err != nil {
+		return map[string]float64{}, err
+	}
+
+	balances := map[string]float64{
+		model.BaseCurrency: userBalance.Balance,
+	}
+	for currency, balance := range userBalance.Balances {
+		balances[currency] = balance
+	}
+
+	return balances, nil
+}
+
+// the balance of every currency of every user
+func (r userRepositoryDB) GetBalances() (map[string]map[string]float64, error) {
+	projection := bson.M{
+		"uid":      1,
+		"balance":  1,
+		"balances": 1,
+	}
+
+	opts := options.Find().SetProjection(projection)
+	cursor, err := r.db.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return map[string]map[string]float64{}, err
+	}
+	defer cursor.Close(ctx)
+
+	balances := map[string]map[string]float64{}
+	for cursor.Next(ctx) {
+		var userAccount UserAccount
+		if err := cursor.Decode(&userAccount); err != nil {
+			return map[string]map[string]float64{}, err
+		}
+
+		balances[userAccount.UID] = map[string]float64{
+			model.BaseCurrency: userAccount.Balance,
+		}
+		for currency, balance := range userAccount.Balances {
+			balances[userAccount.UID][currency] = balance
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return map[string]map[string]float64{}, err
+	}
+
+	return balances, nil
+}
+
 func (r userRepositoryDB) GetFavorite(userId string) ([]string, error) {
 	if len(userId) == 0 {
 		return []string{}, ErrUser
@@ -493,7 +652,7 @@ func (r userRepositoryDB) GetFavorite(userId string) ([]string, error) {
 	return userFavorite.Favorite, nil
 }
 
-func (r userRepositoryDB) Deposit(userId string, depositMoney float64) (string, error) {
+func (r userRepositoryDB) Deposit(userId string, currency string, depositMoney float64) (string, error) {
 	if depositMoney <= 0 {
 		return "", ErrMoney
 	}
@@ -502,6 +661,11 @@ func (r userRepositoryDB) Deposit(userId string, depositMoney float64) (string,
 		return "", ErrUser
 	}
 
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
 	// objectUserId, err := primitive.ObjectIDFromHex(userId)
 	// if err != nil {
 	// 	return "", err
@@ -510,6 +674,7 @@ func (r userRepositoryDB) Deposit(userId string, depositMoney float64) (string,
 	balanceHistory := BalanceHistory{
 		Timestamp: int64(time.Now().Unix()),
 		Balance:   depositMoney,
+		Currency:  currency,
 		Method:    "DEPOSIT",
 	}
 
@@ -518,7 +683,7 @@ func (r userRepositoryDB) Deposit(userId string, depositMoney float64) (string,
 	}
 	update := bson.M{
 		"$inc": bson.M{
-			"balance": depositMoney,
+			util.BalanceField(currency): depositMoney,
 		},
 		"$push": bson.M{
 			"balanceHistory": balanceHistory,
@@ -533,7 +698,7 @@ func (r userRepositoryDB) Deposit(userId string, depositMoney float64) (string,
 	return "Successfully deposited money", nil
 }
 
-func (r userRepositoryDB) Withdraw(userId string, withdrawMoney float64) (string, error) {
+func (r userRepositoryDB) Withdraw(userId string, currency string, withdrawMoney float64) (string, error) {
 	if withdrawMoney <= 0 {
 		return "", ErrMoney
 	}
@@ -542,15 +707,20 @@ func (r userRepositoryDB) Withdraw(userId string, withdrawMoney float64) (string
 		return "", ErrUser
 	}
 
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
 	// objectUserId, err := primitive.ObjectIDFromHex(userId)
 	// if err != nil {
-	// 	fmt.Println("test")
 	// 	return "", err
 	// }
 
 	balanceHistory := BalanceHistory{
 		Timestamp: int64(time.Now().Unix()),
 		Balance:   withdrawMoney,
+		Currency:  currency,
 		Method:    "WITHDRAW",
 	}
 
@@ -563,13 +733,13 @@ func (r userRepositoryDB) Withdraw(userId string, withdrawMoney float64) (string
 		return "", err
 	}
 
-	if userAccount.Balance < withdrawMoney {
+	if util.AccountBalance(userAccount, currency) < withdrawMoney {
 		return "", ErrBalance
 	}
 
 	update := bson.M{
 		"$inc": bson.M{
-			"balance": -withdrawMoney,
+			util.BalanceField(currency): -withdrawMoney,
 		},
 		"$push": bson.M{
 			"balanceHistory": balanceHistory,
@@ -584,6 +754,90 @@ func (r userRepositoryDB) Withdraw(userId string, withdrawMoney float64) (string
 	return "Successfully withdrawed money", nil
 }
 
+// the charge is taken even when the balance goes below zero
+func (r userRepositoryDB) Charge(userId string, method string, amount float64) (string, error) {
+	if amount <= 0 {
+		return "", ErrMoney
+	}
+
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	balanceHistory := BalanceHistory{
+		Timestamp: int64(time.Now().Unix()),
+		Balance:   amount,
+		Currency:  model.BaseCurrency,
+		Method:    method,
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			"balance": -amount,
+		},
+		"$push": bson.M{
+			"balanceHistory": balanceHistory,
+		},
+	}
+
+	_, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully charged money", nil
+}
+
+// money the user receives from the platform, such as a dividend, is
+// kept in the balance history under its own method
+func (r userRepositoryDB) Credit(userId string, currency string, method string, amount float64) (string, error) {
+	if amount <= 0 {
+		return "", ErrMoney
+	}
+
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
+	balanceHistory := BalanceHistory{
+		Timestamp: int64(time.Now().Unix()),
+		Balance:   amount,
+		Currency:  currency,
+		Method:    method,
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			util.BalanceField(currency): amount,
+		},
+		"$push": bson.M{
+			"balanceHistory": balanceHistory,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrUser
+	}
+
+	return "Successfully credited money", nil
+}
+
 func (r userRepositoryDB) GetAccount(userId string) (userAccount UserAccount, err error) {
 	if len(userId) == 0 {
 		return UserAccount{}, ErrUser
@@ -647,6 +901,8 @@ func (r userRepositoryDB) GetAllHistories(userId string, startPage uint) ([]User
 		}
 
 		userHistoryMap := result["userHistory"].(bson.M)
+		tradeId, _ := userHistoryMap["tradeId"].(string)
+		fee, _ := userHistoryMap["fee"].(float64)
 		history := UserHistory{
 			Price:       userHistoryMap["price"].(float64),
 			Amount:      userHistoryMap["amount"].(float64),
@@ -654,6 +910,8 @@ func (r userRepositoryDB) GetAllHistories(userId string, startPage uint) ([]User
 			Timestamp:   userHistoryMap["timestamp"].(int64),
 			OrderType:   userHistoryMap["orderType"].(string),
 			OrderMethod: userHistoryMap["orderMethod"].(string),
+			TradeId:     tradeId,
+			Fee:         fee,
 			StockId:     userHistoryMap["stockId"].(string),
 		}
 
@@ -679,8 +937,6 @@ func (r userRepositoryDB) GetAllHistories(userId string, startPage uint) ([]User
 	// 	return []UserHistory{}, err
 	// }
 
-	// fmt.Println(result)
-
 	//return result.UserHistory, nil
 }
 
@@ -730,6 +986,8 @@ func (r userRepositoryDB) GetUserStockHistory(userId string, stockId string, ski
 		}
 
 		userHistoryMap := result["userHistory"].(bson.M)
+		tradeId, _ := userHistoryMap["tradeId"].(string)
+		fee, _ := userHistoryMap["fee"].(float64)
 		history := UserHistory{
 			Price:       userHistoryMap["price"].(float64),
 			Amount:      userHistoryMap["amount"].(float64),
@@ -737,6 +995,8 @@ func (r userRepositoryDB) GetUserStockHistory(userId string, stockId string, ski
 			Timestamp:   userHistoryMap["timestamp"].(int64),
 			OrderType:   userHistoryMap["orderType"].(string),
 			OrderMethod: userHistoryMap["orderMethod"].(string),
+			TradeId:     tradeId,
+			Fee:         fee,
 		}
 
 		userHistories = append(userHistories, history)
@@ -789,6 +1049,7 @@ func (r userRepositoryDB) GetStockAmount(userId string, stockId string) (UserSto
 		userStockMap := result["userStock"].(bson.M)
 		userStock.Amount = userStockMap["amount"].(float64)
 		userStock.StockId = userStockMap["stockId"].(string)
+		userStock.AveragePrice, _ = userStockMap["averagePrice"].(float64)
 	}
 
 	if err := cursor.Err(); err != nil {
@@ -798,6 +1059,462 @@ func (r userRepositoryDB) GetStockAmount(userId string, stockId string) (UserSto
 	return userStock, nil
 }
 
+func (r userRepositoryDB) GetTradingVolume(userId string, since int64) (float64, error) {
+	if len(userId) == 0 {
+		return 0, ErrUser
+	}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.M{"uid": userId}}},
+		bson.D{{Key: "$unwind", Value: "$userHistory"}},
+		bson.D{{Key: "$match", Value: bson.M{
+			"userHistory.status":    "success",
+			"userHistory.timestamp": bson.M{"$gte": since},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": nil,
+			"volume": bson.M{"$sum": bson.M{
+				"$multiply": bson.A{
+					"$userHistory.price",
+					"$userHistory.amount",
+					bson.M{"$ifNull": bson.A{"$userHistory.fxRate", 1}},
+				},
+			}},
+		}}},
+	}
+
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Volume float64 `bson:"volume"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	return result.Volume, nil
+}
+
+func (r userRepositoryDB) GetFeeSummary(userId string) (FeeSummary, error) {
+	if len(userId) == 0 {
+		return FeeSummary{}, ErrUser
+	}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.M{"uid": userId}}},
+		bson.D{{Key: "$unwind", Value: "$userHistory"}},
+		bson.D{{Key: "$match", Value: bson.M{"userHistory.status": "success"}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":        nil,
+			"totalFee":   bson.M{"$sum": "$userHistory.fee"},
+			"tradeCount": bson.M{"$sum": 1},
+		}}},
+	}
+
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return FeeSummary{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var feeSummary FeeSummary
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&feeSummary); err != nil {
+			return FeeSummary{}, err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return FeeSummary{}, err
+	}
+
+	return feeSummary, nil
+}
+
+func (r userRepositoryDB) SetMarginEnabled(userId string, enabled bool) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"marginEnabled": enabled,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrUser
+	}
+
+	return "Successfully set margin account", nil
+}
+
+func (r userRepositoryDB) SetMarginCall(userId string, marginCall *MarginCall) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	update := bson.M{
+		"$unset": bson.M{
+			"marginCall": "",
+		},
+	}
+	if marginCall != nil {
+		update = bson.M{
+			"$set": bson.M{
+				"marginCall": marginCall,
+			},
+		}
+	}
+
+	_, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully set margin call", nil
+}
+
+func (r userRepositoryDB) GetMarginAccounts() ([]string, error) {
+	filter := bson.M{
+		"marginEnabled": true,
+	}
+	opts := options.Find().SetProjection(bson.M{
+		"uid": 1,
+	})
+
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return []string{}, err
+	}
+
+	var accounts []UserAccount
+	err = cursor.All(ctx, &accounts)
+	if err != nil {
+		return []string{}, err
+	}
+
+	userIds := []string{}
+	for _, account := range accounts {
+		userIds = append(userIds, account.UID)
+	}
+
+	return userIds, nil
+}
+
+// every holder gets ratio shares for each share and the average price is
+// divided by it, the number of holders is returned
+// the action id is kept on the account so a retried action does not split
+// the same position twice
+func (r userRepositoryDB) ApplySplit(stockId string, actionId string, ratio float64) (int64, error) {
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	if len(actionId) == 0 {
+		return 0, ErrCorporateAction
+	}
+
+	if ratio <= 0 {
+		return 0, errs.ErrSplitRatio
+	}
+
+	filter := bson.M{
+		"userStock.stockId": stockId,
+		"splits":            bson.M{"$ne": actionId},
+	}
+	update := bson.M{
+		"$mul": bson.M{
+			"userStock.$[stock].amount":       ratio,
+			"userStock.$[stock].averagePrice": 1 / ratio,
+		},
+		"$push": bson.M{
+			"splits": actionId,
+		},
+	}
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"stock.stockId": stockId}},
+	})
+
+	result, err := r.db.UpdateMany(ctx, filter, update, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r userRepositoryDB) SetDividendReinvest(userId string, enabled bool) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"dividendReinvest": enabled,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrUser
+	}
+
+	return "Successfully set dividend reinvestment", nil
+}
+
+// users holding a long position of the stock with their reinvest choice,
+// short positions are left out
+func (r userRepositoryDB) GetStockHolders(stockId string) ([]DividendHolder, error) {
+	if len(stockId) == 0 {
+		return []DividendHolder{}, ErrInvalidStock
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"userStock.stockId": stockId,
+		}}},
+		bson.D{{Key: "$unwind", Value: "$userStock"}},
+		bson.D{{Key: "$match", Value: bson.M{
+			"userStock.stockId": stockId,
+			"userStock.amount":  bson.M{"$gt": 0},
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"_id":      0,
+			"uid":      1,
+			"amount":   "$userStock.amount",
+			"reinvest": bson.M{"$ifNull": bson.A{"$dividendReinvest", false}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "uid", Value: 1},
+		}}},
+	}
+
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return []DividendHolder{}, err
+	}
+	defer cursor.Close(ctx)
+
+	holders := []DividendHolder{}
+	if err := cursor.All(ctx, &holders); err != nil {
+		return []DividendHolder{}, err
+	}
+
+	return holders, nil
+}
+
+// the stock amount of every user holding a long or short position
+func (r userRepositoryDB) GetStockPositions(stockId string) (map[string]float64, error) {
+	if len(stockId) == 0 {
+		return map[string]float64{}, ErrInvalidStock
+	}
+
+	pipeline := mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"userStock.stockId": stockId,
+		}}},
+		bson.D{{Key: "$unwind", Value: "$userStock"}},
+		bson.D{{Key: "$match", Value: bson.M{
+			"userStock.stockId": stockId,
+			"userStock.amount":  bson.M{"$ne": 0},
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"_id":    0,
+			"uid":    1,
+			"amount": "$userStock.amount",
+		}}},
+	}
+
+	cursor, err := r.db.Aggregate(ctx, pipeline)
+	if err != nil {
+		return map[string]float64{}, err
+	}
+	defer cursor.Close(ctx)
+
+	positions := map[string]float64{}
+	for cursor.Next(ctx) {
+		var result bson.M
+		if err := cursor.Decode(&result); err != nil {
+			return map[string]float64{}, err
+		}
+
+		positions[result["uid"].(string)] = result["amount"].(float64)
+	}
+
+	return positions, nil
+}
+
+// the position is closed at the price in one update, a long position is
+// paid into the balance of the currency and a short one is paid from it,
+// the amount is matched so a concurrent trade is not lost and the settled
+// amount is returned
+func (r userRepositoryDB) SettlePosition(userId string, stockId string, amount float64, price float64, currency string) (float64, error) {
+	if price < 0 {
+		return 0, ErrPrice
+	}
+
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return 0, ErrCurrency
+	}
+
+	if amount == 0 {
+		return 0, nil
+	}
+
+	orderMethod := "sale"
+	if amount < 0 {
+		orderMethod = "buy"
+	}
+
+	history := UserHistory{
+		Timestamp:   time.Now().Unix(),
+		StockId:     stockId,
+		Price:       price,
+		Amount:      math.Abs(amount),
+		Currency:    currency,
+		FxRate:      1,
+		Status:      "success",
+		OrderType:   "delist",
+		OrderMethod: orderMethod,
+	}
+
+	filter := bson.M{
+		"uid": userId,
+		"userStock": bson.M{"$elemMatch": bson.M{
+			"stockId": stockId,
+			"amount":  amount,
+		}},
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			util.BalanceField(currency): amount * price,
+		},
+		"$pull": bson.M{
+			"userStock": bson.M{"stockId": stockId},
+		},
+		"$push": bson.M{
+			"userHistory": history,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.MatchedCount == 0 {
+		return 0, ErrNotEnoughStock
+	}
+
+	return amount, nil
+}
+
+// the fraction of a split position is paid out at the price and the whole
+// shares are kept, the amount is matched so a concurrent trade is not lost
+func (r userRepositoryDB) SettleFraction(userId string, stockId string, amount float64, price float64, currency string) (float64, error) {
+	if price < 0 {
+		return 0, ErrPrice
+	}
+
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return 0, ErrCurrency
+	}
+
+	if len(userId) == 0 {
+		return 0, ErrUser
+	}
+
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	whole := util.WholeShares(amount)
+	fraction := amount - whole
+	if fraction == 0 {
+		return 0, nil
+	}
+
+	orderMethod := "sale"
+	if fraction < 0 {
+		orderMethod = "buy"
+	}
+
+	history := UserHistory{
+		Timestamp:   time.Now().Unix(),
+		StockId:     stockId,
+		Price:       price,
+		Amount:      math.Abs(fraction),
+		Currency:    currency,
+		FxRate:      1,
+		Status:      "success",
+		OrderType:   "split",
+		OrderMethod: orderMethod,
+	}
+
+	filter := bson.M{
+		"uid": userId,
+		"userStock": bson.M{"$elemMatch": bson.M{
+			"stockId": stockId,
+			"amount":  amount,
+		}},
+	}
+	update := bson.M{
+		"$inc": bson.M{
+			util.BalanceField(currency): fraction * price,
+		},
+		"$push": bson.M{
+			"userHistory": history,
+		},
+	}
+	if whole == 0 {
+		update["$pull"] = bson.M{"userStock": bson.M{"stockId": stockId}}
+	} else {
+		update["$set"] = bson.M{"userStock.$.amount": whole}
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.MatchedCount == 0 {
+		return 0, ErrNotEnoughStock
+	}
+
+	return fraction, nil
+}
+
 func (r userRepositoryDB) DeleteFavorite(userId string, stockId string) (string, error) {
 	if len(userId) == 0 {
 		return "", ErrUser
@@ -850,3 +1567,91 @@ func (r userRepositoryDB) DeleteAccount(userId string) (string, error) {
 
 	return "Successfully deleted account", nil
 }
+
+func (r userRepositoryDB) EditProfile(userId string, profile EditProfileRequest) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	set := bson.M{}
+	if len(profile.Name) > 0 {
+		set["name"] = profile.Name
+	}
+	if len(profile.Email) > 0 {
+		set["email"] = profile.Email
+	}
+	if len(set) == 0 {
+		return "", ErrData
+	}
+
+	filter := bson.M{
+		"uid": userId,
+	}
+	update := bson.M{
+		"$set": set,
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", ErrUser
+	}
+
+	return "Successfully updated profile", nil
+}
+
+// the avatar is only swapped while it is still from, an account without an
+// uploaded avatar has none. the profile image becomes the url of the avatar
+func (r userRepositoryDB) SetAvatar(userId string, from string, to string, profileImage string) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	if len(to) == 0 || len(profileImage) == 0 {
+		return "", errs.ErrImageType
+	}
+
+	var avatar interface{} = from
+	if len(from) == 0 {
+		avatar = bson.M{"$in": bson.A{"", nil}}
+	}
+
+	filter := bson.M{
+		"uid":    userId,
+		"avatar": avatar,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"avatar":       to,
+			"profileImage": profileImage,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", err
+	}
+
+	if result.MatchedCount == 0 {
+		return "", errs.ErrImageChanged
+	}
+
+	return "Successfully updated avatar", nil
+}
+
+// avatars are stored by content so accounts with the same avatar share it
+func (r userRepositoryDB) IsAvatarUsed(avatar string) (bool, error) {
+	filter := bson.M{
+		"avatar": avatar,
+	}
+
+	count, err := r.db.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
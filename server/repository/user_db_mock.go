@@ -15,13 +15,13 @@ func (m *userRepositoryDBMock) Create(data CreateAccount) (string, error) {
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *userRepositoryDBMock) Deposit(userId string, depositMoney float64) (string, error) {
-	arge := m.Called(userId, depositMoney)
+func (m *userRepositoryDBMock) Deposit(userId string, currency string, depositMoney float64) (string, error) {
+	arge := m.Called(userId, currency, depositMoney)
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *userRepositoryDBMock) Withdraw(userId string, withdrawMoney float64) (string, error) {
-	arge := m.Called(userId, withdrawMoney)
+func (m *userRepositoryDBMock) Withdraw(userId string, currency string, withdrawMoney float64) (string, error) {
+	arge := m.Called(userId, currency, withdrawMoney)
 	return arge.String(0), arge.Error(1)
 }
 
@@ -50,11 +50,31 @@ func (m *userRepositoryDBMock) GetBalance(userId string) (float64, error) {
 	return float64(arge.Int(0)), arge.Error(1)
 }
 
+func (m *userRepositoryDBMock) GetCurrencyBalances(userId string) (map[string]float64, error) {
+	arge := m.Called(userId)
+	return arge.Get(0).(map[string]float64), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) GetBalances() (map[string]map[string]float64, error) {
+	arge := m.Called()
+	return arge.Get(0).(map[string]map[string]float64), arge.Error(1)
+}
+
 func (m *userRepositoryDBMock) GetFavorite(userId string) ([]string, error) {
 	arge := m.Called(userId)
 	return arge.Get(0).([]string), arge.Error(1)
 }
 
+func (m *userRepositoryDBMock) Charge(userId string, method string, amount float64) (string, error) {
+	arge := m.Called(userId, method, amount)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) Credit(userId string, currency string, method string, amount float64) (string, error) {
+	arge := m.Called(userId, currency, method, amount)
+	return arge.String(0), arge.Error(1)
+}
+
 func (m *userRepositoryDBMock) GetAccount(userId string) (UserAccount, error) {
 	arge := m.Called(userId)
 	return arge.Get(0).(UserAccount), arge.Error(1)
@@ -75,6 +95,61 @@ func (m *userRepositoryDBMock) GetStockAmount(userId string, stockId string) (Us
 	return arge.Get(0).(UserStock), arge.Error(1)
 }
 
+func (m *userRepositoryDBMock) GetTradingVolume(userId string, since int64) (float64, error) {
+	arge := m.Called(userId, since)
+	return arge.Get(0).(float64), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) GetFeeSummary(userId string) (FeeSummary, error) {
+	arge := m.Called(userId)
+	return arge.Get(0).(FeeSummary), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) SetMarginEnabled(userId string, enabled bool) (string, error) {
+	arge := m.Called(userId, enabled)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) SetMarginCall(userId string, marginCall *MarginCall) (string, error) {
+	arge := m.Called(userId, marginCall)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) GetMarginAccounts() ([]string, error) {
+	arge := m.Called()
+	return arge.Get(0).([]string), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) ApplySplit(stockId string, actionId string, ratio float64) (int64, error) {
+	arge := m.Called(stockId, actionId, ratio)
+	return arge.Get(0).(int64), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) SetDividendReinvest(userId string, enabled bool) (string, error) {
+	arge := m.Called(userId, enabled)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) GetStockHolders(stockId string) ([]DividendHolder, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).([]DividendHolder), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) GetStockPositions(stockId string) (map[string]float64, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).(map[string]float64), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) SettlePosition(userId string, stockId string, amount float64, price float64, currency string) (float64, error) {
+	arge := m.Called(userId, stockId, amount, price, currency)
+	return arge.Get(0).(float64), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) SettleFraction(userId string, stockId string, amount float64, price float64, currency string) (float64, error) {
+	arge := m.Called(userId, stockId, amount, price, currency)
+	return arge.Get(0).(float64), arge.Error(1)
+}
+
 func (m *userRepositoryDBMock) DeleteFavorite(userId string, stockId string) (string, error) {
 	arge := m.Called(userId, stockId)
 	return arge.String(0), arge.Error(1)
@@ -84,3 +159,18 @@ func (m *userRepositoryDBMock) DeleteAccount(userId string) (string, error) {
 	arge := m.Called(userId)
 	return arge.String(0), arge.Error(1)
 }
+
+func (m *userRepositoryDBMock) EditProfile(userId string, profile EditProfileRequest) (string, error) {
+	arge := m.Called(userId, profile)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) SetAvatar(userId string, from string, to string, profileImage string) (string, error) {
+	arge := m.Called(userId, from, to, profileImage)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *userRepositoryDBMock) IsAvatarUsed(avatar string) (bool, error) {
+	arge := m.Called(avatar)
+	return arge.Bool(0), arge.Error(1)
+}
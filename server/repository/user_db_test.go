@@ -75,7 +75,7 @@ func TestDeposit(t *testing.T) {
 	t.Run("Error invalid money", func(t *testing.T) {
 		depositMoney := -1
 
-		_, err := userRepo.Deposit(userIdTesting, float64(depositMoney))
+		_, err := userRepo.Deposit(userIdTesting, "THB", float64(depositMoney))
 
 		assert.ErrorIs(t, err, ErrMoney)
 	})
@@ -83,7 +83,7 @@ func TestDeposit(t *testing.T) {
 	t.Run("Error invalid user", func(t *testing.T) {
 		depositMoney := 1
 
-		_, err := userRepo.Deposit("", float64(depositMoney))
+		_, err := userRepo.Deposit("", "THB", float64(depositMoney))
 
 		assert.ErrorIs(t, err, ErrUser)
 	})
@@ -91,7 +91,7 @@ func TestDeposit(t *testing.T) {
 	t.Run("Error convert userId to objectId", func(t *testing.T) {
 		depositMoney := 1
 
-		_, err := userRepo.Deposit("teste", float64(depositMoney))
+		_, err := userRepo.Deposit("teste", "THB", float64(depositMoney))
 
 		assert.Equal(t, err.Error(), "the provided hex string is not a valid ObjectID")
 	})
@@ -99,7 +99,7 @@ func TestDeposit(t *testing.T) {
 	t.Run("Error no documents in result", func(t *testing.T) {
 		depositMoney := 1
 
-		_, err := userRepo.Deposit("65c896695ec42b4f4f77af61", float64(depositMoney))
+		_, err := userRepo.Deposit("65c896695ec42b4f4f77af61", "THB", float64(depositMoney))
 
 		assert.Equal(t, err.Error(), "mongo: no documents in result")
 	})
@@ -107,7 +107,7 @@ func TestDeposit(t *testing.T) {
 	t.Run("Successfully deposited money", func(t *testing.T) {
 		depositMoney := 5000
 
-		actual, _ := userRepo.Deposit(userIdTesting, float64(depositMoney))
+		actual, _ := userRepo.Deposit(userIdTesting, "THB", float64(depositMoney))
 		expected := "Successfully deposited money"
 
 		assert.Equal(t, expected, actual)		
@@ -118,7 +118,7 @@ func TestWithdraw(t *testing.T) {
 	t.Run("Error invalid money", func(t *testing.T) {
 		withdrawMoney := -1
 
-		_, err := userRepo.Withdraw(userIdTesting, float64(withdrawMoney))
+		_, err := userRepo.Withdraw(userIdTesting, "THB", float64(withdrawMoney))
 
 		assert.ErrorIs(t, err, ErrMoney)
 	})
@@ -126,7 +126,7 @@ func TestWithdraw(t *testing.T) {
 	t.Run("Error invalid user", func(t *testing.T) {
 		withdrawMoney := 1
 
-		_, err := userRepo.Withdraw("", float64(withdrawMoney))
+		_, err := userRepo.Withdraw("", "THB", float64(withdrawMoney))
 
 		assert.ErrorIs(t, err, ErrUser)
 	})
@@ -134,7 +134,7 @@ func TestWithdraw(t *testing.T) {
 	t.Run("Error convert userId to objectId", func(t *testing.T) {
 		withdrawMoney := 1
 
-		_, err := userRepo.Withdraw("teste", float64(withdrawMoney))
+		_, err := userRepo.Withdraw("teste", "THB", float64(withdrawMoney))
 
 		assert.Equal(t, err.Error(), "the provided hex string is not a valid ObjectID")
 	})
@@ -142,7 +142,7 @@ func TestWithdraw(t *testing.T) {
 	t.Run("Error no documents in result", func(t *testing.T) {
 		depositMoney := 1
 
-		_, err := userRepo.Withdraw("65c896695ec42b4f4f77af61", float64(depositMoney))
+		_, err := userRepo.Withdraw("65c896695ec42b4f4f77af61", "THB", float64(depositMoney))
 
 		assert.Equal(t, err.Error(), "mongo: no documents in result")
 	})
@@ -150,7 +150,7 @@ func TestWithdraw(t *testing.T) {
 	t.Run("Error balance not enough", func(t *testing.T) {
 		withdrawMoney := 1_000_000
 
-		_, err := userRepo.Withdraw(userIdTesting, float64(withdrawMoney))
+		_, err := userRepo.Withdraw(userIdTesting, "THB", float64(withdrawMoney))
 
 		assert.ErrorIs(t, err, ErrBalance)
 	})
@@ -158,7 +158,7 @@ func TestWithdraw(t *testing.T) {
 	t.Run("Successfully withdrawed money", func(t *testing.T) {
 		withdrawMoney := 1000
 
-		actual, _ := userRepo.Withdraw(userIdTesting, float64(withdrawMoney))
+		actual, _ := userRepo.Withdraw(userIdTesting, "THB", float64(withdrawMoney))
 		expected := "Successfully withdrawed money"
 
 		assert.Equal(t, expected, actual)		
@@ -411,6 +411,101 @@ func TestSale(t *testing.T) {
 	})
 }
 
+func TestSaleShort(t *testing.T) {
+	shortStockId := "65c39a03dfb8060d99995936"
+	orderRequest := OrderRequest{
+		StockId:     shortStockId,
+		UserId:      userIdTesting,
+		Price:       100,
+		Amount:      10,
+		OrderType:   "auto",
+		OrderMethod: "sale",
+	}
+
+	t.Run("Error not enough stock", func(t *testing.T) {
+		_, err := userRepo.Sale(orderRequest)
+
+		assert.ErrorIs(t, err, ErrNotEnoughStock)
+	})
+
+	t.Run("Sell short", func(t *testing.T) {
+		shortOrder := orderRequest
+		shortOrder.Short = true
+
+		actual, err := userRepo.Sale(shortOrder)
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully sold stock", actual)
+
+		userStock, _ := userRepo.GetStockAmount(userIdTesting, shortStockId)
+		assert.Equal(t, float64(-10), userStock.Amount)
+		assert.Equal(t, float64(100), userStock.AveragePrice)
+	})
+
+	t.Run("Buy to cover", func(t *testing.T) {
+		coverOrder := orderRequest
+		coverOrder.Price = 90
+		coverOrder.OrderMethod = "buy"
+
+		actual, err := userRepo.Buy(coverOrder)
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully bought stock", actual)
+
+		account, _ := userRepo.GetAccount(userIdTesting)
+		for _, userStock := range account.Stock {
+			assert.NotEqual(t, shortStockId, userStock.StockId)
+		}
+	})
+}
+
+func TestSettlePosition(t *testing.T) {
+	delistedStockId := "65c39a03dfb8060d99995937"
+
+	t.Run("Error invalid price", func(t *testing.T) {
+		_, err := userRepo.SettlePosition(userIdTesting, delistedStockId, 5, -1, model.BaseCurrency)
+
+		assert.ErrorIs(t, err, errs.ErrPrice)
+	})
+
+	t.Run("Settle long position", func(t *testing.T) {
+		_, err := userRepo.Buy(OrderRequest{
+			StockId:     delistedStockId,
+			UserId:      userIdTesting,
+			Price:       10,
+			Amount:      5,
+			OrderType:   "auto",
+			OrderMethod: "buy",
+		})
+		assert.Empty(t, err)
+
+		positions, err := userRepo.GetStockPositions(delistedStockId)
+		assert.Empty(t, err)
+		assert.Equal(t, float64(5), positions[userIdTesting])
+
+		amount, err := userRepo.SettlePosition(userIdTesting, delistedStockId, 5, 12, model.BaseCurrency)
+		assert.Empty(t, err)
+		assert.Equal(t, float64(5), amount)
+
+		userStock, _ := userRepo.GetStockAmount(userIdTesting, delistedStockId)
+		assert.Equal(t, float64(0), userStock.Amount)
+	})
+}
+
+func TestCharge(t *testing.T) {
+	t.Run("Error invalid money", func(t *testing.T) {
+		_, err := userRepo.Charge(userIdTesting, "BORROW_FEE", 0)
+
+		assert.ErrorIs(t, err, ErrMoney)
+	})
+
+	t.Run("Charge borrow fee", func(t *testing.T) {
+		actual, err := userRepo.Charge(userIdTesting, "BORROW_FEE", 0.1)
+		expected := "Successfully charged money"
+
+		assert.Empty(t, err)
+		assert.Equal(t, expected, actual)
+	})
+}
+
 func TestSetFavorite(t *testing.T) {
 	t.Run("Error invalid user", func(t *testing.T) {
 		_, err := userRepo.SetFavorite("", stockIdTesting)
@@ -659,6 +754,103 @@ func TestGetStockAmount(t *testing.T) {
 	})
 }
 
+func TestGetCurrencyBalances(t *testing.T) {
+	t.Run("Error invalid user", func(t *testing.T) {
+		_, err := userRepo.GetCurrencyBalances("")
+
+		assert.ErrorIs(t, err, ErrUser)
+	})
+
+	t.Run("Get currency balances", func(t *testing.T) {
+		actual, err := userRepo.GetCurrencyBalances(userIdTesting)
+
+		assert.Empty(t, err)
+		assert.Contains(t, actual, "THB")
+	})
+}
+
+func TestGetTradingVolume(t *testing.T) {
+	t.Run("Error invalid user", func(t *testing.T) {
+		_, err := userRepo.GetTradingVolume("", 0)
+
+		assert.ErrorIs(t, err, ErrUser)
+	})
+
+	t.Run("Get trading volume", func(t *testing.T) {
+		actual, err := userRepo.GetTradingVolume("65c8993c48096b5150cee5d6", 0)
+
+		assert.Empty(t, err)
+		assert.GreaterOrEqual(t, actual, float64(0))
+	})
+}
+
+func TestGetFeeSummary(t *testing.T) {
+	t.Run("Error invalid user", func(t *testing.T) {
+		_, err := userRepo.GetFeeSummary("")
+
+		assert.ErrorIs(t, err, ErrUser)
+	})
+
+	t.Run("Get fee summary", func(t *testing.T) {
+		actual, err := userRepo.GetFeeSummary("65c8993c48096b5150cee5d6")
+
+		assert.Empty(t, err)
+		assert.GreaterOrEqual(t, actual.TotalFee, float64(0))
+	})
+}
+
+func TestSetMarginEnabled(t *testing.T) {
+	t.Run("Error invalid user", func(t *testing.T) {
+		_, err := userRepo.SetMarginEnabled("", true)
+
+		assert.ErrorIs(t, err, ErrUser)
+	})
+
+	t.Run("Set margin enabled", func(t *testing.T) {
+		actual, err := userRepo.SetMarginEnabled("65c8993c48096b5150cee5d6", true)
+		expected := "Successfully set margin account"
+
+		assert.Empty(t, err)
+		assert.Equal(t, expected, actual)
+	})
+}
+
+func TestSetMarginCall(t *testing.T) {
+	t.Run("Error invalid user", func(t *testing.T) {
+		_, err := userRepo.SetMarginCall("", nil)
+
+		assert.ErrorIs(t, err, ErrUser)
+	})
+
+	t.Run("Set margin call", func(t *testing.T) {
+		marginCall := &model.MarginCall{Status: model.MarginCallIssued}
+		_, err := userRepo.SetMarginCall("65c8993c48096b5150cee5d6", marginCall)
+		assert.Empty(t, err)
+
+		account, _ := userRepo.GetAccount("65c8993c48096b5150cee5d6")
+		assert.Equal(t, marginCall, account.MarginCall)
+	})
+
+	t.Run("Clear margin call", func(t *testing.T) {
+		_, err := userRepo.SetMarginCall("65c8993c48096b5150cee5d6", nil)
+		assert.Empty(t, err)
+
+		account, _ := userRepo.GetAccount("65c8993c48096b5150cee5d6")
+		assert.Nil(t, account.MarginCall)
+	})
+}
+
+func TestGetMarginAccounts(t *testing.T) {
+	t.Run("Get margin accounts", func(t *testing.T) {
+		userRepo.SetMarginEnabled("65c8993c48096b5150cee5d6", true)
+
+		actual, err := userRepo.GetMarginAccounts()
+
+		assert.Empty(t, err)
+		assert.Contains(t, actual, "65c8993c48096b5150cee5d6")
+	})
+}
+
 func TestDeleteFavorite(t *testing.T) {
 	t.Run("Error invalid user", func(t *testing.T) {
 		_, err := userRepo.DeleteFavorite(
@@ -717,4 +909,42 @@ func TestDeleteAccount(t *testing.T) {
 
 		assert.Equal(t, expected, actual)
 	})
-}
\ No newline at end of file
+}
+func TestEditProfile(t *testing.T) {
+	t.Run("Error invalid data", func(t *testing.T) {
+		_, err := userRepo.EditProfile("65c8993c48096b5150cee5d6", model.EditProfileRequest{})
+
+		assert.ErrorIs(t, err, ErrData)
+	})
+
+	t.Run("Edit profile", func(t *testing.T) {
+		account, _ := userRepo.GetAccount("65c8993c48096b5150cee5d6")
+
+		actual, err := userRepo.EditProfile("65c8993c48096b5150cee5d6", model.EditProfileRequest{Name: "profile"})
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully updated profile", actual)
+
+		userRepo.EditProfile("65c8993c48096b5150cee5d6", model.EditProfileRequest{Name: account.Name})
+	})
+}
+
+func TestSetAvatar(t *testing.T) {
+	userId := "65c8993c48096b5150cee5d6"
+	account, _ := userRepo.GetAccount(userId)
+
+	t.Run("Error image changed", func(t *testing.T) {
+		_, err := userRepo.SetAvatar(userId, account.Avatar+"-changed", "avatar/new", "/api/v1/image/avatar/new/256.png")
+
+		assert.ErrorIs(t, err, errs.ErrImageChanged)
+	})
+
+	t.Run("Swap avatar", func(t *testing.T) {
+		actual, err := userRepo.SetAvatar(userId, account.Avatar, "avatar/new", "/api/v1/image/avatar/new/256.png")
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully updated avatar", actual)
+
+		used, err := userRepo.IsAvatarUsed("avatar/new")
+		assert.Empty(t, err)
+		assert.True(t, used)
+	})
+}
@@ -0,0 +1,1130 @@
+package repository
+
+import (
+	"math"
+	"server/errs"
+	"server/model"
+	"server/util"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// accounts kept in the process with the validation and errors of the mongo
+// repository, for tests and running without a database
+type userRepositoryMemory struct {
+	mu       sync.RWMutex
+	accounts map[string]*UserAccount
+	order    []string
+}
+
+func NewUserRepositoryMemory() UserRepository {
+	return &userRepositoryMemory{
+		accounts: map[string]*UserAccount{},
+	}
+}
+
+func (r *userRepositoryMemory) Create(data CreateAccount) (string, error) {
+	if len(data.Name) == 0 || len(data.ProfileImage) == 0 || len(data.Email) == 0 || len(data.UID) == 0 {
+		return "", ErrData
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[data.UID]; ok {
+		return "", ErrUser
+	}
+
+	r.accounts[data.UID] = &UserAccount{
+		ID:             primitive.NewObjectID(),
+		UID:            data.UID,
+		Name:           data.Name,
+		ProfileImage:   data.ProfileImage,
+		Email:          data.Email,
+		Balance:        0,
+		Balances:       map[string]float64{},
+		BalanceHistory: []BalanceHistory{},
+		Favorite:       []string{},
+		History:        []UserHistory{},
+		Stock:          []UserStock{},
+	}
+	r.order = append(r.order, data.UID)
+
+	return "Successfully created account", nil
+}
+
+func (r *userRepositoryMemory) Buy(orderRequest OrderRequest) (string, error) {
+	userId := orderRequest.UserId
+	stockId := orderRequest.StockId
+	amount := orderRequest.Amount
+	price := orderRequest.Price
+
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	if (len(stockId) == 0) ||
+		(len(orderRequest.OrderType) == 0) ||
+		(len(orderRequest.OrderMethod) == 0) ||
+		(amount <= 0) ||
+		(price <= 0) {
+		return "", ErrData
+	}
+
+	if orderRequest.OrderType != "auto" && orderRequest.OrderType != "order" {
+		return "", ErrOrderType
+	}
+
+	if orderRequest.OrderMethod != "buy" {
+		return "", ErrOrderMethod
+	}
+
+	currency := util.NormalizeCurrency(orderRequest.Currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
+	fxRate := orderRequest.FxRate
+	if fxRate == 0 {
+		fxRate = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return "", mongo.ErrNoDocuments
+	}
+
+	stockValue := price*amount*fxRate + orderRequest.Fee
+	if !orderRequest.Margin && stockValue > util.AccountBalance(*account, currency) {
+		return "", ErrBalance
+	}
+
+	account.History = append(account.History, tradeHistory(orderRequest, currency, fxRate))
+	addBalance(account, currency, -stockValue)
+
+	index := stockIndex(account, stockId)
+	if index < 0 {
+		account.Stock = append(account.Stock, UserStock{
+			StockId:      stockId,
+			Amount:       amount,
+			AveragePrice: price,
+		})
+	} else if userStock := account.Stock[index]; userStock.Amount+amount == 0 {
+		removeStock(account, stockId)
+	} else {
+		account.Stock[index].Amount += amount
+		account.Stock[index].AveragePrice = util.AveragePrice(
+			userStock.Amount,
+			userStock.AveragePrice,
+			amount,
+			price,
+		)
+	}
+
+	return "Successfully bought stock", nil
+}
+
+func (r *userRepositoryMemory) Sale(orderRequest OrderRequest) (string, error) {
+	userId := orderRequest.UserId
+	stockId := orderRequest.StockId
+	amount := orderRequest.Amount
+	price := orderRequest.Price
+
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	if (len(stockId) == 0) ||
+		(len(orderRequest.OrderType) == 0) ||
+		(len(orderRequest.OrderMethod) == 0) ||
+		(amount <= 0) ||
+		(price <= 0) {
+		return "", ErrData
+	}
+
+	if orderRequest.OrderType != "auto" && orderRequest.OrderType != "order" {
+		return "", ErrOrderType
+	}
+
+	if orderRequest.OrderMethod != "sale" {
+		return "", ErrOrderMethod
+	}
+
+	currency := util.NormalizeCurrency(orderRequest.Currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
+	fxRate := orderRequest.FxRate
+	if fxRate == 0 {
+		fxRate = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return "", mongo.ErrNoDocuments
+	}
+
+	index := stockIndex(account, stockId)
+	var userStock UserStock
+	if index >= 0 {
+		userStock = account.Stock[index]
+	}
+
+	if !orderRequest.Short && amount > userStock.Amount {
+		return "", ErrNotEnoughStock
+	}
+
+	stockValue := price*amount*fxRate - orderRequest.Fee
+	if index < 0 && orderRequest.Short {
+		account.Stock = append(account.Stock, UserStock{
+			StockId:      stockId,
+			Amount:       -amount,
+			AveragePrice: price,
+		})
+	} else if index < 0 {
+		return "", ErrInvalidStock
+	} else if userStock.Amount == amount {
+		removeStock(account, stockId)
+	} else if userStock.Amount > amount || orderRequest.Short {
+		account.Stock[index].Amount -= amount
+		account.Stock[index].AveragePrice = util.AveragePrice(
+			userStock.Amount,
+			userStock.AveragePrice,
+			-amount,
+			price,
+		)
+	} else {
+		return "", ErrNotEnoughStock
+	}
+
+	account.History = append(account.History, tradeHistory(orderRequest, currency, fxRate))
+	addBalance(account, currency, stockValue)
+
+	return "Successfully sold stock", nil
+}
+
+func (r *userRepositoryMemory) SetFavorite(userId string, stockId string) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return "Successfully set favorite stock", nil
+	}
+
+	for _, favorite := range account.Favorite {
+		if favorite == stockId {
+			return "", ErrFavoriteStock
+		}
+	}
+
+	account.Favorite = append(account.Favorite, stockId)
+
+	return "Successfully set favorite stock", nil
+}
+
+func (r *userRepositoryMemory) GetBalanceHistory(userId string, method string, skip uint) ([]BalanceHistory, error) {
+	if len(userId) == 0 {
+		return []BalanceHistory{}, ErrUser
+	}
+
+	if method != "ALL" &&
+		method != "DEPOSIT" &&
+		method != "WITHDRAW" &&
+		method != "BORROW_FEE" &&
+		method != "DIVIDEND" {
+		return []BalanceHistory{}, ErrOrderMethod
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return nil, nil
+	}
+
+	var balanceHistories []BalanceHistory
+	for _, balanceHistory := range account.BalanceHistory {
+		if method == "ALL" || balanceHistory.Method == method {
+			balanceHistories = append(balanceHistories, balanceHistory)
+		}
+	}
+
+	sort.SliceStable(balanceHistories, func(i, j int) bool {
+		return balanceHistories[i].Timestamp > balanceHistories[j].Timestamp
+	})
+
+	return page(balanceHistories, skip), nil
+}
+
+func (r *userRepositoryMemory) GetBalance(userId string) (float64, error) {
+	if len(userId) == 0 {
+		return 0, ErrUser
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return 0, mongo.ErrNoDocuments
+	}
+
+	return account.Balance, nil
+}
+
+func (r *userRepositoryMemory) GetCurrencyBalances(userId string) (map[string]float64, error) {
+	if len(userId) == 0 {
+		return map[string]float64{}, ErrUser
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return map[string]float64{}, mongo.ErrNoDocuments
+	}
+
+	balances := map[string]float64{
+		model.BaseCurrency: account.Balance,
+	}
+	for currency, balance := range account.Balances {
+		balances[currency] = balance
+	}
+
+	return balances, nil
+}
+
+func (r *userRepositoryMemory) GetBalances() (map[string]map[string]float64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	balances := map[string]map[string]float64{}
+	for userId, account := range r.accounts {
+		balances[userId] = map[string]float64{
+			model.BaseCurrency: account.Balance,
+		}
+		for currency, balance := range account.Balances {
+			balances[userId][currency] = balance
+		}
+	}
+
+	return balances, nil
+}
+
+func (r *userRepositoryMemory) GetFavorite(userId string) ([]string, error) {
+	if len(userId) == 0 {
+		return []string{}, ErrUser
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return []string{}, mongo.ErrNoDocuments
+	}
+
+	return append([]string{}, account.Favorite...), nil
+}
+
+func (r *userRepositoryMemory) Deposit(userId string, currency string, depositMoney float64) (string, error) {
+	if depositMoney <= 0 {
+		return "", ErrMoney
+	}
+
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if account, ok := r.accounts[userId]; ok {
+		addBalance(account, currency, depositMoney)
+		account.BalanceHistory = append(account.BalanceHistory, BalanceHistory{
+			Timestamp: int64(time.Now().Unix()),
+			Balance:   depositMoney,
+			Currency:  currency,
+			Method:    "DEPOSIT",
+		})
+	}
+
+	return "Successfully deposited money", nil
+}
+
+func (r *userRepositoryMemory) Withdraw(userId string, currency string, withdrawMoney float64) (string, error) {
+	if withdrawMoney <= 0 {
+		return "", ErrMoney
+	}
+
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return "", mongo.ErrNoDocuments
+	}
+
+	if util.AccountBalance(*account, currency) < withdrawMoney {
+		return "", ErrBalance
+	}
+
+	addBalance(account, currency, -withdrawMoney)
+	account.BalanceHistory = append(account.BalanceHistory, BalanceHistory{
+		Timestamp: int64(time.Now().Unix()),
+		Balance:   withdrawMoney,
+		Currency:  currency,
+		Method:    "WITHDRAW",
+	})
+
+	return "Successfully withdrawed money", nil
+}
+
+// the charge is taken even when the balance goes below zero
+func (r *userRepositoryMemory) Charge(userId string, method string, amount float64) (string, error) {
+	if amount <= 0 {
+		return "", ErrMoney
+	}
+
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if account, ok := r.accounts[userId]; ok {
+		account.Balance -= amount
+		account.BalanceHistory = append(account.BalanceHistory, BalanceHistory{
+			Timestamp: int64(time.Now().Unix()),
+			Balance:   amount,
+			Currency:  model.BaseCurrency,
+			Method:    method,
+		})
+	}
+
+	return "Successfully charged money", nil
+}
+
+// money the user receives from the platform, such as a dividend, is
+// kept in the balance history under its own method
+func (r *userRepositoryMemory) Credit(userId string, currency string, method string, amount float64) (string, error) {
+	if amount <= 0 {
+		return "", ErrMoney
+	}
+
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return "", ErrCurrency
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return "", ErrUser
+	}
+
+	addBalance(account, currency, amount)
+	account.BalanceHistory = append(account.BalanceHistory, BalanceHistory{
+		Timestamp: int64(time.Now().Unix()),
+		Balance:   amount,
+		Currency:  currency,
+		Method:    method,
+	})
+
+	return "Successfully credited money", nil
+}
+
+func (r *userRepositoryMemory) GetAccount(userId string) (UserAccount, error) {
+	if len(userId) == 0 {
+		return UserAccount{}, ErrUser
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return UserAccount{}, mongo.ErrNoDocuments
+	}
+
+	return cloneAccount(*account), nil
+}
+
+func (r *userRepositoryMemory) GetAllHistories(userId string, startPage uint) ([]UserHistory, error) {
+	if len(userId) == 0 {
+		return []UserHistory{}, ErrUser
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return nil, nil
+	}
+
+	var userHistories []UserHistory
+	for _, history := range latestHistories(account.History, "", startPage) {
+		userHistories = append(userHistories, UserHistory{
+			Price:       history.Price,
+			Amount:      history.Amount,
+			Status:      history.Status,
+			Timestamp:   history.Timestamp,
+			OrderType:   history.OrderType,
+			OrderMethod: history.OrderMethod,
+			TradeId:     history.TradeId,
+			Fee:         history.Fee,
+			StockId:     history.StockId,
+		})
+	}
+
+	return userHistories, nil
+}
+
+func (r *userRepositoryMemory) GetUserStockHistory(userId string, stockId string, skip uint) ([]UserHistory, error) {
+	if len(userId) == 0 {
+		return []UserHistory{}, ErrUser
+	}
+
+	if len(stockId) == 0 {
+		return []UserHistory{}, ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return nil, nil
+	}
+
+	var userHistories []UserHistory
+	for _, history := range latestHistories(account.History, stockId, skip) {
+		userHistories = append(userHistories, UserHistory{
+			Price:       history.Price,
+			Amount:      history.Amount,
+			Status:      history.Status,
+			Timestamp:   history.Timestamp,
+			OrderType:   history.OrderType,
+			OrderMethod: history.OrderMethod,
+			TradeId:     history.TradeId,
+			Fee:         history.Fee,
+		})
+	}
+
+	return userHistories, nil
+}
+
+func (r *userRepositoryMemory) GetStockAmount(userId string, stockId string) (UserStock, error) {
+	if len(userId) == 0 {
+		return UserStock{}, ErrUser
+	}
+
+	if len(stockId) == 0 {
+		return UserStock{}, ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.stockAmount(userId, stockId), nil
+}
+
+func (r *userRepositoryMemory) GetTradingVolume(userId string, since int64) (float64, error) {
+	if len(userId) == 0 {
+		return 0, ErrUser
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return 0, nil
+	}
+
+	var volume float64
+	for _, history := range account.History {
+		if history.Status != "success" || history.Timestamp < since {
+			continue
+		}
+
+		fxRate := history.FxRate
+		if fxRate == 0 {
+			fxRate = 1
+		}
+		volume += history.Price * history.Amount * fxRate
+	}
+
+	return volume, nil
+}
+
+func (r *userRepositoryMemory) GetFeeSummary(userId string) (FeeSummary, error) {
+	if len(userId) == 0 {
+		return FeeSummary{}, ErrUser
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var feeSummary FeeSummary
+	if account, ok := r.accounts[userId]; ok {
+		for _, history := range account.History {
+			if history.Status == "success" {
+				feeSummary.TotalFee += history.Fee
+				feeSummary.TradeCount++
+			}
+		}
+	}
+
+	return feeSummary, nil
+}
+
+func (r *userRepositoryMemory) SetMarginEnabled(userId string, enabled bool) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return "", ErrUser
+	}
+
+	account.MarginEnabled = enabled
+
+	return "Successfully set margin account", nil
+}
+
+func (r *userRepositoryMemory) SetMarginCall(userId string, marginCall *MarginCall) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if account, ok := r.accounts[userId]; ok {
+		account.MarginCall = nil
+		if marginCall != nil {
+			call := *marginCall
+			account.MarginCall = &call
+		}
+	}
+
+	return "Successfully set margin call", nil
+}
+
+func (r *userRepositoryMemory) GetMarginAccounts() ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userIds := []string{}
+	for _, userId := range r.order {
+		if r.accounts[userId].MarginEnabled {
+			userIds = append(userIds, userId)
+		}
+	}
+
+	return userIds, nil
+}
+
+// every holder gets ratio shares for each share and the average price is
+// divided by it, the number of holders is returned
+func (r *userRepositoryMemory) ApplySplit(stockId string, actionId string, ratio float64) (int64, error) {
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	if len(actionId) == 0 {
+		return 0, errs.ErrCorporateAction
+	}
+
+	if ratio <= 0 {
+		return 0, errs.ErrSplitRatio
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var modified int64
+	for _, account := range r.accounts {
+		if slices.Contains(account.Splits, actionId) {
+			continue
+		}
+
+		held := false
+		for i, userStock := range account.Stock {
+			if userStock.StockId != stockId {
+				continue
+			}
+
+			account.Stock[i].Amount *= ratio
+			account.Stock[i].AveragePrice *= 1 / ratio
+			held = true
+		}
+
+		if held {
+			account.Splits = append(account.Splits, actionId)
+			modified++
+		}
+	}
+
+	return modified, nil
+}
+
+func (r *userRepositoryMemory) SetDividendReinvest(userId string, enabled bool) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return "", ErrUser
+	}
+
+	account.DividendReinvest = enabled
+
+	return "Successfully set dividend reinvestment", nil
+}
+
+// users holding a long position of the stock with their reinvest choice,
+// short positions are left out
+func (r *userRepositoryMemory) GetStockHolders(stockId string) ([]DividendHolder, error) {
+	if len(stockId) == 0 {
+		return []DividendHolder{}, ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	holders := []DividendHolder{}
+	for _, account := range r.accounts {
+		for _, userStock := range account.Stock {
+			if userStock.StockId == stockId && userStock.Amount > 0 {
+				holders = append(holders, DividendHolder{
+					UID:      account.UID,
+					Amount:   userStock.Amount,
+					Reinvest: account.DividendReinvest,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(holders, func(i, j int) bool {
+		return holders[i].UID < holders[j].UID
+	})
+
+	return holders, nil
+}
+
+// the stock amount of every user holding a long or short position
+func (r *userRepositoryMemory) GetStockPositions(stockId string) (map[string]float64, error) {
+	if len(stockId) == 0 {
+		return map[string]float64{}, ErrInvalidStock
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	positions := map[string]float64{}
+	for _, account := range r.accounts {
+		for _, userStock := range account.Stock {
+			if userStock.StockId == stockId && userStock.Amount != 0 {
+				positions[account.UID] = userStock.Amount
+			}
+		}
+	}
+
+	return positions, nil
+}
+
+// the position is closed at the price, a long position is paid into the
+// balance of the currency and a short one is paid from it, the settled
+// amount is returned
+func (r *userRepositoryMemory) SettlePosition(userId string, stockId string, amount float64, price float64, currency string) (float64, error) {
+	if price < 0 {
+		return 0, ErrPrice
+	}
+
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return 0, ErrCurrency
+	}
+
+	if len(userId) == 0 {
+		return 0, ErrUser
+	}
+
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	if amount == 0 {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok || r.stockAmount(userId, stockId).Amount != amount {
+		return 0, ErrNotEnoughStock
+	}
+
+	orderMethod := "sale"
+	if amount < 0 {
+		orderMethod = "buy"
+	}
+
+	addBalance(account, currency, amount*price)
+	removeStock(account, stockId)
+	account.History = append(account.History, UserHistory{
+		Timestamp:   time.Now().Unix(),
+		StockId:     stockId,
+		Price:       price,
+		Amount:      math.Abs(amount),
+		Currency:    currency,
+		FxRate:      1,
+		Status:      "success",
+		OrderType:   "delist",
+		OrderMethod: orderMethod,
+	})
+
+	return amount, nil
+}
+
+func (r *userRepositoryMemory) SettleFraction(userId string, stockId string, amount float64, price float64, currency string) (float64, error) {
+	if price < 0 {
+		return 0, ErrPrice
+	}
+
+	currency = util.NormalizeCurrency(currency)
+	if !util.ValidCurrency(currency) {
+		return 0, ErrCurrency
+	}
+
+	if len(userId) == 0 {
+		return 0, ErrUser
+	}
+
+	if len(stockId) == 0 {
+		return 0, ErrInvalidStock
+	}
+
+	whole := util.WholeShares(amount)
+	fraction := amount - whole
+	if fraction == 0 {
+		return 0, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok || r.stockAmount(userId, stockId).Amount != amount {
+		return 0, ErrNotEnoughStock
+	}
+
+	orderMethod := "sale"
+	if fraction < 0 {
+		orderMethod = "buy"
+	}
+
+	addBalance(account, currency, fraction*price)
+	if whole == 0 {
+		removeStock(account, stockId)
+	} else {
+		for i := range account.Stock {
+			if account.Stock[i].StockId == stockId {
+				account.Stock[i].Amount = whole
+			}
+		}
+	}
+	account.History = append(account.History, UserHistory{
+		Timestamp:   time.Now().Unix(),
+		StockId:     stockId,
+		Price:       price,
+		Amount:      math.Abs(fraction),
+		Currency:    currency,
+		FxRate:      1,
+		Status:      "success",
+		OrderType:   "split",
+		OrderMethod: orderMethod,
+	})
+
+	return fraction, nil
+}
+
+func (r *userRepositoryMemory) DeleteFavorite(userId string, stockId string) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	if len(stockId) == 0 {
+		return "", ErrInvalidStock
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if account, ok := r.accounts[userId]; ok {
+		favorites := []string{}
+		for _, favorite := range account.Favorite {
+			if favorite != stockId {
+				favorites = append(favorites, favorite)
+			}
+		}
+		account.Favorite = favorites
+	}
+
+	return "Successfully deleted favorite stock", nil
+}
+
+func (r *userRepositoryMemory) DeleteAccount(userId string) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[userId]; ok {
+		delete(r.accounts, userId)
+		for i, uid := range r.order {
+			if uid == userId {
+				r.order = append(r.order[:i], r.order[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return "Successfully deleted account", nil
+}
+
+func (r *userRepositoryMemory) EditProfile(userId string, profile EditProfileRequest) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	if len(profile.Name) == 0 && len(profile.Email) == 0 {
+		return "", ErrData
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok {
+		return "", ErrUser
+	}
+
+	if len(profile.Name) > 0 {
+		account.Name = profile.Name
+	}
+	if len(profile.Email) > 0 {
+		account.Email = profile.Email
+	}
+
+	return "Successfully updated profile", nil
+}
+
+// the avatar is only swapped while it is still from, an account without an
+// uploaded avatar has none. the profile image becomes the url of the avatar
+func (r *userRepositoryMemory) SetAvatar(userId string, from string, to string, profileImage string) (string, error) {
+	if len(userId) == 0 {
+		return "", ErrUser
+	}
+
+	if len(to) == 0 || len(profileImage) == 0 {
+		return "", errs.ErrImageType
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[userId]
+	if !ok || account.Avatar != from {
+		return "", errs.ErrImageChanged
+	}
+
+	account.Avatar = to
+	account.ProfileImage = profileImage
+
+	return "Successfully updated avatar", nil
+}
+
+// avatars are stored by content so accounts with the same avatar share it
+func (r *userRepositoryMemory) IsAvatarUsed(avatar string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, account := range r.accounts {
+		if account.Avatar == avatar {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// the last position of the user in the stock, empty when there is none
+func (r *userRepositoryMemory) stockAmount(userId string, stockId string) UserStock {
+	var userStock UserStock
+	if account, ok := r.accounts[userId]; ok {
+		for _, stock := range account.Stock {
+			if stock.StockId == stockId {
+				userStock = stock
+			}
+		}
+	}
+
+	return userStock
+}
+
+func tradeHistory(orderRequest OrderRequest, currency string, fxRate float64) UserHistory {
+	return UserHistory{
+		TradeId:     orderRequest.TradeId,
+		StockId:     orderRequest.StockId,
+		Price:       orderRequest.Price,
+		Amount:      orderRequest.Amount,
+		Fee:         orderRequest.Fee,
+		Currency:    currency,
+		FxRate:      fxRate,
+		Status:      "success",
+		Timestamp:   int64(time.Now().Unix()),
+		OrderType:   orderRequest.OrderType,
+		OrderMethod: orderRequest.OrderMethod,
+	}
+}
+
+func addBalance(account *UserAccount, currency string, amount float64) {
+	if currency == model.BaseCurrency {
+		account.Balance += amount
+		return
+	}
+
+	if account.Balances == nil {
+		account.Balances = map[string]float64{}
+	}
+	account.Balances[currency] += amount
+}
+
+func stockIndex(account *UserAccount, stockId string) int {
+	for i, userStock := range account.Stock {
+		if userStock.StockId == stockId {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func removeStock(account *UserAccount, stockId string) {
+	userStocks := []UserStock{}
+	for _, userStock := range account.Stock {
+		if userStock.StockId != stockId {
+			userStocks = append(userStocks, userStock)
+		}
+	}
+	account.Stock = userStocks
+}
+
+// the trades of the stock, or of every stock when it is empty, newest first
+// and paginated by 10
+func latestHistories(histories []UserHistory, stockId string, skip uint) []UserHistory {
+	var trades []UserHistory
+	for _, history := range histories {
+		if len(stockId) == 0 || history.StockId == stockId {
+			trades = append(trades, history)
+		}
+	}
+
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].Timestamp > trades[j].Timestamp
+	})
+
+	return page(trades, skip)
+}
+
+func page[T any](items []T, skip uint) []T {
+	if int(skip) >= len(items) {
+		return nil
+	}
+
+	items = items[skip:]
+	if len(items) > 10 {
+		items = items[:10]
+	}
+
+	return items
+}
+
+func cloneAccount(account UserAccount) UserAccount {
+	balances := map[string]float64{}
+	for currency, balance := range account.Balances {
+		balances[currency] = balance
+	}
+	account.Balances = balances
+
+	account.BalanceHistory = append([]BalanceHistory{}, account.BalanceHistory...)
+	account.Favorite = append([]string{}, account.Favorite...)
+	account.History = append([]UserHistory{}, account.History...)
+	account.Stock = append([]UserStock{}, account.Stock...)
+
+	if account.MarginCall != nil {
+		marginCall := *account.MarginCall
+		account.MarginCall = &marginCall
+	}
+
+	return account
+}
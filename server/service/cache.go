@@ -0,0 +1,36 @@
+package service
+
+import (
+	"server/errs"
+	"server/model"
+	"time"
+)
+
+type CacheConfig = model.CacheConfig
+type CacheMessage = model.CacheMessage
+
+// a key that is not set is ErrCacheMiss, a ttl of zero keeps the key until
+// it is deleted or evicted
+type Cache interface {
+	Get(string) ([]byte, error)
+	Set(string, []byte, time.Duration) error
+	SetNX(string, []byte, time.Duration) (bool, error)
+	GetSet(string, []byte) ([]byte, error)
+	Delete(...string) error
+	Keys(string) ([]string, error)
+	Publish(string, []byte) error
+	// the messages of the channels until the returned func is called
+	Subscribe(...string) (<-chan CacheMessage, func())
+}
+
+func NewCache(cacheConfig CacheConfig) (Cache, error) {
+	switch cacheConfig.Backend {
+	case model.CacheRedis:
+		redisClient := newRedisClient(cacheConfig.Redis)
+		return NewFailoverCache(redisClient, cacheConfig.Size, cacheConfig.RetryInterval), nil
+	case model.CacheMemory:
+		return NewMemoryCache(cacheConfig.Size), nil
+	}
+
+	return nil, errs.ErrCache
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// what happened to a user or a stock, the cache is invalidated by events
+// instead of by keys so a new cached value only has to be added to the
+// dependencies below
+type cacheEvent struct {
+	kind    cacheEventKind
+	userId  string
+	stockId string
+}
+
+type cacheEventKind int
+
+const (
+	accountCreated cacheEventKind = iota
+	accountDeleted
+	profileChanged
+	balanceChanged
+	favoriteChanged
+	// a trade or settlement of the user in the stock
+	positionChanged
+	stockListed
+	// name, sign, image, price, trading rule, status or a trade of the stock
+	stockChanged
+	stockSplit
+)
+
+const (
+	stockCollectionsCacheKey = "stockCollections"
+	top10StockCacheKey       = "top10Stock"
+)
+
+func userCacheKey(userId string) string {
+	return fmt.Sprintf("user:%s", userId)
+}
+
+func balanceCacheKey(userId string) string {
+	return fmt.Sprintf("balance:%s", userId)
+}
+
+func favoriteCacheKey(userId string) string {
+	return fmt.Sprintf("favorite:%s", userId)
+}
+
+func userHistoryCacheKey(userId string) string {
+	return fmt.Sprintf("userHistory:%s", userId)
+}
+
+func userStockHistoryCacheKey(userId string, stockId string) string {
+	return fmt.Sprintf("userStockHistory:%s:%s", userId, stockId)
+}
+
+func stockAmountCacheKey(userId string, stockId string) string {
+	return fmt.Sprintf("stockAmount:%s:%s", userId, stockId)
+}
+
+func stockCollectionCacheKey(stockId string) string {
+	return fmt.Sprintf("stockCollection:%s", stockId)
+}
+
+// the keys whose cached value depends on each event, a key with "*" is a
+// pattern of the keys of every user or stock
+var cacheDependencies = map[cacheEventKind]func(event cacheEvent) []string{
+	// a missing user is cached as well
+	accountCreated: func(event cacheEvent) []string {
+		return []string{
+			userCacheKey(event.userId),
+			balanceCacheKey(event.userId),
+			favoriteCacheKey(event.userId),
+			userHistoryCacheKey(event.userId),
+			userStockHistoryCacheKey(event.userId, "*"),
+			stockAmountCacheKey(event.userId, "*"),
+		}
+	},
+	accountDeleted: func(event cacheEvent) []string {
+		return []string{
+			userCacheKey(event.userId),
+			balanceCacheKey(event.userId),
+			favoriteCacheKey(event.userId),
+			userHistoryCacheKey(event.userId),
+			userStockHistoryCacheKey(event.userId, "*"),
+			stockAmountCacheKey(event.userId, "*"),
+		}
+	},
+	profileChanged: func(event cacheEvent) []string {
+		return []string{userCacheKey(event.userId)}
+	},
+	balanceChanged: func(event cacheEvent) []string {
+		return []string{balanceCacheKey(event.userId)}
+	},
+	favoriteChanged: func(event cacheEvent) []string {
+		return []string{favoriteCacheKey(event.userId)}
+	},
+	positionChanged: func(event cacheEvent) []string {
+		return []string{
+			balanceCacheKey(event.userId),
+			stockAmountCacheKey(event.userId, event.stockId),
+			userHistoryCacheKey(event.userId),
+			userStockHistoryCacheKey(event.userId, event.stockId),
+		}
+	},
+	stockListed: func(event cacheEvent) []string {
+		return []string{stockCollectionsCacheKey, top10StockCacheKey}
+	},
+	stockChanged: func(event cacheEvent) []string {
+		return []string{
+			stockCollectionCacheKey(event.stockId),
+			stockCollectionsCacheKey,
+			top10StockCacheKey,
+		}
+	},
+	// the amount of every holder is multiplied
+	stockSplit: func(event cacheEvent) []string {
+		return []string{
+			stockCollectionCacheKey(event.stockId),
+			stockCollectionsCacheKey,
+			top10StockCacheKey,
+			stockAmountCacheKey("*", event.stockId),
+		}
+	},
+}
+
+func publishCacheEvents(cache Cache, events ...cacheEvent) {
+	var keys []string
+	for _, event := range events {
+		keys = append(keys, cacheKeys(cache, event)...)
+	}
+
+	if len(keys) > 0 {
+		invalidateCache(cache, keys...)
+	}
+}
+
+func cacheKeys(cache Cache, event cacheEvent) []string {
+	dependencies, ok := cacheDependencies[event.kind]
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, key := range dependencies(event) {
+		if !strings.Contains(key, "*") {
+			keys = append(keys, key)
+			continue
+		}
+
+		matches, _ := cache.Keys(key)
+		keys = append(keys, matches...)
+	}
+
+	return keys
+}
@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"server/repository"
+	"server/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const staleCacheValue = "stale"
+
+// the keys get a stale value before a change, the change has to replace
+// every one of them
+func seedCache(t *testing.T, cache service.Cache, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		err := cache.Set(key, []byte(staleCacheValue), time.Minute)
+		assert.Empty(t, err)
+	}
+}
+
+func assertCacheInvalidated(t *testing.T, cache service.Cache, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		value, _ := cache.Get(key)
+		assert.NotEqual(t, staleCacheValue, string(value), key)
+	}
+}
+
+func TestCacheEvents(t *testing.T) {
+	t.Run("Create user account", func(t *testing.T) {
+		cache := newCache()
+		userId := primitive.NewObjectID().Hex()
+		stockId := primitive.NewObjectID().Hex()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Create", CreateAccount{UID: userId}).Return("Successfully created user account", nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+		keys := []string{
+			"user:" + userId,
+			"balance:" + userId,
+			"favorite:" + userId,
+			"userHistory:" + userId,
+			"userStockHistory:" + userId + ":" + stockId,
+			"stockAmount:" + userId + ":" + stockId,
+		}
+		seedCache(t, cache, keys...)
+
+		_, err := userService.CreateUserAccount(CreateAccount{UID: userId})
+
+		assert.Empty(t, err)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+
+	t.Run("Delete user account", func(t *testing.T) {
+		cache := newCache()
+		userId := primitive.NewObjectID().Hex()
+		stockId := primitive.NewObjectID().Hex()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("DeleteAccount", userId).Return("Successfully deleted user account", nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+		keys := []string{
+			"user:" + userId,
+			"balance:" + userId,
+			"favorite:" + userId,
+			"userHistory:" + userId,
+			"userStockHistory:" + userId + ":" + stockId,
+			"stockAmount:" + userId + ":" + stockId,
+		}
+		seedCache(t, cache, keys...)
+
+		_, err := userService.DeleteUserAccount(userId)
+
+		assert.Empty(t, err)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+
+	t.Run("Set and delete favorite stock", func(t *testing.T) {
+		cache := newCache()
+		userId := primitive.NewObjectID().Hex()
+		stockId := primitive.NewObjectID().Hex()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("SetFavorite", userId, stockId).Return("Successfully set favorite stock", nil)
+		userRepo.On("DeleteFavorite", userId, stockId).Return("Successfully deleted favorite stock", nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+
+		seedCache(t, cache, "favorite:"+userId)
+		_, err := userService.SetFavoriteStock(userId, stockId)
+		assert.Empty(t, err)
+		assertCacheInvalidated(t, cache, "favorite:"+userId)
+
+		seedCache(t, cache, "favorite:"+userId)
+		_, err = userService.DeleteFavoriteStock(userId, stockId)
+		assert.Empty(t, err)
+		assertCacheInvalidated(t, cache, "favorite:"+userId)
+	})
+
+	t.Run("Stock history is cached per stock", func(t *testing.T) {
+		cache := newCache()
+		userId := primitive.NewObjectID().Hex()
+		stockIds := []string{primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()}
+		userRepo := repository.NewUserRepositoryDBMock()
+		for _, stockId := range stockIds {
+			userRepo.On("GetUserStockHistory", userId, stockId, uint(0)).Return([]UserHistory{{StockId: stockId}}, nil)
+		}
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+
+		for _, stockId := range stockIds {
+			actual, err := userService.GetUserStockHistory(userId, stockId, 0)
+
+			assert.Empty(t, err)
+			assert.Equal(t, stockId, actual[0].StockId)
+		}
+	})
+}
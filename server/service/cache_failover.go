@@ -0,0 +1,246 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"server/errs"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redis is used while it answers, the in-process cache takes over when it
+// fails so the server keeps working on a single instance. the keys changed
+// in the meantime, and the keys of the patterns looked up to invalidate
+// them, are deleted from redis before it is used again, a value cached in
+// redis before the failure could be stale by then
+type failoverCache struct {
+	redis         redisCache
+	memory        *memoryCache
+	retryInterval time.Duration
+
+	mu            sync.Mutex
+	down          bool
+	dirty         map[string]bool
+	dirtyPatterns map[string]bool
+}
+
+func NewFailoverCache(redisClient *redis.Client, size int, retryInterval time.Duration) Cache {
+	return &failoverCache{
+		redis:         redisCache{redisClient},
+		memory:        newMemoryCache(size),
+		retryInterval: retryInterval,
+		dirty:         map[string]bool{},
+		dirtyPatterns: map[string]bool{},
+	}
+}
+
+func (c *failoverCache) Get(key string) ([]byte, error) {
+	if !c.isDown() {
+		value, err := c.redis.Get(key)
+		if !c.failed(err) {
+			return value, err
+		}
+	}
+
+	return c.memory.Get(key)
+}
+
+func (c *failoverCache) Set(key string, value []byte, ttl time.Duration) error {
+	if !c.isDown() {
+		err := c.redis.Set(key, value, ttl)
+		if !c.failed(err) {
+			return err
+		}
+	}
+
+	c.markDirty(key)
+	return c.memory.Set(key, value, ttl)
+}
+
+func (c *failoverCache) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	if !c.isDown() {
+		set, err := c.redis.SetNX(key, value, ttl)
+		if !c.failed(err) {
+			return set, err
+		}
+	}
+
+	c.markDirty(key)
+	return c.memory.SetNX(key, value, ttl)
+}
+
+func (c *failoverCache) GetSet(key string, value []byte) ([]byte, error) {
+	if !c.isDown() {
+		previous, err := c.redis.GetSet(key, value)
+		if !c.failed(err) {
+			return previous, err
+		}
+	}
+
+	c.markDirty(key)
+	return c.memory.GetSet(key, value)
+}
+
+func (c *failoverCache) Delete(keys ...string) error {
+	if !c.isDown() {
+		err := c.redis.Delete(keys...)
+		if !c.failed(err) {
+			return err
+		}
+	}
+
+	c.markDirty(keys...)
+	return c.memory.Delete(keys...)
+}
+
+// the keys of a pattern are looked up to be deleted, the memory cache only
+// has the keys set during the failure so the pattern is kept for redis
+func (c *failoverCache) Keys(pattern string) ([]string, error) {
+	if !c.isDown() {
+		keys, err := c.redis.Keys(pattern)
+		if !c.failed(err) {
+			return keys, err
+		}
+	}
+
+	c.markDirtyPattern(pattern)
+	return c.memory.Keys(pattern)
+}
+
+func (c *failoverCache) Publish(channel string, message []byte) error {
+	if !c.isDown() {
+		err := c.redis.Publish(channel, message)
+		if !c.failed(err) {
+			return err
+		}
+	}
+
+	return c.memory.Publish(channel, message)
+}
+
+// a message is only published to one of the caches, the subscriber gets
+// the messages of both
+func (c *failoverCache) Subscribe(channels ...string) (<-chan CacheMessage, func()) {
+	redisMessages, closeRedis := c.redis.Subscribe(channels...)
+	memoryMessages, closeMemory := c.memory.Subscribe(channels...)
+	messages := make(chan CacheMessage)
+
+	var wg sync.WaitGroup
+	for _, source := range []<-chan CacheMessage{redisMessages, memoryMessages} {
+		wg.Add(1)
+		go func(source <-chan CacheMessage) {
+			defer wg.Done()
+			for message := range source {
+				messages <- message
+			}
+		}(source)
+	}
+
+	go func() {
+		wg.Wait()
+		close(messages)
+	}()
+
+	return messages, func() {
+		closeRedis()
+		closeMemory()
+	}
+}
+
+func (c *failoverCache) isDown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.down
+}
+
+// a miss is an answer of redis, any other error means it is not reachable
+func (c *failoverCache) failed(err error) bool {
+	if err == nil || errors.Is(err, errs.ErrCacheMiss) {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.down {
+		log.Printf("cache: redis is down, using the in-process cache: %v", err)
+		// entries left from an earlier failure were not invalidated since
+		c.memory.flush()
+		c.down = true
+		go c.retry()
+	}
+
+	return true
+}
+
+func (c *failoverCache) markDirty(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.down {
+		return
+	}
+
+	for _, key := range keys {
+		c.dirty[key] = true
+	}
+}
+
+func (c *failoverCache) markDirtyPattern(pattern string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.down {
+		return
+	}
+
+	c.dirtyPatterns[pattern] = true
+}
+
+func (c *failoverCache) retry() {
+	ticker := time.NewTicker(c.retryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if c.recover() {
+			log.Println("cache: redis is back")
+			return
+		}
+	}
+}
+
+func (c *failoverCache) recover() bool {
+	if c.redis.ping() != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]string, 0, len(c.dirty))
+	for key := range c.dirty {
+		keys = append(keys, key)
+	}
+
+	for pattern := range c.dirtyPatterns {
+		matches, err := c.redis.Keys(pattern)
+		if err != nil {
+			return false
+		}
+
+		keys = append(keys, matches...)
+	}
+
+	if c.redis.Delete(keys...) != nil {
+		return false
+	}
+
+	c.dirty = map[string]bool{}
+	c.dirtyPatterns = map[string]bool{}
+	c.down = false
+
+	return true
+}
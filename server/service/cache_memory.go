@@ -0,0 +1,213 @@
+package service
+
+import (
+	"container/list"
+	"path"
+	"server/errs"
+	"sync"
+	"time"
+)
+
+// the least recently used entry is evicted once the cache is full, expired
+// entries are dropped when they are read
+type memoryCache struct {
+	mu          sync.Mutex
+	size        int
+	entries     map[string]*list.Element
+	order       *list.List
+	subscribers map[string]map[chan CacheMessage]bool
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// messages are dropped for a subscriber that is this far behind
+const memoryCacheBacklog = 64
+
+func NewMemoryCache(size int) Cache {
+	return newMemoryCache(size)
+}
+
+func newMemoryCache(size int) *memoryCache {
+	return &memoryCache{
+		size:        size,
+		entries:     map[string]*list.Element{},
+		order:       list.New(),
+		subscribers: map[string]map[chan CacheMessage]bool{},
+	}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.get(key)
+	if entry == nil {
+		return nil, errs.ErrCacheMiss
+	}
+
+	return entry.value, nil
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value, ttl)
+
+	return nil
+}
+
+func (c *memoryCache) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.get(key) != nil {
+		return false, nil
+	}
+
+	c.set(key, value, ttl)
+
+	return true, nil
+}
+
+func (c *memoryCache) GetSet(key string, value []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.get(key)
+	c.set(key, value, 0)
+	if entry == nil {
+		return nil, errs.ErrCacheMiss
+	}
+
+	return entry.value, nil
+}
+
+func (c *memoryCache) Delete(keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		c.remove(key)
+	}
+
+	return nil
+}
+
+func (c *memoryCache) Keys(pattern string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := []string{}
+	for key := range c.entries {
+		matched, err := path.Match(pattern, key)
+		if err != nil {
+			return []string{}, err
+		}
+
+		if matched && c.get(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (c *memoryCache) Publish(channel string, message []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for messages := range c.subscribers[channel] {
+		select {
+		case messages <- CacheMessage{Channel: channel, Payload: string(message)}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (c *memoryCache) Subscribe(channels ...string) (<-chan CacheMessage, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	messages := make(chan CacheMessage, memoryCacheBacklog)
+	for _, channel := range channels {
+		if c.subscribers[channel] == nil {
+			c.subscribers[channel] = map[chan CacheMessage]bool{}
+		}
+		c.subscribers[channel][messages] = true
+	}
+
+	var once sync.Once
+	return messages, func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			for _, channel := range channels {
+				delete(c.subscribers[channel], messages)
+			}
+			close(messages)
+		})
+	}
+}
+
+// every entry is dropped, the subscribers are kept
+func (c *memoryCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+func (c *memoryCache) get(key string) *memoryCacheEntry {
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(key)
+		return nil
+	}
+
+	c.order.MoveToFront(element)
+
+	return entry
+}
+
+func (c *memoryCache) set(key string, value []byte, ttl time.Duration) {
+	entry := &memoryCacheEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.remove(oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+func (c *memoryCache) remove(key string) {
+	element, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	c.order.Remove(element)
+	delete(c.entries, key)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"server/errs"
+	"server/model"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type redisCache struct {
+	redisClient *redis.Client
+}
+
+func NewRedisCache(redisClient *redis.Client) Cache {
+	return redisCache{redisClient}
+}
+
+func newRedisClient(redisConfig model.RedisConfig) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:     redisConfig.Addr,
+		Password: redisConfig.Password,
+		DB:       redisConfig.DB,
+	})
+}
+
+func (c redisCache) Get(key string) ([]byte, error) {
+	value, err := c.redisClient.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.ErrCacheMiss
+	}
+
+	return value, err
+}
+
+func (c redisCache) Set(key string, value []byte, ttl time.Duration) error {
+	return c.redisClient.Set(ctx, key, value, ttl).Err()
+}
+
+func (c redisCache) SetNX(key string, value []byte, ttl time.Duration) (bool, error) {
+	return c.redisClient.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (c redisCache) GetSet(key string, value []byte) ([]byte, error) {
+	previous, err := c.redisClient.GetSet(ctx, key, value).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errs.ErrCacheMiss
+	}
+
+	return previous, err
+}
+
+func (c redisCache) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return c.redisClient.Del(ctx, keys...).Err()
+}
+
+// SCAN instead of KEYS so a pattern does not block redis while the whole
+// keyspace is walked
+func (c redisCache) Keys(pattern string) ([]string, error) {
+	keys := []string{}
+	iter := c.redisClient.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	return keys, iter.Err()
+}
+
+func (c redisCache) Publish(channel string, message []byte) error {
+	return c.redisClient.Publish(ctx, channel, message).Err()
+}
+
+// the subscription reconnects by itself when redis comes back
+func (c redisCache) Subscribe(channels ...string) (<-chan CacheMessage, func()) {
+	pubsub := c.redisClient.Subscribe(ctx, channels...)
+	messages := make(chan CacheMessage)
+
+	go func() {
+		defer close(messages)
+		for msg := range pubsub.Channel() {
+			messages <- CacheMessage{Channel: msg.Channel, Payload: msg.Payload}
+		}
+	}()
+
+	return messages, func() { pubsub.Close() }
+}
+
+func (c redisCache) ping() error {
+	return c.redisClient.Ping(ctx).Err()
+}
@@ -0,0 +1,278 @@
+package service_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path"
+	"server/errs"
+	"server/service"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache(t *testing.T) {
+	t.Run("Get set and delete", func(t *testing.T) {
+		memoryCache := service.NewMemoryCache(10)
+
+		_, err := memoryCache.Get("key")
+		assert.ErrorIs(t, err, errs.ErrCacheMiss)
+
+		err = memoryCache.Set("key", []byte("value"), 0)
+		assert.Empty(t, err)
+		value, err := memoryCache.Get("key")
+		assert.Empty(t, err)
+		assert.Equal(t, "value", string(value))
+
+		set, err := memoryCache.SetNX("key", []byte("other"), 0)
+		assert.Empty(t, err)
+		assert.False(t, set)
+
+		previous, err := memoryCache.GetSet("key", []byte("next"))
+		assert.Empty(t, err)
+		assert.Equal(t, "value", string(previous))
+
+		err = memoryCache.Delete("key")
+		assert.Empty(t, err)
+		_, err = memoryCache.Get("key")
+		assert.ErrorIs(t, err, errs.ErrCacheMiss)
+	})
+
+	t.Run("Expire entry", func(t *testing.T) {
+		memoryCache := service.NewMemoryCache(10)
+		memoryCache.Set("key", []byte("value"), 10*time.Millisecond)
+
+		time.Sleep(20 * time.Millisecond)
+
+		_, err := memoryCache.Get("key")
+		assert.ErrorIs(t, err, errs.ErrCacheMiss)
+		set, _ := memoryCache.SetNX("key", []byte("value"), 0)
+		assert.True(t, set)
+	})
+
+	t.Run("Evict least recently used", func(t *testing.T) {
+		memoryCache := service.NewMemoryCache(2)
+		memoryCache.Set("a", []byte("a"), 0)
+		memoryCache.Set("b", []byte("b"), 0)
+		memoryCache.Get("a")
+		memoryCache.Set("c", []byte("c"), 0)
+
+		_, err := memoryCache.Get("b")
+		assert.ErrorIs(t, err, errs.ErrCacheMiss)
+		_, err = memoryCache.Get("a")
+		assert.Empty(t, err)
+		_, err = memoryCache.Get("c")
+		assert.Empty(t, err)
+	})
+
+	t.Run("Keys by pattern", func(t *testing.T) {
+		memoryCache := service.NewMemoryCache(10)
+		memoryCache.Set("stockAmount:user1:stock1", []byte("1"), 0)
+		memoryCache.Set("stockAmount:user2:stock1", []byte("1"), 0)
+		memoryCache.Set("stockAmount:user1:stock2", []byte("1"), 0)
+
+		keys, err := memoryCache.Keys("stockAmount:*:stock1")
+
+		assert.Empty(t, err)
+		assert.ElementsMatch(t, []string{"stockAmount:user1:stock1", "stockAmount:user2:stock1"}, keys)
+	})
+
+	t.Run("Publish to subscribers", func(t *testing.T) {
+		memoryCache := service.NewMemoryCache(10)
+		messages, unsubscribe := memoryCache.Subscribe("channel")
+
+		err := memoryCache.Publish("channel", []byte("message"))
+		assert.Empty(t, err)
+		memoryCache.Publish("other", []byte("message"))
+
+		assert.Equal(t, service.CacheMessage{Channel: "channel", Payload: "message"}, <-messages)
+		unsubscribe()
+		_, ok := <-messages
+		assert.False(t, ok)
+	})
+}
+
+func TestFailoverCache(t *testing.T) {
+	downClient := goredis.NewClient(&goredis.Options{Addr: "localhost:1"})
+
+	t.Run("Use memory while redis is down", func(t *testing.T) {
+		failoverCache := service.NewFailoverCache(downClient, 10, time.Hour)
+
+		err := failoverCache.Set("key", []byte("value"), 0)
+		assert.Empty(t, err)
+		value, err := failoverCache.Get("key")
+		assert.Empty(t, err)
+		assert.Equal(t, "value", string(value))
+
+		keys, err := failoverCache.Keys("*")
+		assert.Empty(t, err)
+		assert.Equal(t, []string{"key"}, keys)
+	})
+
+	t.Run("Publish to memory while redis is down", func(t *testing.T) {
+		failoverCache := service.NewFailoverCache(downClient, 10, time.Hour)
+		messages, unsubscribe := failoverCache.Subscribe("channel")
+		defer unsubscribe()
+
+		err := failoverCache.Publish("channel", []byte("message"))
+
+		assert.Empty(t, err)
+		assert.Equal(t, service.CacheMessage{Channel: "channel", Payload: "message"}, <-messages)
+	})
+}
+
+// answers the few commands the failover cache sends when redis is back
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func startFakeRedis(t *testing.T, addr string, values map[string]string) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeRedis{values: values}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		conn.Write([]byte(s.reply(args)))
+	}
+}
+
+func (s *fakeRedis) reply(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SCAN":
+		keys := []string{}
+		for key := range s.values {
+			if matched, _ := path.Match(args[3], key); matched {
+				keys = append(keys, fmt.Sprintf("$%d\r\n%s\r\n", len(key), key))
+			}
+		}
+
+		return fmt.Sprintf("*2\r\n$1\r\n0\r\n*%d\r\n%s", len(keys), strings.Join(keys, ""))
+	case "DEL":
+		for _, key := range args[1:] {
+			delete(s.values, key)
+		}
+
+		return fmt.Sprintf(":%d\r\n", len(args)-1)
+	case "CLIENT":
+		return "+OK\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func (s *fakeRedis) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.values[key]
+	return ok
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(line)[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+
+	return args, nil
+}
+
+func TestFailoverCacheRecover(t *testing.T) {
+	// the port is free until the fake redis listens on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	redisClient := goredis.NewClient(&goredis.Options{Addr: addr, MaxRetries: -1})
+	failoverCache := service.NewFailoverCache(redisClient, 10, 10*time.Millisecond)
+
+	_, err = failoverCache.Keys("stockAmount:*:stock1")
+	assert.Empty(t, err)
+	err = failoverCache.Delete("user:user1")
+	assert.Empty(t, err)
+
+	redis := startFakeRedis(t, addr, map[string]string{
+		"stockAmount:user1:stock1": "stale",
+		"stockAmount:user2:stock1": "stale",
+		"stockAmount:user1:stock2": "1",
+		"user:user1":               "stale",
+	})
+
+	assert.Eventually(t, func() bool {
+		return !redis.has("stockAmount:user1:stock1") &&
+			!redis.has("stockAmount:user2:stock1") &&
+			!redis.has("user:user1")
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, redis.has("stockAmount:user1:stock2"))
+}
+
+func TestNewCache(t *testing.T) {
+	memoryCache, err := service.NewCache(service.CacheConfig{Backend: "memory", Size: 10})
+	assert.Empty(t, err)
+	assert.NotNil(t, memoryCache)
+
+	_, err = service.NewCache(service.CacheConfig{Backend: "unknown"})
+	assert.ErrorIs(t, err, errs.ErrCache)
+}
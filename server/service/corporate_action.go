@@ -0,0 +1,14 @@
+package service
+
+import "server/model"
+
+type CorporateAction = model.CorporateAction
+type CorporateActionEvent = model.CorporateActionEvent
+type CorporateActionRequest = model.CorporateActionRequest
+
+type CorporateActionService interface {
+	CreateCorporateAction(string, CorporateActionRequest, string) (CorporateAction, error)
+	CancelCorporateAction(string, string) (string, error)
+	GetStockCorporateActions(string) ([]CorporateAction, error)
+	ApplyDueCorporateActions() ([]string, error)
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/util"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CorporateActionRepository = repository.CorporateActionRepository
+
+type corporateActionService struct {
+	corporateActionRepo CorporateActionRepository
+	stockRepo           StockRepository
+	userRepo            UserRepository
+	queuedOrderRepo     QueuedOrderRepository
+	ledgerRepo          LedgerRepository
+	cache               Cache
+}
+
+func NewCorporateActionService(
+	corporateActionRepo CorporateActionRepository,
+	stockRepo StockRepository,
+	userRepo UserRepository,
+	queuedOrderRepo QueuedOrderRepository,
+	ledgerRepo LedgerRepository,
+	cache Cache,
+) CorporateActionService {
+	return corporateActionService{corporateActionRepo, stockRepo, userRepo, queuedOrderRepo, ledgerRepo, cache}
+}
+
+// an action that is not in the future is applied right away, the others
+// wait for ApplyDueCorporateActions
+func (s corporateActionService) CreateCorporateAction(
+	stockId string,
+	request CorporateActionRequest,
+	adminId string,
+) (corporateAction CorporateAction, err error) {
+	err = checkCorporateAction(request)
+	if err != nil {
+		return CorporateAction{}, err
+	}
+
+	stock, err := s.stockRepo.GetStock(stockId)
+	if err != nil {
+		return CorporateAction{}, err
+	}
+
+	now := time.Now().Unix()
+	effectiveAt := request.EffectiveAt
+	if effectiveAt == 0 {
+		effectiveAt = now
+	}
+
+	corporateAction = CorporateAction{
+		ID:          primitive.NewObjectID(),
+		StockId:     stockId,
+		Type:        request.Type,
+		NewShares:   request.NewShares,
+		OldShares:   request.OldShares,
+		OldSign:     stock.Sign,
+		NewSign:     strings.TrimSpace(request.Sign),
+		EffectiveAt: effectiveAt,
+		Status:      model.CorporateActionScheduled,
+		History: []CorporateActionEvent{
+			{Status: model.CorporateActionScheduled, Timestamp: now, Actor: adminId},
+		},
+	}
+
+	_, err = s.corporateActionRepo.Create(corporateAction)
+	if err != nil {
+		return CorporateAction{}, err
+	}
+
+	if effectiveAt > now {
+		return corporateAction, nil
+	}
+
+	err = s.apply(corporateAction, adminId)
+	if err != nil {
+		return CorporateAction{}, err
+	}
+
+	return s.corporateActionRepo.Get(corporateAction.ID.Hex())
+}
+
+func (s corporateActionService) CancelCorporateAction(actionId string, adminId string) (message string, err error) {
+	_, err = s.corporateActionRepo.UpdateStatus(actionId, model.CorporateActionScheduled, CorporateActionEvent{
+		Status: model.CorporateActionCancelled,
+		Actor:  adminId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully cancelled corporate action", nil
+}
+
+func (s corporateActionService) GetStockCorporateActions(stockId string) (corporateActions []CorporateAction, err error) {
+	corporateActions, err = s.corporateActionRepo.GetStockActions(stockId)
+	if err != nil {
+		return []CorporateAction{}, err
+	}
+
+	return corporateActions, nil
+}
+
+// the applied action ids are returned, a failed action is logged and
+// the others go ahead
+func (s corporateActionService) ApplyDueCorporateActions() (actionIds []string, err error) {
+	corporateActions, err := s.corporateActionRepo.GetDueActions(time.Now().Unix())
+	if err != nil {
+		return []string{}, err
+	}
+
+	actionIds = []string{}
+	for _, corporateAction := range corporateActions {
+		err = s.apply(corporateAction, "system")
+		if errors.Is(err, errs.ErrCorporateActionStatus) {
+			continue
+		}
+		if err != nil {
+			log.Printf("corporate action %s: %v", corporateAction.ID.Hex(), err)
+			continue
+		}
+
+		actionIds = append(actionIds, corporateAction.ID.Hex())
+	}
+
+	return actionIds, nil
+}
+
+// the action is moved to applying first so it is applied only once at a
+// time, a failed action is tried again by the next run and every step skips
+// what the failed try already applied
+func (s corporateActionService) apply(corporateAction CorporateAction, actor string) error {
+	actionId := corporateAction.ID.Hex()
+	_, err := s.corporateActionRepo.UpdateStatus(actionId, corporateAction.Status, CorporateActionEvent{
+		Status: model.CorporateActionApplying,
+		Actor:  actor,
+	})
+	if err != nil {
+		return err
+	}
+
+	note, err := s.applyAction(corporateAction)
+	if err != nil {
+		s.corporateActionRepo.UpdateStatus(actionId, model.CorporateActionApplying, CorporateActionEvent{
+			Status: model.CorporateActionFailed,
+			Actor:  actor,
+			Note:   err.Error(),
+		})
+
+		return err
+	}
+
+	_, err = s.corporateActionRepo.UpdateStatus(actionId, model.CorporateActionApplying, CorporateActionEvent{
+		Status: model.CorporateActionApplied,
+		Actor:  actor,
+		Note:   note,
+	})
+	if err != nil {
+		return err
+	}
+
+	event := cacheEvent{kind: stockSplit, stockId: corporateAction.StockId}
+	if corporateAction.Type == model.CorporateActionSymbolChange {
+		event.kind = stockChanged
+	}
+	publishCacheEvents(s.cache, event)
+
+	return nil
+}
+
+func (s corporateActionService) applyAction(corporateAction CorporateAction) (string, error) {
+	stockId := corporateAction.StockId
+
+	if corporateAction.Type == model.CorporateActionSymbolChange {
+		_, err := s.stockRepo.EditSign(stockId, corporateAction.NewSign)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("sign %s to %s", corporateAction.OldSign, corporateAction.NewSign), nil
+	}
+
+	actionId := corporateAction.ID.Hex()
+	ratio := corporateAction.NewShares / corporateAction.OldShares
+	holders, err := s.userRepo.ApplySplit(stockId, actionId, ratio)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.stockRepo.ApplySplit(stockId, actionId, ratio)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.queuedOrderRepo.ApplySplit(stockId, actionId, ratio)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.payCashInLieu(stockId, actionId)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"%v for %v split of %d holders",
+		corporateAction.NewShares,
+		corporateAction.OldShares,
+		holders,
+	), nil
+}
+
+// a position left with a fraction of a share is rounded toward zero and the
+// fraction is traded with the market at the new price, unless the stock
+// trades fractional shares
+func (s corporateActionService) payCashInLieu(stockId string, actionId string) error {
+	tradingRule, err := s.stockRepo.GetTradingRule(stockId)
+	if err != nil {
+		return err
+	}
+
+	if tradingRule.FractionalShare {
+		return nil
+	}
+
+	positions, err := s.userRepo.GetStockPositions(stockId)
+	if err != nil {
+		return err
+	}
+
+	price, err := s.stockRepo.GetPrice(stockId)
+	if err != nil {
+		return err
+	}
+
+	currency, err := s.stockRepo.GetCurrency(stockId)
+	if err != nil {
+		return err
+	}
+
+	for userId, amount := range positions {
+		fraction := amount - util.WholeShares(amount)
+		if fraction == 0 {
+			continue
+		}
+
+		method := "SALE"
+		if fraction < 0 {
+			method = "BUY"
+		}
+
+		transaction, err := newCashTransaction(userId, currency, method, actionId, math.Abs(fraction)*price, 0)
+		if err != nil {
+			return err
+		}
+
+		_, err = journal(s.ledgerRepo, transaction, func() (string, error) {
+			_, err := s.userRepo.SettleFraction(userId, stockId, amount, price, currency)
+			return "", err
+		})
+		if err != nil {
+			return err
+		}
+
+		publishCacheEvents(s.cache, cacheEvent{kind: positionChanged, userId: userId, stockId: stockId})
+	}
+
+	return nil
+}
+
+func checkCorporateAction(request CorporateActionRequest) error {
+	switch request.Type {
+	case model.CorporateActionSymbolChange:
+		if len(strings.TrimSpace(request.Sign)) == 0 {
+			return errs.ErrSign
+		}
+	case model.CorporateActionSplit:
+		if request.OldShares <= 0 || request.NewShares <= request.OldShares {
+			return errs.ErrSplitRatio
+		}
+	case model.CorporateActionReverseSplit:
+		if request.NewShares <= 0 || request.NewShares >= request.OldShares {
+			return errs.ErrSplitRatio
+		}
+	default:
+		return errs.ErrCorporateAction
+	}
+
+	return nil
+}
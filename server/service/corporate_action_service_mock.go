@@ -0,0 +1,31 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type corporateActionServiceMock struct {
+	mock.Mock
+}
+
+func NewCorporateActionServiceMock() *corporateActionServiceMock {
+	return &corporateActionServiceMock{}
+}
+
+func (m *corporateActionServiceMock) CreateCorporateAction(stockId string, request CorporateActionRequest, adminId string) (CorporateAction, error) {
+	arge := m.Called(stockId, request, adminId)
+	return arge.Get(0).(CorporateAction), arge.Error(1)
+}
+
+func (m *corporateActionServiceMock) CancelCorporateAction(actionId string, adminId string) (string, error) {
+	arge := m.Called(actionId, adminId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *corporateActionServiceMock) GetStockCorporateActions(stockId string) ([]CorporateAction, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).([]CorporateAction), arge.Error(1)
+}
+
+func (m *corporateActionServiceMock) ApplyDueCorporateActions() ([]string, error) {
+	arge := m.Called()
+	return arge.Get(0).([]string), arge.Error(1)
+}
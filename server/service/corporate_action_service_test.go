@@ -0,0 +1,445 @@
+package service_test
+
+import (
+	"errors"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CorporateAction = model.CorporateAction
+type CorporateActionEvent = model.CorporateActionEvent
+type CorporateActionRequest = model.CorporateActionRequest
+
+const corporateActionStockId = "65c39a03dfb8060d99995934"
+
+func matchActionStatus(status string) interface{} {
+	return mock.MatchedBy(func(event CorporateActionEvent) bool {
+		return event.Status == status
+	})
+}
+
+func TestCreateCorporateAction(t *testing.T) {
+	stock := StockCollectionResponse{ID: corporateActionStockId, Sign: "AAA"}
+
+	t.Run("Apply split", func(t *testing.T) {
+		corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo.On("GetStock", corporateActionStockId).Return(stock, nil)
+		stockRepo.On("ApplySplit", corporateActionStockId, mock.Anything, float64(2)).Return("Successfully applied split", nil)
+		stockRepo.On("GetTradingRule", corporateActionStockId).Return(TradingRule{}, nil)
+		stockRepo.On("GetPrice", corporateActionStockId).Return(50, nil)
+		stockRepo.On("GetCurrency", corporateActionStockId).Return(model.BaseCurrency, nil)
+		userRepo.On("ApplySplit", corporateActionStockId, mock.Anything, float64(2)).Return(int64(3), nil)
+		userRepo.On("GetStockPositions", corporateActionStockId).Return(map[string]float64{"holder": 4}, nil)
+		queuedOrderRepo.On("ApplySplit", corporateActionStockId, mock.Anything, float64(2)).Return(int64(1), nil)
+		corporateActionRepo.On(
+			"Create",
+			mock.MatchedBy(func(corporateAction CorporateAction) bool {
+				return corporateAction.Type == model.CorporateActionSplit &&
+					corporateAction.OldSign == "AAA" &&
+					corporateAction.Status == model.CorporateActionScheduled &&
+					corporateAction.History[0].Actor == "admin"
+			}),
+		).Return("Successfully created corporate action", nil)
+		corporateActionRepo.On(
+			"UpdateStatus",
+			mock.Anything,
+			model.CorporateActionScheduled,
+			matchActionStatus(model.CorporateActionApplying),
+		).Return("Successfully updated corporate action status", nil)
+		corporateActionRepo.On(
+			"UpdateStatus",
+			mock.Anything,
+			model.CorporateActionApplying,
+			mock.MatchedBy(func(event CorporateActionEvent) bool {
+				return event.Status == model.CorporateActionApplied &&
+					event.Note == "2 for 1 split of 3 holders"
+			}),
+		).Return("Successfully updated corporate action status", nil)
+		corporateActionRepo.On("Get", mock.Anything).Return(CorporateAction{Status: model.CorporateActionApplied}, nil)
+		corporateActionService := service.NewCorporateActionService(corporateActionRepo, stockRepo, userRepo, queuedOrderRepo, ledgerRepo, newCache())
+
+		corporateAction, err := corporateActionService.CreateCorporateAction(
+			corporateActionStockId,
+			CorporateActionRequest{Type: model.CorporateActionSplit, NewShares: 2, OldShares: 1},
+			"admin",
+		)
+
+		assert.Empty(t, err)
+		assert.Equal(t, model.CorporateActionApplied, corporateAction.Status)
+		corporateActionRepo.AssertExpectations(t)
+		stockRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+		userRepo.AssertNotCalled(t, "SettleFraction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Apply reverse split with cash in lieu", func(t *testing.T) {
+		cache := newCache()
+		corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		stockRepo.On("GetStock", corporateActionStockId).Return(stock, nil)
+		stockRepo.On("ApplySplit", corporateActionStockId, mock.Anything, 0.1).Return("Successfully applied split", nil)
+		stockRepo.On("GetTradingRule", corporateActionStockId).Return(TradingRule{LotSize: 1}, nil)
+		stockRepo.On("GetPrice", corporateActionStockId).Return(20, nil)
+		stockRepo.On("GetCurrency", corporateActionStockId).Return(model.BaseCurrency, nil)
+		userRepo.On("ApplySplit", corporateActionStockId, mock.Anything, 0.1).Return(int64(3), nil)
+		userRepo.On("GetStockPositions", corporateActionStockId).Return(map[string]float64{
+			"long":  2.5,
+			"whole": 3,
+			"short": -1.5,
+		}, nil)
+		userRepo.On("SettleFraction", "long", corporateActionStockId, 2.5, float64(20), model.BaseCurrency).Return(0.5, nil)
+		userRepo.On("SettleFraction", "short", corporateActionStockId, -1.5, float64(20), model.BaseCurrency).Return(-0.5, nil)
+		queuedOrderRepo.On("ApplySplit", corporateActionStockId, mock.Anything, 0.1).Return(int64(0), nil)
+		ledgerRepo.On("Append", mock.MatchedBy(func(transaction LedgerTransaction) bool {
+			return transaction.UID == "long" &&
+				transaction.Method == "SALE" &&
+				transaction.Entries[0].Debit == 10
+		})).Return("Successfully appended ledger transaction", nil)
+		ledgerRepo.On("Append", mock.MatchedBy(func(transaction LedgerTransaction) bool {
+			return transaction.UID == "short" &&
+				transaction.Method == "BUY" &&
+				transaction.Entries[0].Debit == 10
+		})).Return("Successfully appended ledger transaction", nil)
+		corporateActionRepo.On("Create", mock.Anything).Return("Successfully created corporate action", nil)
+		corporateActionRepo.On("UpdateStatus", mock.Anything, mock.Anything, mock.Anything).Return("Successfully updated corporate action status", nil)
+		corporateActionRepo.On("Get", mock.Anything).Return(CorporateAction{Status: model.CorporateActionApplied}, nil)
+		corporateActionService := service.NewCorporateActionService(corporateActionRepo, stockRepo, userRepo, queuedOrderRepo, ledgerRepo, cache)
+
+		keys := []string{
+			"balance:long",
+			"stockAmount:long:" + corporateActionStockId,
+			"balance:short",
+		}
+		seedCache(t, cache, keys...)
+
+		_, err := corporateActionService.CreateCorporateAction(
+			corporateActionStockId,
+			CorporateActionRequest{Type: model.CorporateActionReverseSplit, NewShares: 1, OldShares: 10},
+			"admin",
+		)
+
+		assert.Empty(t, err)
+		userRepo.AssertExpectations(t)
+		ledgerRepo.AssertExpectations(t)
+		userRepo.AssertNotCalled(t, "SettleFraction", "whole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+
+	t.Run("Schedule reverse split", func(t *testing.T) {
+		effectiveAt := time.Now().Add(time.Hour).Unix()
+		corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo.On("GetStock", corporateActionStockId).Return(stock, nil)
+		corporateActionRepo.On("Create", mock.Anything).Return("Successfully created corporate action", nil)
+		corporateActionService := service.NewCorporateActionService(corporateActionRepo, stockRepo, userRepo, queuedOrderRepo, ledgerRepo, newCache())
+
+		corporateAction, err := corporateActionService.CreateCorporateAction(
+			corporateActionStockId,
+			CorporateActionRequest{
+				Type:        model.CorporateActionReverseSplit,
+				NewShares:   1,
+				OldShares:   10,
+				EffectiveAt: effectiveAt,
+			},
+			"admin",
+		)
+
+		assert.Empty(t, err)
+		assert.Equal(t, model.CorporateActionScheduled, corporateAction.Status)
+		assert.Equal(t, effectiveAt, corporateAction.EffectiveAt)
+		corporateActionRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Apply symbol change", func(t *testing.T) {
+		cache := newCache()
+		corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo.On("GetStock", corporateActionStockId).Return(stock, nil)
+		stockRepo.On("EditSign", corporateActionStockId, "BBB").Return("Successfully updated sign", nil)
+		corporateActionRepo.On("Create", mock.Anything).Return("Successfully created corporate action", nil)
+		corporateActionRepo.On(
+			"UpdateStatus",
+			mock.Anything,
+			model.CorporateActionScheduled,
+			matchActionStatus(model.CorporateActionApplying),
+		).Return("Successfully updated corporate action status", nil)
+		corporateActionRepo.On(
+			"UpdateStatus",
+			mock.Anything,
+			model.CorporateActionApplying,
+			mock.MatchedBy(func(event CorporateActionEvent) bool {
+				return event.Status == model.CorporateActionApplied &&
+					event.Note == "sign AAA to BBB"
+			}),
+		).Return("Successfully updated corporate action status", nil)
+		corporateActionRepo.On("Get", mock.Anything).Return(CorporateAction{Status: model.CorporateActionApplied}, nil)
+		corporateActionService := service.NewCorporateActionService(corporateActionRepo, stockRepo, userRepo, queuedOrderRepo, ledgerRepo, cache)
+
+		keys := []string{
+			"stockCollection:" + corporateActionStockId,
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
+
+		_, err := corporateActionService.CreateCorporateAction(
+			corporateActionStockId,
+			CorporateActionRequest{Type: model.CorporateActionSymbolChange, Sign: "BBB"},
+			"admin",
+		)
+
+		assert.Empty(t, err)
+		corporateActionRepo.AssertExpectations(t)
+		stockRepo.AssertExpectations(t)
+		userRepo.AssertNotCalled(t, "ApplySplit", mock.Anything, mock.Anything, mock.Anything)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+
+	t.Run("Failed split", func(t *testing.T) {
+		errSplit := errors.New("split failed")
+		corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo.On("GetStock", corporateActionStockId).Return(stock, nil)
+		userRepo.On("ApplySplit", corporateActionStockId, mock.Anything, float64(2)).Return(int64(0), errSplit)
+		corporateActionRepo.On("Create", mock.Anything).Return("Successfully created corporate action", nil)
+		corporateActionRepo.On(
+			"UpdateStatus",
+			mock.Anything,
+			model.CorporateActionScheduled,
+			matchActionStatus(model.CorporateActionApplying),
+		).Return("Successfully updated corporate action status", nil)
+		corporateActionRepo.On(
+			"UpdateStatus",
+			mock.Anything,
+			model.CorporateActionApplying,
+			mock.MatchedBy(func(event CorporateActionEvent) bool {
+				return event.Status == model.CorporateActionFailed &&
+					event.Note == errSplit.Error()
+			}),
+		).Return("Successfully updated corporate action status", nil)
+		corporateActionService := service.NewCorporateActionService(corporateActionRepo, stockRepo, userRepo, queuedOrderRepo, ledgerRepo, newCache())
+
+		_, err := corporateActionService.CreateCorporateAction(
+			corporateActionStockId,
+			CorporateActionRequest{Type: model.CorporateActionSplit, NewShares: 2, OldShares: 1},
+			"admin",
+		)
+
+		assert.ErrorIs(t, err, errSplit)
+		corporateActionRepo.AssertExpectations(t)
+		stockRepo.AssertNotCalled(t, "ApplySplit", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	cases := []struct {
+		name     string
+		request  CorporateActionRequest
+		expected error
+	}{
+		{
+			"Error invalid type",
+			CorporateActionRequest{Type: "MERGER"},
+			errs.ErrCorporateAction,
+		},
+		{
+			"Error split ratio",
+			CorporateActionRequest{Type: model.CorporateActionSplit, NewShares: 1, OldShares: 2},
+			errs.ErrSplitRatio,
+		},
+		{
+			"Error reverse split ratio",
+			CorporateActionRequest{Type: model.CorporateActionReverseSplit, NewShares: 2, OldShares: 1},
+			errs.ErrSplitRatio,
+		},
+		{
+			"Error empty sign",
+			CorporateActionRequest{Type: model.CorporateActionSymbolChange, Sign: " "},
+			errs.ErrSign,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			corporateActionService := service.NewCorporateActionService(
+				repository.NewCorporateActionRepositoryDBMock(),
+				repository.NewStockRepositoryDBMock(),
+				repository.NewUserRepositoryDBMock(),
+				repository.NewQueuedOrderRepositoryDBMock(),
+				repository.NewLedgerRepositoryDBMock(),
+				newCache(),
+			)
+
+			_, err := corporateActionService.CreateCorporateAction(corporateActionStockId, c.request, "admin")
+
+			assert.ErrorIs(t, err, c.expected)
+		})
+	}
+}
+
+func TestCancelCorporateAction(t *testing.T) {
+	actionId := primitive.NewObjectID().Hex()
+
+	t.Run("Cancel corporate action", func(t *testing.T) {
+		corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+		corporateActionRepo.On(
+			"UpdateStatus",
+			actionId,
+			model.CorporateActionScheduled,
+			CorporateActionEvent{Status: model.CorporateActionCancelled, Actor: "admin"},
+		).Return("Successfully updated corporate action status", nil)
+		corporateActionService := service.NewCorporateActionService(corporateActionRepo, nil, nil, nil, nil, newCache())
+
+		message, err := corporateActionService.CancelCorporateAction(actionId, "admin")
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully cancelled corporate action", message)
+	})
+
+	t.Run("Error already applied", func(t *testing.T) {
+		corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+		corporateActionRepo.On(
+			"UpdateStatus",
+			actionId,
+			model.CorporateActionScheduled,
+			mock.Anything,
+		).Return("", errs.ErrCorporateActionStatus)
+		corporateActionService := service.NewCorporateActionService(corporateActionRepo, nil, nil, nil, nil, newCache())
+
+		_, err := corporateActionService.CancelCorporateAction(actionId, "admin")
+
+		assert.ErrorIs(t, err, errs.ErrCorporateActionStatus)
+	})
+}
+
+func TestApplyDueCorporateActions(t *testing.T) {
+	cache := newCache()
+	dueAction := CorporateAction{
+		ID:        primitive.NewObjectID(),
+		StockId:   corporateActionStockId,
+		Type:      model.CorporateActionSplit,
+		NewShares: 3,
+		OldShares: 2,
+		Status:    model.CorporateActionFailed,
+	}
+	takenAction := dueAction
+	takenAction.ID = primitive.NewObjectID()
+	takenAction.Status = model.CorporateActionScheduled
+	dueActionId := dueAction.ID.Hex()
+
+	corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+	stockRepo := repository.NewStockRepositoryDBMock()
+	userRepo := repository.NewUserRepositoryDBMock()
+	queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+	corporateActionRepo.On("GetDueActions", mock.Anything).Return([]CorporateAction{dueAction, takenAction}, nil)
+	corporateActionRepo.On(
+		"UpdateStatus",
+		takenAction.ID.Hex(),
+		model.CorporateActionScheduled,
+		mock.Anything,
+	).Return("", errs.ErrCorporateActionStatus)
+	corporateActionRepo.On(
+		"UpdateStatus",
+		dueActionId,
+		model.CorporateActionFailed,
+		matchActionStatus(model.CorporateActionApplying),
+	).Return("Successfully updated corporate action status", nil)
+	corporateActionRepo.On(
+		"UpdateStatus",
+		dueActionId,
+		model.CorporateActionApplying,
+		matchActionStatus(model.CorporateActionApplied),
+	).Return("Successfully updated corporate action status", nil)
+	userRepo.On("ApplySplit", corporateActionStockId, dueActionId, 1.5).Return(int64(1), nil).Once()
+	userRepo.On("GetStockPositions", corporateActionStockId).Return(map[string]float64{"holder": 3}, nil)
+	stockRepo.On("ApplySplit", corporateActionStockId, dueActionId, 1.5).Return("Successfully applied split", nil).Once()
+	stockRepo.On("GetTradingRule", corporateActionStockId).Return(TradingRule{}, nil)
+	stockRepo.On("GetPrice", corporateActionStockId).Return(20, nil)
+	stockRepo.On("GetCurrency", corporateActionStockId).Return(model.BaseCurrency, nil)
+	queuedOrderRepo.On("ApplySplit", corporateActionStockId, dueActionId, 1.5).Return(int64(0), nil).Once()
+	corporateActionService := service.NewCorporateActionService(corporateActionRepo, stockRepo, userRepo, queuedOrderRepo, ledgerRepo, cache)
+	keys := []string{
+		"stockCollection:" + corporateActionStockId,
+		"stockCollections",
+		"top10Stock",
+		"stockAmount:holder:" + corporateActionStockId,
+	}
+	seedCache(t, cache, keys...)
+
+	actionIds, err := corporateActionService.ApplyDueCorporateActions()
+
+	assert.Empty(t, err)
+	assert.Equal(t, []string{dueActionId}, actionIds)
+	corporateActionRepo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+	stockRepo.AssertExpectations(t)
+	queuedOrderRepo.AssertExpectations(t)
+	assertCacheInvalidated(t, cache, keys...)
+}
+
+// the previous close is split with the price so the first trade at the new
+// price stays inside the band and does not halt the stock
+func TestTradeAfterSplit(t *testing.T) {
+	stockId := primitive.NewObjectID()
+	userId := "holder"
+	stockRepo := repository.NewStockRepositoryMemory()
+	userRepo := repository.NewUserRepositoryMemory()
+	corporateActionRepo := repository.NewCorporateActionRepositoryDBMock()
+	queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+	stockRepo.CreateStock(StockCollection{
+		ID:         stockId,
+		StockImage: "image",
+		Name:       "AAA",
+		Sign:       "AAA",
+		Price:      100,
+	})
+	stockRepo.SetTradingRule(stockId.Hex(), TradingRule{LotSize: 1, LimitUp: 0.1, LimitDown: 0.1})
+	stockRepo.GetPreviousClose(stockId.Hex(), time.Now().Format(time.DateOnly))
+	userRepo.Create(model.CreateAccount{UID: userId, Name: "holder", ProfileImage: "image", Email: "holder@example.com"})
+	userRepo.Deposit(userId, "", 1000)
+	corporateActionRepo.On("Create", mock.Anything).Return("Successfully created corporate action", nil)
+	corporateActionRepo.On("UpdateStatus", mock.Anything, mock.Anything, mock.Anything).Return("Successfully updated corporate action status", nil)
+	corporateActionRepo.On("Get", mock.Anything).Return(CorporateAction{Status: model.CorporateActionApplied}, nil)
+	queuedOrderRepo.On("ApplySplit", stockId.Hex(), mock.Anything, float64(2)).Return(int64(0), nil)
+	corporateActionService := service.NewCorporateActionService(corporateActionRepo, stockRepo, userRepo, queuedOrderRepo, ledgerRepo, newCache())
+	userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+	_, err := corporateActionService.CreateCorporateAction(
+		stockId.Hex(),
+		CorporateActionRequest{Type: model.CorporateActionSplit, NewShares: 2, OldShares: 1},
+		"admin",
+	)
+	assert.Empty(t, err)
+
+	message, err := userService.BuyStock(OrderRequest{
+		StockId:     stockId.Hex(),
+		UserId:      userId,
+		Price:       50,
+		Amount:      2,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	})
+
+	assert.Empty(t, err)
+	assert.Equal(t, "Successfully bought stock", message)
+	status, _ := stockRepo.GetStatus(stockId.Hex())
+	assert.Equal(t, model.StockActive, status)
+}
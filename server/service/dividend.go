@@ -0,0 +1,15 @@
+package service
+
+import "server/model"
+
+type Dividend = model.Dividend
+type DividendHolder = model.DividendHolder
+type DividendRequest = model.DividendRequest
+
+type DividendService interface {
+	DeclareDividend(string, DividendRequest, string) (Dividend, error)
+	CancelDividend(string) (string, error)
+	GetStockDividends(string) ([]Dividend, error)
+	SetDividendReinvest(string, bool) (string, error)
+	ProcessDueDividends() ([]string, error)
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"math"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/util"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DividendRepository = repository.DividendRepository
+
+type dividendService struct {
+	dividendRepo DividendRepository
+	stockRepo    StockRepository
+	userRepo     UserRepository
+	ledgerRepo   LedgerRepository
+	userService  UserService
+	cache        Cache
+}
+
+func NewDividendService(
+	dividendRepo DividendRepository,
+	stockRepo StockRepository,
+	userRepo UserRepository,
+	ledgerRepo LedgerRepository,
+	userService UserService,
+	cache Cache,
+) DividendService {
+	return dividendService{dividendRepo, stockRepo, userRepo, ledgerRepo, userService, cache}
+}
+
+// the dividend is paid in the quote currency of the stock
+func (s dividendService) DeclareDividend(
+	stockId string,
+	request DividendRequest,
+	adminId string,
+) (dividend Dividend, err error) {
+	if request.AmountPerShare <= 0 {
+		return Dividend{}, errs.ErrDividend
+	}
+
+	if request.RecordDate <= 0 || request.PayDate < request.RecordDate {
+		return Dividend{}, errs.ErrDividendDate
+	}
+
+	currency, err := s.stockRepo.GetCurrency(stockId)
+	if err != nil {
+		return Dividend{}, err
+	}
+
+	dividend = Dividend{
+		ID:             primitive.NewObjectID(),
+		StockId:        stockId,
+		AmountPerShare: request.AmountPerShare,
+		Currency:       currency,
+		RecordDate:     request.RecordDate,
+		PayDate:        request.PayDate,
+		Status:         model.DividendDeclared,
+		DeclaredBy:     adminId,
+		Holders:        []DividendHolder{},
+	}
+
+	_, err = s.dividendRepo.Create(dividend)
+	if err != nil {
+		return Dividend{}, err
+	}
+
+	return dividend, nil
+}
+
+func (s dividendService) CancelDividend(dividendId string) (message string, err error) {
+	_, err = s.dividendRepo.UpdateStatus(dividendId, model.DividendDeclared, model.DividendCancelled)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully cancelled dividend", nil
+}
+
+func (s dividendService) GetStockDividends(stockId string) (dividends []Dividend, err error) {
+	dividends, err = s.dividendRepo.GetStockDividends(stockId)
+	if err != nil {
+		return []Dividend{}, err
+	}
+
+	return dividends, nil
+}
+
+func (s dividendService) SetDividendReinvest(userId string, enabled bool) (message string, err error) {
+	message, err = s.userRepo.SetDividendReinvest(userId, enabled)
+	if err != nil {
+		return "", err
+	}
+
+	return message, nil
+}
+
+// holders are recorded once the record date has passed and paid once the
+// pay date has passed, the paid dividend ids are returned
+func (s dividendService) ProcessDueDividends() (dividendIds []string, err error) {
+	now := time.Now().Unix()
+	dividends, err := s.dividendRepo.GetDueDividends(now)
+	if err != nil {
+		return []string{}, err
+	}
+
+	dividendIds = []string{}
+	for _, dividend := range dividends {
+		if dividend.Status == model.DividendDeclared {
+			dividend, err = s.record(dividend)
+			if err != nil {
+				log.Printf("dividend %s: %v", dividend.ID.Hex(), err)
+				continue
+			}
+		}
+
+		if dividend.PayDate > now {
+			continue
+		}
+
+		err = s.pay(dividend)
+		if err != nil {
+			log.Printf("dividend %s: %v", dividend.ID.Hex(), err)
+			continue
+		}
+
+		dividendIds = append(dividendIds, dividend.ID.Hex())
+	}
+
+	return dividendIds, nil
+}
+
+func (s dividendService) record(dividend Dividend) (Dividend, error) {
+	holders, err := s.userRepo.GetStockHolders(dividend.StockId)
+	if err != nil {
+		return dividend, err
+	}
+
+	recordedHolders := []DividendHolder{}
+	for _, holder := range holders {
+		holder.Cash = math.Round(holder.Amount*dividend.AmountPerShare*100) / 100
+		if holder.Cash <= 0 {
+			continue
+		}
+
+		recordedHolders = append(recordedHolders, holder)
+	}
+
+	_, err = s.dividendRepo.Record(dividend.ID.Hex(), recordedHolders)
+	if err != nil {
+		return dividend, err
+	}
+
+	dividend.Status = model.DividendRecorded
+	dividend.Holders = recordedHolders
+
+	return dividend, nil
+}
+
+// a holder is marked paid before the cash is credited so a holder is never
+// paid twice, the mark is taken back when the credit fails and the
+// dividend stays recorded for the next run
+func (s dividendService) pay(dividend Dividend) error {
+	dividendId := dividend.ID.Hex()
+
+	var payErr error
+	for _, holder := range dividend.Holders {
+		if holder.Paid {
+			continue
+		}
+
+		_, err := s.dividendRepo.SetHolderPaid(dividendId, holder.UID, true)
+		if errors.Is(err, errs.ErrDividendStatus) {
+			continue
+		}
+		if err != nil {
+			payErr = err
+			continue
+		}
+
+		err = s.payHolder(dividend, holder)
+		if err != nil {
+			s.dividendRepo.SetHolderPaid(dividendId, holder.UID, false)
+			payErr = err
+			continue
+		}
+
+		if holder.Reinvest {
+			err = s.reinvest(dividend, holder)
+			if err != nil {
+				log.Printf("dividend %s reinvest %s: %v", dividendId, holder.UID, err)
+			}
+		}
+	}
+
+	if payErr != nil {
+		return payErr
+	}
+
+	_, err := s.dividendRepo.UpdateStatus(dividendId, model.DividendRecorded, model.DividendPaid)
+
+	return err
+}
+
+func (s dividendService) payHolder(dividend Dividend, holder DividendHolder) error {
+	transaction, err := newCashTransaction(
+		holder.UID,
+		dividend.Currency,
+		"DIVIDEND",
+		dividend.ID.Hex(),
+		holder.Cash,
+		0,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = journal(s.ledgerRepo, transaction, func() (string, error) {
+		return s.userRepo.Credit(holder.UID, dividend.Currency, "DIVIDEND", holder.Cash)
+	})
+	if err != nil {
+		return err
+	}
+
+	publishCacheEvents(s.cache, cacheEvent{kind: balanceChanged, userId: holder.UID})
+
+	return nil
+}
+
+// the dividend less the fee of the order buys the stock at market price as
+// an auto order of the holder through the market session, the cash stays
+// in the balance when it is not enough for a lot
+func (s dividendService) reinvest(dividend Dividend, holder DividendHolder) error {
+	price, err := s.stockRepo.GetPrice(dividend.StockId)
+	if err != nil {
+		return err
+	}
+
+	tradingRule, err := s.stockRepo.GetTradingRule(dividend.StockId)
+	if err != nil {
+		return err
+	}
+
+	feeSummary, err := s.userService.GetUserFeeSummary(holder.UID)
+	if err != nil {
+		return err
+	}
+
+	feeSchedule := FeeSchedule{
+		Flat:      feeSummary.FlatFee,
+		Percent:   feeSummary.Percent,
+		MakerRate: feeSummary.MakerRate,
+		TakerRate: feeSummary.TakerRate,
+	}
+	cash := util.ValueAfterFee(feeSchedule, "auto", holder.Cash, feeSummary.Volume30Day)
+	amount := util.ReinvestAmount(tradingRule, cash, price)
+	if amount <= 0 {
+		return nil
+	}
+
+	_, err = s.userService.BuyStock(OrderRequest{
+		StockId:     dividend.StockId,
+		UserId:      holder.UID,
+		Price:       price,
+		Amount:      amount,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+		Currency:    dividend.Currency,
+	})
+
+	return err
+}
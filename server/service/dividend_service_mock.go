@@ -0,0 +1,36 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type dividendServiceMock struct {
+	mock.Mock
+}
+
+func NewDividendServiceMock() *dividendServiceMock {
+	return &dividendServiceMock{}
+}
+
+func (m *dividendServiceMock) DeclareDividend(stockId string, request DividendRequest, adminId string) (Dividend, error) {
+	arge := m.Called(stockId, request, adminId)
+	return arge.Get(0).(Dividend), arge.Error(1)
+}
+
+func (m *dividendServiceMock) CancelDividend(dividendId string) (string, error) {
+	arge := m.Called(dividendId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *dividendServiceMock) GetStockDividends(stockId string) ([]Dividend, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).([]Dividend), arge.Error(1)
+}
+
+func (m *dividendServiceMock) SetDividendReinvest(userId string, enabled bool) (string, error) {
+	arge := m.Called(userId, enabled)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *dividendServiceMock) ProcessDueDividends() ([]string, error) {
+	arge := m.Called()
+	return arge.Get(0).([]string), arge.Error(1)
+}
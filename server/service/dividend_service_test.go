@@ -0,0 +1,222 @@
+package service_test
+
+import (
+	"errors"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Dividend = model.Dividend
+type DividendHolder = model.DividendHolder
+type DividendRequest = model.DividendRequest
+
+const dividendStockId = "65c39a03dfb8060d99995934"
+
+func TestDeclareDividend(t *testing.T) {
+	now := time.Now().Unix()
+
+	t.Run("Declare dividend", func(t *testing.T) {
+		dividendRepo := repository.NewDividendRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetCurrency", dividendStockId).Return("USD", nil)
+		dividendRepo.On(
+			"Create",
+			mock.MatchedBy(func(dividend Dividend) bool {
+				return dividend.Currency == "USD" &&
+					dividend.Status == model.DividendDeclared &&
+					dividend.DeclaredBy == "admin"
+			}),
+		).Return("Successfully declared dividend", nil)
+		dividendService := service.NewDividendService(dividendRepo, stockRepo, nil, nil, nil, newCache())
+
+		dividend, err := dividendService.DeclareDividend(
+			dividendStockId,
+			DividendRequest{AmountPerShare: 0.5, RecordDate: now, PayDate: now + 3600},
+			"admin",
+		)
+
+		assert.Empty(t, err)
+		assert.Equal(t, 0.5, dividend.AmountPerShare)
+		dividendRepo.AssertExpectations(t)
+	})
+
+	cases := []struct {
+		name     string
+		request  DividendRequest
+		expected error
+	}{
+		{
+			"Error invalid amount",
+			DividendRequest{AmountPerShare: 0, RecordDate: now, PayDate: now},
+			errs.ErrDividend,
+		},
+		{
+			"Error pay before record",
+			DividendRequest{AmountPerShare: 1, RecordDate: now, PayDate: now - 1},
+			errs.ErrDividendDate,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dividendService := service.NewDividendService(
+				repository.NewDividendRepositoryDBMock(),
+				repository.NewStockRepositoryDBMock(),
+				nil,
+				nil,
+				nil,
+				newCache(),
+			)
+
+			_, err := dividendService.DeclareDividend(dividendStockId, c.request, "admin")
+
+			assert.ErrorIs(t, err, c.expected)
+		})
+	}
+}
+
+func TestCancelDividend(t *testing.T) {
+	dividendId := primitive.NewObjectID().Hex()
+	dividendRepo := repository.NewDividendRepositoryDBMock()
+	dividendRepo.On(
+		"UpdateStatus",
+		dividendId,
+		model.DividendDeclared,
+		model.DividendCancelled,
+	).Return("", errs.ErrDividendStatus)
+	dividendService := service.NewDividendService(dividendRepo, nil, nil, nil, nil, newCache())
+
+	_, err := dividendService.CancelDividend(dividendId)
+
+	assert.ErrorIs(t, err, errs.ErrDividendStatus)
+}
+
+func TestProcessDueDividends(t *testing.T) {
+	now := time.Now().Unix()
+
+	t.Run("Record and pay with reinvestment", func(t *testing.T) {
+		cache := newCache()
+		dividend := Dividend{
+			ID:             primitive.NewObjectID(),
+			StockId:        dividendStockId,
+			AmountPerShare: 1.5,
+			Currency:       model.BaseCurrency,
+			RecordDate:     now,
+			PayDate:        now,
+			Status:         model.DividendDeclared,
+		}
+		dividendId := dividend.ID.Hex()
+		dividendRepo := repository.NewDividendRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		dividendRepo.On("GetDueDividends", mock.Anything).Return([]Dividend{dividend}, nil)
+		userRepo.On("GetStockHolders", dividendStockId).Return([]DividendHolder{
+			{UID: "holder1", Amount: 10},
+			{UID: "holder2", Amount: 100, Reinvest: true},
+		}, nil)
+		dividendRepo.On("Record", dividendId, []DividendHolder{
+			{UID: "holder1", Amount: 10, Cash: 15},
+			{UID: "holder2", Amount: 100, Cash: 150, Reinvest: true},
+		}).Return("Successfully recorded dividend holders", nil)
+		dividendRepo.On("SetHolderPaid", dividendId, mock.Anything, true).Return("Successfully updated dividend holder", nil)
+		userRepo.On("Credit", "holder1", model.BaseCurrency, "DIVIDEND", float64(15)).Return("Successfully credited money", nil)
+		userRepo.On("Credit", "holder2", model.BaseCurrency, "DIVIDEND", float64(150)).Return("Successfully credited money", nil)
+		ledgerRepo.On("Append", mock.Anything).Return("Successfully appended ledger transaction", nil)
+		stockRepo.On("GetPrice", dividendStockId).Return(50, nil)
+		stockRepo.On("GetTradingRule", dividendStockId).Return(TradingRule{LotSize: 1}, nil)
+		// 150 buys 3 shares at 50 but not with the fee
+		userService.On("GetUserFeeSummary", "holder2").Return(model.FeeSummary{FlatFee: 1}, nil)
+		userService.On("BuyStock", OrderRequest{
+			StockId:     dividendStockId,
+			UserId:      "holder2",
+			Price:       50,
+			Amount:      2,
+			OrderType:   "auto",
+			OrderMethod: "buy",
+			Currency:    model.BaseCurrency,
+		}).Return("Successfully bought stock", nil)
+		dividendRepo.On(
+			"UpdateStatus",
+			dividendId,
+			model.DividendRecorded,
+			model.DividendPaid,
+		).Return("Successfully updated dividend status", nil)
+		dividendService := service.NewDividendService(dividendRepo, stockRepo, userRepo, ledgerRepo, userService, cache)
+
+		seedCache(t, cache, "balance:holder1", "balance:holder2")
+
+		dividendIds, err := dividendService.ProcessDueDividends()
+
+		assert.Empty(t, err)
+		assert.Equal(t, []string{dividendId}, dividendIds)
+		dividendRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+		userService.AssertExpectations(t)
+		assertCacheInvalidated(t, cache, "balance:holder1", "balance:holder2")
+	})
+
+	t.Run("Record before pay date", func(t *testing.T) {
+		dividend := Dividend{
+			ID:             primitive.NewObjectID(),
+			StockId:        dividendStockId,
+			AmountPerShare: 1,
+			RecordDate:     now,
+			PayDate:        now + 3600,
+			Status:         model.DividendDeclared,
+		}
+		dividendRepo := repository.NewDividendRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		dividendRepo.On("GetDueDividends", mock.Anything).Return([]Dividend{dividend}, nil)
+		userRepo.On("GetStockHolders", dividendStockId).Return([]DividendHolder{{UID: "holder1", Amount: 10}}, nil)
+		dividendRepo.On("Record", dividend.ID.Hex(), mock.Anything).Return("Successfully recorded dividend holders", nil)
+		dividendService := service.NewDividendService(dividendRepo, nil, userRepo, nil, nil, newCache())
+
+		dividendIds, err := dividendService.ProcessDueDividends()
+
+		assert.Empty(t, err)
+		assert.Empty(t, dividendIds)
+		dividendRepo.AssertNotCalled(t, "SetHolderPaid", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed credit is paid next run", func(t *testing.T) {
+		errCredit := errors.New("credit failed")
+		dividend := Dividend{
+			ID:             primitive.NewObjectID(),
+			StockId:        dividendStockId,
+			AmountPerShare: 1,
+			Currency:       model.BaseCurrency,
+			PayDate:        now,
+			Status:         model.DividendRecorded,
+			Holders: []DividendHolder{
+				{UID: "holder1", Amount: 10, Cash: 10, Paid: true},
+				{UID: "holder2", Amount: 10, Cash: 10},
+			},
+		}
+		dividendId := dividend.ID.Hex()
+		dividendRepo := repository.NewDividendRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		dividendRepo.On("GetDueDividends", mock.Anything).Return([]Dividend{dividend}, nil)
+		dividendRepo.On("SetHolderPaid", dividendId, "holder2", true).Return("Successfully updated dividend holder", nil)
+		dividendRepo.On("SetHolderPaid", dividendId, "holder2", false).Return("Successfully updated dividend holder", nil)
+		userRepo.On("Credit", "holder2", model.BaseCurrency, "DIVIDEND", float64(10)).Return("", errCredit)
+		dividendService := service.NewDividendService(dividendRepo, nil, userRepo, ledgerRepo, nil, newCache())
+
+		dividendIds, err := dividendService.ProcessDueDividends()
+
+		assert.Empty(t, err)
+		assert.Empty(t, dividendIds)
+		dividendRepo.AssertExpectations(t)
+		dividendRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+		userRepo.AssertNotCalled(t, "Credit", "holder1", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
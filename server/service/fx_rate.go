@@ -0,0 +1,9 @@
+package service
+
+import "server/model"
+
+type FxRates = model.FxRates
+
+type FxRateProvider interface {
+	GetRate(string, string) (float64, error)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// rates are read again when the file is modified, so they can be updated
+// without restarting the server
+type fileFxRateProvider struct {
+	path    string
+	mu      sync.RWMutex
+	modTime time.Time
+	rates   FxRates
+}
+
+func NewFileFxRateProvider(path string) (FxRateProvider, error) {
+	p := &fileFxRateProvider{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *fileFxRateProvider) GetRate(from string, to string) (float64, error) {
+	if err := p.reload(); err != nil {
+		return 0, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return convertRate(p.rates, from, to)
+}
+
+func (p *fileFxRateProvider) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("error reading fx rates: %v", err)
+	}
+
+	p.mu.RLock()
+	modified := !info.ModTime().Equal(p.modTime)
+	p.mu.RUnlock()
+	if !modified {
+		return nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("error reading fx rates: %v", err)
+	}
+
+	var rates FxRates
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return fmt.Errorf("error parsing fx rates: %v", err)
+	}
+
+	p.mu.Lock()
+	p.rates = rates
+	p.modTime = info.ModTime()
+	p.mu.Unlock()
+
+	return nil
+}
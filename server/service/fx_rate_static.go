@@ -0,0 +1,50 @@
+package service
+
+import (
+	"server/errs"
+	"server/model"
+)
+
+type staticFxRateProvider struct {
+	rates FxRates
+}
+
+func NewStaticFxRateProvider(rates FxRates) FxRateProvider {
+	return staticFxRateProvider{rates}
+}
+
+func (p staticFxRateProvider) GetRate(from string, to string) (float64, error) {
+	return convertRate(p.rates, from, to)
+}
+
+// amount in from * rate = amount in to
+func convertRate(rates FxRates, from string, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, err := baseRate(rates, from)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := baseRate(rates, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return fromRate / toRate, nil
+}
+
+func baseRate(rates FxRates, currency string) (float64, error) {
+	if currency == model.BaseCurrency {
+		return 1, nil
+	}
+
+	rate, ok := rates[currency]
+	if !ok || rate <= 0 {
+		return 0, errs.ErrFxRate
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,71 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"server/errs"
+	"server/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticFxRateProvider(t *testing.T) {
+	fxRateProvider := service.NewStaticFxRateProvider(service.FxRates{
+		"USD": 36,
+		"JPY": 0.24,
+	})
+
+	cases := []struct {
+		from     string
+		to       string
+		expected float64
+	}{
+		{"THB", "THB", 1},
+		{"USD", "THB", 36},
+		{"THB", "USD", 1.0 / 36},
+		{"USD", "JPY", 150},
+	}
+
+	for _, c := range cases {
+		actual, err := fxRateProvider.GetRate(c.from, c.to)
+
+		assert.Empty(t, err)
+		assert.InDelta(t, c.expected, actual, 1e-9)
+	}
+
+	_, err := fxRateProvider.GetRate("EUR", "THB")
+
+	assert.ErrorIs(t, err, errs.ErrFxRate)
+}
+
+func TestFileFxRateProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fx_rate.json")
+	os.WriteFile(path, []byte(`{"USD": 36}`), 0644)
+
+	fxRateProvider, err := service.NewFileFxRateProvider(path)
+	assert.Empty(t, err)
+
+	actual, err := fxRateProvider.GetRate("USD", "THB")
+
+	assert.Empty(t, err)
+	assert.Equal(t, float64(36), actual)
+
+	t.Run("Reload modified file", func(t *testing.T) {
+		os.WriteFile(path, []byte(`{"USD": 35}`), 0644)
+		modTime := time.Now().Add(time.Second)
+		os.Chtimes(path, modTime, modTime)
+
+		actual, err := fxRateProvider.GetRate("USD", "THB")
+
+		assert.Empty(t, err)
+		assert.Equal(t, float64(35), actual)
+	})
+
+	t.Run("Error missing file", func(t *testing.T) {
+		_, err := service.NewFileFxRateProvider(filepath.Join(t.TempDir(), "missing.json"))
+
+		assert.Error(t, err)
+	})
+}
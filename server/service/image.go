@@ -0,0 +1,84 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"server/errs"
+	"server/model"
+	"server/util"
+	"strconv"
+	"strings"
+)
+
+// the image is checked and stored as thumbnails under the hash of its
+// content, so the same image is stored once and a new image never
+// overwrites another. the returned key is kept in place of the image
+func storeImage(objectStore ObjectStore, prefix string, r io.Reader) (string, error) {
+	data, img, err := util.ReadImage(r)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	key := fmt.Sprintf("%s/%x", prefix, hash[:16])
+	for _, size := range model.ImageSizes {
+		thumbnail, err := util.EncodeThumbnail(util.Thumbnail(img, size))
+		if err != nil {
+			return "", err
+		}
+
+		err = objectStore.Put(thumbnailKey(key, size), bytes.NewReader(thumbnail), "image/png")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return key, nil
+}
+
+// url of every thumbnail by size, an image stored before thumbnails were
+// made is only available as the original
+func imageURLs(objectStore ObjectStore, key string) map[string]string {
+	if len(key) == 0 {
+		return nil
+	}
+
+	if !strings.Contains(key, "/") {
+		return map[string]string{"original": objectStore.URL(key)}
+	}
+
+	urls := map[string]string{}
+	for _, size := range model.ImageSizes {
+		urls[strconv.Itoa(size)] = objectStore.URL(thumbnailKey(key, size))
+	}
+
+	return urls
+}
+
+func thumbnailKey(key string, size int) string {
+	return fmt.Sprintf("%s/%d.png", key, size)
+}
+
+// every thumbnail of the image is deleted, thumbnails already gone are
+// skipped
+func deleteImage(objectStore ObjectStore, key string) error {
+	keys := []string{key}
+	if strings.Contains(key, "/") {
+		keys = []string{}
+		for _, size := range model.ImageSizes {
+			keys = append(keys, thumbnailKey(key, size))
+		}
+	}
+
+	for _, key := range keys {
+		err := objectStore.Delete(key)
+		if err != nil && !errors.Is(err, errs.ErrObjectNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+package service
+
+import "server/model"
+
+type LedgerTransaction = model.LedgerTransaction
+type Reconciliation = model.Reconciliation
+
+type LedgerService interface {
+	GetUserLedger(string, uint) ([]LedgerTransaction, error)
+	Reconcile() ([]Reconciliation, error)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"log"
+	"math"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/util"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LedgerRepository = repository.LedgerRepository
+type LedgerEntry = model.LedgerEntry
+
+type ledgerService struct {
+	ledgerRepo LedgerRepository
+	userRepo   UserRepository
+}
+
+func NewLedgerService(ledgerRepo LedgerRepository, userRepo UserRepository) LedgerService {
+	return ledgerService{ledgerRepo, userRepo}
+}
+
+func (s ledgerService) GetUserLedger(userId string, startPage uint) (transactions []LedgerTransaction, err error) {
+	transactions, err = s.ledgerRepo.GetTransactions(userId, startPage)
+	if err != nil {
+		return []LedgerTransaction{}, err
+	}
+
+	return transactions, nil
+}
+
+// recompute every user cash balance of every currency from the ledger and
+// report the balances that do not match
+func (s ledgerService) Reconcile() (mismatches []Reconciliation, err error) {
+	ledgerBalances, err := s.ledgerRepo.GetBalances()
+	if err != nil {
+		return []Reconciliation{}, err
+	}
+
+	userBalances, err := s.userRepo.GetBalances()
+	if err != nil {
+		return []Reconciliation{}, err
+	}
+
+	for account := range ledgerBalances {
+		if uid, currency, ok := util.UserFromCashAccount(account); ok {
+			if _, exist := userBalances[uid]; !exist {
+				userBalances[uid] = map[string]float64{}
+			}
+			if _, exist := userBalances[uid][currency]; !exist {
+				userBalances[uid][currency] = 0
+			}
+		}
+	}
+
+	mismatches = []Reconciliation{}
+	for uid, balances := range userBalances {
+		for currency, balance := range balances {
+			ledgerBalance := ledgerBalances[util.CurrencyAccount(util.UserCashAccount(uid), currency)]
+			difference := balance - ledgerBalance
+			if math.Abs(difference) < 1e-6 {
+				continue
+			}
+
+			mismatches = append(mismatches, Reconciliation{
+				UID:           uid,
+				Currency:      currency,
+				Balance:       balance,
+				LedgerBalance: ledgerBalance,
+				Difference:    difference,
+			})
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].UID != mismatches[j].UID {
+			return mismatches[i].UID < mismatches[j].UID
+		}
+
+		return mismatches[i].Currency < mismatches[j].Currency
+	})
+
+	return mismatches, nil
+}
+
+// the transaction is appended before the balance is changed, a change that
+// failed is reversed in the ledger so an error is only returned when no
+// money moved
+func journal(ledgerRepo LedgerRepository, transaction LedgerTransaction, change func() (string, error)) (string, error) {
+	_, err := ledgerRepo.Append(transaction)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := change()
+	if err != nil {
+		_, reverseErr := ledgerRepo.Append(reverseTransaction(transaction))
+		if reverseErr != nil {
+			log.Printf("ledger %s: reverse: %v", transaction.ID.Hex(), reverseErr)
+		}
+
+		return "", err
+	}
+
+	return message, nil
+}
+
+// every entry of the transaction the other way around, it references the
+// reversed transaction
+func reverseTransaction(transaction LedgerTransaction) LedgerTransaction {
+	entries := make([]LedgerEntry, len(transaction.Entries))
+	for i, entry := range transaction.Entries {
+		entries[i] = LedgerEntry{Account: entry.Account, Debit: entry.Credit, Credit: entry.Debit}
+	}
+
+	return LedgerTransaction{
+		ID:        primitive.NewObjectID(),
+		UID:       transaction.UID,
+		Timestamp: time.Now().Unix(),
+		Method:    "REVERSAL",
+		Reference: transaction.ID.Hex(),
+		Currency:  transaction.Currency,
+		Entries:   entries,
+	}
+}
+
+// cash moves from the debited account to the credited one, user cash
+// accounts are credited when the user receives money, a trading fee is
+// its own pair of entries from the user to the fee account
+func newCashTransaction(userId string, currency string, method string, reference string, amount float64, fee float64) (LedgerTransaction, error) {
+	userAccount := util.CurrencyAccount(util.UserCashAccount(userId), currency)
+	bankAccount := util.CurrencyAccount(model.LedgerBankAccount, currency)
+	marketAccount := util.CurrencyAccount(model.LedgerMarketAccount, currency)
+	feeAccount := util.CurrencyAccount(model.LedgerFeeAccount, currency)
+	issuerAccount := util.CurrencyAccount(model.LedgerIssuerAccount, currency)
+
+	var debitAccount, creditAccount string
+	switch method {
+	case "DEPOSIT":
+		debitAccount, creditAccount = bankAccount, userAccount
+	case "WITHDRAW":
+		debitAccount, creditAccount = userAccount, bankAccount
+	case "BUY":
+		debitAccount, creditAccount = userAccount, marketAccount
+	case "SALE":
+		debitAccount, creditAccount = marketAccount, userAccount
+	case "BORROW_FEE":
+		debitAccount, creditAccount = userAccount, feeAccount
+	case "DIVIDEND":
+		debitAccount, creditAccount = issuerAccount, userAccount
+	default:
+		return LedgerTransaction{}, errs.ErrLedgerMethod
+	}
+
+	entries := []LedgerEntry{
+		{Account: debitAccount, Debit: amount},
+		{Account: creditAccount, Credit: amount},
+	}
+	if fee > 0 {
+		entries = append(
+			entries,
+			LedgerEntry{Account: userAccount, Debit: fee},
+			LedgerEntry{Account: feeAccount, Credit: fee},
+		)
+	}
+
+	return LedgerTransaction{
+		ID:        primitive.NewObjectID(),
+		UID:       userId,
+		Timestamp: time.Now().Unix(),
+		Method:    method,
+		Reference: reference,
+		Currency:  currency,
+		Entries:   entries,
+	}, nil
+}
@@ -0,0 +1,21 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type ledgerServiceMock struct {
+	mock.Mock
+}
+
+func NewLedgerServiceMock() *ledgerServiceMock {
+	return &ledgerServiceMock{}
+}
+
+func (m *ledgerServiceMock) GetUserLedger(userId string, startPage uint) ([]LedgerTransaction, error) {
+	arge := m.Called(userId, startPage)
+	return arge.Get(0).([]LedgerTransaction), arge.Error(1)
+}
+
+func (m *ledgerServiceMock) Reconcile() ([]Reconciliation, error) {
+	arge := m.Called()
+	return arge.Get(0).([]Reconciliation), arge.Error(1)
+}
@@ -0,0 +1,106 @@
+package service_test
+
+import (
+	"server/model"
+	"server/repository"
+	"server/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Reconciliation = model.Reconciliation
+
+func TestGetUserLedger(t *testing.T) {
+	expected := []LedgerTransaction{{
+		UID:       "65c8993c48096b5150cee5d6",
+		Timestamp: 1,
+		Method:    "DEPOSIT",
+		Entries: []LedgerEntry{
+			{Account: model.LedgerBankAccount, Debit: 10},
+			{Account: "user:65c8993c48096b5150cee5d6:cash", Credit: 10},
+		},
+	}}
+
+	t.Run("Get user ledger", func(t *testing.T) {
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		ledgerRepo.On(
+			"GetTransactions",
+			"65c8993c48096b5150cee5d6",
+			uint(0),
+		).Return(expected, nil)
+		ledgerService := service.NewLedgerService(ledgerRepo, userRepo)
+
+		actual, err := ledgerService.GetUserLedger("65c8993c48096b5150cee5d6", 0)
+
+		assert.Empty(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Error invalid user", func(t *testing.T) {
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		ledgerRepo.On(
+			"GetTransactions",
+			"",
+			uint(0),
+		).Return([]LedgerTransaction{}, ErrUser)
+		ledgerService := service.NewLedgerService(ledgerRepo, userRepo)
+
+		_, err := ledgerService.GetUserLedger("", 0)
+
+		assert.ErrorIs(t, err, ErrUser)
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	t.Run("Balances match ledger", func(t *testing.T) {
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		ledgerRepo.On("GetBalances").Return(map[string]float64{
+			"user:a:cash":           100,
+			"user:a:cash:USD":       10,
+			model.LedgerBankAccount: -100,
+			"bank:USD":              -10,
+		}, nil)
+		userRepo.On("GetBalances").Return(map[string]map[string]float64{
+			"a": {model.BaseCurrency: 100, "USD": 10},
+			"b": {model.BaseCurrency: 0},
+		}, nil)
+		ledgerService := service.NewLedgerService(ledgerRepo, userRepo)
+
+		actual, err := ledgerService.Reconcile()
+
+		assert.Empty(t, err)
+		assert.Equal(t, []Reconciliation{}, actual)
+	})
+
+	t.Run("Report mismatches", func(t *testing.T) {
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		ledgerRepo.On("GetBalances").Return(map[string]float64{
+			"user:a:cash":           80,
+			"user:a:cash:USD":       5,
+			"user:c:cash":           20,
+			"user:c:cash:JPY":       300,
+			model.LedgerBankAccount: -100,
+			"bank:USD":              -5,
+			"bank:JPY":              -300,
+		}, nil)
+		userRepo.On("GetBalances").Return(map[string]map[string]float64{
+			"a": {model.BaseCurrency: 100, "USD": 10},
+			"b": {model.BaseCurrency: 50},
+		}, nil)
+		ledgerService := service.NewLedgerService(ledgerRepo, userRepo)
+
+		actual, err := ledgerService.Reconcile()
+
+		assert.Empty(t, err)
+		assert.Equal(t, []Reconciliation{
+			{UID: "a", Currency: "THB", Balance: 100, LedgerBalance: 80, Difference: 20},
+			{UID: "a", Currency: "USD", Balance: 10, LedgerBalance: 5, Difference: 5},
+			{UID: "b", Currency: "THB", Balance: 50, LedgerBalance: 0, Difference: 50},
+			{UID: "c", Currency: "JPY", Balance: 0, LedgerBalance: 300, Difference: -300},
+			{UID: "c", Currency: "THB", Balance: 0, LedgerBalance: 20, Difference: -20},
+		}, actual)
+	})
+}
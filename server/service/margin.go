@@ -0,0 +1,15 @@
+package service
+
+import "server/model"
+
+type MarginSummary = model.MarginSummary
+type MarginPosition = model.MarginPosition
+type MarginCall = model.MarginCall
+type UserAccount = model.UserAccount
+
+type MarginService interface {
+	GetMarginSummary(string) (MarginSummary, error)
+	SetMarginEnabled(string, bool) (string, error)
+	CheckMarginCalls() ([]string, error)
+	AccrueBorrowFees() ([]string, error)
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"server/errs"
+	"server/model"
+	"server/util"
+	"sort"
+	"time"
+)
+
+type marginService struct {
+	userRepo             UserRepository
+	stockRepo            StockRepository
+	ledgerRepo           LedgerRepository
+	marketSessionService MarketSessionService
+	fxRateProvider       FxRateProvider
+	callGrace            time.Duration
+	cache                Cache
+}
+
+func NewMarginService(
+	userRepo UserRepository,
+	stockRepo StockRepository,
+	ledgerRepo LedgerRepository,
+	marketSessionService MarketSessionService,
+	fxRateProvider FxRateProvider,
+	callGrace time.Duration,
+	cache Cache,
+) MarginService {
+	return marginService{userRepo, stockRepo, ledgerRepo, marketSessionService, fxRateProvider, callGrace, cache}
+}
+
+func (s marginService) GetMarginSummary(userId string) (marginSummary MarginSummary, err error) {
+	return getMarginSummary(s.userRepo, s.stockRepo, s.fxRateProvider, userId)
+}
+
+func (s marginService) SetMarginEnabled(userId string, enabled bool) (message string, err error) {
+	message, err = s.userRepo.SetMarginEnabled(userId, enabled)
+	if err != nil {
+		return "", err
+	}
+
+	return message, nil
+}
+
+// a margin call is issued when the equity falls below the maintenance
+// requirement, positions are sold largest first once the call is older
+// than the grace period, the checked user ids are returned
+func (s marginService) CheckMarginCalls() (userIds []string, err error) {
+	userIds, err = s.userRepo.GetMarginAccounts()
+	if err != nil {
+		return []string{}, err
+	}
+
+	for _, userId := range userIds {
+		err = s.checkMarginCall(userId)
+		if err != nil {
+			log.Printf("margin call %s: %v", userId, err)
+		}
+	}
+
+	return userIds, nil
+}
+
+// a day of borrow fee is charged on the value of every short position,
+// the charged user ids are returned
+func (s marginService) AccrueBorrowFees() (userIds []string, err error) {
+	marginUserIds, err := s.userRepo.GetMarginAccounts()
+	if err != nil {
+		return []string{}, err
+	}
+
+	userIds = []string{}
+	for _, userId := range marginUserIds {
+		charged, err := s.accrueBorrowFee(userId)
+		if err != nil {
+			log.Printf("borrow fee %s: %v", userId, err)
+			continue
+		}
+
+		if charged {
+			userIds = append(userIds, userId)
+		}
+	}
+
+	return userIds, nil
+}
+
+func (s marginService) accrueBorrowFee(userId string) (bool, error) {
+	marginSummary, err := s.GetMarginSummary(userId)
+	if err != nil {
+		return false, err
+	}
+
+	charged := false
+	for _, position := range marginSummary.Positions {
+		if position.Amount >= 0 {
+			continue
+		}
+
+		tradingRule, err := s.stockRepo.GetTradingRule(position.StockId)
+		if err != nil {
+			return charged, err
+		}
+
+		fee := math.Round(-position.Value*tradingRule.BorrowRate/365*100) / 100
+		if fee <= 0 {
+			continue
+		}
+
+		transaction, err := newCashTransaction(
+			userId,
+			model.BaseCurrency,
+			"BORROW_FEE",
+			position.StockId,
+			fee,
+			0,
+		)
+		if err != nil {
+			return charged, err
+		}
+
+		_, err = journal(s.ledgerRepo, transaction, func() (string, error) {
+			return s.userRepo.Charge(userId, "BORROW_FEE", fee)
+		})
+		if err != nil {
+			return charged, err
+		}
+
+		publishCacheEvents(s.cache, cacheEvent{kind: balanceChanged, userId: userId})
+
+		charged = true
+	}
+
+	return charged, nil
+}
+
+func (s marginService) checkMarginCall(userId string) error {
+	marginSummary, err := s.GetMarginSummary(userId)
+	if err != nil {
+		return err
+	}
+
+	marginCall := marginSummary.MarginCall
+	isCalled := marginCall != nil && marginCall.Status == model.MarginCallIssued
+
+	if marginSummary.Equity >= marginSummary.MaintenanceRequirement {
+		if !isCalled {
+			return nil
+		}
+
+		marginCall.Status = model.MarginCallMet
+		_, err = s.userRepo.SetMarginCall(userId, marginCall)
+
+		return err
+	}
+
+	if !isCalled {
+		marginCall = newMarginCall(marginSummary)
+		_, err = s.userRepo.SetMarginCall(userId, marginCall)
+
+		return err
+	}
+
+	if time.Since(time.Unix(marginCall.Timestamp, 0)) < s.callGrace {
+		return nil
+	}
+
+	marginSummary, err = s.liquidate(userId, marginSummary)
+	if err != nil {
+		return err
+	}
+
+	marginCall.Status = model.MarginCallLiquidated
+	marginCall.Equity = marginSummary.Equity
+	marginCall.MaintenanceRequirement = marginSummary.MaintenanceRequirement
+	marginCall.Deficit = marginDeficit(marginSummary)
+	_, err = s.userRepo.SetMarginCall(userId, marginCall)
+
+	return err
+}
+
+// positions are sold through the market session, a stock that does not
+// trade continuously stops the liquidation since a queued order would be
+// queued again by the next check, the call stays issued until then
+func (s marginService) liquidate(userId string, marginSummary MarginSummary) (MarginSummary, error) {
+	positions := append([]MarginPosition{}, marginSummary.Positions...)
+	sort.SliceStable(positions, func(i, j int) bool {
+		return math.Abs(positions[i].Value) > math.Abs(positions[j].Value)
+	})
+
+	for _, position := range positions {
+		if marginSummary.Equity >= marginSummary.MaintenanceRequirement {
+			break
+		}
+
+		marketSession, err := s.marketSessionService.GetMarketSession(position.StockId)
+		if err != nil {
+			return MarginSummary{}, err
+		}
+
+		if marketSession.Session != model.SessionContinuous {
+			return MarginSummary{}, fmt.Errorf("error liquidating %s: %w", position.StockId, errs.ErrMarketClosed)
+		}
+
+		price, err := s.stockRepo.GetPrice(position.StockId)
+		if err != nil {
+			return MarginSummary{}, err
+		}
+
+		// a short position is bought back
+		orderRequest := OrderRequest{
+			UserId:      userId,
+			StockId:     position.StockId,
+			Amount:      position.Amount,
+			Price:       price,
+			OrderType:   "auto",
+			OrderMethod: "sale",
+		}
+		if position.Amount < 0 {
+			orderRequest.Amount = -position.Amount
+			orderRequest.OrderMethod = "buy"
+		}
+
+		_, err = s.marketSessionService.SubmitOrder(orderRequest.OrderMethod, orderRequest)
+		if err != nil {
+			return MarginSummary{}, fmt.Errorf("error liquidating %s: %v", position.StockId, err)
+		}
+
+		marginSummary, err = s.GetMarginSummary(userId)
+		if err != nil {
+			return MarginSummary{}, err
+		}
+	}
+
+	return marginSummary, nil
+}
+
+func newMarginCall(marginSummary MarginSummary) *MarginCall {
+	return &MarginCall{
+		Timestamp:              time.Now().Unix(),
+		Equity:                 marginSummary.Equity,
+		MaintenanceRequirement: marginSummary.MaintenanceRequirement,
+		Deficit:                marginDeficit(marginSummary),
+		Status:                 model.MarginCallIssued,
+	}
+}
+
+func marginDeficit(marginSummary MarginSummary) float64 {
+	deficit := marginSummary.MaintenanceRequirement - marginSummary.Equity
+	if deficit < 0 {
+		return 0
+	}
+
+	return deficit
+}
+
+// every balance and position is valued in base currency, a stock that
+// is not marginable has an initial margin of 1 and adds no collateral,
+// requirements are on the absolute value so shorts add to them
+func getMarginSummary(
+	userRepo UserRepository,
+	stockRepo StockRepository,
+	fxRateProvider FxRateProvider,
+	userId string,
+) (MarginSummary, error) {
+	userAccount, err := userRepo.GetAccount(userId)
+	if err != nil {
+		return MarginSummary{}, err
+	}
+
+	cash, err := getAccountCash(fxRateProvider, userAccount)
+	if err != nil {
+		return MarginSummary{}, err
+	}
+
+	marginSummary := MarginSummary{
+		MarginEnabled: userAccount.MarginEnabled,
+		Cash:          cash,
+		Positions:     []MarginPosition{},
+		MarginCall:    userAccount.MarginCall,
+	}
+
+	for _, userStock := range userAccount.Stock {
+		if userStock.Amount == 0 {
+			continue
+		}
+
+		position, err := getMarginPosition(stockRepo, fxRateProvider, userStock)
+		if err != nil {
+			return MarginSummary{}, err
+		}
+
+		marginSummary.Positions = append(marginSummary.Positions, position)
+		marginSummary.MarketValue += position.Value
+		marginSummary.InitialRequirement += math.Abs(position.Value) * position.InitialMargin
+		marginSummary.MaintenanceRequirement += math.Abs(position.Value) * position.MaintenanceMargin
+	}
+
+	marginSummary.CollateralValue = marginSummary.MarketValue - marginSummary.InitialRequirement
+	marginSummary.Equity = marginSummary.Cash + marginSummary.MarketValue
+	marginSummary.BuyingPower = marginSummary.Cash
+	if marginSummary.MarginEnabled {
+		marginSummary.BuyingPower = marginSummary.Equity - marginSummary.InitialRequirement
+	}
+
+	if marginSummary.BuyingPower < 0 {
+		marginSummary.BuyingPower = 0
+	}
+
+	return marginSummary, nil
+}
+
+func getMarginPosition(
+	stockRepo StockRepository,
+	fxRateProvider FxRateProvider,
+	userStock UserStock,
+) (MarginPosition, error) {
+	price, _, fxRate, err := getStockQuote(stockRepo, fxRateProvider, userStock.StockId)
+	if err != nil {
+		return MarginPosition{}, err
+	}
+
+	tradingRule, err := stockRepo.GetTradingRule(userStock.StockId)
+	if err != nil {
+		return MarginPosition{}, err
+	}
+
+	initialMargin, maintenanceMargin := util.MarginRates(tradingRule)
+
+	return MarginPosition{
+		StockId:           userStock.StockId,
+		Amount:            userStock.Amount,
+		Value:             price * userStock.Amount * fxRate,
+		InitialMargin:     initialMargin,
+		MaintenanceMargin: maintenanceMargin,
+	}, nil
+}
+
+// the market price in the quote currency and the rate of the quote
+// currency to base currency
+func getStockQuote(
+	stockRepo StockRepository,
+	fxRateProvider FxRateProvider,
+	stockId string,
+) (float64, string, float64, error) {
+	price, err := stockRepo.GetPrice(stockId)
+	if err != nil {
+		return 0, "", 0, err
+	}
+
+	currency, err := stockRepo.GetCurrency(stockId)
+	if err != nil {
+		return 0, "", 0, err
+	}
+
+	fxRate, err := fxRateProvider.GetRate(currency, model.BaseCurrency)
+	if err != nil {
+		return 0, "", 0, err
+	}
+
+	return price, currency, fxRate, nil
+}
+
+// every balance converted to base currency
+func getAccountCash(fxRateProvider FxRateProvider, userAccount UserAccount) (float64, error) {
+	cash := userAccount.Balance
+	for currency, balance := range userAccount.Balances {
+		fxRate, err := fxRateProvider.GetRate(currency, model.BaseCurrency)
+		if err != nil {
+			return 0, err
+		}
+
+		cash += balance * fxRate
+	}
+
+	return cash, nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type marginServiceMock struct {
+	mock.Mock
+}
+
+func NewMarginServiceMock() *marginServiceMock {
+	return &marginServiceMock{}
+}
+
+func (m *marginServiceMock) GetMarginSummary(userId string) (MarginSummary, error) {
+	arge := m.Called(userId)
+	return arge.Get(0).(MarginSummary), arge.Error(1)
+}
+
+func (m *marginServiceMock) SetMarginEnabled(userId string, enabled bool) (string, error) {
+	arge := m.Called(userId, enabled)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *marginServiceMock) CheckMarginCalls() ([]string, error) {
+	arge := m.Called()
+	return arge.Get(0).([]string), arge.Error(1)
+}
+
+func (m *marginServiceMock) AccrueBorrowFees() ([]string, error) {
+	arge := m.Called()
+	return arge.Get(0).([]string), arge.Error(1)
+}
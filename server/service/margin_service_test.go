@@ -0,0 +1,644 @@
+package service_test
+
+import (
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MarginSummary = model.MarginSummary
+type MarginPosition = model.MarginPosition
+type MarginCall = model.MarginCall
+
+const marginStockId = "65c39a03dfb8060d99995934"
+const marginUserId = "65c8993c48096b5150cee5d6"
+
+var marginTradingRule = TradingRule{
+	InitialMargin:     0.5,
+	MaintenanceMargin: 0.25,
+	Shortable:         true,
+	BorrowRate:        0.0365,
+}
+
+func initMarginStockRepo() repository.StockRepository {
+	return initStockRepoWithRule(marginTradingRule)
+}
+
+func initStockRepoWithRule(tradingRule TradingRule) repository.StockRepository {
+	stockRepo := repository.NewStockRepositoryDBMock()
+	stockRepo.On("GetPrice", marginStockId).Return(100, nil)
+	stockRepo.On("GetCurrency", marginStockId).Return(model.BaseCurrency, nil)
+	stockRepo.On("GetStatus", marginStockId).Return(model.StockActive, nil)
+	stockRepo.On("GetTradingRule", marginStockId).Return(tradingRule, nil)
+	stockRepo.On(
+		"CreateStockOrder",
+		marginStockId,
+		mock.Anything,
+	).Return("Successfully created stock order", nil)
+
+	return stockRepo
+}
+
+func TestGetMarginSummary(t *testing.T) {
+	stockRepo := initMarginStockRepo()
+
+	t.Run("Margin enabled account", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:           marginUserId,
+			Balance:       1000,
+			Balances:      map[string]float64{"USD": 10},
+			Stock:         []UserStock{{StockId: marginStockId, Amount: 10}},
+			MarginEnabled: true,
+		}, nil)
+		marginService := service.NewMarginService(userRepo, stockRepo, ledgerRepo, nil, fxRateProvider, 0, newCache())
+
+		marginSummary, err := marginService.GetMarginSummary(marginUserId)
+
+		assert.Empty(t, err)
+		assert.Equal(t, MarginSummary{
+			MarginEnabled:          true,
+			Cash:                   1365,
+			MarketValue:            1000,
+			CollateralValue:        500,
+			Equity:                 2365,
+			InitialRequirement:     500,
+			MaintenanceRequirement: 250,
+			BuyingPower:            1865,
+			Positions: []MarginPosition{
+				{
+					StockId:           marginStockId,
+					Amount:            10,
+					Value:             1000,
+					InitialMargin:     0.5,
+					MaintenanceMargin: 0.25,
+				},
+			},
+		}, marginSummary)
+	})
+
+	t.Run("Cash account buying power", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:     marginUserId,
+			Balance: 1000,
+			Stock:   []UserStock{{StockId: marginStockId, Amount: 10}},
+		}, nil)
+		marginService := service.NewMarginService(userRepo, stockRepo, ledgerRepo, nil, fxRateProvider, 0, newCache())
+
+		marginSummary, err := marginService.GetMarginSummary(marginUserId)
+
+		assert.Empty(t, err)
+		assert.Equal(t, float64(1000), marginSummary.BuyingPower)
+	})
+}
+
+func TestBuyStockOnMargin(t *testing.T) {
+	orderRequest := OrderRequest{
+		StockId:     marginStockId,
+		UserId:      marginUserId,
+		Price:       100,
+		Amount:      10,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	}
+
+	marginOrder := orderRequest
+	marginOrder.Margin = true
+
+	t.Run("Buy on margin", func(t *testing.T) {
+		stockRepo := initMarginStockRepo()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Buy", matchOrder(orderRequest)).Return("", errs.ErrBalance)
+		userRepo.On("Buy", matchOrder(marginOrder)).Return("Successfully bought stock", nil)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:           marginUserId,
+			Balance:       500,
+			MarginEnabled: true,
+		}, nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		message, err := userService.BuyStock(orderRequest)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully bought stock", message)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error buying power not enough", func(t *testing.T) {
+		stockRepo := initMarginStockRepo()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Buy", matchOrder(orderRequest)).Return("", errs.ErrBalance)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:           marginUserId,
+			Balance:       400,
+			MarginEnabled: true,
+		}, nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.BuyStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrBuyingPower)
+		userRepo.AssertNotCalled(t, "Buy", matchOrder(marginOrder))
+	})
+
+	t.Run("Error margin call issued", func(t *testing.T) {
+		stockRepo := initMarginStockRepo()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Buy", matchOrder(orderRequest)).Return("", errs.ErrBalance)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:           marginUserId,
+			Balance:       5000,
+			MarginEnabled: true,
+			MarginCall:    &MarginCall{Status: model.MarginCallIssued},
+		}, nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.BuyStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrBuyingPower)
+	})
+
+	t.Run("Error cash account", func(t *testing.T) {
+		stockRepo := initMarginStockRepo()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Buy", matchOrder(orderRequest)).Return("", errs.ErrBalance)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:     marginUserId,
+			Balance: 500,
+		}, nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.BuyStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrBalance)
+	})
+}
+
+func TestCheckMarginCalls(t *testing.T) {
+	// cash -800, value 1000, equity 200 is below the requirement of 250
+	account := UserAccount{
+		UID:           marginUserId,
+		Balance:       -800,
+		Stock:         []UserStock{{StockId: marginStockId, Amount: 10}},
+		MarginEnabled: true,
+	}
+
+	t.Run("Issue margin call", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		marketSessionService := service.NewMarketSessionServiceMock()
+		userRepo.On("GetMarginAccounts").Return([]string{marginUserId}, nil)
+		userRepo.On("GetAccount", marginUserId).Return(account, nil)
+		userRepo.On(
+			"SetMarginCall",
+			marginUserId,
+			mock.MatchedBy(func(marginCall *MarginCall) bool {
+				return marginCall.Status == model.MarginCallIssued &&
+					marginCall.Equity == 200 &&
+					marginCall.Deficit == 50
+			}),
+		).Return("Successfully set margin call", nil)
+		marginService := service.NewMarginService(userRepo, initMarginStockRepo(), ledgerRepo, marketSessionService, fxRateProvider, time.Hour, newCache())
+
+		userIds, err := marginService.CheckMarginCalls()
+
+		assert.Empty(t, err)
+		assert.Equal(t, []string{marginUserId}, userIds)
+		userRepo.AssertExpectations(t)
+		marketSessionService.AssertNotCalled(t, "SubmitOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wait for grace period", func(t *testing.T) {
+		calledAccount := account
+		calledAccount.MarginCall = &MarginCall{
+			Timestamp: time.Now().Unix(),
+			Status:    model.MarginCallIssued,
+		}
+		userRepo := repository.NewUserRepositoryDBMock()
+		marketSessionService := service.NewMarketSessionServiceMock()
+		userRepo.On("GetMarginAccounts").Return([]string{marginUserId}, nil)
+		userRepo.On("GetAccount", marginUserId).Return(calledAccount, nil)
+		marginService := service.NewMarginService(userRepo, initMarginStockRepo(), ledgerRepo, marketSessionService, fxRateProvider, time.Hour, newCache())
+
+		_, err := marginService.CheckMarginCalls()
+
+		assert.Empty(t, err)
+		userRepo.AssertNotCalled(t, "SetMarginCall", mock.Anything, mock.Anything)
+		marketSessionService.AssertNotCalled(t, "SubmitOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Liquidate after grace period", func(t *testing.T) {
+		calledAccount := account
+		calledAccount.MarginCall = &MarginCall{
+			Timestamp: time.Now().Add(-2 * time.Hour).Unix(),
+			Status:    model.MarginCallIssued,
+		}
+		soldAccount := UserAccount{
+			UID:           marginUserId,
+			Balance:       200,
+			MarginEnabled: true,
+			MarginCall:    calledAccount.MarginCall,
+		}
+		userRepo := repository.NewUserRepositoryDBMock()
+		marketSessionService := service.NewMarketSessionServiceMock()
+		userRepo.On("GetMarginAccounts").Return([]string{marginUserId}, nil)
+		userRepo.On("GetAccount", marginUserId).Return(calledAccount, nil).Once()
+		userRepo.On("GetAccount", marginUserId).Return(soldAccount, nil)
+		userRepo.On(
+			"SetMarginCall",
+			marginUserId,
+			mock.MatchedBy(func(marginCall *MarginCall) bool {
+				return marginCall.Status == model.MarginCallLiquidated &&
+					marginCall.Equity == 200 &&
+					marginCall.Deficit == 0
+			}),
+		).Return("Successfully set margin call", nil)
+		marketSessionService.On("GetMarketSession", marginStockId).Return(MarketSession{Session: model.SessionContinuous}, nil)
+		marketSessionService.On("SubmitOrder", "sale", OrderRequest{
+			UserId:      marginUserId,
+			StockId:     marginStockId,
+			Amount:      10,
+			Price:       100,
+			OrderType:   "auto",
+			OrderMethod: "sale",
+		}).Return("Successfully sold stock", nil)
+		marginService := service.NewMarginService(userRepo, initMarginStockRepo(), ledgerRepo, marketSessionService, fxRateProvider, time.Hour, newCache())
+
+		_, err := marginService.CheckMarginCalls()
+
+		assert.Empty(t, err)
+		userRepo.AssertExpectations(t)
+		marketSessionService.AssertExpectations(t)
+	})
+
+	t.Run("Wait for market to liquidate", func(t *testing.T) {
+		calledAccount := account
+		calledAccount.MarginCall = &MarginCall{
+			Timestamp: time.Now().Add(-2 * time.Hour).Unix(),
+			Status:    model.MarginCallIssued,
+		}
+		userRepo := repository.NewUserRepositoryDBMock()
+		marketSessionService := service.NewMarketSessionServiceMock()
+		userRepo.On("GetMarginAccounts").Return([]string{marginUserId}, nil)
+		userRepo.On("GetAccount", marginUserId).Return(calledAccount, nil)
+		marketSessionService.On("GetMarketSession", marginStockId).Return(MarketSession{Session: model.SessionClosed}, nil)
+		marginService := service.NewMarginService(userRepo, initMarginStockRepo(), ledgerRepo, marketSessionService, fxRateProvider, time.Hour, newCache())
+
+		_, err := marginService.CheckMarginCalls()
+
+		assert.Empty(t, err)
+		userRepo.AssertNotCalled(t, "SetMarginCall", mock.Anything, mock.Anything)
+		marketSessionService.AssertNotCalled(t, "SubmitOrder", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Margin call met", func(t *testing.T) {
+		metAccount := account
+		metAccount.Balance = 0
+		metAccount.MarginCall = &MarginCall{
+			Timestamp: time.Now().Unix(),
+			Status:    model.MarginCallIssued,
+		}
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetMarginAccounts").Return([]string{marginUserId}, nil)
+		userRepo.On("GetAccount", marginUserId).Return(metAccount, nil)
+		userRepo.On(
+			"SetMarginCall",
+			marginUserId,
+			mock.MatchedBy(func(marginCall *MarginCall) bool {
+				return marginCall.Status == model.MarginCallMet
+			}),
+		).Return("Successfully set margin call", nil)
+		marginService := service.NewMarginService(userRepo, initMarginStockRepo(), ledgerRepo, service.NewMarketSessionServiceMock(), fxRateProvider, time.Hour, newCache())
+
+		_, err := marginService.CheckMarginCalls()
+
+		assert.Empty(t, err)
+		userRepo.AssertExpectations(t)
+	})
+}
+
+func TestSaleStockShort(t *testing.T) {
+	orderRequest := OrderRequest{
+		StockId:     marginStockId,
+		UserId:      marginUserId,
+		Price:       100,
+		Amount:      10,
+		OrderType:   "auto",
+		OrderMethod: "sale",
+	}
+	shortOrder := orderRequest
+	shortOrder.Short = true
+
+	t.Run("Sell short", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Sale", matchOrder(orderRequest)).Return("", errs.ErrNotEnoughStock)
+		userRepo.On("Sale", matchOrder(shortOrder)).Return("Successfully sold stock", nil)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:           marginUserId,
+			Balance:       500,
+			MarginEnabled: true,
+		}, nil)
+		userService := service.NewUserService(userRepo, initMarginStockRepo(), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		message, err := userService.SaleStock(orderRequest)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully sold stock", message)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Only the short part needs buying power", func(t *testing.T) {
+		// 6 held, 4 shorted needs 200 of the 250 buying power
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Sale", matchOrder(orderRequest)).Return("", errs.ErrNotEnoughStock)
+		userRepo.On("Sale", matchOrder(shortOrder)).Return("Successfully sold stock", nil)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:           marginUserId,
+			Balance:       -50,
+			Stock:         []UserStock{{StockId: marginStockId, Amount: 6}},
+			MarginEnabled: true,
+		}, nil)
+		userService := service.NewUserService(userRepo, initMarginStockRepo(), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.SaleStock(orderRequest)
+
+		assert.Empty(t, err)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error buying power not enough", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Sale", matchOrder(orderRequest)).Return("", errs.ErrNotEnoughStock)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:           marginUserId,
+			Balance:       400,
+			MarginEnabled: true,
+		}, nil)
+		userService := service.NewUserService(userRepo, initMarginStockRepo(), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.SaleStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrBuyingPower)
+	})
+
+	t.Run("Error stock not shortable", func(t *testing.T) {
+		tradingRule := marginTradingRule
+		tradingRule.Shortable = false
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Sale", matchOrder(orderRequest)).Return("", errs.ErrNotEnoughStock)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:           marginUserId,
+			Balance:       5000,
+			MarginEnabled: true,
+		}, nil)
+		userService := service.NewUserService(userRepo, initStockRepoWithRule(tradingRule), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.SaleStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrShort)
+	})
+
+	t.Run("Error cash account", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("Sale", matchOrder(orderRequest)).Return("", errs.ErrNotEnoughStock)
+		userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+			UID:     marginUserId,
+			Balance: 5000,
+		}, nil)
+		userService := service.NewUserService(userRepo, initMarginStockRepo(), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.SaleStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrNotEnoughStock)
+	})
+}
+
+func TestWithdrawBalanceMargin(t *testing.T) {
+	// 1000 of proceeds from shorting 10 at 100 need 500 of initial margin
+	shortAccount := UserAccount{
+		UID:           marginUserId,
+		Balance:       1500,
+		Stock:         []UserStock{{StockId: marginStockId, Amount: -10}},
+		MarginEnabled: true,
+	}
+
+	t.Run("Error withdraw the short proceeds", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", marginUserId).Return(shortAccount, nil)
+		userService := service.NewUserService(userRepo, initMarginStockRepo(), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.WithdrawBalance(marginUserId, model.BaseCurrency, 1000)
+
+		assert.ErrorIs(t, err, errs.ErrBuyingPower)
+		userRepo.AssertNotCalled(t, "Withdraw", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error withdraw after margin is turned off", func(t *testing.T) {
+		cashAccount := shortAccount
+		cashAccount.MarginEnabled = false
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", marginUserId).Return(cashAccount, nil)
+		userService := service.NewUserService(userRepo, initMarginStockRepo(), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.WithdrawBalance(marginUserId, model.BaseCurrency, 1000)
+
+		assert.ErrorIs(t, err, errs.ErrBuyingPower)
+	})
+
+	t.Run("Withdraw within buying power", func(t *testing.T) {
+		account := shortAccount
+		account.Balance = 2000
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", marginUserId).Return(account, nil)
+		userRepo.On("Withdraw", marginUserId, model.BaseCurrency, float64(500)).Return("Successfully withdrawed money", nil)
+		userService := service.NewUserService(userRepo, initMarginStockRepo(), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.WithdrawBalance(marginUserId, model.BaseCurrency, 500)
+
+		assert.Empty(t, err)
+		userRepo.AssertExpectations(t)
+	})
+}
+
+func TestBuyStockCoverShort(t *testing.T) {
+	orderRequest := OrderRequest{
+		StockId:     marginStockId,
+		UserId:      marginUserId,
+		Price:       100,
+		Amount:      10,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	}
+	marginOrder := orderRequest
+	marginOrder.Margin = true
+
+	// covering needs no buying power even while a margin call is issued
+	userRepo := repository.NewUserRepositoryDBMock()
+	userRepo.On("Buy", matchOrder(orderRequest)).Return("", errs.ErrBalance)
+	userRepo.On("Buy", matchOrder(marginOrder)).Return("Successfully bought stock", nil)
+	userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+		UID:           marginUserId,
+		Balance:       900,
+		Stock:         []UserStock{{StockId: marginStockId, Amount: -10}},
+		MarginEnabled: true,
+		MarginCall:    &MarginCall{Status: model.MarginCallIssued},
+	}, nil)
+	userService := service.NewUserService(userRepo, initMarginStockRepo(), ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+	_, err := userService.BuyStock(orderRequest)
+
+	assert.Empty(t, err)
+	userRepo.AssertExpectations(t)
+}
+
+func TestGetMarginSummaryShort(t *testing.T) {
+	userRepo := repository.NewUserRepositoryDBMock()
+	userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+		UID:           marginUserId,
+		Balance:       1500,
+		Stock:         []UserStock{{StockId: marginStockId, Amount: -10}},
+		MarginEnabled: true,
+	}, nil)
+	marginService := service.NewMarginService(userRepo, initMarginStockRepo(), ledgerRepo, nil, fxRateProvider, 0, newCache())
+
+	marginSummary, err := marginService.GetMarginSummary(marginUserId)
+
+	assert.Empty(t, err)
+	assert.Equal(t, float64(-1000), marginSummary.MarketValue)
+	assert.Equal(t, float64(500), marginSummary.Equity)
+	assert.Equal(t, float64(500), marginSummary.InitialRequirement)
+	assert.Equal(t, float64(250), marginSummary.MaintenanceRequirement)
+	assert.Equal(t, float64(0), marginSummary.BuyingPower)
+}
+
+func TestLiquidateShort(t *testing.T) {
+	// cash 1200, short value 1000, equity 200 is below the requirement of 250
+	userRepo := repository.NewUserRepositoryDBMock()
+	marketSessionService := service.NewMarketSessionServiceMock()
+	userRepo.On("GetMarginAccounts").Return([]string{marginUserId}, nil)
+	userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+		UID:           marginUserId,
+		Balance:       1200,
+		Stock:         []UserStock{{StockId: marginStockId, Amount: -10}},
+		MarginEnabled: true,
+		MarginCall: &MarginCall{
+			Timestamp: time.Now().Add(-2 * time.Hour).Unix(),
+			Status:    model.MarginCallIssued,
+		},
+	}, nil).Once()
+	userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+		UID:           marginUserId,
+		Balance:       200,
+		MarginEnabled: true,
+	}, nil)
+	userRepo.On("SetMarginCall", marginUserId, mock.Anything).Return("Successfully set margin call", nil)
+	marketSessionService.On("GetMarketSession", marginStockId).Return(MarketSession{Session: model.SessionContinuous}, nil)
+	marketSessionService.On("SubmitOrder", "buy", OrderRequest{
+		UserId:      marginUserId,
+		StockId:     marginStockId,
+		Amount:      10,
+		Price:       100,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	}).Return("Successfully bought stock", nil)
+	marginService := service.NewMarginService(userRepo, initMarginStockRepo(), ledgerRepo, marketSessionService, fxRateProvider, time.Hour, newCache())
+
+	_, err := marginService.CheckMarginCalls()
+
+	assert.Empty(t, err)
+	marketSessionService.AssertExpectations(t)
+}
+
+func TestAccrueBorrowFees(t *testing.T) {
+	cache := newCache()
+	// 1000 short value * 3.65% / 365
+	userRepo := repository.NewUserRepositoryDBMock()
+	ledgerRepo := repository.NewLedgerRepositoryDBMock()
+	userRepo.On("GetMarginAccounts").Return([]string{marginUserId}, nil)
+	userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+		UID:           marginUserId,
+		Balance:       1500,
+		Stock:         []UserStock{{StockId: marginStockId, Amount: -10}},
+		MarginEnabled: true,
+	}, nil)
+	userRepo.On("Charge", marginUserId, "BORROW_FEE", 0.1).Return("Successfully charged money", nil)
+	ledgerRepo.On(
+		"Append",
+		mock.MatchedBy(func(transaction LedgerTransaction) bool {
+			return transaction.Method == "BORROW_FEE" &&
+				transaction.Reference == marginStockId &&
+				assert.ObjectsAreEqual([]LedgerEntry{
+					{Account: "user:" + marginUserId + ":cash", Debit: 0.1},
+					{Account: model.LedgerFeeAccount, Credit: 0.1},
+				}, transaction.Entries)
+		}),
+	).Return("Successfully appended ledger transaction", nil)
+	marginService := service.NewMarginService(userRepo, initMarginStockRepo(), ledgerRepo, nil, fxRateProvider, 0, cache)
+	seedCache(t, cache, "balance:"+marginUserId)
+
+	userIds, err := marginService.AccrueBorrowFees()
+
+	assert.Empty(t, err)
+	assert.Equal(t, []string{marginUserId}, userIds)
+	userRepo.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
+	assertCacheInvalidated(t, cache, "balance:"+marginUserId)
+}
+
+func TestGetUserPortfolio(t *testing.T) {
+	usdStockId := "65c39a03dfb8060d99995935"
+	stockRepo := repository.NewStockRepositoryDBMock()
+	stockRepo.On("GetPrice", marginStockId).Return(90, nil)
+	stockRepo.On("GetCurrency", marginStockId).Return(model.BaseCurrency, nil)
+	stockRepo.On("GetPrice", usdStockId).Return(12, nil)
+	stockRepo.On("GetCurrency", usdStockId).Return("USD", nil)
+	userRepo := repository.NewUserRepositoryDBMock()
+	userRepo.On("GetAccount", marginUserId).Return(UserAccount{
+		UID:     marginUserId,
+		Balance: 2000,
+		Stock: []UserStock{
+			{StockId: marginStockId, Amount: -10, AveragePrice: 100},
+			{StockId: usdStockId, Amount: 2, AveragePrice: 10},
+		},
+	}, nil)
+	userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+	portfolio, err := userService.GetUserPortfolio(marginUserId)
+
+	assert.Empty(t, err)
+	assert.Equal(t, service.Portfolio{
+		Cash:          2000,
+		MarketValue:   -900 + 876,
+		CostBasis:     -1000 + 730,
+		UnrealizedPnl: 100 + 146,
+		Equity:        2000 - 900 + 876,
+		Positions: []service.PortfolioPosition{
+			{
+				StockId:       marginStockId,
+				Amount:        -10,
+				Currency:      model.BaseCurrency,
+				Price:         90,
+				AveragePrice:  100,
+				Value:         -900,
+				CostBasis:     -1000,
+				UnrealizedPnl: 100,
+			},
+			{
+				StockId:       usdStockId,
+				Amount:        2,
+				Currency:      "USD",
+				Price:         12,
+				AveragePrice:  10,
+				Value:         876,
+				CostBasis:     730,
+				UnrealizedPnl: 146,
+			},
+		},
+	}, portfolio)
+}
@@ -0,0 +1,19 @@
+package service
+
+import "server/model"
+
+type MarketCalendar = model.MarketCalendar
+type MarketSession = model.MarketSession
+type MarketSessionMessage = model.MarketSessionMessage
+type QueuedOrder = model.QueuedOrder
+type AuctionResult = model.AuctionResult
+
+type MarketSessionService interface {
+	GetMarketSession(string) (MarketSession, error)
+	SubmitOrder(string, OrderRequest) (string, error)
+	GetUserQueuedOrders(string) ([]QueuedOrder, error)
+	CancelQueuedOrder(string, string) (string, error)
+	ProcessQueuedOrders() ([]string, error)
+	PublishSessionChanges() ([]string, error)
+	RunCallAuction(string, string) (AuctionResult, error)
+}
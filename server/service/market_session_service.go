@@ -0,0 +1,443 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/util"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type QueuedOrderRepository = repository.QueuedOrderRepository
+
+type marketSessionService struct {
+	queuedOrderRepo QueuedOrderRepository
+	stockRepo       StockRepository
+	userService     UserService
+	marketCalendar  MarketCalendar
+	location        *time.Location
+	cache           Cache
+}
+
+// userService is the one orders are sent to in the continuous session, not
+// the one returned by NewSessionUserService
+func NewMarketSessionService(
+	queuedOrderRepo QueuedOrderRepository,
+	stockRepo StockRepository,
+	userService UserService,
+	marketCalendar MarketCalendar,
+	cache Cache,
+) MarketSessionService {
+	location := time.Local
+	if len(marketCalendar.Timezone) > 0 {
+		if marketLocation, err := time.LoadLocation(marketCalendar.Timezone); err == nil {
+			location = marketLocation
+		}
+	}
+
+	return marketSessionService{queuedOrderRepo, stockRepo, userService, marketCalendar, location, cache}
+}
+
+func (s marketSessionService) GetMarketSession(stockId string) (marketSession MarketSession, err error) {
+	if len(stockId) == 0 {
+		return MarketSession{}, errs.ErrInvalidStock
+	}
+
+	return MarketSession{
+		StockId:  stockId,
+		Session:  s.getSession(stockId),
+		OffHours: s.marketCalendar.OffHours,
+	}, nil
+}
+
+// an order is sent right away in the continuous session, outside of it the
+// order is checked against the trading rule and queued or rejected. orders
+// of the auction sessions are always queued in call auction mode
+func (s marketSessionService) SubmitOrder(orderMethod string, orderRequest OrderRequest) (message string, err error) {
+	session := s.getSession(orderRequest.StockId)
+	if session == model.SessionContinuous {
+		return s.sendOrder(orderMethod, orderRequest)
+	}
+
+	if s.marketCalendar.OffHours != model.OffHoursQueue && !s.isAuction(session) {
+		return "", errs.ErrMarketClosed
+	}
+
+	if len(orderRequest.UserId) == 0 {
+		return "", errs.ErrUser
+	}
+
+	tradingRule, err := s.stockRepo.GetTradingRule(orderRequest.StockId)
+	if err != nil {
+		return "", err
+	}
+
+	err = util.CheckTradingRule(tradingRule, orderRequest.Price, orderRequest.Amount)
+	if err != nil {
+		return "", err
+	}
+
+	return s.queuedOrderRepo.Create(QueuedOrder{
+		ID:          primitive.NewObjectID(),
+		UserId:      orderRequest.UserId,
+		StockId:     orderRequest.StockId,
+		Price:       orderRequest.Price,
+		Amount:      orderRequest.Amount,
+		OrderType:   orderRequest.OrderType,
+		OrderMethod: orderMethod,
+		MaxSlippage: orderRequest.MaxSlippage,
+		Currency:    orderRequest.Currency,
+		Status:      model.QueuedOrderQueued,
+	})
+}
+
+func (s marketSessionService) GetUserQueuedOrders(userId string) (queuedOrders []QueuedOrder, err error) {
+	queuedOrders, err = s.queuedOrderRepo.GetUserQueuedOrders(userId)
+	if err != nil {
+		return []QueuedOrder{}, err
+	}
+
+	return queuedOrders, nil
+}
+
+func (s marketSessionService) CancelQueuedOrder(userId string, orderId string) (message string, err error) {
+	message, err = s.queuedOrderRepo.Cancel(orderId, userId)
+	if err != nil {
+		return "", err
+	}
+
+	return message, nil
+}
+
+// queued orders of stocks in the continuous session are sent oldest first,
+// an order that is not filled is kept as failed with the reason
+func (s marketSessionService) ProcessQueuedOrders() (orderIds []string, err error) {
+	queuedOrders, err := s.queuedOrderRepo.GetQueuedOrders()
+	if err != nil {
+		return []string{}, err
+	}
+
+	orderIds = []string{}
+	sessions := map[string]string{}
+	var processErr error
+	for _, queuedOrder := range queuedOrders {
+		session, ok := sessions[queuedOrder.StockId]
+		if !ok {
+			session = s.getSession(queuedOrder.StockId)
+			sessions[queuedOrder.StockId] = session
+		}
+
+		if session != model.SessionContinuous {
+			continue
+		}
+
+		err = s.fill(queuedOrder)
+		if err != nil {
+			log.Printf("queued order %s: %v", queuedOrder.ID.Hex(), err)
+			processErr = err
+			continue
+		}
+
+		orderIds = append(orderIds, queuedOrder.ID.Hex())
+	}
+
+	return orderIds, processErr
+}
+
+// the last session of every listed stock is kept in the cache, a stock whose
+// session changed since is published to the websocket hub. the call auction
+// of the session that ended is uncrossed first and published with it
+func (s marketSessionService) PublishSessionChanges() (stockIds []string, err error) {
+	stocks, err := s.stockRepo.GetAllStocks()
+	if err != nil {
+		return []string{}, err
+	}
+
+	stockIds = []string{}
+	for _, stock := range stocks {
+		session := s.getSession(stock.ID)
+		sessionKey := fmt.Sprintf("marketSession:%s", stock.ID)
+
+		previousValue, err := s.cache.GetSet(sessionKey, []byte(session))
+		if err != nil && !errors.Is(err, errs.ErrCacheMiss) {
+			return stockIds, err
+		}
+
+		previous := string(previousValue)
+
+		if previous == session {
+			continue
+		}
+
+		sessionMessage := MarketSessionMessage{
+			StockId:   stock.ID,
+			Session:   session,
+			Previous:  previous,
+			Timestamp: time.Now().Unix(),
+		}
+
+		if s.isAuction(previous) {
+			auctionResult, err := s.RunCallAuction(stock.ID, previous)
+			if err != nil {
+				log.Printf("call auction %s: %v", stock.ID, err)
+			} else {
+				sessionMessage.Auction = &auctionResult
+			}
+		}
+
+		data, err := json.Marshal(sessionMessage)
+		if err != nil {
+			return stockIds, err
+		}
+
+		s.cache.Publish(model.MarketSessionChannel, data)
+		stockIds = append(stockIds, stock.ID)
+	}
+
+	return stockIds, nil
+}
+
+// the queued orders of the stock are uncrossed at the equilibrium price, it
+// becomes the price of the stock and the official open of the day for the
+// pre-open session or the official close for the closing session
+func (s marketSessionService) RunCallAuction(stockId string, session string) (auctionResult AuctionResult, err error) {
+	if !s.isAuction(session) {
+		return AuctionResult{}, errs.ErrMarketSession
+	}
+
+	queuedOrders, err := s.queuedOrderRepo.GetQueuedOrders()
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	stockOrders := []QueuedOrder{}
+	for _, queuedOrder := range queuedOrders {
+		if queuedOrder.StockId == stockId {
+			stockOrders = append(stockOrders, queuedOrder)
+		}
+	}
+
+	reference, err := s.stockRepo.GetPrice(stockId)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	auctionResult = AuctionResult{
+		StockId:   stockId,
+		Session:   session,
+		Timestamp: time.Now().Unix(),
+	}
+	auctionResult.Price, auctionResult.Volume = util.EquilibriumPrice(stockOrders, reference)
+	if auctionResult.Volume == 0 {
+		return auctionResult, nil
+	}
+
+	date := time.Now().In(s.location).Format(time.DateOnly)
+	if session == model.SessionPreOpen {
+		// the previous close is taken before the open moves the price
+		_, err = s.stockRepo.GetPreviousClose(stockId, date)
+		if err != nil {
+			return AuctionResult{}, err
+		}
+	}
+
+	err = checkPriceBand(s.stockRepo, s.cache, stockId, auctionResult.Price)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	_, err = s.stockRepo.SetPrice(stockId, auctionResult.Price)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	_, err = s.stockRepo.SetAuctionPrice(stockId, session, auctionResult.Price, date)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	tradingRule, err := s.stockRepo.GetTradingRule(stockId)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	var fillErr error
+	for _, auctionMatch := range util.MatchAuction(stockOrders, auctionResult.Price, auctionResult.Volume, tradingRule) {
+		err = s.fillMatch(auctionMatch, auctionResult.Price)
+		if err != nil {
+			log.Printf(
+				"call auction %s orders %s %s: %v",
+				stockId,
+				auctionMatch.Buy.ID.Hex(),
+				auctionMatch.Sale.ID.Hex(),
+				err,
+			)
+			fillErr = err
+			continue
+		}
+
+		auctionResult.Filled++
+	}
+
+	publishCacheEvents(s.cache, cacheEvent{kind: stockChanged, stockId: stockId})
+
+	return auctionResult, fillErr
+}
+
+// a matched buy and sale are one trade between the two users. the sale is
+// settled first, a buy that fails after it leaves the sale bought by the
+// market like an order of the continuous session
+func (s marketSessionService) fillMatch(auctionMatch util.AuctionMatch, price float64) error {
+	tradeId := primitive.NewObjectID().Hex()
+
+	saleRequest, err := s.takeAuctionOrder(auctionMatch.Sale, auctionMatch.Amount, price)
+	if err != nil {
+		return err
+	}
+
+	saleRequest.TradeId = tradeId
+	saleRequest.Counterparty = auctionMatch.Buy.UserId
+	_, err = s.sendOrder("sale", saleRequest)
+	s.releaseAuctionOrder(auctionMatch.Sale, auctionMatch.Amount, err)
+	if err != nil {
+		return err
+	}
+
+	buyRequest, err := s.takeAuctionOrder(auctionMatch.Buy, auctionMatch.Amount, price)
+	if err == nil {
+		buyRequest.TradeId = tradeId
+		buyRequest.Counterparty = auctionMatch.Sale.UserId
+		_, err = s.sendOrder("buy", buyRequest)
+		s.releaseAuctionOrder(auctionMatch.Buy, auctionMatch.Amount, err)
+	}
+	if err != nil {
+		recordTrade(s.stockRepo, saleRequest, model.MarketCounterparty, saleRequest.UserId)
+		return err
+	}
+
+	recordTrade(s.stockRepo, buyRequest, buyRequest.UserId, saleRequest.UserId)
+
+	return nil
+}
+
+// the match takes the rest of the order or else only reduces its amount
+// and the rest stays queued
+func (s marketSessionService) takeAuctionOrder(queuedOrder QueuedOrder, amount float64, price float64) (OrderRequest, error) {
+	orderId := queuedOrder.ID.Hex()
+
+	var err error
+	if amount < queuedOrder.Amount-1e-9 {
+		_, err = s.queuedOrderRepo.ReduceAmount(orderId, amount)
+	} else {
+		_, err = s.queuedOrderRepo.UpdateStatus(orderId, model.QueuedOrderQueued, model.QueuedOrderFilling, "")
+	}
+	if err != nil {
+		return OrderRequest{}, err
+	}
+
+	orderRequest := queuedOrderRequest(queuedOrder)
+	orderRequest.Price = price
+	orderRequest.Amount = amount
+
+	return orderRequest, nil
+}
+
+// a taken order is filled or failed, the amount taken from an order that
+// stays queued is given back when it could not be filled
+func (s marketSessionService) releaseAuctionOrder(queuedOrder QueuedOrder, amount float64, fillErr error) {
+	orderId := queuedOrder.ID.Hex()
+
+	var err error
+	switch {
+	case amount >= queuedOrder.Amount-1e-9 && fillErr != nil:
+		_, err = s.queuedOrderRepo.UpdateStatus(orderId, model.QueuedOrderFilling, model.QueuedOrderFailed, fillErr.Error())
+	case amount >= queuedOrder.Amount-1e-9:
+		_, err = s.queuedOrderRepo.UpdateStatus(orderId, model.QueuedOrderFilling, model.QueuedOrderFilled, "")
+	case fillErr != nil:
+		_, err = s.queuedOrderRepo.ReduceAmount(orderId, -amount)
+	}
+	if err != nil {
+		log.Printf("queued order %s: %v", orderId, err)
+	}
+}
+
+// the order is moved to filling first so it is sent only once
+func (s marketSessionService) fill(queuedOrder QueuedOrder) error {
+	orderId := queuedOrder.ID.Hex()
+	_, err := s.queuedOrderRepo.UpdateStatus(orderId, model.QueuedOrderQueued, model.QueuedOrderFilling, "")
+	if err != nil {
+		return err
+	}
+
+	status := model.QueuedOrderFilled
+	note := ""
+	_, err = s.sendOrder(queuedOrder.OrderMethod, queuedOrderRequest(queuedOrder))
+	if err != nil {
+		status = model.QueuedOrderFailed
+		note = err.Error()
+	}
+
+	_, err = s.queuedOrderRepo.UpdateStatus(orderId, model.QueuedOrderFilling, status, note)
+
+	return err
+}
+
+func (s marketSessionService) sendOrder(orderMethod string, orderRequest OrderRequest) (string, error) {
+	switch orderMethod {
+	case "buy":
+		return s.userService.BuyStock(orderRequest)
+	case "sale":
+		return s.userService.SaleStock(orderRequest)
+	default:
+		return "", errs.ErrOrderMethod
+	}
+}
+
+func (s marketSessionService) isAuction(session string) bool {
+	return s.marketCalendar.Auction &&
+		(session == model.SessionPreOpen || session == model.SessionClosing)
+}
+
+func (s marketSessionService) getSession(stockId string) string {
+	schedule := util.StockSchedule(s.marketCalendar, stockId)
+
+	return util.GetMarketSession(schedule, time.Now().In(s.location))
+}
+
+func queuedOrderRequest(queuedOrder QueuedOrder) OrderRequest {
+	return OrderRequest{
+		StockId:     queuedOrder.StockId,
+		UserId:      queuedOrder.UserId,
+		Price:       queuedOrder.Price,
+		Amount:      queuedOrder.Amount,
+		OrderType:   queuedOrder.OrderType,
+		OrderMethod: queuedOrder.OrderMethod,
+		MaxSlippage: queuedOrder.MaxSlippage,
+		Currency:    queuedOrder.Currency,
+	}
+}
+
+type sessionUserService struct {
+	UserService
+	marketSessionService MarketSessionService
+}
+
+// buy and sale go through the market session, the other methods are those
+// of userService
+func NewSessionUserService(userService UserService, marketSessionService MarketSessionService) UserService {
+	return sessionUserService{userService, marketSessionService}
+}
+
+func (s sessionUserService) BuyStock(orderRequest OrderRequest) (string, error) {
+	return s.marketSessionService.SubmitOrder("buy", orderRequest)
+}
+
+func (s sessionUserService) SaleStock(orderRequest OrderRequest) (string, error) {
+	return s.marketSessionService.SubmitOrder("sale", orderRequest)
+}
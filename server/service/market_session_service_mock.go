@@ -0,0 +1,46 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type marketSessionServiceMock struct {
+	mock.Mock
+}
+
+func NewMarketSessionServiceMock() *marketSessionServiceMock {
+	return &marketSessionServiceMock{}
+}
+
+func (m *marketSessionServiceMock) GetMarketSession(stockId string) (MarketSession, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).(MarketSession), arge.Error(1)
+}
+
+func (m *marketSessionServiceMock) SubmitOrder(orderMethod string, orderRequest OrderRequest) (string, error) {
+	arge := m.Called(orderMethod, orderRequest)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *marketSessionServiceMock) GetUserQueuedOrders(userId string) ([]QueuedOrder, error) {
+	arge := m.Called(userId)
+	return arge.Get(0).([]QueuedOrder), arge.Error(1)
+}
+
+func (m *marketSessionServiceMock) CancelQueuedOrder(userId string, orderId string) (string, error) {
+	arge := m.Called(userId, orderId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *marketSessionServiceMock) ProcessQueuedOrders() ([]string, error) {
+	arge := m.Called()
+	return arge.Get(0).([]string), arge.Error(1)
+}
+
+func (m *marketSessionServiceMock) PublishSessionChanges() ([]string, error) {
+	arge := m.Called()
+	return arge.Get(0).([]string), arge.Error(1)
+}
+
+func (m *marketSessionServiceMock) RunCallAuction(stockId string, session string) (AuctionResult, error) {
+	arge := m.Called(stockId, session)
+	return arge.Get(0).(AuctionResult), arge.Error(1)
+}
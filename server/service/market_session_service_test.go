@@ -0,0 +1,395 @@
+package service_test
+
+import (
+	"errors"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MarketCalendar = model.MarketCalendar
+type MarketSchedule = model.MarketSchedule
+type MarketSession = model.MarketSession
+type TradingSession = model.TradingSession
+type QueuedOrder = model.QueuedOrder
+
+const (
+	sessionStockId = "65c39a03dfb8060d99995938"
+	closedStockId  = "65c39a03dfb8060d99995939"
+)
+
+// the market is in pre-open all day and closedStockId is on holiday today
+func preOpenCalendar(offHours string) MarketCalendar {
+	return MarketCalendar{
+		OffHours: offHours,
+		MarketSchedule: MarketSchedule{
+			Sessions: []TradingSession{{Session: model.SessionPreOpen, Start: "00:00", End: "24:00"}},
+		},
+		Stocks: map[string]MarketSchedule{
+			closedStockId: {Holidays: []string{time.Now().Format(time.DateOnly)}},
+		},
+	}
+}
+
+func TestGetMarketSession(t *testing.T) {
+	cases := []struct {
+		name     string
+		calendar MarketCalendar
+		stockId  string
+		expected string
+	}{
+		{"Continuous without calendar", MarketCalendar{}, sessionStockId, model.SessionContinuous},
+		{"Pre-open", preOpenCalendar(model.OffHoursQueue), sessionStockId, model.SessionPreOpen},
+		{"Closed on stock holiday", preOpenCalendar(model.OffHoursQueue), closedStockId, model.SessionClosed},
+		{"Closed on weekday", MarketCalendar{
+			MarketSchedule: MarketSchedule{Weekdays: []time.Weekday{(time.Now().Weekday() + 1) % 7}},
+		}, sessionStockId, model.SessionClosed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			marketSessionService := service.NewMarketSessionService(nil, nil, nil, c.calendar, newCache())
+
+			actual, err := marketSessionService.GetMarketSession(c.stockId)
+
+			assert.Empty(t, err)
+			assert.Equal(t, c.expected, actual.Session)
+		})
+	}
+}
+
+func TestSubmitOrder(t *testing.T) {
+	orderRequest := OrderRequest{
+		StockId:   sessionStockId,
+		UserId:    "queued",
+		Price:     10,
+		Amount:    100,
+		OrderType: "order",
+	}
+
+	t.Run("Send order in continuous session", func(t *testing.T) {
+		userService := service.NewUserServiceMock()
+		userService.On("SaleStock", orderRequest).Return("Successfully sold stock", nil)
+		marketSessionService := service.NewMarketSessionService(nil, nil, userService, MarketCalendar{}, newCache())
+
+		message, err := marketSessionService.SubmitOrder("sale", orderRequest)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully sold stock", message)
+	})
+
+	t.Run("Queue order outside of continuous session", func(t *testing.T) {
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetTradingRule", sessionStockId).Return(TradingRule{LotSize: 100}, nil)
+		queuedOrderRepo.On("Create", mock.MatchedBy(func(queuedOrder QueuedOrder) bool {
+			return queuedOrder.UserId == "queued" &&
+				queuedOrder.OrderMethod == "buy" &&
+				queuedOrder.Amount == 100 &&
+				queuedOrder.Status == model.QueuedOrderQueued
+		})).Return("Successfully queued order", nil)
+		marketSessionService := service.NewMarketSessionService(
+			queuedOrderRepo,
+			stockRepo,
+			nil,
+			preOpenCalendar(model.OffHoursQueue),
+			newCache(),
+		)
+
+		message, err := marketSessionService.SubmitOrder("buy", orderRequest)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully queued order", message)
+	})
+
+	t.Run("Error trading rule", func(t *testing.T) {
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetTradingRule", sessionStockId).Return(TradingRule{LotSize: 30}, nil)
+		marketSessionService := service.NewMarketSessionService(
+			queuedOrderRepo,
+			stockRepo,
+			nil,
+			preOpenCalendar(model.OffHoursQueue),
+			newCache(),
+		)
+
+		_, err := marketSessionService.SubmitOrder("buy", orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrLotSize)
+		queuedOrderRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Queue order of call auction", func(t *testing.T) {
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetTradingRule", sessionStockId).Return(TradingRule{}, nil)
+		queuedOrderRepo.On("Create", mock.Anything).Return("Successfully queued order", nil)
+		calendar := preOpenCalendar(model.OffHoursReject)
+		calendar.Auction = true
+		marketSessionService := service.NewMarketSessionService(queuedOrderRepo, stockRepo, nil, calendar, newCache())
+
+		message, err := marketSessionService.SubmitOrder("buy", orderRequest)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully queued order", message)
+	})
+
+	t.Run("Error market closed", func(t *testing.T) {
+		marketSessionService := service.NewMarketSessionService(
+			nil,
+			nil,
+			nil,
+			preOpenCalendar(model.OffHoursReject),
+			newCache(),
+		)
+
+		_, err := marketSessionService.SubmitOrder("buy", orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrMarketClosed)
+	})
+}
+
+func TestProcessQueuedOrders(t *testing.T) {
+	filledOrder := QueuedOrder{
+		ID:          primitive.NewObjectID(),
+		UserId:      "queued",
+		StockId:     sessionStockId,
+		Price:       10,
+		Amount:      100,
+		OrderType:   "order",
+		OrderMethod: "buy",
+		Status:      model.QueuedOrderQueued,
+	}
+	failedOrder := filledOrder
+	failedOrder.ID = primitive.NewObjectID()
+	failedOrder.OrderMethod = "sale"
+	closedOrder := filledOrder
+	closedOrder.ID = primitive.NewObjectID()
+	closedOrder.StockId = closedStockId
+
+	calendar := MarketCalendar{
+		OffHours: model.OffHoursQueue,
+		Stocks:   preOpenCalendar(model.OffHoursQueue).Stocks,
+	}
+	errSale := errors.New("stock not enough")
+
+	queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+	userService := service.NewUserServiceMock()
+	queuedOrderRepo.On("GetQueuedOrders").Return([]QueuedOrder{filledOrder, failedOrder, closedOrder}, nil)
+	queuedOrderRepo.On(
+		"UpdateStatus",
+		mock.Anything,
+		model.QueuedOrderQueued,
+		model.QueuedOrderFilling,
+		"",
+	).Return("Successfully updated queued order status", nil)
+	queuedOrderRepo.On(
+		"UpdateStatus",
+		filledOrder.ID.Hex(),
+		model.QueuedOrderFilling,
+		model.QueuedOrderFilled,
+		"",
+	).Return("Successfully updated queued order status", nil)
+	queuedOrderRepo.On(
+		"UpdateStatus",
+		failedOrder.ID.Hex(),
+		model.QueuedOrderFilling,
+		model.QueuedOrderFailed,
+		errSale.Error(),
+	).Return("Successfully updated queued order status", nil)
+	userService.On("BuyStock", mock.MatchedBy(func(orderRequest OrderRequest) bool {
+		return orderRequest.UserId == "queued" && orderRequest.OrderMethod == "buy"
+	})).Return("Successfully bought stock", nil)
+	userService.On("SaleStock", mock.Anything).Return("", errSale)
+	marketSessionService := service.NewMarketSessionService(queuedOrderRepo, nil, userService, calendar, newCache())
+
+	orderIds, err := marketSessionService.ProcessQueuedOrders()
+
+	assert.Empty(t, err)
+	assert.Equal(t, []string{filledOrder.ID.Hex(), failedOrder.ID.Hex()}, orderIds)
+	queuedOrderRepo.AssertExpectations(t)
+	queuedOrderRepo.AssertNotCalled(t, "UpdateStatus", closedOrder.ID.Hex(), mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunCallAuction(t *testing.T) {
+	limitBuy := QueuedOrder{
+		ID:          primitive.NewObjectID(),
+		UserId:      "queued",
+		StockId:     sessionStockId,
+		Price:       11,
+		Amount:      100,
+		OrderType:   "order",
+		OrderMethod: "buy",
+		Status:      model.QueuedOrderQueued,
+		CreatedAt:   1,
+	}
+	autoBuy := limitBuy
+	autoBuy.ID = primitive.NewObjectID()
+	autoBuy.OrderType = "auto"
+	autoBuy.CreatedAt = 2
+	limitSale := limitBuy
+	limitSale.ID = primitive.NewObjectID()
+	limitSale.Price = 10
+	limitSale.UserId = "seller"
+	limitSale.Amount = 150
+	limitSale.OrderMethod = "sale"
+	otherOrder := limitSale
+	otherOrder.ID = primitive.NewObjectID()
+	otherOrder.StockId = closedStockId
+
+	calendar := preOpenCalendar(model.OffHoursReject)
+	calendar.Auction = true
+
+	t.Run("Error market session", func(t *testing.T) {
+		marketSessionService := service.NewMarketSessionService(nil, nil, nil, calendar, newCache())
+
+		_, err := marketSessionService.RunCallAuction(sessionStockId, model.SessionContinuous)
+
+		assert.ErrorIs(t, err, errs.ErrMarketSession)
+	})
+
+	t.Run("No volume", func(t *testing.T) {
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		queuedOrderRepo.On("GetQueuedOrders").Return([]QueuedOrder{limitBuy, otherOrder}, nil)
+		stockRepo.On("GetPrice", sessionStockId).Return(10, nil)
+		marketSessionService := service.NewMarketSessionService(queuedOrderRepo, stockRepo, nil, calendar, newCache())
+
+		actual, err := marketSessionService.RunCallAuction(sessionStockId, model.SessionPreOpen)
+
+		assert.Empty(t, err)
+		assert.Equal(t, 0.0, actual.Volume)
+		stockRepo.AssertNotCalled(t, "SetPrice", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Uncross opening auction", func(t *testing.T) {
+		cache := newCache()
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		queuedOrderRepo.On("GetQueuedOrders").Return([]QueuedOrder{limitBuy, autoBuy, limitSale, otherOrder}, nil)
+		stockRepo.On("GetPrice", sessionStockId).Return(10, nil)
+		stockRepo.On("GetPreviousClose", sessionStockId, mock.Anything).Return(9.0, nil)
+		stockRepo.On("GetTradingRule", sessionStockId).Return(TradingRule{}, nil)
+		stockRepo.On("SetPrice", sessionStockId, 10.0).Return("Successfully set price", nil)
+		stockRepo.On("SetAuctionPrice", sessionStockId, model.SessionPreOpen, 10.0, mock.Anything).
+			Return("Successfully set auction price", nil)
+		queuedOrderRepo.On("UpdateStatus", mock.Anything, mock.Anything, mock.Anything, "").
+			Return("Successfully updated queued order status", nil)
+		queuedOrderRepo.On("ReduceAmount", limitBuy.ID.Hex(), 50.0).Return("Successfully reduced queued order", nil)
+		queuedOrderRepo.On("ReduceAmount", limitSale.ID.Hex(), 100.0).Return("Successfully reduced queued order", nil)
+		userService.On("BuyStock", mock.MatchedBy(func(orderRequest OrderRequest) bool {
+			return orderRequest.Price == 10 &&
+				orderRequest.Counterparty == "seller" &&
+				(orderRequest.OrderType == "auto" && orderRequest.Amount == 100 ||
+					orderRequest.OrderType == "order" && orderRequest.Amount == 50)
+		})).Return("Successfully bought stock", nil)
+		userService.On("SaleStock", mock.MatchedBy(func(orderRequest OrderRequest) bool {
+			return orderRequest.Price == 10 &&
+				orderRequest.Counterparty == "queued" &&
+				(orderRequest.Amount == 100 || orderRequest.Amount == 50)
+		})).Return("Successfully sold stock", nil)
+		stockRepo.On("CreateStockOrder", sessionStockId, mock.MatchedBy(func(trade StockHistory) bool {
+			return trade.BuyerId == "queued" &&
+				trade.SellerId == "seller" &&
+				trade.Price == 10 &&
+				len(trade.TradeId) > 0
+		})).Return("Successfully created stock order", nil).Twice()
+		marketSessionService := service.NewMarketSessionService(queuedOrderRepo, stockRepo, userService, calendar, cache)
+
+		keys := []string{
+			"stockCollection:" + sessionStockId,
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
+
+		actual, err := marketSessionService.RunCallAuction(sessionStockId, model.SessionPreOpen)
+
+		assert.Empty(t, err)
+		assert.Equal(t, 10.0, actual.Price)
+		assert.Equal(t, 150.0, actual.Volume)
+		assert.Equal(t, 2, actual.Filled)
+		stockRepo.AssertExpectations(t)
+		userService.AssertNumberOfCalls(t, "BuyStock", 2)
+		userService.AssertNumberOfCalls(t, "SaleStock", 2)
+		queuedOrderRepo.AssertCalled(t, "UpdateStatus", autoBuy.ID.Hex(), model.QueuedOrderFilling, model.QueuedOrderFilled, "")
+		queuedOrderRepo.AssertCalled(t, "UpdateStatus", limitSale.ID.Hex(), model.QueuedOrderFilling, model.QueuedOrderFilled, "")
+		queuedOrderRepo.AssertNotCalled(t, "UpdateStatus", limitBuy.ID.Hex(), mock.Anything, mock.Anything, mock.Anything)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+
+	t.Run("Fill in whole lots", func(t *testing.T) {
+		lotBuy := limitBuy
+		lotBuy.Amount = 150
+		lotSale := limitSale
+		lotSale.Amount = 125
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		queuedOrderRepo.On("GetQueuedOrders").Return([]QueuedOrder{lotBuy, lotSale}, nil)
+		stockRepo.On("GetPrice", sessionStockId).Return(10, nil)
+		stockRepo.On("GetPreviousClose", sessionStockId, mock.Anything).Return(9.0, nil)
+		stockRepo.On("GetTradingRule", sessionStockId).Return(TradingRule{LotSize: 100}, nil)
+		stockRepo.On("SetPrice", sessionStockId, 10.0).Return("Successfully set price", nil)
+		stockRepo.On("SetAuctionPrice", sessionStockId, model.SessionPreOpen, 10.0, mock.Anything).
+			Return("Successfully set auction price", nil)
+		stockRepo.On("CreateStockOrder", sessionStockId, mock.Anything).Return("Successfully created stock order", nil)
+		queuedOrderRepo.On("ReduceAmount", mock.Anything, 100.0).Return("Successfully reduced queued order", nil)
+		userService.On("SaleStock", mock.Anything).Return("Successfully sold stock", nil)
+		userService.On("BuyStock", mock.Anything).Return("Successfully bought stock", nil)
+		marketSessionService := service.NewMarketSessionService(queuedOrderRepo, stockRepo, userService, calendar, newCache())
+
+		actual, err := marketSessionService.RunCallAuction(sessionStockId, model.SessionPreOpen)
+
+		assert.Empty(t, err)
+		assert.Equal(t, 1, actual.Filled)
+		userService.AssertCalled(t, "SaleStock", mock.MatchedBy(func(orderRequest OrderRequest) bool {
+			return orderRequest.Amount == 100
+		}))
+		userService.AssertCalled(t, "BuyStock", mock.MatchedBy(func(orderRequest OrderRequest) bool {
+			return orderRequest.Amount == 100
+		}))
+		queuedOrderRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Failed buy leaves the sale to the market", func(t *testing.T) {
+		errBuy := errors.New("buy failed")
+		queuedOrderRepo := repository.NewQueuedOrderRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		matchedSale := limitSale
+		matchedSale.Amount = 100
+		queuedOrderRepo.On("GetQueuedOrders").Return([]QueuedOrder{limitBuy, matchedSale}, nil)
+		stockRepo.On("GetPrice", sessionStockId).Return(10, nil)
+		stockRepo.On("GetPreviousClose", sessionStockId, mock.Anything).Return(9.0, nil)
+		stockRepo.On("GetTradingRule", sessionStockId).Return(TradingRule{}, nil)
+		stockRepo.On("SetPrice", sessionStockId, mock.Anything).Return("Successfully set price", nil)
+		stockRepo.On("SetAuctionPrice", sessionStockId, model.SessionPreOpen, mock.Anything, mock.Anything).
+			Return("Successfully set auction price", nil)
+		stockRepo.On("CreateStockOrder", sessionStockId, mock.MatchedBy(func(trade StockHistory) bool {
+			return trade.BuyerId == model.MarketCounterparty && trade.SellerId == "seller"
+		})).Return("Successfully created stock order", nil)
+		queuedOrderRepo.On("UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return("Successfully updated queued order status", nil)
+		userService.On("SaleStock", mock.Anything).Return("Successfully sold stock", nil)
+		userService.On("BuyStock", mock.Anything).Return("", errBuy)
+		marketSessionService := service.NewMarketSessionService(queuedOrderRepo, stockRepo, userService, calendar, newCache())
+
+		actual, err := marketSessionService.RunCallAuction(sessionStockId, model.SessionPreOpen)
+
+		assert.ErrorIs(t, err, errBuy)
+		assert.Equal(t, 0, actual.Filled)
+		stockRepo.AssertExpectations(t)
+		queuedOrderRepo.AssertCalled(t, "UpdateStatus", matchedSale.ID.Hex(), model.QueuedOrderFilling, model.QueuedOrderFilled, "")
+		queuedOrderRepo.AssertCalled(t, "UpdateStatus", limitBuy.ID.Hex(), model.QueuedOrderFilling, model.QueuedOrderFailed, errBuy.Error())
+	})
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"io"
+	"server/errs"
+	"server/model"
+	"strings"
+)
+
+type ObjectStoreConfig = model.ObjectStoreConfig
+
+type ObjectStore interface {
+	Put(string, io.Reader, string) error
+	Get(string) (io.ReadCloser, string, error)
+	Delete(string) error
+	URL(string) string
+}
+
+func NewObjectStore(objectStoreConfig ObjectStoreConfig) (ObjectStore, error) {
+	switch objectStoreConfig.Backend {
+	case model.ObjectStoreGCS:
+		return NewGCSObjectStore(objectStoreConfig.GCS)
+	case model.ObjectStoreLocal:
+		return NewLocalObjectStore(objectStoreConfig.Dir, objectStoreConfig.BaseURL)
+	case model.ObjectStoreMemory:
+		return NewMemoryObjectStore(objectStoreConfig.BaseURL), nil
+	}
+
+	return nil, errs.ErrObjectStore
+}
+
+// keys are slash separated paths that cannot leave the root of the store
+func validObjectKey(key string) bool {
+	if len(key) == 0 || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if len(part) == 0 || part == "." || part == ".." {
+			return false
+		}
+	}
+
+	return true
+}
+
+func objectURL(baseURL string, key string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + key
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"server/errs"
+	"server/model"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+type gcsObjectStore struct {
+	client     *storage.Client
+	bucketName string
+	uploadPath string
+}
+
+func NewGCSObjectStore(gcsConfig model.GCSConfig) (ObjectStore, error) {
+	client, err := storage.NewClient(ctx, option.WithCredentialsJSON(gcsConfig.CredentialsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("error creating storage client: %v", err)
+	}
+
+	return gcsObjectStore{client, gcsConfig.BucketName, gcsConfig.UploadPath}, nil
+}
+
+func (s gcsObjectStore) Put(key string, body io.Reader, contentType string) error {
+	if !validObjectKey(key) {
+		return errs.ErrObjectKey
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*50)
+	defer cancel()
+
+	wc := s.object(key).NewWriter(ctx)
+	wc.ContentType = contentType
+	if _, err := io.Copy(wc, body); err != nil {
+		wc.Close()
+		return fmt.Errorf("io.Copy: %v", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %v", err)
+	}
+
+	return nil
+}
+
+func (s gcsObjectStore) Get(key string) (io.ReadCloser, string, error) {
+	if !validObjectKey(key) {
+		return nil, "", errs.ErrObjectKey
+	}
+
+	rc, err := s.object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, "", errs.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rc, rc.Attrs.ContentType, nil
+}
+
+func (s gcsObjectStore) Delete(key string) error {
+	if !validObjectKey(key) {
+		return errs.ErrObjectKey
+	}
+
+	err := s.object(key).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return errs.ErrObjectNotFound
+	}
+
+	return err
+}
+
+func (s gcsObjectStore) URL(key string) string {
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s%s", s.bucketName, s.uploadPath, key)
+}
+
+func (s gcsObjectStore) object(key string) *storage.ObjectHandle {
+	return s.client.Bucket(s.bucketName).Object(s.uploadPath + key)
+}
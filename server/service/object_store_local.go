@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"server/errs"
+)
+
+// objects are files under dir, the content type is taken from the extension
+// of the key or sniffed from the content
+type localObjectStore struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalObjectStore(dir string, baseURL string) (ObjectStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating object store directory: %v", err)
+	}
+
+	return localObjectStore{dir, baseURL}, nil
+}
+
+// the object is written to a temporary file first so a reader never sees a
+// partly written object
+func (s localObjectStore) Put(key string, body io.Reader, contentType string) error {
+	if !validObjectKey(key) {
+		return errs.ErrObjectKey
+	}
+
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("io.Copy: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s localObjectStore) Get(key string) (io.ReadCloser, string, error) {
+	if !validObjectKey(key) {
+		return nil, "", errs.ErrObjectKey
+	}
+
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", errs.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if len(contentType) == 0 {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(file, head)
+		contentType = http.DetectContentType(head[:n])
+
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			file.Close()
+			return nil, "", err
+		}
+	}
+
+	return file, contentType, nil
+}
+
+func (s localObjectStore) Delete(key string) error {
+	if !validObjectKey(key) {
+		return errs.ErrObjectKey
+	}
+
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return errs.ErrObjectNotFound
+	}
+
+	return err
+}
+
+func (s localObjectStore) URL(key string) string {
+	return objectURL(s.baseURL, key)
+}
+
+func (s localObjectStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
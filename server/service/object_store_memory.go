@@ -0,0 +1,78 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"server/errs"
+	"sync"
+)
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+}
+
+// objects are kept in the process, for tests and running without storage
+type memoryObjectStore struct {
+	baseURL string
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+func NewMemoryObjectStore(baseURL string) ObjectStore {
+	return &memoryObjectStore{baseURL: baseURL, objects: map[string]memoryObject{}}
+}
+
+func (s *memoryObjectStore) Put(key string, body io.Reader, contentType string) error {
+	if !validObjectKey(key) {
+		return errs.ErrObjectKey
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	if len(contentType) == 0 {
+		contentType = http.DetectContentType(data)
+	}
+
+	s.mu.Lock()
+	s.objects[key] = memoryObject{data, contentType}
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *memoryObjectStore) Get(key string) (io.ReadCloser, string, error) {
+	if !validObjectKey(key) {
+		return nil, "", errs.ErrObjectKey
+	}
+
+	s.mu.RLock()
+	object, ok := s.objects[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, "", errs.ErrObjectNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(object.data)), object.contentType, nil
+}
+
+func (s *memoryObjectStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.objects[key]; !ok {
+		return errs.ErrObjectNotFound
+	}
+
+	delete(s.objects, key)
+
+	return nil
+}
+
+func (s *memoryObjectStore) URL(key string) string {
+	return objectURL(s.baseURL, key)
+}
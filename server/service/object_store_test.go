@@ -0,0 +1,65 @@
+package service_test
+
+import (
+	"io"
+	"server/errs"
+	"server/model"
+	"server/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectStore(t *testing.T) {
+	localObjectStore, err := service.NewLocalObjectStore(t.TempDir(), "/api/v1/image/")
+	assert.Empty(t, err)
+
+	objectStores := map[string]service.ObjectStore{
+		"local":  localObjectStore,
+		"memory": service.NewMemoryObjectStore("/api/v1/image"),
+	}
+
+	for name, objectStore := range objectStores {
+		t.Run(name, func(t *testing.T) {
+			err := objectStore.Put("stock/test.txt", strings.NewReader("image"), "text/plain")
+			assert.Empty(t, err)
+
+			body, contentType, err := objectStore.Get("stock/test.txt")
+			assert.Empty(t, err)
+			actual, _ := io.ReadAll(body)
+			body.Close()
+			assert.Equal(t, "image", string(actual))
+			assert.True(t, strings.HasPrefix(contentType, "text/plain"))
+			assert.Equal(t, "/api/v1/image/stock/test.txt", objectStore.URL("stock/test.txt"))
+
+			err = objectStore.Delete("stock/test.txt")
+			assert.Empty(t, err)
+
+			_, _, err = objectStore.Get("stock/test.txt")
+			assert.ErrorIs(t, err, errs.ErrObjectNotFound)
+
+			err = objectStore.Delete("stock/test.txt")
+			assert.ErrorIs(t, err, errs.ErrObjectNotFound)
+
+			for _, key := range []string{"", "/test", "../test", "stock//test", "stock/./test"} {
+				err = objectStore.Put(key, strings.NewReader("image"), "")
+				assert.ErrorIs(t, err, errs.ErrObjectKey, key)
+			}
+		})
+	}
+}
+
+func TestNewObjectStore(t *testing.T) {
+	objectStore, err := service.NewObjectStore(service.ObjectStoreConfig{
+		Backend: model.ObjectStoreLocal,
+		Dir:     t.TempDir(),
+	})
+
+	assert.Empty(t, err)
+	assert.NotNil(t, objectStore)
+
+	_, err = service.NewObjectStore(service.ObjectStoreConfig{Backend: "ftp"})
+
+	assert.ErrorIs(t, err, errs.ErrObjectStore)
+}
@@ -0,0 +1,20 @@
+package service
+
+import "server/model"
+
+type Payment = model.Payment
+type PaymentEvent = model.PaymentEvent
+type PaymentRequest = model.PaymentRequest
+type PaymentCallback = model.PaymentCallback
+type PaymentLimit = model.PaymentLimit
+
+type PaymentService interface {
+	RequestDeposit(string, PaymentRequest) (Payment, error)
+	RequestWithdraw(string, PaymentRequest) (Payment, error)
+	HandleCallback([]byte, string) (string, error)
+	ApprovePayment(string, string) (string, error)
+	RejectPayment(string, string) (string, error)
+	GetPayment(string, string) (Payment, error)
+	GetUserPayments(string, uint) ([]Payment, error)
+	GetPendingApprovals() ([]Payment, error)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"server/errs"
+	"server/model"
+	"time"
+)
+
+// the provider confirms a submitted payment later by calling back the
+// payment callback endpoint
+type PaymentProvider interface {
+	Submit(Payment) (string, error)
+	ParseCallback([]byte, string) (PaymentCallback, error)
+}
+
+type PaymentConfig = model.PaymentConfig
+
+func NewPaymentProvider(paymentConfig PaymentConfig) (PaymentProvider, error) {
+	switch paymentConfig.Provider {
+	case model.PaymentProviderLocal:
+		return NewLocalPaymentProvider(paymentConfig.CallbackURL, paymentConfig.WebhookSecret, 2*time.Second), nil
+	case model.PaymentProviderNone:
+		return noPaymentProvider{}, nil
+	}
+
+	return nil, errs.ErrPaymentProvider
+}
+
+// every payment fails when it is submitted and no callback is accepted
+type noPaymentProvider struct{}
+
+func (noPaymentProvider) Submit(payment Payment) (string, error) {
+	return "", errs.ErrPaymentProvider
+}
+
+func (noPaymentProvider) ParseCallback(body []byte, signature string) (PaymentCallback, error) {
+	return PaymentCallback{}, errs.ErrPaymentProvider
+}
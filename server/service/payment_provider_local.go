@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"server/errs"
+	"server/model"
+	"server/util"
+	"time"
+)
+
+const PaymentSignatureHeader = "X-Payment-Signature"
+
+// fake provider for development, every payment is completed by a signed
+// callback shortly after it is submitted
+type localPaymentProvider struct {
+	callbackUrl string
+	secret      string
+	delay       time.Duration
+	client      *http.Client
+}
+
+func NewLocalPaymentProvider(callbackUrl string, secret string, delay time.Duration) PaymentProvider {
+	return localPaymentProvider{
+		callbackUrl: callbackUrl,
+		secret:      secret,
+		delay:       delay,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p localPaymentProvider) Submit(payment Payment) (string, error) {
+	reference := fmt.Sprintf("local_%s", payment.ID.Hex())
+	if len(p.callbackUrl) == 0 {
+		return reference, nil
+	}
+
+	callback := PaymentCallback{
+		PaymentId: payment.ID.Hex(),
+		Reference: reference,
+		Status:    model.PaymentCompleted,
+	}
+	go func() {
+		time.Sleep(p.delay)
+		if err := p.sendCallback(callback); err != nil {
+			log.Printf("error sending payment callback: %v", err)
+		}
+	}()
+
+	return reference, nil
+}
+
+func (p localPaymentProvider) ParseCallback(body []byte, signature string) (PaymentCallback, error) {
+	if !util.VerifyPayload(p.secret, body, signature) {
+		return PaymentCallback{}, errs.ErrPaymentSignature
+	}
+
+	var callback PaymentCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return PaymentCallback{}, errs.ErrData
+	}
+
+	return callback, nil
+}
+
+func (p localPaymentProvider) sendCallback(callback PaymentCallback) error {
+	body, err := json.Marshal(callback)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", p.callbackUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(PaymentSignatureHeader, util.SignPayload(p.secret, body))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("payment callback status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package service_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"server/errs"
+	"server/model"
+	"server/service"
+	"server/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewPaymentProvider(t *testing.T) {
+	t.Run("Successfully create the local provider", func(t *testing.T) {
+		paymentProvider, err := service.NewPaymentProvider(service.PaymentConfig{Provider: model.PaymentProviderLocal})
+
+		assert.Empty(t, err)
+		assert.NotNil(t, paymentProvider)
+	})
+
+	t.Run("Failed to submit without a provider", func(t *testing.T) {
+		paymentProvider, err := service.NewPaymentProvider(service.PaymentConfig{Provider: model.PaymentProviderNone})
+		assert.Empty(t, err)
+
+		_, err = paymentProvider.Submit(Payment{ID: primitive.NewObjectID()})
+		assert.ErrorIs(t, err, errs.ErrPaymentProvider)
+
+		_, err = paymentProvider.ParseCallback([]byte("{}"), "")
+		assert.ErrorIs(t, err, errs.ErrPaymentProvider)
+	})
+
+	t.Run("Error unknown provider", func(t *testing.T) {
+		_, err := service.NewPaymentProvider(service.PaymentConfig{Provider: "paypal"})
+
+		assert.ErrorIs(t, err, errs.ErrPaymentProvider)
+	})
+}
+
+func TestLocalPaymentProvider(t *testing.T) {
+	callbacks := make(chan PaymentCallback, 1)
+	var paymentProvider service.PaymentProvider
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		callback, err := paymentProvider.ParseCallback(body, r.Header.Get(service.PaymentSignatureHeader))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		callbacks <- callback
+	}))
+	defer server.Close()
+
+	paymentProvider = service.NewLocalPaymentProvider(server.URL, "secret", 0)
+	payment := Payment{ID: primitive.NewObjectID()}
+
+	reference, err := paymentProvider.Submit(payment)
+
+	assert.Empty(t, err)
+	assert.Equal(t, "local_"+payment.ID.Hex(), reference)
+
+	select {
+	case callback := <-callbacks:
+		assert.Equal(t, payment.ID.Hex(), callback.PaymentId)
+		assert.Equal(t, model.PaymentCompleted, callback.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback was not sent")
+	}
+
+	t.Run("Error invalid signature", func(t *testing.T) {
+		body := []byte(`{"paymentId":"1","status":"completed"}`)
+
+		_, err := paymentProvider.ParseCallback(body, util.SignPayload("other", body))
+
+		assert.ErrorIs(t, err, errs.ErrPaymentSignature)
+	})
+}
@@ -0,0 +1,21 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type paymentProviderMock struct {
+	mock.Mock
+}
+
+func NewPaymentProviderMock() *paymentProviderMock {
+	return &paymentProviderMock{}
+}
+
+func (m *paymentProviderMock) Submit(payment Payment) (string, error) {
+	arge := m.Called(payment)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *paymentProviderMock) ParseCallback(body []byte, signature string) (PaymentCallback, error) {
+	arge := m.Called(body, signature)
+	return arge.Get(0).(PaymentCallback), arge.Error(1)
+}
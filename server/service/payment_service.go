@@ -0,0 +1,313 @@
+package service
+
+import (
+	"errors"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/util"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PaymentRepository = repository.PaymentRepository
+
+type paymentService struct {
+	paymentRepo     PaymentRepository
+	userService     UserService
+	paymentProvider PaymentProvider
+	fxRateProvider  FxRateProvider
+	paymentLimit    PaymentLimit
+}
+
+func NewPaymentService(
+	paymentRepo PaymentRepository,
+	userService UserService,
+	paymentProvider PaymentProvider,
+	fxRateProvider FxRateProvider,
+	paymentLimit PaymentLimit,
+) PaymentService {
+	return paymentService{paymentRepo, userService, paymentProvider, fxRateProvider, paymentLimit}
+}
+
+// the balance is credited when the provider confirms the deposit
+func (s paymentService) RequestDeposit(userId string, paymentRequest PaymentRequest) (payment Payment, err error) {
+	payment, err = s.newPayment(userId, "DEPOSIT", paymentRequest)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	_, err = s.paymentRepo.Create(payment)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	return s.submit(payment, userId)
+}
+
+// the balance is held when the withdrawal is requested and given back
+// when it fails, withdrawals above the approval threshold wait for an admin
+// before they are submitted to the provider
+func (s paymentService) RequestWithdraw(userId string, paymentRequest PaymentRequest) (payment Payment, err error) {
+	payment, err = s.newPayment(userId, "WITHDRAW", paymentRequest)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	threshold := s.paymentLimit.WithdrawApprovalThreshold
+	payment.ApprovalRequired = threshold > 0 && payment.BaseAmount > threshold
+
+	_, err = s.paymentRepo.Create(payment)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	_, err = s.userService.WithdrawBalance(userId, payment.Currency, payment.Amount)
+	if err != nil {
+		s.paymentRepo.UpdateStatus(payment.ID.Hex(), model.PaymentRequested, PaymentEvent{
+			Status: model.PaymentFailed,
+			Actor:  userId,
+			Note:   err.Error(),
+		})
+
+		return Payment{}, err
+	}
+
+	if payment.ApprovalRequired {
+		return payment, nil
+	}
+
+	return s.submit(payment, userId)
+}
+
+// a callback for the status the payment already has is a retry of the
+// provider and is accepted without changing anything
+func (s paymentService) HandleCallback(body []byte, signature string) (message string, err error) {
+	callback, err := s.paymentProvider.ParseCallback(body, signature)
+	if err != nil {
+		return "", err
+	}
+
+	payment, err := s.paymentRepo.Get(callback.PaymentId)
+	if err != nil {
+		return "", err
+	}
+
+	if payment.Status == callback.Status {
+		return "Successfully handled payment callback", nil
+	}
+
+	_, err = s.paymentRepo.UpdateStatus(payment.ID.Hex(), payment.Status, PaymentEvent{
+		Status: callback.Status,
+		Actor:  "provider",
+		Note:   callback.Reason,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case callback.Status == model.PaymentCompleted && payment.Method == "DEPOSIT":
+		_, err = s.userService.DepositBalance(payment.UID, payment.Currency, payment.Amount)
+	case callback.Status == model.PaymentReversed && payment.Method == "DEPOSIT":
+		_, err = s.userService.WithdrawBalance(payment.UID, payment.Currency, payment.Amount)
+	case (callback.Status == model.PaymentFailed || callback.Status == model.PaymentReversed) &&
+		payment.Method == "WITHDRAW":
+		_, err = s.userService.DepositBalance(payment.UID, payment.Currency, payment.Amount)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully handled payment callback", nil
+}
+
+func (s paymentService) ApprovePayment(paymentId string, adminId string) (message string, err error) {
+	payment, err := s.getApproval(paymentId)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.submit(payment, adminId)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully approved payment", nil
+}
+
+func (s paymentService) RejectPayment(paymentId string, adminId string) (message string, err error) {
+	payment, err := s.getApproval(paymentId)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.paymentRepo.UpdateStatus(paymentId, model.PaymentRequested, PaymentEvent{
+		Status: model.PaymentFailed,
+		Actor:  adminId,
+		Note:   "rejected",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.userService.DepositBalance(payment.UID, payment.Currency, payment.Amount)
+	if err != nil {
+		return "", err
+	}
+
+	return "Successfully rejected payment", nil
+}
+
+func (s paymentService) GetPayment(userId string, paymentId string) (payment Payment, err error) {
+	payment, err = s.paymentRepo.Get(paymentId)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	if payment.UID != userId {
+		return Payment{}, errs.ErrPayment
+	}
+
+	return payment, nil
+}
+
+func (s paymentService) GetUserPayments(userId string, startPage uint) (payments []Payment, err error) {
+	payments, err = s.paymentRepo.GetUserPayments(userId, startPage)
+	if err != nil {
+		return []Payment{}, err
+	}
+
+	return payments, nil
+}
+
+func (s paymentService) GetPendingApprovals() (payments []Payment, err error) {
+	payments, err = s.paymentRepo.GetPendingApprovals()
+	if err != nil {
+		return []Payment{}, err
+	}
+
+	return payments, nil
+}
+
+func (s paymentService) newPayment(userId string, method string, paymentRequest PaymentRequest) (Payment, error) {
+	if len(userId) == 0 {
+		return Payment{}, errs.ErrUser
+	}
+
+	if paymentRequest.Amount <= 0 {
+		return Payment{}, errs.ErrMoney
+	}
+
+	currency := util.NormalizeCurrency(paymentRequest.Currency)
+	if !util.ValidCurrency(currency) {
+		return Payment{}, errs.ErrCurrency
+	}
+
+	fxRate, err := s.fxRateProvider.GetRate(currency, model.BaseCurrency)
+	if errors.Is(err, errs.ErrFxRate) {
+		return Payment{}, errs.ErrCurrency
+	}
+	if err != nil {
+		return Payment{}, err
+	}
+
+	baseAmount := paymentRequest.Amount * fxRate
+	err = s.checkDailyLimit(userId, method, baseAmount)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	now := time.Now().Unix()
+	return Payment{
+		ID:         primitive.NewObjectID(),
+		UID:        userId,
+		Method:     method,
+		Currency:   currency,
+		Amount:     paymentRequest.Amount,
+		BaseAmount: baseAmount,
+		Status:     model.PaymentRequested,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		History: []PaymentEvent{
+			{Status: model.PaymentRequested, Timestamp: now, Actor: userId},
+		},
+	}, nil
+}
+
+func (s paymentService) checkDailyLimit(userId string, method string, baseAmount float64) error {
+	limit := s.paymentLimit.DailyDeposit
+	if method == "WITHDRAW" {
+		limit = s.paymentLimit.DailyWithdraw
+	}
+
+	if limit <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	total, err := s.paymentRepo.GetDailyTotal(userId, method, startOfDay.Unix())
+	if err != nil {
+		return err
+	}
+
+	if total+baseAmount > limit {
+		return errs.ErrDailyLimit
+	}
+
+	return nil
+}
+
+// the payment is pending before it is submitted, so a callback that comes
+// back before Submit returns finds it in the right status
+func (s paymentService) submit(payment Payment, actor string) (Payment, error) {
+	paymentId := payment.ID.Hex()
+	_, err := s.paymentRepo.UpdateStatus(paymentId, model.PaymentRequested, PaymentEvent{
+		Status: model.PaymentPending,
+		Actor:  actor,
+	})
+	if err != nil {
+		return Payment{}, err
+	}
+
+	payment.Status = model.PaymentPending
+	payment.Reference, err = s.paymentProvider.Submit(payment)
+	if err != nil {
+		s.paymentRepo.UpdateStatus(paymentId, model.PaymentPending, PaymentEvent{
+			Status: model.PaymentFailed,
+			Actor:  "provider",
+			Note:   err.Error(),
+		})
+		if payment.Method == "WITHDRAW" {
+			s.userService.DepositBalance(payment.UID, payment.Currency, payment.Amount)
+		}
+
+		return Payment{}, err
+	}
+
+	_, err = s.paymentRepo.SetReference(paymentId, payment.Reference)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	return payment, nil
+}
+
+func (s paymentService) getApproval(paymentId string) (Payment, error) {
+	payment, err := s.paymentRepo.Get(paymentId)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	if !payment.ApprovalRequired {
+		return Payment{}, errs.ErrApproval
+	}
+
+	if payment.Status != model.PaymentRequested {
+		return Payment{}, errs.ErrPaymentStatus
+	}
+
+	return payment, nil
+}
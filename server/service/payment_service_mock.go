@@ -0,0 +1,51 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type paymentServiceMock struct {
+	mock.Mock
+}
+
+func NewPaymentServiceMock() *paymentServiceMock {
+	return &paymentServiceMock{}
+}
+
+func (m *paymentServiceMock) RequestDeposit(userId string, paymentRequest PaymentRequest) (Payment, error) {
+	arge := m.Called(userId, paymentRequest)
+	return arge.Get(0).(Payment), arge.Error(1)
+}
+
+func (m *paymentServiceMock) RequestWithdraw(userId string, paymentRequest PaymentRequest) (Payment, error) {
+	arge := m.Called(userId, paymentRequest)
+	return arge.Get(0).(Payment), arge.Error(1)
+}
+
+func (m *paymentServiceMock) HandleCallback(body []byte, signature string) (string, error) {
+	arge := m.Called(body, signature)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *paymentServiceMock) ApprovePayment(paymentId string, adminId string) (string, error) {
+	arge := m.Called(paymentId, adminId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *paymentServiceMock) RejectPayment(paymentId string, adminId string) (string, error) {
+	arge := m.Called(paymentId, adminId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *paymentServiceMock) GetPayment(userId string, paymentId string) (Payment, error) {
+	arge := m.Called(userId, paymentId)
+	return arge.Get(0).(Payment), arge.Error(1)
+}
+
+func (m *paymentServiceMock) GetUserPayments(userId string, startPage uint) ([]Payment, error) {
+	arge := m.Called(userId, startPage)
+	return arge.Get(0).([]Payment), arge.Error(1)
+}
+
+func (m *paymentServiceMock) GetPendingApprovals() ([]Payment, error) {
+	arge := m.Called()
+	return arge.Get(0).([]Payment), arge.Error(1)
+}
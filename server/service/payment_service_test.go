@@ -0,0 +1,407 @@
+package service_test
+
+import (
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Payment = model.Payment
+type PaymentEvent = model.PaymentEvent
+type PaymentRequest = model.PaymentRequest
+type PaymentCallback = model.PaymentCallback
+
+func TestRequestDeposit(t *testing.T) {
+	t.Run("Submit deposit", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		paymentProvider := service.NewPaymentProviderMock()
+
+		paymentRepo.On(
+			"Create",
+			mock.MatchedBy(func(payment Payment) bool {
+				return payment.UID == "65c8993c48096b5150cee5d6" &&
+					payment.Method == "DEPOSIT" &&
+					payment.Status == model.PaymentRequested
+			}),
+		).Return("Successfully created payment", nil)
+		paymentRepo.On(
+			"UpdateStatus",
+			mock.Anything,
+			model.PaymentRequested,
+			PaymentEvent{Status: model.PaymentPending, Actor: "65c8993c48096b5150cee5d6"},
+		).Return("Successfully updated payment status", nil)
+		paymentProvider.On(
+			"Submit",
+			mock.Anything,
+		).Return("local_1", nil)
+		paymentRepo.On(
+			"SetReference",
+			mock.Anything,
+			"local_1",
+		).Return("Successfully set payment reference", nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			userService,
+			paymentProvider,
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		actual, err := paymentService.RequestDeposit(
+			"65c8993c48096b5150cee5d6",
+			PaymentRequest{Amount: 10, Currency: "usd"},
+		)
+
+		assert.Empty(t, err)
+		assert.Equal(t, model.PaymentPending, actual.Status)
+		assert.Equal(t, "local_1", actual.Reference)
+		assert.Equal(t, "USD", actual.Currency)
+		assert.Equal(t, float64(365), actual.BaseAmount)
+		userService.AssertNotCalled(t, "DepositBalance", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error daily limit", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		paymentRepo.On(
+			"GetDailyTotal",
+			"65c8993c48096b5150cee5d6",
+			"DEPOSIT",
+			mock.Anything,
+		).Return(float64(900), nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			service.NewUserServiceMock(),
+			service.NewPaymentProviderMock(),
+			fxRateProvider,
+			service.PaymentLimit{DailyDeposit: 1000},
+		)
+
+		_, err := paymentService.RequestDeposit(
+			"65c8993c48096b5150cee5d6",
+			PaymentRequest{Amount: 200},
+		)
+
+		assert.ErrorIs(t, err, errs.ErrDailyLimit)
+	})
+
+	t.Run("Error invalid money", func(t *testing.T) {
+		paymentService := service.NewPaymentService(
+			repository.NewPaymentRepositoryDBMock(),
+			service.NewUserServiceMock(),
+			service.NewPaymentProviderMock(),
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		_, err := paymentService.RequestDeposit(
+			"65c8993c48096b5150cee5d6",
+			PaymentRequest{Amount: 0},
+		)
+
+		assert.ErrorIs(t, err, errs.ErrMoney)
+	})
+}
+
+func TestRequestWithdraw(t *testing.T) {
+	t.Run("Wait for approval", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		paymentProvider := service.NewPaymentProviderMock()
+
+		paymentRepo.On(
+			"Create",
+			mock.MatchedBy(func(payment Payment) bool {
+				return payment.ApprovalRequired
+			}),
+		).Return("Successfully created payment", nil)
+		userService.On(
+			"WithdrawBalance",
+			"65c8993c48096b5150cee5d6",
+			"THB",
+			float64(5000),
+		).Return("Successfully withdrawed money", nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			userService,
+			paymentProvider,
+			fxRateProvider,
+			service.PaymentLimit{WithdrawApprovalThreshold: 1000},
+		)
+
+		actual, err := paymentService.RequestWithdraw(
+			"65c8993c48096b5150cee5d6",
+			PaymentRequest{Amount: 5000},
+		)
+
+		assert.Empty(t, err)
+		assert.Equal(t, model.PaymentRequested, actual.Status)
+		assert.True(t, actual.ApprovalRequired)
+		userService.AssertExpectations(t)
+		paymentProvider.AssertNotCalled(t, "Submit", mock.Anything)
+	})
+
+	t.Run("Error balance not enough", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+
+		paymentRepo.On(
+			"Create",
+			mock.Anything,
+		).Return("Successfully created payment", nil)
+		userService.On(
+			"WithdrawBalance",
+			"65c8993c48096b5150cee5d6",
+			"THB",
+			float64(50),
+		).Return("", errs.ErrBalance)
+		paymentRepo.On(
+			"UpdateStatus",
+			mock.Anything,
+			model.PaymentRequested,
+			PaymentEvent{
+				Status: model.PaymentFailed,
+				Actor:  "65c8993c48096b5150cee5d6",
+				Note:   errs.ErrBalance.Error(),
+			},
+		).Return("Successfully updated payment status", nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			userService,
+			service.NewPaymentProviderMock(),
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		_, err := paymentService.RequestWithdraw(
+			"65c8993c48096b5150cee5d6",
+			PaymentRequest{Amount: 50},
+		)
+
+		assert.ErrorIs(t, err, errs.ErrBalance)
+		paymentRepo.AssertExpectations(t)
+	})
+}
+
+func TestHandlePaymentCallback(t *testing.T) {
+	paymentId := primitive.NewObjectID()
+	body := []byte(`{}`)
+
+	t.Run("Complete deposit", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		paymentProvider := service.NewPaymentProviderMock()
+
+		paymentProvider.On(
+			"ParseCallback",
+			body,
+			"signature",
+		).Return(PaymentCallback{PaymentId: paymentId.Hex(), Status: model.PaymentCompleted}, nil)
+		paymentRepo.On(
+			"Get",
+			paymentId.Hex(),
+		).Return(Payment{
+			ID:       paymentId,
+			UID:      "65c8993c48096b5150cee5d6",
+			Method:   "DEPOSIT",
+			Currency: "THB",
+			Amount:   100,
+			Status:   model.PaymentPending,
+		}, nil)
+		paymentRepo.On(
+			"UpdateStatus",
+			paymentId.Hex(),
+			model.PaymentPending,
+			PaymentEvent{Status: model.PaymentCompleted, Actor: "provider"},
+		).Return("Successfully updated payment status", nil)
+		userService.On(
+			"DepositBalance",
+			"65c8993c48096b5150cee5d6",
+			"THB",
+			float64(100),
+		).Return("Successfully deposited money", nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			userService,
+			paymentProvider,
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		_, err := paymentService.HandleCallback(body, "signature")
+
+		assert.Empty(t, err)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("Retry of completed callback", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		paymentProvider := service.NewPaymentProviderMock()
+
+		paymentProvider.On(
+			"ParseCallback",
+			body,
+			"signature",
+		).Return(PaymentCallback{PaymentId: paymentId.Hex(), Status: model.PaymentCompleted}, nil)
+		paymentRepo.On(
+			"Get",
+			paymentId.Hex(),
+		).Return(Payment{ID: paymentId, Method: "DEPOSIT", Status: model.PaymentCompleted}, nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			userService,
+			paymentProvider,
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		_, err := paymentService.HandleCallback(body, "signature")
+
+		assert.Empty(t, err)
+		userService.AssertNotCalled(t, "DepositBalance", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refund failed withdrawal", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+		paymentProvider := service.NewPaymentProviderMock()
+
+		paymentProvider.On(
+			"ParseCallback",
+			body,
+			"signature",
+		).Return(PaymentCallback{
+			PaymentId: paymentId.Hex(),
+			Status:    model.PaymentFailed,
+			Reason:    "account closed",
+		}, nil)
+		paymentRepo.On(
+			"Get",
+			paymentId.Hex(),
+		).Return(Payment{
+			ID:       paymentId,
+			UID:      "65c8993c48096b5150cee5d6",
+			Method:   "WITHDRAW",
+			Currency: "THB",
+			Amount:   100,
+			Status:   model.PaymentPending,
+		}, nil)
+		paymentRepo.On(
+			"UpdateStatus",
+			paymentId.Hex(),
+			model.PaymentPending,
+			PaymentEvent{Status: model.PaymentFailed, Actor: "provider", Note: "account closed"},
+		).Return("Successfully updated payment status", nil)
+		userService.On(
+			"DepositBalance",
+			"65c8993c48096b5150cee5d6",
+			"THB",
+			float64(100),
+		).Return("Successfully deposited money", nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			userService,
+			paymentProvider,
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		_, err := paymentService.HandleCallback(body, "signature")
+
+		assert.Empty(t, err)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("Error invalid signature", func(t *testing.T) {
+		paymentProvider := service.NewPaymentProviderMock()
+		paymentProvider.On(
+			"ParseCallback",
+			body,
+			"",
+		).Return(PaymentCallback{}, errs.ErrPaymentSignature)
+		paymentService := service.NewPaymentService(
+			repository.NewPaymentRepositoryDBMock(),
+			service.NewUserServiceMock(),
+			paymentProvider,
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		_, err := paymentService.HandleCallback(body, "")
+
+		assert.ErrorIs(t, err, errs.ErrPaymentSignature)
+	})
+}
+
+func TestApprovePayment(t *testing.T) {
+	paymentId := primitive.NewObjectID()
+
+	t.Run("Error approval not required", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		paymentRepo.On(
+			"Get",
+			paymentId.Hex(),
+		).Return(Payment{ID: paymentId, Status: model.PaymentRequested}, nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			service.NewUserServiceMock(),
+			service.NewPaymentProviderMock(),
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		_, err := paymentService.ApprovePayment(paymentId.Hex(), "admin")
+
+		assert.ErrorIs(t, err, errs.ErrApproval)
+	})
+
+	t.Run("Reject and refund", func(t *testing.T) {
+		paymentRepo := repository.NewPaymentRepositoryDBMock()
+		userService := service.NewUserServiceMock()
+
+		paymentRepo.On(
+			"Get",
+			paymentId.Hex(),
+		).Return(Payment{
+			ID:               paymentId,
+			UID:              "65c8993c48096b5150cee5d6",
+			Method:           "WITHDRAW",
+			Currency:         "THB",
+			Amount:           5000,
+			Status:           model.PaymentRequested,
+			ApprovalRequired: true,
+		}, nil)
+		paymentRepo.On(
+			"UpdateStatus",
+			paymentId.Hex(),
+			model.PaymentRequested,
+			PaymentEvent{Status: model.PaymentFailed, Actor: "admin", Note: "rejected"},
+		).Return("Successfully updated payment status", nil)
+		userService.On(
+			"DepositBalance",
+			"65c8993c48096b5150cee5d6",
+			"THB",
+			float64(5000),
+		).Return("Successfully deposited money", nil)
+		paymentService := service.NewPaymentService(
+			paymentRepo,
+			userService,
+			service.NewPaymentProviderMock(),
+			fxRateProvider,
+			service.PaymentLimit{},
+		)
+
+		actual, err := paymentService.RejectPayment(paymentId.Hex(), "admin")
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully rejected payment", actual)
+		userService.AssertExpectations(t)
+	})
+}
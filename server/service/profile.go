@@ -0,0 +1,13 @@
+package service
+
+import (
+	"mime/multipart"
+	"server/model"
+)
+
+type EditProfileRequest = model.EditProfileRequest
+
+type ProfileService interface {
+	EditProfile(string, EditProfileRequest) (string, error)
+	EditAvatar(string, multipart.File) (string, error)
+}
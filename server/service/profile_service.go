@@ -0,0 +1,85 @@
+package service
+
+import (
+	"log"
+	"mime/multipart"
+	"net/mail"
+	"server/errs"
+)
+
+// size of the avatar thumbnail kept as the profile image
+const avatarSize = 256
+
+type profileService struct {
+	userRepo    UserRepository
+	objectStore ObjectStore
+	cache       Cache
+}
+
+func NewProfileService(userRepo UserRepository, objectStore ObjectStore, cache Cache) ProfileService {
+	return profileService{userRepo, objectStore, cache}
+}
+
+func (s profileService) EditProfile(userId string, profile EditProfileRequest) (message string, err error) {
+	if len(profile.Email) > 0 {
+		address, err := mail.ParseAddress(profile.Email)
+		if err != nil || address.Address != profile.Email {
+			return "", errs.ErrEmail
+		}
+	}
+
+	message, err = s.userRepo.EditProfile(userId, profile)
+	if err != nil {
+		return "", err
+	}
+
+	publishCacheEvents(s.cache, cacheEvent{kind: profileChanged, userId: userId})
+
+	return message, nil
+}
+
+// the avatar goes through the same checks and thumbnails as stock images,
+// the old one is deleted unless another account has the same avatar
+func (s profileService) EditAvatar(userId string, image multipart.File) (message string, err error) {
+	userAccount, err := s.userRepo.GetAccount(userId)
+	if err != nil {
+		return "", err
+	}
+
+	oldAvatar := userAccount.Avatar
+	newAvatar, err := storeImage(s.objectStore, "avatar", image)
+	if err != nil {
+		return "", err
+	}
+
+	if newAvatar == oldAvatar {
+		return "Successfully updated avatar", nil
+	}
+
+	profileImage := s.objectStore.URL(thumbnailKey(newAvatar, avatarSize))
+	message, err = s.userRepo.SetAvatar(userId, oldAvatar, newAvatar, profileImage)
+	if err != nil {
+		s.deleteUnusedAvatar(newAvatar)
+		return "", err
+	}
+
+	s.deleteUnusedAvatar(oldAvatar)
+	publishCacheEvents(s.cache, cacheEvent{kind: profileChanged, userId: userId})
+
+	return message, nil
+}
+
+func (s profileService) deleteUnusedAvatar(key string) {
+	if len(key) == 0 {
+		return
+	}
+
+	used, err := s.userRepo.IsAvatarUsed(key)
+	if err != nil || used {
+		return
+	}
+
+	if err := deleteImage(s.objectStore, key); err != nil {
+		log.Printf("delete avatar %s: %v", key, err)
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"mime/multipart"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type profileServiceMock struct {
+	mock.Mock
+}
+
+func NewProfileServiceMock() *profileServiceMock {
+	return &profileServiceMock{}
+}
+
+func (m *profileServiceMock) EditProfile(userId string, profile EditProfileRequest) (string, error) {
+	arge := m.Called(userId, profile)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *profileServiceMock) EditAvatar(userId string, image multipart.File) (string, error) {
+	arge := m.Called(userId, image)
+	return arge.String(0), arge.Error(1)
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"os"
+	"server/errs"
+	"server/repository"
+	"server/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type EditProfileRequest = service.EditProfileRequest
+
+func TestEditProfile(t *testing.T) {
+	t.Run("Edit profile", func(t *testing.T) {
+		cache := newCache()
+		profile := EditProfileRequest{Name: "test", Email: "test@example.com"}
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("EditProfile", "profile", profile).Return("Successfully updated profile", nil)
+		profileService := service.NewProfileService(userRepo, objectStore, cache)
+
+		seedCache(t, cache, "user:profile")
+
+		actual, err := profileService.EditProfile("profile", profile)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully updated profile", actual)
+		assertCacheInvalidated(t, cache, "user:profile")
+	})
+
+	t.Run("Error invalid email", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		profileService := service.NewProfileService(userRepo, objectStore, newCache())
+
+		_, err := profileService.EditProfile("profile", EditProfileRequest{Email: "Test <test@example.com>"})
+
+		assert.ErrorIs(t, err, errs.ErrEmail)
+		userRepo.AssertNotCalled(t, "EditProfile", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEditAvatar(t *testing.T) {
+	openImage := func() *os.File {
+		file, _ := os.Open("../handler/test.png")
+		return file
+	}
+
+	t.Run("Upload first avatar", func(t *testing.T) {
+		cache := newCache()
+		objectStore := service.NewMemoryObjectStore("/api/v1/image")
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", "profile").Return(UserAccount{ProfileImage: "https://example.com/a.png"}, nil)
+		userRepo.On("SetAvatar", "profile", "", mock.Anything, mock.Anything).Return("Successfully updated avatar", nil)
+		profileService := service.NewProfileService(userRepo, objectStore, cache)
+
+		seedCache(t, cache, "user:profile")
+
+		actual, err := profileService.EditAvatar("profile", openImage())
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully updated avatar", actual)
+		avatar := userRepo.Calls[1].Arguments.String(2)
+		profileImage := userRepo.Calls[1].Arguments.String(3)
+		assert.True(t, strings.HasPrefix(avatar, "avatar/"))
+		assert.Equal(t, "/api/v1/image/"+avatar+"/256.png", profileImage)
+		_, _, err = objectStore.Get(avatar + "/256.png")
+		assert.Empty(t, err)
+		userRepo.AssertNotCalled(t, "IsAvatarUsed", mock.Anything)
+		assertCacheInvalidated(t, cache, "user:profile")
+	})
+
+	t.Run("Replace avatar", func(t *testing.T) {
+		objectStore := service.NewMemoryObjectStore("/api/v1/image")
+		objectStore.Put("avatar/old/64.png", strings.NewReader("old"), "image/png")
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", "profile").Return(UserAccount{Avatar: "avatar/old"}, nil)
+		userRepo.On("SetAvatar", "profile", "avatar/old", mock.Anything, mock.Anything).Return("Successfully updated avatar", nil)
+		userRepo.On("IsAvatarUsed", "avatar/old").Return(false, nil)
+		profileService := service.NewProfileService(userRepo, objectStore, newCache())
+
+		_, err := profileService.EditAvatar("profile", openImage())
+
+		assert.Empty(t, err)
+		_, _, err = objectStore.Get("avatar/old/64.png")
+		assert.ErrorIs(t, err, errs.ErrObjectNotFound)
+	})
+
+	t.Run("Error image type", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", "profile").Return(UserAccount{}, nil)
+		profileService := service.NewProfileService(userRepo, objectStore, newCache())
+
+		_, err := profileService.EditAvatar("profile", textFile{strings.NewReader("not an image")})
+
+		assert.ErrorIs(t, err, errs.ErrImageType)
+		userRepo.AssertNotCalled(t, "SetAvatar", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"server/errs"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	cacheTTL         = time.Hour
+	negativeCacheTTL = 30 * time.Second
+	// loads that started before an invalidation cannot cache their value
+	// until the tombstone expires
+	tombstoneTTL = 5 * time.Second
+)
+
+// errors that mean the value does not exist, they are cached for a short
+// time so a missing id does not reach the database on every request
+var cacheableErrors = []error{errs.ErrUser, errs.ErrInvalidStock, mongo.ErrNoDocuments}
+
+var cacheGroup singleflight.Group
+
+type cacheEntry struct {
+	Value     json.RawMessage `json:"value,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Tombstone bool            `json:"tombstone,omitempty"`
+}
+
+// the value is read from the cache and only loaded from the database on a
+// miss, concurrent misses of a key share one load. the server keeps working
+// from the database when the cache fails
+func readThrough[T any](cache Cache, key string, load func() (T, error)) (T, error) {
+	var value T
+	data, err := cache.Get(key)
+	if err == nil {
+		var entry cacheEntry
+		if json.Unmarshal(data, &entry) != nil {
+			// written before the entries, it would block the new one
+			cache.Delete(key)
+		} else if cachedErr := cachedError(entry.Error); cachedErr != nil {
+			return value, cachedErr
+		} else if !entry.Tombstone && json.Unmarshal(entry.Value, &value) == nil {
+			return value, nil
+		}
+	}
+
+	result, err, _ := cacheGroup.Do(key, func() (interface{}, error) {
+		value, err := load()
+		storeCacheEntry(cache, key, value, err)
+
+		return value, err
+	})
+
+	return result.(T), err
+}
+
+// keys are replaced by a tombstone instead of being deleted, so a load that
+// read the database before the change cannot cache the old value
+func invalidateCache(cache Cache, keys ...string) {
+	data, _ := json.Marshal(cacheEntry{Tombstone: true})
+
+	for _, key := range keys {
+		cache.Set(key, data, tombstoneTTL)
+	}
+}
+
+// an entry is only added when there is none, a tombstone is never replaced
+func storeCacheEntry(cache Cache, key string, value interface{}, err error) {
+	entry, ttl := cacheEntry{}, cacheTTL
+	if err != nil {
+		cacheableErr := cacheableError(err)
+		if cacheableErr == nil {
+			return
+		}
+
+		entry.Error, ttl = cacheableErr.Error(), negativeCacheTTL
+	} else {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return
+		}
+
+		entry.Value = data
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	cache.SetNX(key, data, ttl)
+}
+
+func cachedError(message string) error {
+	if len(message) == 0 {
+		return nil
+	}
+
+	for _, err := range cacheableErrors {
+		if err.Error() == message {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cacheableError(err error) error {
+	for _, cacheableErr := range cacheableErrors {
+		if errors.Is(err, cacheableErr) {
+			return cacheableErr
+		}
+	}
+
+	return nil
+}
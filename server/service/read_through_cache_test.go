@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"server/repository"
+	"server/service"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReadThroughCache(t *testing.T) {
+	t.Run("Concurrent misses share one load", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", userId).After(100*time.Millisecond).Return(UserAccount{Name: "kongphop"}, nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				actual, err := userService.GetUserAccount(userId)
+				assert.Empty(t, err)
+				assert.Equal(t, "kongphop", actual.Name)
+			}()
+		}
+		wg.Wait()
+
+		userRepo.AssertNumberOfCalls(t, "GetAccount", 1)
+	})
+
+	t.Run("Load from database when the cache fails", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", userId).Return(UserAccount{Name: "kongphop"}, nil)
+		downCache := service.NewRedisCache(goredis.NewClient(&goredis.Options{Addr: "localhost:1"}))
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, downCache)
+
+		for i := 0; i < 2; i++ {
+			actual, err := userService.GetUserAccount(userId)
+			assert.Empty(t, err)
+			assert.Equal(t, "kongphop", actual.Name)
+		}
+
+		userRepo.AssertNumberOfCalls(t, "GetAccount", 2)
+	})
+
+	t.Run("Cache missing user", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", userId).Return(UserAccount{}, ErrUser)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		for i := 0; i < 2; i++ {
+			_, err := userService.GetUserAccount(userId)
+			assert.ErrorIs(t, err, ErrUser)
+		}
+
+		userRepo.AssertNumberOfCalls(t, "GetAccount", 1)
+	})
+
+	t.Run("Invalidate on change", func(t *testing.T) {
+		userId := primitive.NewObjectID().Hex()
+		profile := EditProfileRequest{Name: "kongphop2"}
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", userId).Return(UserAccount{Name: "kongphop"}, nil).Once()
+		userRepo.On("GetAccount", userId).Return(UserAccount{Name: "kongphop2"}, nil)
+		userRepo.On("EditProfile", userId, profile).Return("Successfully edited profile", nil)
+		cache := newCache()
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+		profileService := service.NewProfileService(userRepo, objectStore, cache)
+
+		actual, err := userService.GetUserAccount(userId)
+		assert.Empty(t, err)
+		assert.Equal(t, "kongphop", actual.Name)
+
+		_, err = profileService.EditProfile(userId, profile)
+		assert.Empty(t, err)
+
+		actual, err = userService.GetUserAccount(userId)
+		assert.Empty(t, err)
+		assert.Equal(t, "kongphop2", actual.Name)
+	})
+}
@@ -1,6 +1,9 @@
 package service
 
-import "server/model"
+import (
+	"mime/multipart"
+	"server/model"
+)
 
 type StockCollection = model.StockCollection
 type AllStock = model.AllStock
@@ -10,11 +13,11 @@ type StockCollectionResponse = model.StockCollectionResponse
 type StockHistoryResponse = model.StockHistoryResponse
 type StockCollectionRequest = model.StockCollectionRequest
 type Graph = model.Graph
+type TradingRule = model.TradingRule
 
 
 type StockService interface {
 	CreateStockCollection(StockCollectionRequest) (string, error)
-	CreateStockOrder(string, StockHistory) (string, error)
 	GetAllStockCollections() ([]StockCollectionResponse, error)
 	GetTop10Stocks() ([]TopStock, error)
 	GetStockCollection(string) (StockCollectionResponse, error)
@@ -22,8 +25,9 @@ type StockService interface {
 	GetStockHistory(string) ([]StockHistoryResponse, error) 
 	GetStockPrice(string) (float64, error)
 	GetStockGraph(string) ([]Graph, error)
+	GetStockTradingRule(string) (TradingRule, error)
 	SetStockPrice(string, float64) (string, error)
+	SetStockTradingRule(string, TradingRule) (string, error)
 	EditStockName(string, string) (string, error)
-	EditStockSign(string, string) (string, error)
-	DeleteStockCollection(string) (string, error)
+	EditStockImage(string, multipart.File) (string, error)
 }
\ No newline at end of file
@@ -1,136 +1,123 @@
 package service
 
 import (
-	"encoding/json"
-	"fmt"
+	"log"
+	"mime/multipart"
+	"server/errs"
 	"server/model"
 	"server/repository"
 	"server/util"
 	"sort"
-	"time"
-
-	"github.com/redis/go-redis/v9"
 )
 
 type StockRepository = repository.StockRepository
 type StockGraph = model.StockGraph
 
+var defaultTradingRule = TradingRule{
+	TickSize:    0.01,
+	LotSize:     1,
+	MinQuantity: 1,
+}
+
 type stockService struct {
 	stockRepo   StockRepository
-	redisClient *redis.Client
-	googleCloudUpload *model.ClientUploader
+	cache       Cache
+	objectStore ObjectStore
 }
 
 
-func NewStockService(stockRepo StockRepository, redisClient *redis.Client, googleCloudUpload *model.ClientUploader) StockService {
-	return stockService{stockRepo, redisClient, googleCloudUpload}
+func NewStockService(stockRepo StockRepository, cache Cache, objectStore ObjectStore) StockService {
+	return stockService{stockRepo, cache, objectStore}
 }
 
 func (s stockService) CreateStockCollection(stockCollection StockCollectionRequest) (message string, err error) {
-	err = util.UploadFile(
-		stockCollection.StockImage, 
-		stockCollection.Name, 
-		s.googleCloudUpload,
-	)
+	currency := util.NormalizeCurrency(stockCollection.Currency)
+	if !util.ValidCurrency(currency) {
+		return "", errs.ErrCurrency
+	}
+
+	stockImage, err := storeImage(s.objectStore, "stock", stockCollection.StockImage)
 	if err != nil {
 		return "", err
 	}
 
-	stockCollectionsKey := "stockCollections"
 	stock := StockCollection{
-		StockImage: stockCollection.Name,
-		Name:       stockCollection.Name,
-		Sign:       stockCollection.Sign,
-		Price:      stockCollection.Price,
-		History:    []StockHistory{},
+		StockImage:  stockImage,
+		Name:        stockCollection.Name,
+		Sign:        stockCollection.Sign,
+		Price:       stockCollection.Price,
+		Currency:    currency,
+		TradingRule: defaultTradingRule,
+		History:     []StockHistory{},
 	}
 	message, err = s.stockRepo.CreateStock(stock)
 	if err != nil {
 		return "", err
 	}
 
-	s.redisClient.Del(ctx, stockCollectionsKey)
-
-	return message, nil
-}
-
-func (s stockService) CreateStockOrder(stockId string, stockOrder StockHistory) (message string, err error) {
-	message, err = s.stockRepo.CreateStockOrder(stockId, stockOrder)
-	if err != nil {
-		return "", err
-	}
+	publishCacheEvents(s.cache, cacheEvent{kind: stockListed})
 
 	return message, nil
 }
 
 func (s stockService) GetAllStockCollections() (stockCollections []StockCollectionResponse, err error) {
-	stockCollectionsKey := "stockCollections"
-	result, err := s.stockRepo.GetAllStocks()
-	if err != nil {
-		return []StockCollectionResponse{}, err
-	}
+	stockCollections, err = readThrough(s.cache, stockCollectionsCacheKey, func() ([]StockCollectionResponse, error) {
+		result, err := s.stockRepo.GetAllStocks()
+		if err != nil {
+			return nil, err
+		}
 
-	if stockCollectionJson, err := s.redisClient.Get(ctx, stockCollectionsKey).Result(); err == nil {
-		if json.Unmarshal([]byte(stockCollectionJson), &stockCollections) == nil {
-			return stockCollections, nil
+		for i := range result {
+			result[i].Images = imageURLs(s.objectStore, result[i].StockImage)
 		}
-	}
 
-	if data, err := json.Marshal(result); err == nil {
-		s.redisClient.Set(ctx, stockCollectionsKey, string(data), time.Second*3600)
+		return result, nil
+	})
+	if err != nil {
+		return []StockCollectionResponse{}, err
 	}
 
-	return result, nil
+	return stockCollections, nil
 }
 
 func (s stockService) GetTop10Stocks() (top10Stock []TopStock, err error) {
-	top10StockKey := "top10Stock"
-	result, err := s.stockRepo.GetTopStocks()
-	if err != nil {
-		return []TopStock{}, err
-	}
-
-	if stockCollectionJson, err := s.redisClient.Get(ctx, top10StockKey).Result(); err == nil {
-		if json.Unmarshal([]byte(stockCollectionJson), &top10Stock) == nil {
-			return top10Stock, nil
+	top10Stock, err = readThrough(s.cache, top10StockCacheKey, func() ([]TopStock, error) {
+		result, err := s.stockRepo.GetTopStocks()
+		if err != nil {
+			return nil, err
 		}
-	}
 
-	for _, stock := range result {
-		topStock := TopStock{
-			ID:    stock.ID,
-			Sign:  stock.Sign,
-			Price: stock.Price,
-		}
+		var top10Stock []TopStock
+		for _, stock := range result {
+			topStock := TopStock{
+				ID:    stock.ID,
+				Sign:  stock.Sign,
+				Price: stock.Price,
+			}
 
-		top10Stock = append(top10Stock, topStock)
-	}
+			top10Stock = append(top10Stock, topStock)
+		}
 
-	if data, err := json.Marshal(top10Stock); err == nil {
-		s.redisClient.Set(ctx, top10StockKey, string(data), time.Second*3600)
+		return top10Stock, nil
+	})
+	if err != nil {
+		return []TopStock{}, err
 	}
 
 	return top10Stock, nil
 }
 
 func (s stockService) GetStockCollection(stockId string) (stockCollection StockCollectionResponse, err error) {
-	stockCollectionKey := fmt.Sprintf("stockCollection:%s", stockId)
-	result, err := s.stockRepo.GetStock(stockId)
-	if err != nil {
-		return StockCollectionResponse{}, err
-	}
-
-	if stockCollectionJson, err := s.redisClient.Get(ctx, stockCollectionKey).Result(); err == nil {
-		if json.Unmarshal([]byte(stockCollectionJson), &stockCollection) == nil {
-			return stockCollection, nil
+	return readThrough(s.cache, stockCollectionCacheKey(stockId), func() (StockCollectionResponse, error) {
+		result, err := s.stockRepo.GetStock(stockId)
+		if err != nil {
+			return StockCollectionResponse{}, err
 		}
-	}
 
-	if data, err := json.Marshal(result); err == nil {
-		s.redisClient.Set(ctx, stockCollectionKey, string(data), time.Second*3600)
-	}
+		result.Images = imageURLs(s.objectStore, result.StockImage)
 
-	return result, nil
+		return result, nil
+	})
 }
 
 func (s stockService) GetFavoriteStock(favoriteStockIds []string) (favoriteStocks []StockCollectionResponse, err error) {
@@ -139,6 +126,10 @@ func (s stockService) GetFavoriteStock(favoriteStockIds []string) (favoriteStock
 		return []StockCollectionResponse{}, err
 	}
 
+	for i := range favoriteStocks {
+		favoriteStocks[i].Images = imageURLs(s.objectStore, favoriteStocks[i].StockImage)
+	}
+
 	return favoriteStocks, nil
 }
 
@@ -184,7 +175,6 @@ func (s stockService) GetStockGraph(stockId string) (graph []Graph, err error) {
 
 	for _, timestamp := range keys {
 		items := groupedData[timestamp]
-		// fmt.Printf("Timestamp: %d\n", timestamp)
 		min := items[0].Price
 		max := items[0].Price
 		for i, item := range items {
@@ -194,7 +184,6 @@ func (s stockService) GetStockGraph(stockId string) (graph []Graph, err error) {
 			if items[i].Price > max {
 				max = item.Price
 			}
-			// fmt.Println("Price:", item.Price, "timestamp:", item.Timestamp)
 		}
 
 		// Y -> [open, max, min, close]
@@ -212,48 +201,93 @@ func (s stockService) GetStockGraph(stockId string) (graph []Graph, err error) {
 	return graph, nil
 }
 
+func (s stockService) GetStockTradingRule(stockId string) (tradingRule TradingRule, err error) {
+	tradingRule, err = s.stockRepo.GetTradingRule(stockId)
+	if err != nil {
+		return TradingRule{}, err
+	}
+
+	return tradingRule, nil
+}
 
 func (s stockService) SetStockPrice(stockId string, price float64) (message string, err error) {
+	err = checkPriceBand(s.stockRepo, s.cache, stockId, price)
+	if err != nil {
+		return "", err
+	}
+
 	message, err = s.stockRepo.SetPrice(stockId, price)
 	if err != nil {
 		return "", err
 	}
-	
+
+	publishCacheEvents(s.cache, cacheEvent{kind: stockChanged, stockId: stockId})
+
 	return message, nil
 }
 
-func (s stockService) EditStockName(stockId string, name string) (message string, err error) {
-	stockCollectionsKey := "stockCollections"
-	message, err = s.stockRepo.EditName(stockId, name)
+func (s stockService) SetStockTradingRule(stockId string, tradingRule TradingRule) (message string, err error) {
+	message, err = s.stockRepo.SetTradingRule(stockId, tradingRule)
 	if err != nil {
 		return "", err
 	}
 
-	s.redisClient.Del(ctx, stockCollectionsKey)
-	
+	publishCacheEvents(s.cache, cacheEvent{kind: stockChanged, stockId: stockId})
+
 	return message, nil
 }
 
-func (s stockService) EditStockSign(stockId string, sign string) (message string, err error) {
-	stockCollectionsKey := "stockCollections"
-	message, err = s.stockRepo.EditSign(stockId, sign)
+func (s stockService) EditStockName(stockId string, name string) (message string, err error) {
+	message, err = s.stockRepo.EditName(stockId, name)
 	if err != nil {
 		return "", err
 	}
 
-	s.redisClient.Del(ctx, stockCollectionsKey)
-	
+	publishCacheEvents(s.cache, cacheEvent{kind: stockChanged, stockId: stockId})
+
 	return message, nil
 }
 
-func (s stockService) DeleteStockCollection(stockId string) (message string, err error) {
-	stockCollectionsKey := "stockCollections"
-	message, err = s.stockRepo.DeleteStock(stockId)
+// the new image is stored before the swap and the old one is deleted after
+// it, an image is kept while another stock still shows it
+func (s stockService) EditStockImage(stockId string, image multipart.File) (message string, err error) {
+	oldImage, err := s.stockRepo.GetImage(stockId)
 	if err != nil {
 		return "", err
 	}
 
-	s.redisClient.Del(ctx, stockCollectionsKey)
-	
+	newImage, err := storeImage(s.objectStore, "stock", image)
+	if err != nil {
+		return "", err
+	}
+
+	if newImage == oldImage {
+		return "Successfully updated stock image", nil
+	}
+
+	message, err = s.stockRepo.SetImage(stockId, oldImage, newImage)
+	if err != nil {
+		s.deleteUnusedImage(newImage)
+		return "", err
+	}
+
+	s.deleteUnusedImage(oldImage)
+	publishCacheEvents(s.cache, cacheEvent{kind: stockChanged, stockId: stockId})
+
 	return message, nil
 }
+
+func (s stockService) deleteUnusedImage(key string) {
+	if len(key) == 0 {
+		return
+	}
+
+	used, err := s.stockRepo.IsImageUsed(key)
+	if err != nil || used {
+		return
+	}
+
+	if err := deleteImage(s.objectStore, key); err != nil {
+		log.Printf("delete image %s: %v", key, err)
+	}
+}
@@ -1,6 +1,10 @@
 package service
 
-import "github.com/stretchr/testify/mock"
+import (
+	"mime/multipart"
+
+	"github.com/stretchr/testify/mock"
+)
 
 type stockServiceMock struct {
 	mock.Mock
@@ -15,11 +19,6 @@ func (m *stockServiceMock) CreateStockCollection(stockCollection StockCollection
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *stockServiceMock) CreateStockOrder(stockId string, stockOrder StockHistory) (string, error) {
-	arge := m.Called(stockId, stockOrder)
-	return arge.String(0), arge.Error(1)
-}
-
 func (m *stockServiceMock) GetAllStockCollections() ([]StockCollectionResponse, error) {
 	arge := m.Called()
 	return arge.Get(0).([]StockCollectionResponse), arge.Error(1)
@@ -55,22 +54,27 @@ func (m *stockServiceMock) GetStockGraph(stockId string) ([]Graph, error) {
 	return arge.Get(0).([]Graph), arge.Error(1)
 }
 
+func (m *stockServiceMock) GetStockTradingRule(stockId string) (TradingRule, error) {
+	arge := m.Called(stockId)
+	return arge.Get(0).(TradingRule), arge.Error(1)
+}
+
 func (m *stockServiceMock) SetStockPrice(stockId string, price float64) (string, error) {
 	arge := m.Called(stockId, price)
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *stockServiceMock) EditStockName(stockId string, name string) (string, error) {
-	arge := m.Called(stockId, name)
+func (m *stockServiceMock) SetStockTradingRule(stockId string, tradingRule TradingRule) (string, error) {
+	arge := m.Called(stockId, tradingRule)
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *stockServiceMock) EditStockSign(stockId string, sign string) (string, error) {
-	arge := m.Called(stockId, sign)
+func (m *stockServiceMock) EditStockName(stockId string, name string) (string, error) {
+	arge := m.Called(stockId, name)
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *stockServiceMock) DeleteStockCollection(stockId string) (string, error) {
-	arge := m.Called(stockId)
+func (m *stockServiceMock) EditStockImage(stockId string, image multipart.File) (string, error) {
+	arge := m.Called(stockId, image)
 	return arge.String(0), arge.Error(1)
-}
\ No newline at end of file
+}
@@ -1,14 +1,19 @@
 package service_test
 
 import (
-	"mime/multipart"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
 	"server/errs"
 	"server/model"
 	"server/repository"
 	"server/service"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type StockHistory = model.StockHistory
@@ -18,105 +23,95 @@ type StockGroup = model.StockGroup
 type TopStock = model.TopStock
 type StockHistoryResponse = model.StockHistoryResponse
 type StockCollectionRequest = model.StockCollectionRequest
+type TradingRule = model.TradingRule
 
 var stockRepo = repository.NewStockRepositoryDBMock()
-var uploader *model.ClientUploader
+var objectStore = service.NewMemoryObjectStore("/api/v1/image")
 var (
 	ErrPrice = errs.ErrPrice
 	ErrName = errs.ErrName
 	ErrSign = errs.ErrSign
 )
-var file multipart.File
+
+func matchTrade(expected StockHistory) interface{} {
+	return mock.MatchedBy(func(actual StockHistory) bool {
+		return len(actual.TradeId) > 0 &&
+			actual.BuyerId == expected.ID &&
+			actual.SellerId == model.MarketCounterparty &&
+			actual.ID == expected.ID &&
+			actual.Amount == expected.Amount &&
+			actual.Price == expected.Price
+	})
+}
+
+type textFile struct {
+	*strings.Reader
+}
+
+func (f textFile) Close() error {
+	return nil
+}
 
 func TestCreateStockCollection(t *testing.T) {
 	expected := "Successfully created stock collection"
-
-	t.Run("Create stock collection", func(t *testing.T) {
-		stockCollection := StockCollectionRequest{
+	stockCollection := func() StockCollectionRequest {
+		file, _ := os.Open("../handler/test.png")
+		return StockCollectionRequest{
 			StockImage: file,
 			Name:       "test",
 			Sign:       "test",
 			Price:      20,
 		}
+	}
+	matchStock := mock.MatchedBy(func(stock StockCollection) bool {
+		return stock.Name == "test" && strings.HasPrefix(stock.StockImage, "stock/") && stock.Currency == model.BaseCurrency
+	})
 
-		stockRepo.On(
-			"CreateStock",
-			stockCollection,
-		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+	t.Run("Create stock collection", func(t *testing.T) {
+		cache := newCache()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("CreateStock", matchStock).Return(expected, nil)
+		stockService := service.NewStockService(stockRepo, cache, objectStore)
 
-		actual, err := stockService.CreateStockCollection(stockCollection)
+		seedCache(t, cache, "stockCollections", "top10Stock")
+
+		actual, err := stockService.CreateStockCollection(stockCollection())
 
 		assert.Empty(t, err)
 		assert.Equal(t, expected, actual)
+
+		stock := stockRepo.Calls[0].Arguments.Get(0).(StockCollection)
+		thumbnail, contentType, err := objectStore.Get(stock.StockImage + "/64.png")
+		assert.Empty(t, err)
+		assert.Equal(t, "image/png", contentType)
+		thumbnailImage, err := png.Decode(thumbnail)
+		thumbnail.Close()
+		assert.Empty(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 64), thumbnailImage.Bounds())
+		assertCacheInvalidated(t, cache, "stockCollections", "top10Stock")
 	})
 
-	t.Run("Error invalid data", func(t *testing.T) {
-		stockCollection := StockCollectionRequest{
-			StockImage: file,
+	t.Run("Error image type", func(t *testing.T) {
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
+
+		_, err := stockService.CreateStockCollection(StockCollectionRequest{
+			StockImage: textFile{strings.NewReader("not an image")},
 			Name:       "test",
 			Sign:       "test",
 			Price:      20,
-		}
-
-		stockRepo.On(
-			"CreateStock",
-			stockCollection,
-		).Return(expected, ErrData)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
-
-		_, err := stockService.CreateStockCollection(stockCollection)
-
-		assert.ErrorIs(t, err, ErrData)
-	})
-}
-
-func TestCreateStockOrder(t *testing.T) {
-	expected := "Successfully created stock order"
-
-	t.Run("Create stock order", func(t *testing.T) {
-		stockOrder := StockHistory{
-			ID: "65c8993c48096b5150cee5d6",
-			Timestamp: int64(1),
-			Amount: float64(1),
-			Price: float64(1),
-		}
+		})
 
-		stockRepo.On(
-			"CreateStockOrder",
-			"65cc5fd45aa71b64fbb551a9",
-			stockOrder,
-		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
-
-		actual, err := stockService.CreateStockOrder(
-			"65cc5fd45aa71b64fbb551a9",
-			stockOrder,
-		)
-
-		assert.Empty(t, err)
-		assert.Equal(t, expected, actual)		
+		assert.ErrorIs(t, err, errs.ErrImageType)
+		stockRepo.AssertNotCalled(t, "CreateStock", mock.Anything)
 	})
 
 	t.Run("Error invalid data", func(t *testing.T) {
-		stockOrder := StockHistory{
-			ID: "",
-			Timestamp: int64(1),
-			Amount: float64(1),
-			Price: float64(1),
-		}
-
-		stockRepo.On(
-			"CreateStockOrder",
-			"65cc5fd45aa71b64fbb551a9",
-			stockOrder,
-		).Return(expected, ErrData)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("CreateStock", matchStock).Return("", ErrData)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
-		_, err := stockService.CreateStockOrder(
-			"65cc5fd45aa71b64fbb551a9",
-			stockOrder,
-		)
+		_, err := stockService.CreateStockCollection(stockCollection())
 
 		assert.ErrorIs(t, err, ErrData)
 	})
@@ -132,10 +127,11 @@ func TestGetAllStockCollections(t *testing.T) {
 	}}
 	
 	t.Run("Get all stock collections", func(t *testing.T) {
+		stockRepo := repository.NewStockRepositoryDBMock()
 		stockRepo.On(
 			"GetAllStocks",
 		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		actual, err := stockService.GetAllStockCollections()
 
@@ -162,7 +158,7 @@ func TestGetTop10Stocks(t *testing.T) {
 			Price: 1,
 			Volume: 1,
 		}}, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		actual, err := stockService.GetTop10Stocks()
 		 
@@ -185,11 +181,13 @@ func TestGetStockCollection(t *testing.T) {
 			"GetStock",
 			"65cc5fd45aa71b64fbb551a9",
 		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		actual, err := stockService.GetStockCollection("65cc5fd45aa71b64fbb551a9")
 
 		assert.Empty(t, err)
+		assert.Equal(t, map[string]string{"original": "/api/v1/image/test"}, actual.Images)
+		actual.Images = nil
 		assert.Equal(t, expected, actual)
 	})
 
@@ -198,7 +196,7 @@ func TestGetStockCollection(t *testing.T) {
 			"GetStock",
 			"",
 		).Return(expected, ErrInvalidStock)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		_, err := stockService.GetStockCollection("")
 
@@ -218,11 +216,12 @@ func TestGetFavoriteStock(t *testing.T) {
 
 	t.Run("Get favorite stock", func(t *testing.T) {
 		stockIds := []string{"65cc5fd45aa71b64fbb551a9"}
+		stockRepo := repository.NewStockRepositoryDBMock()
 		stockRepo.On(
 			"GetFavoriteStock",
 			stockIds,
 		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		actual, err := stockService.GetFavoriteStock(stockIds)
 
@@ -235,7 +234,7 @@ func TestGetFavoriteStock(t *testing.T) {
 			"GetFavoriteStock",
 			[]string{""},
 		).Return(expected, ErrInvalidStock)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		_, err := stockService.GetFavoriteStock([]string{""})
 
@@ -254,7 +253,7 @@ func TestGetStockHistory(t *testing.T) {
 			"GetStockHistory", 
 			"65cc5fd45aa71b64fbb551a9",
 		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		actual, err := stockService.GetStockHistory("65cc5fd45aa71b64fbb551a9")
 
@@ -267,7 +266,7 @@ func TestGetStockHistory(t *testing.T) {
 			"GetStockHistory", 
 			"",
 		).Return(expected, ErrInvalidStock)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		_, err := stockService.GetStockHistory("")
 
@@ -278,13 +277,26 @@ func TestGetStockHistory(t *testing.T) {
 func TestSetStockPrice(t *testing.T) {
 	expected := "Successfully set stock price"
 
+	stockRepo.On(
+		"GetTradingRule",
+		"65cc5fd45aa71b64fbb551a9",
+	).Return(TradingRule{}, nil)
+
 	t.Run("Set stock price", func(t *testing.T) {
+		cache := newCache()
 		stockRepo.On(
 			"SetPrice",
 			"65cc5fd45aa71b64fbb551a9",
 			float64(1),
 		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, cache, objectStore)
+
+		keys := []string{
+			"stockCollection:65cc5fd45aa71b64fbb551a9",
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
 
 		actual, err := stockService.SetStockPrice(
 			"65cc5fd45aa71b64fbb551a9", 
@@ -293,6 +305,7 @@ func TestSetStockPrice(t *testing.T) {
 
 		assert.Empty(t, err)
 		assert.Equal(t, expected, actual)
+		assertCacheInvalidated(t, cache, keys...)
 	})
 
 	t.Run("Error invalid price", func(t *testing.T) {
@@ -301,7 +314,7 @@ func TestSetStockPrice(t *testing.T) {
 			"65cc5fd45aa71b64fbb551a9",
 			float64(0),
 		).Return(expected, ErrPrice)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
 		_, err := stockService.SetStockPrice(
 			"65cc5fd45aa71b64fbb551a9", 
@@ -312,72 +325,134 @@ func TestSetStockPrice(t *testing.T) {
 	})
 }
 
-func TestEditStockName(t *testing.T) {
-	expected := "Successfully edit stock name"
+func TestGetStockTradingRule(t *testing.T) {
+	expected := TradingRule{
+		TickSize:    0.01,
+		LotSize:     1,
+		MinQuantity: 1,
+	}
 
-	t.Run("Edit stock name", func(t *testing.T) {
+	t.Run("Get stock trading rule", func(t *testing.T) {
 		stockRepo.On(
-			"EditName",
+			"GetTradingRule",
+			"65cc5fd45aa71b64fbb551a1",
+		).Return(expected, nil)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
+
+		actual, err := stockService.GetStockTradingRule("65cc5fd45aa71b64fbb551a1")
+
+		assert.Empty(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Error invalid stock", func(t *testing.T) {
+		stockRepo.On(
+			"GetTradingRule",
+			"",
+		).Return(TradingRule{}, ErrInvalidStock)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
+
+		_, err := stockService.GetStockTradingRule("")
+
+		assert.ErrorIs(t, err, ErrInvalidStock)
+	})
+}
+
+func TestSetStockTradingRule(t *testing.T) {
+	expected := "Successfully set trading rule"
+
+	t.Run("Set stock trading rule", func(t *testing.T) {
+		cache := newCache()
+		tradingRule := TradingRule{
+			TickSize:    0.05,
+			LotSize:     100,
+			MinNotional: 1000,
+		}
+
+		stockRepo.On(
+			"SetTradingRule",
 			"65cc5fd45aa71b64fbb551a9",
-			"T",
+			tradingRule,
 		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, cache, objectStore)
 
-		actual, err := stockService.EditStockName(
-			"65cc5fd45aa71b64fbb551a9", 
-			"T",
+		keys := []string{
+			"stockCollection:65cc5fd45aa71b64fbb551a9",
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
+
+		actual, err := stockService.SetStockTradingRule(
+			"65cc5fd45aa71b64fbb551a9",
+			tradingRule,
 		)
 
 		assert.Empty(t, err)
 		assert.Equal(t, expected, actual)
+		assertCacheInvalidated(t, cache, keys...)
 	})
 
-	t.Run("Error invalid name", func(t *testing.T) {
+	t.Run("Error invalid trading rule", func(t *testing.T) {
+		tradingRule := TradingRule{
+			MinQuantity: 100,
+			MaxQuantity: 10,
+		}
+
 		stockRepo.On(
-			"EditName",
+			"SetTradingRule",
 			"65cc5fd45aa71b64fbb551a9",
-			"",
-		).Return(expected, ErrName)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+			tradingRule,
+		).Return("", errs.ErrTradingRule)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
-		_, err := stockService.EditStockName(
-			"65cc5fd45aa71b64fbb551a9", 
-			"",
+		_, err := stockService.SetStockTradingRule(
+			"65cc5fd45aa71b64fbb551a9",
+			tradingRule,
 		)
 
-		assert.ErrorIs(t, err, ErrName)
+		assert.ErrorIs(t, err, errs.ErrTradingRule)
 	})
 }
 
-func TestEditStockSign(t *testing.T) {
-	expected := "Successfully edit stock sign"
+func TestEditStockName(t *testing.T) {
+	expected := "Successfully edit stock name"
 
-	t.Run("Edit stock sign", func(t *testing.T) {
+	t.Run("Edit stock name", func(t *testing.T) {
+		cache := newCache()
 		stockRepo.On(
-			"EditSign",
+			"EditName",
 			"65cc5fd45aa71b64fbb551a9",
 			"T",
 		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, cache, objectStore)
 
-		actual, err := stockService.EditStockSign(
+		keys := []string{
+			"stockCollection:65cc5fd45aa71b64fbb551a9",
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
+
+		actual, err := stockService.EditStockName(
 			"65cc5fd45aa71b64fbb551a9", 
 			"T",
 		)
 
 		assert.Empty(t, err)
 		assert.Equal(t, expected, actual)
+		assertCacheInvalidated(t, cache, keys...)
 	})
 
-	t.Run("Error invalid sign", func(t *testing.T) {
+	t.Run("Error invalid name", func(t *testing.T) {
 		stockRepo.On(
-			"EditSign",
+			"EditName",
 			"65cc5fd45aa71b64fbb551a9",
 			"",
 		).Return(expected, ErrName)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
-		_, err := stockService.EditStockSign(
+		_, err := stockService.EditStockName(
 			"65cc5fd45aa71b64fbb551a9", 
 			"",
 		)
@@ -386,32 +461,88 @@ func TestEditStockSign(t *testing.T) {
 	})
 }
 
-func TestDeleteStockCollection(t *testing.T) {
-	expected := "Successfully deleted stock"
 
-	t.Run("Delete stock collection", func(t *testing.T) {
-		stockRepo.On(
-			"DeleteStock",
-			"65cc5fd45aa71b64fbb551a9",
-		).Return(expected, nil)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+func TestEditStockImage(t *testing.T) {
+	stockId := "65cc5fd45aa71b64fbb551a9"
+	openImage := func() *os.File {
+		file, _ := os.Open("../handler/test.png")
+		return file
+	}
+	putOldImage := func(objectStore service.ObjectStore) {
+		for _, size := range model.ImageSizes {
+			objectStore.Put(fmt.Sprintf("stock/old/%d.png", size), strings.NewReader("old"), "image/png")
+		}
+	}
 
-		actual, err := stockService.DeleteStockCollection("65cc5fd45aa71b64fbb551a9")
+	t.Run("Replace stock image", func(t *testing.T) {
+		cache := newCache()
+		objectStore := service.NewMemoryObjectStore("/api/v1/image")
+		putOldImage(objectStore)
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetImage", stockId).Return("stock/old", nil)
+		stockRepo.On("SetImage", stockId, "stock/old", mock.Anything).Return("Successfully updated stock image", nil)
+		stockRepo.On("IsImageUsed", "stock/old").Return(false, nil)
+		stockService := service.NewStockService(stockRepo, cache, objectStore)
+
+		keys := []string{
+			"stockCollection:" + stockId,
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
+
+		actual, err := stockService.EditStockImage(stockId, openImage())
 
 		assert.Empty(t, err)
-		assert.Equal(t, expected, actual)
+		assert.Equal(t, "Successfully updated stock image", actual)
+		newImage := stockRepo.Calls[1].Arguments.String(2)
+		_, _, err = objectStore.Get(newImage + "/512.png")
+		assert.Empty(t, err)
+		_, _, err = objectStore.Get("stock/old/64.png")
+		assert.ErrorIs(t, err, errs.ErrObjectNotFound)
+		assertCacheInvalidated(t, cache, keys...)
 	})
 
-	t.Run("Error invalid stock", func(t *testing.T) {
-		stockRepo.On(
-			"DeleteStock",
-			"",
-		).Return(expected, ErrInvalidStock)
-		stockService := service.NewStockService(stockRepo, redisClient, uploader)
+	t.Run("Keep image of another stock", func(t *testing.T) {
+		objectStore := service.NewMemoryObjectStore("/api/v1/image")
+		putOldImage(objectStore)
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetImage", stockId).Return("stock/old", nil)
+		stockRepo.On("SetImage", stockId, "stock/old", mock.Anything).Return("Successfully updated stock image", nil)
+		stockRepo.On("IsImageUsed", "stock/old").Return(true, nil)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
 
-		_, err := stockService.DeleteStockCollection("")
+		_, err := stockService.EditStockImage(stockId, openImage())
 
-		assert.ErrorIs(t, err, ErrInvalidStock)
+		assert.Empty(t, err)
+		_, _, err = objectStore.Get("stock/old/64.png")
+		assert.Empty(t, err)
 	})
-}
 
+	t.Run("Error image changed", func(t *testing.T) {
+		objectStore := service.NewMemoryObjectStore("/api/v1/image")
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetImage", stockId).Return("stock/old", nil)
+		stockRepo.On("SetImage", stockId, "stock/old", mock.Anything).Return("", errs.ErrImageChanged)
+		stockRepo.On("IsImageUsed", mock.Anything).Return(false, nil)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
+
+		_, err := stockService.EditStockImage(stockId, openImage())
+
+		assert.ErrorIs(t, err, errs.ErrImageChanged)
+		newImage := stockRepo.Calls[1].Arguments.String(2)
+		_, _, err = objectStore.Get(newImage + "/64.png")
+		assert.ErrorIs(t, err, errs.ErrObjectNotFound)
+	})
+
+	t.Run("Error image type", func(t *testing.T) {
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetImage", stockId).Return("stock/old", nil)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
+
+		_, err := stockService.EditStockImage(stockId, textFile{strings.NewReader("not an image")})
+
+		assert.ErrorIs(t, err, errs.ErrImageType)
+		stockRepo.AssertNotCalled(t, "SetImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
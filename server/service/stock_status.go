@@ -0,0 +1,14 @@
+package service
+
+import "server/model"
+
+type StockStatusEvent = model.StockStatusEvent
+type StockStatusRequest = model.StockStatusRequest
+type DelistRequest = model.DelistRequest
+type StockStatusMessage = model.StockStatusMessage
+
+type StockStatusService interface {
+	SetStockStatus(string, StockStatusRequest, string) (string, error)
+	DelistStock(string, DelistRequest, string) (string, error)
+	ResumeHaltedStocks() ([]string, error)
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"server/errs"
+	"server/model"
+	"server/util"
+	"strings"
+	"time"
+)
+
+type stockStatusService struct {
+	stockRepo  StockRepository
+	userRepo   UserRepository
+	ledgerRepo LedgerRepository
+	cache      Cache
+}
+
+func NewStockStatusService(
+	stockRepo StockRepository,
+	userRepo UserRepository,
+	ledgerRepo LedgerRepository,
+	cache Cache,
+) StockStatusService {
+	return stockStatusService{stockRepo, userRepo, ledgerRepo, cache}
+}
+
+// halts, suspends or resumes trading, a stock is only delisted through
+// DelistStock so its positions are closed
+func (s stockStatusService) SetStockStatus(
+	stockId string,
+	request StockStatusRequest,
+	adminId string,
+) (message string, err error) {
+	if request.Status == model.StockDelisted {
+		return "", errs.ErrStockStatus
+	}
+
+	status, err := s.stockRepo.GetStatus(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	event := StockStatusEvent{
+		Status: request.Status,
+		Actor:  adminId,
+		Reason: strings.TrimSpace(request.Reason),
+	}
+	message, err = s.stockRepo.SetStatus(stockId, status, event)
+	if err != nil {
+		return "", err
+	}
+
+	publishStockStatus(s.cache, stockId, event)
+	s.clearStockCache(stockId)
+
+	return message, nil
+}
+
+// trading is stopped first and every position is closed after, a position
+// that could not be closed is left for the next call on the delisted stock
+func (s stockStatusService) DelistStock(
+	stockId string,
+	request DelistRequest,
+	adminId string,
+) (message string, err error) {
+	price, err := s.getSettlementPrice(stockId, request)
+	if err != nil {
+		return "", err
+	}
+
+	currency, err := s.stockRepo.GetCurrency(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	status, err := s.stockRepo.GetStatus(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	if status != model.StockDelisted {
+		event := StockStatusEvent{
+			Status: model.StockDelisted,
+			Actor:  adminId,
+			Reason: strings.TrimSpace(request.Reason),
+		}
+		_, err = s.stockRepo.SetStatus(stockId, status, event)
+		if err != nil {
+			return "", err
+		}
+
+		publishStockStatus(s.cache, stockId, event)
+	}
+
+	positions, err := s.userRepo.GetStockPositions(stockId)
+	if err != nil {
+		return "", err
+	}
+
+	var settleErr error
+	for userId, amount := range positions {
+		err = s.settlePosition(userId, stockId, amount, price, currency)
+		if err != nil {
+			log.Printf("delist %s settle %s: %v", stockId, userId, err)
+			settleErr = err
+		}
+	}
+
+	s.clearStockCache(stockId)
+
+	if settleErr != nil {
+		return "", settleErr
+	}
+
+	return "Successfully delisted stock", nil
+}
+
+// lifts the halts of the circuit breaker whose halt duration is over, a halt
+// an admin changed in the meantime is left as it is
+func (s stockStatusService) ResumeHaltedStocks() (stockIds []string, err error) {
+	expiredIds, err := s.stockRepo.GetExpiredHalts(time.Now().Unix())
+	if err != nil {
+		return []string{}, err
+	}
+
+	stockIds = []string{}
+	var resumeErr error
+	for _, stockId := range expiredIds {
+		event := StockStatusEvent{
+			Status: model.StockActive,
+			Actor:  model.StockSystemActor,
+			Reason: "halt duration is over",
+		}
+		_, err = s.stockRepo.SetStatus(stockId, model.StockHalted, event)
+		if err != nil {
+			log.Printf("resume %s: %v", stockId, err)
+			resumeErr = err
+			continue
+		}
+
+		publishStockStatus(s.cache, stockId, event)
+		s.clearStockCache(stockId)
+		stockIds = append(stockIds, stockId)
+	}
+
+	return stockIds, resumeErr
+}
+
+func (s stockStatusService) getSettlementPrice(stockId string, request DelistRequest) (float64, error) {
+	switch request.Settlement {
+	case model.SettlementCancel:
+		return 0, nil
+	case model.SettlementCash:
+		if request.Price < 0 {
+			return 0, errs.ErrPrice
+		}
+
+		if request.Price > 0 {
+			return request.Price, nil
+		}
+
+		return s.stockRepo.GetPrice(stockId)
+	default:
+		return 0, errs.ErrSettlement
+	}
+}
+
+// the settlement is a trade with the market at the settlement price, a
+// cancelled position has no value and leaves the ledger untouched
+func (s stockStatusService) settlePosition(userId string, stockId string, amount float64, price float64, currency string) error {
+	settle := func() (string, error) {
+		_, err := s.userRepo.SettlePosition(userId, stockId, amount, price, currency)
+		return "", err
+	}
+
+	value := math.Abs(amount) * price
+	if value > 0 {
+		method := "SALE"
+		if amount < 0 {
+			method = "BUY"
+		}
+
+		transaction, err := newCashTransaction(userId, currency, method, stockId, value, 0)
+		if err != nil {
+			return err
+		}
+
+		_, err = journal(s.ledgerRepo, transaction, settle)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := settle()
+		if err != nil {
+			return err
+		}
+	}
+
+	publishCacheEvents(s.cache, cacheEvent{kind: positionChanged, userId: userId, stockId: stockId})
+
+	return nil
+}
+
+func (s stockStatusService) clearStockCache(stockId string) {
+	publishCacheEvents(s.cache, cacheEvent{kind: stockChanged, stockId: stockId})
+}
+
+// orders are only taken while the stock is active
+func checkStockActive(stockRepo StockRepository, stockId string) error {
+	status, err := stockRepo.GetStatus(stockId)
+	if err != nil {
+		return err
+	}
+
+	if util.StockStatus(status) != model.StockActive {
+		return errs.ErrStockNotActive
+	}
+
+	return nil
+}
+
+// a price outside of the daily band is rejected and halts trading, the halt
+// is lifted by ResumeHaltedStocks after the halt duration
+func checkPriceBand(stockRepo StockRepository, cache Cache, stockId string, price float64) error {
+	tradingRule, err := stockRepo.GetTradingRule(stockId)
+	if err != nil {
+		return err
+	}
+
+	if !util.HasPriceBand(tradingRule) {
+		return nil
+	}
+
+	previousClose, err := stockRepo.GetPreviousClose(stockId, time.Now().Format(time.DateOnly))
+	if err != nil {
+		return err
+	}
+
+	err = util.CheckPriceBand(tradingRule, previousClose, price)
+	if !errors.Is(err, errs.ErrPriceBand) {
+		return err
+	}
+
+	now := time.Now().Unix()
+	event := StockStatusEvent{
+		Status:    model.StockHalted,
+		Timestamp: now,
+		Actor:     model.StockSystemActor,
+		Reason:    fmt.Sprintf("price %v is outside of the price band of %v", price, previousClose),
+		Until:     now + util.HaltDuration(tradingRule),
+	}
+	_, haltErr := stockRepo.SetStatus(stockId, model.StockActive, event)
+	if haltErr != nil {
+		// already halted, suspended or delisted
+		log.Printf("halt %s: %v", stockId, haltErr)
+		return err
+	}
+
+	publishStockStatus(cache, stockId, event)
+	publishCacheEvents(cache, cacheEvent{kind: stockChanged, stockId: stockId})
+
+	return err
+}
+
+// the websocket hub forwards every status change to the price channel of
+// the stock
+func publishStockStatus(cache Cache, stockId string, event StockStatusEvent) {
+	data, err := json.Marshal(StockStatusMessage{
+		StockId:          stockId,
+		StockStatusEvent: event,
+	})
+	if err != nil {
+		log.Printf("publish %s status: %v", stockId, err)
+		return
+	}
+
+	cache.Publish(model.StockStatusChannel, data)
+}
@@ -0,0 +1,26 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type stockStatusServiceMock struct {
+	mock.Mock
+}
+
+func NewStockStatusServiceMock() *stockStatusServiceMock {
+	return &stockStatusServiceMock{}
+}
+
+func (m *stockStatusServiceMock) SetStockStatus(stockId string, request StockStatusRequest, adminId string) (string, error) {
+	arge := m.Called(stockId, request, adminId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *stockStatusServiceMock) DelistStock(stockId string, request DelistRequest, adminId string) (string, error) {
+	arge := m.Called(stockId, request, adminId)
+	return arge.String(0), arge.Error(1)
+}
+
+func (m *stockStatusServiceMock) ResumeHaltedStocks() ([]string, error) {
+	arge := m.Called()
+	return arge.Get(0).([]string), arge.Error(1)
+}
@@ -0,0 +1,334 @@
+package service_test
+
+import (
+	"errors"
+	"server/errs"
+	"server/model"
+	"server/repository"
+	"server/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type StockStatusEvent = model.StockStatusEvent
+type StockStatusRequest = model.StockStatusRequest
+type DelistRequest = model.DelistRequest
+
+const statusStockId = "65c39a03dfb8060d99995937"
+
+func TestSetStockStatus(t *testing.T) {
+	t.Run("Halt stock", func(t *testing.T) {
+		cache := newCache()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetStatus", statusStockId).Return(model.StockActive, nil)
+		stockRepo.On(
+			"SetStatus",
+			statusStockId,
+			model.StockActive,
+			StockStatusEvent{Status: model.StockHalted, Actor: "admin", Reason: "pending news"},
+		).Return("Successfully set stock status", nil)
+		stockStatusService := service.NewStockStatusService(stockRepo, nil, nil, cache)
+
+		keys := []string{
+			"stockCollection:" + statusStockId,
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
+
+		message, err := stockStatusService.SetStockStatus(
+			statusStockId,
+			StockStatusRequest{Status: model.StockHalted, Reason: " pending news "},
+			"admin",
+		)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully set stock status", message)
+		stockRepo.AssertExpectations(t)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+
+	t.Run("Error delist through status", func(t *testing.T) {
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockStatusService := service.NewStockStatusService(stockRepo, nil, nil, newCache())
+
+		_, err := stockStatusService.SetStockStatus(
+			statusStockId,
+			StockStatusRequest{Status: model.StockDelisted},
+			"admin",
+		)
+
+		assert.ErrorIs(t, err, errs.ErrStockStatus)
+		stockRepo.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDelistStock(t *testing.T) {
+	t.Run("Settle positions at last price", func(t *testing.T) {
+		cache := newCache()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		stockRepo.On("GetPrice", statusStockId).Return(20, nil)
+		stockRepo.On("GetCurrency", statusStockId).Return(model.BaseCurrency, nil)
+		stockRepo.On("GetStatus", statusStockId).Return(model.StockSuspended, nil)
+		stockRepo.On(
+			"SetStatus",
+			statusStockId,
+			model.StockSuspended,
+			StockStatusEvent{Status: model.StockDelisted, Actor: "admin"},
+		).Return("Successfully set stock status", nil)
+		userRepo.On("GetStockPositions", statusStockId).Return(map[string]float64{
+			"holder": 10,
+			"seller": -5,
+		}, nil)
+		userRepo.On("SettlePosition", "holder", statusStockId, float64(10), float64(20), model.BaseCurrency).Return(float64(10), nil)
+		userRepo.On("SettlePosition", "seller", statusStockId, float64(-5), float64(20), model.BaseCurrency).Return(float64(-5), nil)
+		ledgerRepo.On(
+			"Append",
+			mock.MatchedBy(func(transaction LedgerTransaction) bool {
+				return transaction.UID == "holder" &&
+					transaction.Method == "SALE" &&
+					transaction.Entries[0].Debit == 200
+			}),
+		).Return("Successfully appended ledger transaction", nil)
+		ledgerRepo.On(
+			"Append",
+			mock.MatchedBy(func(transaction LedgerTransaction) bool {
+				return transaction.UID == "seller" &&
+					transaction.Method == "BUY" &&
+					transaction.Entries[0].Debit == 100
+			}),
+		).Return("Successfully appended ledger transaction", nil)
+		stockStatusService := service.NewStockStatusService(stockRepo, userRepo, ledgerRepo, cache)
+
+		keys := []string{
+			"stockCollection:" + statusStockId,
+			"stockCollections",
+			"top10Stock",
+			"balance:holder",
+			"stockAmount:holder:" + statusStockId,
+			"userHistory:holder",
+			"userStockHistory:holder:" + statusStockId,
+			"balance:seller",
+			"stockAmount:seller:" + statusStockId,
+		}
+		seedCache(t, cache, keys...)
+
+		message, err := stockStatusService.DelistStock(
+			statusStockId,
+			DelistRequest{Settlement: model.SettlementCash},
+			"admin",
+		)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully delisted stock", message)
+		stockRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+		ledgerRepo.AssertExpectations(t)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+
+	t.Run("Cancel leftover positions of delisted stock", func(t *testing.T) {
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		stockRepo.On("GetCurrency", statusStockId).Return(model.BaseCurrency, nil)
+		stockRepo.On("GetStatus", statusStockId).Return(model.StockDelisted, nil)
+		userRepo.On("GetStockPositions", statusStockId).Return(map[string]float64{"holder": 10}, nil)
+		userRepo.On("SettlePosition", "holder", statusStockId, float64(10), float64(0), model.BaseCurrency).Return(float64(10), nil)
+		stockStatusService := service.NewStockStatusService(stockRepo, userRepo, ledgerRepo, newCache())
+
+		_, err := stockStatusService.DelistStock(
+			statusStockId,
+			DelistRequest{Settlement: model.SettlementCancel},
+			"admin",
+		)
+
+		assert.Empty(t, err)
+		stockRepo.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+		ledgerRepo.AssertNotCalled(t, "Append", mock.Anything)
+	})
+
+	t.Run("Error settle position", func(t *testing.T) {
+		errSettle := errors.New("settle failed")
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		stockRepo.On("GetCurrency", statusStockId).Return(model.BaseCurrency, nil)
+		stockRepo.On("GetStatus", statusStockId).Return(model.StockActive, nil)
+		stockRepo.On("SetStatus", statusStockId, model.StockActive, mock.Anything).Return("Successfully set stock status", nil)
+		userRepo.On("GetStockPositions", statusStockId).Return(map[string]float64{"holder": 10}, nil)
+		userRepo.On("SettlePosition", "holder", statusStockId, float64(10), float64(15), model.BaseCurrency).Return(float64(0), errSettle)
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		ledgerRepo.On("Append", mock.Anything).Return("Successfully appended ledger transaction", nil)
+		stockStatusService := service.NewStockStatusService(stockRepo, userRepo, ledgerRepo, newCache())
+
+		_, err := stockStatusService.DelistStock(
+			statusStockId,
+			DelistRequest{Settlement: model.SettlementCash, Price: 15},
+			"admin",
+		)
+
+		assert.ErrorIs(t, err, errSettle)
+		ledgerRepo.AssertCalled(t, "Append", mock.MatchedBy(func(transaction LedgerTransaction) bool {
+			return transaction.Method == "REVERSAL"
+		}))
+	})
+
+	t.Run("Error ledger leaves the position", func(t *testing.T) {
+		errLedger := errors.New("ledger failed")
+		stockRepo := repository.NewStockRepositoryDBMock()
+		userRepo := repository.NewUserRepositoryDBMock()
+		stockRepo.On("GetCurrency", statusStockId).Return(model.BaseCurrency, nil)
+		stockRepo.On("GetStatus", statusStockId).Return(model.StockDelisted, nil)
+		userRepo.On("GetStockPositions", statusStockId).Return(map[string]float64{"holder": 10}, nil)
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		ledgerRepo.On("Append", mock.Anything).Return("", errLedger)
+		stockStatusService := service.NewStockStatusService(stockRepo, userRepo, ledgerRepo, newCache())
+
+		_, err := stockStatusService.DelistStock(
+			statusStockId,
+			DelistRequest{Settlement: model.SettlementCash, Price: 15},
+			"admin",
+		)
+
+		assert.ErrorIs(t, err, errLedger)
+		userRepo.AssertNotCalled(t, "SettlePosition", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error invalid settlement", func(t *testing.T) {
+		stockStatusService := service.NewStockStatusService(repository.NewStockRepositoryDBMock(), nil, nil, newCache())
+
+		_, err := stockStatusService.DelistStock(statusStockId, DelistRequest{Settlement: "swap"}, "admin")
+
+		assert.ErrorIs(t, err, errs.ErrSettlement)
+	})
+}
+
+func TestBuyStockNotActive(t *testing.T) {
+	userRepo := repository.NewUserRepositoryDBMock()
+	stockRepo := repository.NewStockRepositoryDBMock()
+	stockRepo.On("GetStatus", statusStockId).Return(model.StockHalted, nil)
+	userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+	_, err := userService.BuyStock(OrderRequest{
+		StockId:     statusStockId,
+		UserId:      "65c8993c48096b5150cee5d6",
+		Price:       10,
+		Amount:      1,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	})
+
+	assert.ErrorIs(t, err, errs.ErrStockNotActive)
+	userRepo.AssertNotCalled(t, "Buy", mock.Anything)
+}
+
+func TestResumeHaltedStocks(t *testing.T) {
+	cache := newCache()
+	stockRepo := repository.NewStockRepositoryDBMock()
+	stockRepo.On("GetExpiredHalts", mock.Anything).Return([]string{statusStockId, "resumed"}, nil)
+	stockRepo.On(
+		"SetStatus",
+		statusStockId,
+		model.StockHalted,
+		StockStatusEvent{Status: model.StockActive, Actor: model.StockSystemActor, Reason: "halt duration is over"},
+	).Return("Successfully set stock status", nil)
+	stockRepo.On(
+		"SetStatus",
+		"resumed",
+		model.StockHalted,
+		mock.Anything,
+	).Return("", errs.ErrStockStatus)
+	stockStatusService := service.NewStockStatusService(stockRepo, nil, nil, cache)
+	keys := []string{
+		"stockCollection:" + statusStockId,
+		"stockCollections",
+		"top10Stock",
+	}
+	seedCache(t, cache, keys...)
+
+	stockIds, err := stockStatusService.ResumeHaltedStocks()
+
+	assert.ErrorIs(t, err, errs.ErrStockStatus)
+	assert.Equal(t, []string{statusStockId}, stockIds)
+	stockRepo.AssertExpectations(t)
+	assertCacheInvalidated(t, cache, keys...)
+}
+
+func TestSetStockPriceBand(t *testing.T) {
+	tradingRule := TradingRule{LimitUp: 0.1, LimitDown: 0.1, HaltDuration: 60}
+
+	t.Run("Set price inside band", func(t *testing.T) {
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetTradingRule", statusStockId).Return(tradingRule, nil)
+		stockRepo.On("GetPreviousClose", statusStockId, mock.Anything).Return(float64(100), nil)
+		stockRepo.On("SetPrice", statusStockId, float64(110)).Return("Successfully set price", nil)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
+
+		message, err := stockService.SetStockPrice(statusStockId, 110)
+
+		assert.Empty(t, err)
+		assert.Equal(t, "Successfully set price", message)
+		stockRepo.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Halt on limit down", func(t *testing.T) {
+		cache := newCache()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetTradingRule", statusStockId).Return(tradingRule, nil)
+		stockRepo.On("GetPreviousClose", statusStockId, mock.Anything).Return(float64(100), nil)
+		stockRepo.On(
+			"SetStatus",
+			statusStockId,
+			model.StockActive,
+			mock.MatchedBy(func(event StockStatusEvent) bool {
+				return event.Status == model.StockHalted &&
+					event.Actor == model.StockSystemActor &&
+					event.Until == event.Timestamp+60
+			}),
+		).Return("Successfully set stock status", nil)
+		stockService := service.NewStockService(stockRepo, cache, objectStore)
+
+		keys := []string{
+			"stockCollection:" + statusStockId,
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
+
+		_, err := stockService.SetStockPrice(statusStockId, 89)
+
+		assert.ErrorIs(t, err, errs.ErrPriceBand)
+		stockRepo.AssertExpectations(t)
+		stockRepo.AssertNotCalled(t, "SetPrice", mock.Anything, mock.Anything)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+
+	t.Run("Error already halted", func(t *testing.T) {
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetTradingRule", statusStockId).Return(tradingRule, nil)
+		stockRepo.On("GetPreviousClose", statusStockId, mock.Anything).Return(float64(100), nil)
+		stockRepo.On("SetStatus", statusStockId, model.StockActive, mock.Anything).Return("", errs.ErrStockStatus)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
+
+		_, err := stockService.SetStockPrice(statusStockId, 111)
+
+		assert.ErrorIs(t, err, errs.ErrPriceBand)
+		stockRepo.AssertNotCalled(t, "SetPrice", mock.Anything, mock.Anything)
+	})
+
+	t.Run("No band", func(t *testing.T) {
+		stockRepo := repository.NewStockRepositoryDBMock()
+		stockRepo.On("GetTradingRule", statusStockId).Return(TradingRule{}, nil)
+		stockRepo.On("SetPrice", statusStockId, float64(1000)).Return("Successfully set price", nil)
+		stockService := service.NewStockService(stockRepo, newCache(), objectStore)
+
+		_, err := stockService.SetStockPrice(statusStockId, 1000)
+
+		assert.Empty(t, err)
+		stockRepo.AssertNotCalled(t, "GetPreviousClose", mock.Anything, mock.Anything)
+	})
+}
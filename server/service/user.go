@@ -8,21 +8,28 @@ type ResponseUserHistory = model.UserHistory
 type OrderRequest = model.OrderRequest
 type BalanceHistory = model.BalanceHistory
 type UserStock = model.UserStock
+type FeeSchedule = model.FeeSchedule
+type FeeSummary = model.FeeSummary
+type Portfolio = model.Portfolio
+type PortfolioPosition = model.PortfolioPosition
 
 type UserService interface {
 	CreateUserAccount(CreateAccount) (string, error)
-	DepositBalance(string, float64) (string, error)
-	WithdrawBalance(string, float64) (string, error)
+	DepositBalance(string, string, float64) (string, error)
+	WithdrawBalance(string, string, float64) (string, error)
 	BuyStock(OrderRequest) (string, error)
 	SaleStock(OrderRequest) (string, error)
 	SetFavoriteStock(string, string) (string, error)
 	GetUserBalanceHistory(string, string, uint) ([]BalanceHistory, error)
 	GetUserBalance(string) (float64, error)
+	GetUserCurrencyBalances(string) (map[string]float64, error)
 	GetUserFavoriteStock(string) ([]string, error)
 	GetUserAccount(string) (UserResponse, error)
 	GetUserTradingHistories(string, uint) ([]ResponseUserHistory, error)
 	GetUserStockHistory(string, string, uint) ([]ResponseUserHistory, error)
 	GetUserStockAmount(string, string) (UserStock, error) 
+	GetUserFeeSummary(string) (FeeSummary, error)
+	GetUserPortfolio(string) (Portfolio, error)
 	DeleteFavoriteStock(string, string) (string, error)
 	DeleteUserAccount(string) (string, error)
 }
\ No newline at end of file
@@ -2,25 +2,40 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
+	"log"
+	"math"
+	"server/errs"
+	"server/model"
 	"server/repository"
+	"server/util"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type UserRepository = repository.UserRepository
 
 type userService struct {
-	userRepo    UserRepository
-	redisClient *redis.Client
+	userRepo       UserRepository
+	stockRepo      StockRepository
+	ledgerRepo     LedgerRepository
+	fxRateProvider FxRateProvider
+	feeSchedule    FeeSchedule
+	cache          Cache
 }
 
 var ctx = context.Background()
 
-func NewUserService(userRepo UserRepository, redisClient *redis.Client) UserService {
-	return userService{userRepo, redisClient}
+func NewUserService(
+	userRepo UserRepository,
+	stockRepo StockRepository,
+	ledgerRepo LedgerRepository,
+	fxRateProvider FxRateProvider,
+	feeSchedule FeeSchedule,
+	cache Cache,
+) UserService {
+	return userService{userRepo, stockRepo, ledgerRepo, fxRateProvider, feeSchedule, cache}
 }
 
 func (s userService) CreateUserAccount(userAccount CreateAccount) (message string, err error) {
@@ -29,63 +44,215 @@ func (s userService) CreateUserAccount(userAccount CreateAccount) (message strin
 		return "", err
 	}
 
+	publishCacheEvents(s.cache, cacheEvent{kind: accountCreated, userId: userAccount.UID})
+
 	return message, nil
 }
 
-func (s userService) DepositBalance(userId string, depositMoney float64) (message string, err error) {
-	message, err = s.userRepo.Deposit(userId, depositMoney)
+func (s userService) DepositBalance(userId string, currency string, depositMoney float64) (message string, err error) {
+	currency = util.NormalizeCurrency(currency)
+	err = s.checkCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+
+	transaction, err := newCashTransaction(userId, currency, "DEPOSIT", "", depositMoney, 0)
+	if err != nil {
+		return "", err
+	}
+
+	message, err = journal(s.ledgerRepo, transaction, func() (string, error) {
+		return s.userRepo.Deposit(userId, currency, depositMoney)
+	})
 	if err != nil {
 		return "", err
 	}
 
-	balanceKey := fmt.Sprintf("balance:%s", userId)
-	s.redisClient.Del(ctx, balanceKey)
+	publishCacheEvents(s.cache, cacheEvent{kind: balanceChanged, userId: userId})
 
 	return message, nil
 }
 
-func (s userService) WithdrawBalance(userId string, withdrawMoney float64) (message string, err error) {
-	message, err = s.userRepo.Withdraw(userId, withdrawMoney)
+func (s userService) WithdrawBalance(userId string, currency string, withdrawMoney float64) (message string, err error) {
+	currency = util.NormalizeCurrency(currency)
+	err = s.checkCurrency(currency)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.checkWithdrawable(userId, currency, withdrawMoney)
 	if err != nil {
 		return "", err
 	}
 
-	balanceKey := fmt.Sprintf("balance:%s", userId)
-	s.redisClient.Del(ctx, balanceKey)
+	transaction, err := newCashTransaction(userId, currency, "WITHDRAW", "", withdrawMoney, 0)
+	if err != nil {
+		return "", err
+	}
+
+	message, err = journal(s.ledgerRepo, transaction, func() (string, error) {
+		return s.userRepo.Withdraw(userId, currency, withdrawMoney)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	publishCacheEvents(s.cache, cacheEvent{kind: balanceChanged, userId: userId})
 
 	return message, nil
 }
 
+// the cash of a margin account backs its loans and short positions, so only
+// the buying power can be withdrawn from it
+func (s userService) checkWithdrawable(userId string, currency string, withdrawMoney float64) error {
+	marginSummary, err := getMarginSummary(s.userRepo, s.stockRepo, s.fxRateProvider, userId)
+	if err != nil {
+		return err
+	}
+
+	if !marginSummary.MarginEnabled && !hasShortPosition(marginSummary) {
+		return nil
+	}
+
+	fxRate, err := s.fxRateProvider.GetRate(currency, model.BaseCurrency)
+	if err != nil {
+		return err
+	}
+
+	buyingPower := math.Max(marginSummary.Equity-marginSummary.InitialRequirement, 0)
+	if withdrawMoney*fxRate > buyingPower {
+		return errs.ErrBuyingPower
+	}
+
+	return nil
+}
+
 func (s userService) BuyStock(orderRequest OrderRequest) (message string, err error) {
-	message, err = s.userRepo.Buy(orderRequest)
+	if len(orderRequest.UserId) == 0 {
+		return "", errs.ErrUser
+	}
+
+	orderRequest.Price, err = s.getFillPrice(orderRequest)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.checkTradingRule(orderRequest)
 	if err != nil {
 		return "", err
 	}
 
-	userId := orderRequest.UserId 
-	stockId := orderRequest.StockId
-	balanceKey := fmt.Sprintf("balance:%s", userId)
-	stockAmountKey := fmt.Sprintf("stockAmount:%s:%s", userId, stockId)
-	userHistoryKey := fmt.Sprintf("userHistory:%s", userId)
-	userStockHistoryKey := fmt.Sprintf("userStockHistory:%s", userId)
-	s.redisClient.Del(ctx, stockAmountKey, userHistoryKey, userStockHistoryKey, balanceKey)
+	orderRequest.Currency, orderRequest.FxRate, err = s.getFxRate(orderRequest)
+	if err != nil {
+		return "", err
+	}
+
+	orderRequest.Fee, err = s.getFee(orderRequest)
+	if err != nil {
+		return "", err
+	}
+
+	if len(orderRequest.TradeId) == 0 {
+		orderRequest.TradeId = primitive.NewObjectID().Hex()
+	}
+	transaction, err := newCashTransaction(
+		orderRequest.UserId,
+		orderRequest.Currency,
+		"BUY",
+		orderRequest.TradeId,
+		orderRequest.Price*orderRequest.Amount*orderRequest.FxRate,
+		orderRequest.Fee,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	message, err = journal(s.ledgerRepo, transaction, func() (string, error) {
+		message, err := s.userRepo.Buy(orderRequest)
+		if errors.Is(err, errs.ErrBalance) {
+			return s.buyOnMargin(orderRequest)
+		}
+
+		return message, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(orderRequest.Counterparty) == 0 {
+		recordTrade(s.stockRepo, orderRequest, orderRequest.UserId, model.MarketCounterparty)
+	}
+
+	publishCacheEvents(
+		s.cache,
+		cacheEvent{kind: positionChanged, userId: orderRequest.UserId, stockId: orderRequest.StockId},
+		cacheEvent{kind: stockChanged, stockId: orderRequest.StockId},
+	)
 
 	return message, nil
 }
 
 func (s userService) SaleStock(orderRequest OrderRequest) (message string, err error) {
-	message, err = s.userRepo.Sale(orderRequest)
+	if len(orderRequest.UserId) == 0 {
+		return "", errs.ErrUser
+	}
+
+	orderRequest.Price, err = s.getFillPrice(orderRequest)
 	if err != nil {
 		return "", err
 	}
 
-	userId := orderRequest.UserId 
-	stockId := orderRequest.StockId
-	balanceKey := fmt.Sprintf("balance:%s", userId)
-	stockAmountKey := fmt.Sprintf("stockAmount:%s:%s", userId, stockId)
-	userHistoryKey := fmt.Sprintf("userHistory:%s", userId)
-	userStockHistoryKey := fmt.Sprintf("userStockHistory:%s", userId)
-	s.redisClient.Del(ctx, stockAmountKey, userHistoryKey, userStockHistoryKey, balanceKey)
+	err = s.checkTradingRule(orderRequest)
+	if err != nil {
+		return "", err
+	}
+
+	orderRequest.Currency, orderRequest.FxRate, err = s.getFxRate(orderRequest)
+	if err != nil {
+		return "", err
+	}
+
+	orderRequest.Fee, err = s.getFee(orderRequest)
+	if err != nil {
+		return "", err
+	}
+
+	if len(orderRequest.TradeId) == 0 {
+		orderRequest.TradeId = primitive.NewObjectID().Hex()
+	}
+	transaction, err := newCashTransaction(
+		orderRequest.UserId,
+		orderRequest.Currency,
+		"SALE",
+		orderRequest.TradeId,
+		orderRequest.Price*orderRequest.Amount*orderRequest.FxRate,
+		orderRequest.Fee,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	message, err = journal(s.ledgerRepo, transaction, func() (string, error) {
+		message, err := s.userRepo.Sale(orderRequest)
+		if errors.Is(err, errs.ErrNotEnoughStock) {
+			return s.sellShort(orderRequest)
+		}
+
+		return message, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(orderRequest.Counterparty) == 0 {
+		recordTrade(s.stockRepo, orderRequest, model.MarketCounterparty, orderRequest.UserId)
+	}
+
+	publishCacheEvents(
+		s.cache,
+		cacheEvent{kind: positionChanged, userId: orderRequest.UserId, stockId: orderRequest.StockId},
+		cacheEvent{kind: stockChanged, stockId: orderRequest.StockId},
+	)
 
 	return message, nil
 }
@@ -96,8 +263,7 @@ func (s userService) SetFavoriteStock(userId string, stockId string) (message st
 		return "", err
 	}
 
-	favoriteKey := fmt.Sprintf("favorite:%s", userId)
-	s.redisClient.Del(ctx, favoriteKey)
+	publishCacheEvents(s.cache, cacheEvent{kind: favoriteChanged, userId: userId})
 
 	return message, nil
 }
@@ -112,155 +278,404 @@ func (s userService) GetUserBalanceHistory(userId string, method string, perpage
 }
 
 func (s userService) GetUserBalance(userId string) (balance float64, err error) {
-	balanceKey := fmt.Sprintf("balance:%s", userId)
-	result, err := s.userRepo.GetBalance(userId)
+	return readThrough(s.cache, balanceCacheKey(userId), func() (float64, error) {
+		return s.userRepo.GetBalance(userId)
+	})
+}
+
+func (s userService) GetUserCurrencyBalances(userId string) (balances map[string]float64, err error) {
+	balances, err = s.userRepo.GetCurrencyBalances(userId)
 	if err != nil {
-		return 0, err
+		return map[string]float64{}, err
 	}
 
-	if balanceJson, err := s.redisClient.Get(ctx, balanceKey).Result(); err == nil {
-		if json.Unmarshal([]byte(balanceJson), &balance) == nil {
-			return balance, nil
+	return balances, nil
+}
+
+func (s userService) GetUserFavoriteStock(userId string) (favoriteStocks []string, err error) {
+	favoriteStocks, err = readThrough(s.cache, favoriteCacheKey(userId), func() ([]string, error) {
+		return s.userRepo.GetFavorite(userId)
+	})
+	if err != nil {
+		return []string{}, err
+	}
+
+	return favoriteStocks, nil
+}
+
+func (s userService) GetUserAccount(userId string) (userResponse UserResponse, err error) {
+	return readThrough(s.cache, userCacheKey(userId), func() (UserResponse, error) {
+		result, err := s.userRepo.GetAccount(userId)
+		if err != nil {
+			return UserResponse{}, err
 		}
+
+		return UserResponse{
+			Name:         result.Name,
+			ProfileImage: result.ProfileImage,
+			Email:        result.Email,
+		}, nil
+	})
+}
+
+// only the first page is cached
+func (s userService) GetUserTradingHistories(userId string, startPage uint) (userHistories []ResponseUserHistory, err error) {
+	load := func() ([]ResponseUserHistory, error) {
+		return s.userRepo.GetAllHistories(userId, startPage)
 	}
 
-	if data, err := json.Marshal(result); err == nil {
-		s.redisClient.Set(ctx, balanceKey, string(data), time.Second*3600)
+	if startPage == 0 {
+		userHistories, err = readThrough(s.cache, userHistoryCacheKey(userId), load)
+	} else {
+		userHistories, err = load()
+	}
+	if err != nil {
+		return []ResponseUserHistory{}, err
 	}
 
-	return result, nil
+	return userHistories, nil
 }
 
-func (s userService) GetUserFavoriteStock(userId string) (favoriteStocks []string, err error) {
-	favoriteKey := fmt.Sprintf("favorite:%s", userId)
-	result, err := s.userRepo.GetFavorite(userId)
+// only the first page is cached
+func (s userService) GetUserStockHistory(userId string, stockId string, startPage uint) (userStockHistories []ResponseUserHistory, err error) {
+	load := func() ([]ResponseUserHistory, error) {
+		return s.userRepo.GetUserStockHistory(userId, stockId, startPage)
+	}
+
+	if startPage == 0 {
+		userStockHistories, err = readThrough(s.cache, userStockHistoryCacheKey(userId, stockId), load)
+	} else {
+		userStockHistories, err = load()
+	}
 	if err != nil {
-		return []string{}, err
+		return []ResponseUserHistory{}, err
 	}
 
-	if balanceJson, err := s.redisClient.Get(ctx, favoriteKey).Result(); err == nil {
-		if json.Unmarshal([]byte(balanceJson), &favoriteStocks) == nil {
-			return favoriteStocks, nil
-		}
+	return userStockHistories, nil
+}
+
+func (s userService) GetUserStockAmount(userId string, stockId string) (userStock UserStock, err error) {
+	return readThrough(s.cache, stockAmountCacheKey(userId, stockId), func() (UserStock, error) {
+		return s.userRepo.GetStockAmount(userId, stockId)
+	})
+}
+
+func (s userService) GetUserFeeSummary(userId string) (feeSummary FeeSummary, err error) {
+	feeSummary, err = s.userRepo.GetFeeSummary(userId)
+	if err != nil {
+		return FeeSummary{}, err
 	}
 
-	if data, err := json.Marshal(result); err == nil {
-		s.redisClient.Set(ctx, favoriteKey, string(data), time.Second*3600)
+	since := time.Now().AddDate(0, 0, -30).Unix()
+	feeSummary.Volume30Day, err = s.userRepo.GetTradingVolume(userId, since)
+	if err != nil {
+		return FeeSummary{}, err
 	}
 
-	return result, nil
+	feeSummary.FlatFee = s.feeSchedule.Flat
+	feeSummary.Percent = util.FeePercent(s.feeSchedule, feeSummary.Volume30Day)
+	feeSummary.MakerRate = s.feeSchedule.MakerRate
+	feeSummary.TakerRate = s.feeSchedule.TakerRate
+
+	return feeSummary, nil
 }
 
-func (s userService) GetUserAccount(userId string) (userResponse UserResponse, err error) {
-	userKey := fmt.Sprintf("user:%s", userId)
-	result, err := s.userRepo.GetAccount(userId)
+// positions are valued at the market price and the current fx rate, the
+// pnl of a short position is above zero when the price fell
+func (s userService) GetUserPortfolio(userId string) (portfolio Portfolio, err error) {
+	userAccount, err := s.userRepo.GetAccount(userId)
+	if err != nil {
+		return Portfolio{}, err
+	}
+
+	cash, err := getAccountCash(s.fxRateProvider, userAccount)
 	if err != nil {
-		return UserResponse{}, err
+		return Portfolio{}, err
+	}
+
+	portfolio = Portfolio{
+		Cash:      cash,
+		Positions: []PortfolioPosition{},
 	}
+	for _, userStock := range userAccount.Stock {
+		if userStock.Amount == 0 {
+			continue
+		}
+
+		price, currency, fxRate, err := getStockQuote(s.stockRepo, s.fxRateProvider, userStock.StockId)
+		if err != nil {
+			return Portfolio{}, err
+		}
 
-	if userResponseJson, err := s.redisClient.Get(ctx, userKey).Result(); err == nil {
-		if json.Unmarshal([]byte(userResponseJson), &userResponse) == nil {
-			return userResponse, nil
+		position := PortfolioPosition{
+			StockId:      userStock.StockId,
+			Amount:       userStock.Amount,
+			Currency:     currency,
+			Price:        price,
+			AveragePrice: userStock.AveragePrice,
+			Value:        price * userStock.Amount * fxRate,
+			CostBasis:    userStock.AveragePrice * userStock.Amount * fxRate,
 		}
+		position.UnrealizedPnl = position.Value - position.CostBasis
+
+		portfolio.Positions = append(portfolio.Positions, position)
+		portfolio.MarketValue += position.Value
+		portfolio.CostBasis += position.CostBasis
+		portfolio.UnrealizedPnl += position.UnrealizedPnl
 	}
 
-	userResponse = UserResponse{
-		Name:         result.Name,
-		ProfileImage: result.ProfileImage,
-		Email:        result.Email,
+	portfolio.Equity = portfolio.Cash + portfolio.MarketValue
+
+	return portfolio, nil
+}
+
+func (s userService) DeleteFavoriteStock(userId string, stockId string) (message string, err error) {
+	message, err = s.userRepo.DeleteFavorite(userId, stockId)
+	if err != nil {
+		return "", err
 	}
 
-	if data, err := json.Marshal(userResponse); err == nil {
-		s.redisClient.Set(ctx, userKey, string(data), time.Second*3600)
+	publishCacheEvents(s.cache, cacheEvent{kind: favoriteChanged, userId: userId})
+
+	return message, nil
+}
+
+func (s userService) DeleteUserAccount(userId string) (message string, err error) {
+	message, err = s.userRepo.DeleteAccount(userId)
+	if err != nil {
+		return "", err
 	}
 
-	return userResponse, nil
+	publishCacheEvents(s.cache, cacheEvent{kind: accountDeleted, userId: userId})
+
+	return message, nil
 }
 
-func (s userService) GetUserTradingHistories(userId string, startPage uint) (userHistories []ResponseUserHistory, err error) {
-	result, err := s.userRepo.GetAllHistories(userId, startPage)
+// the price of the order request is the fill price
+func (s userService) checkTradingRule(orderRequest OrderRequest) error {
+	tradingRule, err := s.stockRepo.GetTradingRule(orderRequest.StockId)
 	if err != nil {
-		return []ResponseUserHistory{}, err
+		return err
 	}
 
-	if startPage == 0 {
-		userHistoryKey := fmt.Sprintf("userHistory:%s", userId)
-		if historyJson, err := s.redisClient.Get(ctx, userHistoryKey).Result(); err == nil {
-			if json.Unmarshal([]byte(historyJson), &userHistories) == nil {
-				return userHistories, nil
-			}
-		}
-	
-		if data, err := json.Marshal(result); err == nil {
-			s.redisClient.Set(ctx, userHistoryKey, string(data), time.Second*3600)
-		}
+	err = util.CheckTradingRule(tradingRule, orderRequest.Price, orderRequest.Amount)
+	if err != nil {
+		return err
 	}
 
-	return result, nil
+	return checkPriceBand(s.stockRepo, s.cache, orderRequest.StockId, orderRequest.Price)
 }
 
-func (s userService) GetUserStockHistory(userId string, stockId string, startPage uint) (userStockHistories []ResponseUserHistory, err error) {
-	userStockHistoryKey := fmt.Sprintf("userStockHistory:%s", userId)
-	result, err := s.userRepo.GetUserStockHistory(userId, stockId, startPage)
+// an order the cash cannot cover is bought on margin when the account is
+// margin enabled, the loan is always in base currency and buying back a
+// short position needs no buying power
+func (s userService) buyOnMargin(orderRequest OrderRequest) (string, error) {
+	if orderRequest.Currency != model.BaseCurrency {
+		return "", errs.ErrBalance
+	}
+
+	marginSummary, err := getMarginSummary(s.userRepo, s.stockRepo, s.fxRateProvider, orderRequest.UserId)
 	if err != nil {
-		return []ResponseUserHistory{}, err
+		return "", err
 	}
 
-	if startPage == 0 {
-		if stockHistoryJson, err := s.redisClient.Get(ctx, userStockHistoryKey).Result(); err == nil {
-			if json.Unmarshal([]byte(stockHistoryJson), &userStockHistories) == nil {
-				return userStockHistories, nil
-			}
-		}
-	
-		if data, err := json.Marshal(result); err == nil {
-			s.redisClient.Set(ctx, userStockHistoryKey, string(data), time.Second*3600)
-		}
+	if !marginSummary.MarginEnabled {
+		return "", errs.ErrBalance
+	}
+
+	openAmount := orderRequest.Amount + math.Min(positionAmount(marginSummary, orderRequest.StockId), 0)
+	err = s.checkBuyingPower(marginSummary, orderRequest, openAmount)
+	if err != nil {
+		return "", err
 	}
 
-	return result, nil
+	orderRequest.Margin = true
+
+	return s.userRepo.Buy(orderRequest)
 }
 
-func (s userService) GetUserStockAmount(userId string, stockId string) (userStock UserStock, err error) {
-	stockAmountKey := fmt.Sprintf("stockAmount:%s:%s", userId, stockId)
-	result, err := s.userRepo.GetStockAmount(userId, stockId)
+// a sale of more than the position opens a short position for the rest,
+// the proceeds are kept as collateral
+func (s userService) sellShort(orderRequest OrderRequest) (string, error) {
+	if orderRequest.Currency != model.BaseCurrency {
+		return "", errs.ErrNotEnoughStock
+	}
+
+	marginSummary, err := getMarginSummary(s.userRepo, s.stockRepo, s.fxRateProvider, orderRequest.UserId)
 	if err != nil {
-		return UserStock{}, err
+		return "", err
+	}
+
+	if !marginSummary.MarginEnabled {
+		return "", errs.ErrNotEnoughStock
 	}
 
-	if userStockJson, err := s.redisClient.Get(ctx, stockAmountKey).Result(); err == nil {
-		if json.Unmarshal([]byte(userStockJson), &userStock) == nil {
- 			return userStock, nil
+	tradingRule, err := s.stockRepo.GetTradingRule(orderRequest.StockId)
+	if err != nil {
+		return "", err
+	}
+
+	if !tradingRule.Shortable {
+		return "", errs.ErrShort
+	}
+
+	openAmount := orderRequest.Amount - math.Max(positionAmount(marginSummary, orderRequest.StockId), 0)
+	err = s.checkBuyingPower(marginSummary, orderRequest, openAmount)
+	if err != nil {
+		return "", err
+	}
+
+	orderRequest.Short = true
+
+	return s.userRepo.Sale(orderRequest)
+}
+
+// only the amount that opens or adds to a position needs buying power
+func (s userService) checkBuyingPower(marginSummary MarginSummary, orderRequest OrderRequest, openAmount float64) error {
+	if openAmount <= 0 {
+		return nil
+	}
+
+	marginCall := marginSummary.MarginCall
+	if marginCall != nil && marginCall.Status == model.MarginCallIssued {
+		return errs.ErrBuyingPower
+	}
+
+	tradingRule, err := s.stockRepo.GetTradingRule(orderRequest.StockId)
+	if err != nil {
+		return err
+	}
+
+	initialMargin, _ := util.MarginRates(tradingRule)
+	value := orderRequest.Price * openAmount * orderRequest.FxRate
+	if value*initialMargin+orderRequest.Fee > marginSummary.BuyingPower {
+		return errs.ErrBuyingPower
+	}
+
+	return nil
+}
+
+// a short position stays after margin is turned off
+func hasShortPosition(marginSummary MarginSummary) bool {
+	for _, position := range marginSummary.Positions {
+		if position.Amount < 0 {
+			return true
 		}
 	}
 
-	if data, err := json.Marshal(result); err == nil {
-		s.redisClient.Set(ctx, stockAmountKey, string(data), time.Second*3600)
+	return false
+}
+
+func positionAmount(marginSummary MarginSummary, stockId string) float64 {
+	for _, position := range marginSummary.Positions {
+		if position.StockId == stockId {
+			return position.Amount
+		}
 	}
 
-	return result, nil
+	return 0
 }
 
-func (s userService) DeleteFavoriteStock(userId string, stockId string) (message string, err error) {
-	message, err = s.userRepo.DeleteFavorite(userId, stockId)
+func (s userService) getFillPrice(orderRequest OrderRequest) (float64, error) {
+	err := checkStockActive(s.stockRepo, orderRequest.StockId)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
 
-	favoriteKey := fmt.Sprintf("favorite:%s", userId)
-	s.redisClient.Del(ctx, favoriteKey)
+	marketPrice, err := s.stockRepo.GetPrice(orderRequest.StockId)
+	if err != nil {
+		return 0, err
+	}
 
-	return message, nil
+	fillPrice, err := util.GetFillPrice(
+		orderRequest.OrderType,
+		orderRequest.OrderMethod,
+		orderRequest.Price,
+		marketPrice,
+		orderRequest.MaxSlippage,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return fillPrice, nil
 }
 
-func (s userService) DeleteUserAccount(userId string) (message string, err error) {
-	message, err = s.userRepo.DeleteAccount(userId)
+func (s userService) checkCurrency(currency string) error {
+	if !util.ValidCurrency(currency) {
+		return errs.ErrCurrency
+	}
+
+	_, err := s.fxRateProvider.GetRate(currency, model.BaseCurrency)
+	if errors.Is(err, errs.ErrFxRate) {
+		return errs.ErrCurrency
+	}
+
+	return err
+}
+
+// the order is quoted in the stock currency and settled in the order
+// currency at the rate of the order time
+func (s userService) getFxRate(orderRequest OrderRequest) (string, float64, error) {
+	currency := util.NormalizeCurrency(orderRequest.Currency)
+	err := s.checkCurrency(currency)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
-	userKey := fmt.Sprintf("user:%s", userId)
-	s.redisClient.Del(ctx, userKey)
+	quoteCurrency, err := s.stockRepo.GetCurrency(orderRequest.StockId)
+	if err != nil {
+		return "", 0, err
+	}
 
-	return message, nil
+	fxRate, err := s.fxRateProvider.GetRate(quoteCurrency, currency)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return currency, fxRate, nil
+}
+
+// the 30-day volume is only needed when the schedule is tiered
+func (s userService) getFee(orderRequest OrderRequest) (float64, error) {
+	value := orderRequest.Price * orderRequest.Amount * orderRequest.FxRate
+
+	volume := 0.0
+	if len(s.feeSchedule.Tiers) > 0 {
+		since := time.Now().AddDate(0, 0, -30).Unix()
+
+		var err error
+		volume, err = s.userRepo.GetTradingVolume(orderRequest.UserId, since)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	fee := util.CalculateFee(s.feeSchedule, orderRequest.OrderType, value, volume)
+	if orderRequest.OrderMethod == "sale" && fee >= value {
+		return 0, errs.ErrFee
+	}
+
+	return fee, nil
+}
+
+// the stock trade record is what volume, graph and top stocks are built
+// from, it shares the trade id with the user history entry
+// the trade already moved the money and the stock, a trade that could not
+// be recorded is only logged
+func recordTrade(stockRepo StockRepository, orderRequest OrderRequest, buyerId string, sellerId string) {
+	trade := StockHistory{
+		ID:        orderRequest.UserId,
+		TradeId:   orderRequest.TradeId,
+		BuyerId:   buyerId,
+		SellerId:  sellerId,
+		Timestamp: time.Now().Unix(),
+		Amount:    orderRequest.Amount,
+		Price:     orderRequest.Price,
+	}
+
+	_, err := stockRepo.CreateStockOrder(orderRequest.StockId, trade)
+	if err != nil {
+		log.Printf("trade %s: record: %v", orderRequest.TradeId, err)
+	}
 }
@@ -15,13 +15,13 @@ func (m *userServiceMock) CreateUserAccount(userAccount CreateAccount) (string,
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *userServiceMock) DepositBalance(userId string, depositMoney float64) (string, error) {
-	arge := m.Called(userId, depositMoney)
+func (m *userServiceMock) DepositBalance(userId string, currency string, depositMoney float64) (string, error) {
+	arge := m.Called(userId, currency, depositMoney)
 	return arge.String(0), arge.Error(1)
 }
 
-func (m *userServiceMock) WithdrawBalance(userId string, withdrawMoney float64) (string, error) {
-	arge := m.Called(userId, withdrawMoney)
+func (m *userServiceMock) WithdrawBalance(userId string, currency string, withdrawMoney float64) (string, error) {
+	arge := m.Called(userId, currency, withdrawMoney)
 	return arge.String(0), arge.Error(1)
 }
 
@@ -50,6 +50,11 @@ func (m *userServiceMock) GetUserBalance(userId string) (float64, error) {
 	return float64(arge.Int(0)), arge.Error(1)
 }
 
+func (m *userServiceMock) GetUserCurrencyBalances(userId string) (map[string]float64, error) {
+	arge := m.Called(userId)
+	return arge.Get(0).(map[string]float64), arge.Error(1)
+}
+
 func (m *userServiceMock) GetUserFavoriteStock(userId string) ([]string, error) {
 	arge := m.Called(userId)
 	return arge.Get(0).([]string), arge.Error(1)
@@ -75,6 +80,16 @@ func (m *userServiceMock) GetUserStockAmount(userId string, stockId string) (Use
 	return arge.Get(0).(UserStock), arge.Error(1)
 }
 
+func (m *userServiceMock) GetUserFeeSummary(userId string) (FeeSummary, error) {
+	arge := m.Called(userId)
+	return arge.Get(0).(FeeSummary), arge.Error(1)
+}
+
+func (m *userServiceMock) GetUserPortfolio(userId string) (Portfolio, error) {
+	arge := m.Called(userId)
+	return arge.Get(0).(Portfolio), arge.Error(1)
+}
+
 func (m *userServiceMock) DeleteFavoriteStock(userId string, stockId string) (string, error) {
 	arge := m.Called(userId, stockId)
 	return arge.String(0), arge.Error(1)
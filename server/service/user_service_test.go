@@ -3,12 +3,12 @@ package service_test
 import (
 	"server/errs"
 	"server/model"
-	"server/redis"
 	"server/repository"
 	"server/service"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -18,9 +18,18 @@ type BalanceHistory = model.BalanceHistory
 type UserAccount = model.UserAccount
 type UserHistory = model.UserHistory
 type UserStock = model.UserStock
+type LedgerTransaction = model.LedgerTransaction
+type LedgerEntry = model.LedgerEntry
 
-var redisClient = redis.InitRedis()
 var userRepo = repository.NewUserRepositoryDBMock()
+var ledgerRepo = initLedgerRepo()
+var fxRateProvider = service.NewStaticFxRateProvider(service.FxRates{"USD": 36.5})
+
+// every test gets its own cache so a value cached by one test, including
+// a cached error, is never read by another
+func newCache() service.Cache {
+	return service.NewMemoryCache(10000)
+}
 
 var (
 	ErrData         = errs.ErrData
@@ -30,12 +39,34 @@ var (
 	ErrOrderMethod  = errs.ErrOrderMethod
 )
 
+func initLedgerRepo() repository.LedgerRepository {
+	ledgerRepo := repository.NewLedgerRepositoryDBMock()
+	ledgerRepo.
+		On("Append", mock.Anything).
+		Return("Successfully appended ledger transaction", nil)
+
+	return ledgerRepo
+}
+
+// orders without a currency are settled in base currency at rate 1
+func matchOrder(expected OrderRequest) interface{} {
+	if len(expected.Currency) == 0 {
+		expected.Currency = model.BaseCurrency
+		expected.FxRate = 1
+	}
+
+	return mock.MatchedBy(func(actual OrderRequest) bool {
+		actual.TradeId = ""
+		return actual == expected
+	})
+}
+
 func TestCreateUserAccount(t *testing.T) {
 	expected := "Successfully created account"
 
 	t.Run("Error invalid data", func(t *testing.T) {
 		userRepo.On("Create", CreateAccount{}).Return(expected, ErrData)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.CreateUserAccount(CreateAccount{})
 
@@ -50,7 +81,7 @@ func TestCreateUserAccount(t *testing.T) {
 		}
 
 		userRepo.On("Create", account).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.CreateUserAccount(account)
 
@@ -66,12 +97,14 @@ func TestDepositBalance(t *testing.T) {
 		userRepo.On(
 			"Deposit",
 			"65c8993c48096b5150cee5d6",
+			"THB",
 			float64(0),
 		).Return(expected, ErrMoney)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.DepositBalance(
 			"65c8993c48096b5150cee5d6",
+			"THB",
 			float64(0),
 		)
 
@@ -79,36 +112,169 @@ func TestDepositBalance(t *testing.T) {
 	})
 
 	t.Run("Deposit balance", func(t *testing.T) {
+		cache := newCache()
 		userRepo.On(
 			"Deposit",
 			"65c8993c48096b5150cee5d6",
+			"THB",
 			float64(1),
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+
+		seedCache(t, cache, "balance:65c8993c48096b5150cee5d6")
 
 		actual, err := userService.DepositBalance(
 			"65c8993c48096b5150cee5d6",
+			"THB",
 			1,
 		)
 
 		assert.Empty(t, err)
 		assert.Equal(t, expected, actual)
+		assertCacheInvalidated(t, cache, "balance:65c8993c48096b5150cee5d6")
+	})
+}
+
+func TestDepositBalanceLedger(t *testing.T) {
+	userRepo := repository.NewUserRepositoryDBMock()
+	ledgerRepo := repository.NewLedgerRepositoryDBMock()
+
+	userRepo.On(
+		"Deposit",
+		"65c8993c48096b5150cee5d7",
+		"THB",
+		float64(100),
+	).Return("Successfully deposited money", nil)
+	ledgerRepo.On(
+		"Append",
+		mock.MatchedBy(func(transaction LedgerTransaction) bool {
+			return transaction.UID == "65c8993c48096b5150cee5d7" &&
+				transaction.Method == "DEPOSIT" &&
+				assert.ObjectsAreEqual([]LedgerEntry{
+					{Account: model.LedgerBankAccount, Debit: 100},
+					{Account: "user:65c8993c48096b5150cee5d7:cash", Credit: 100},
+				}, transaction.Entries)
+		}),
+	).Return("Successfully appended ledger transaction", nil)
+	userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+	_, err := userService.DepositBalance("65c8993c48096b5150cee5d7", "THB", 100)
+
+	assert.Empty(t, err)
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestDepositBalanceCurrency(t *testing.T) {
+	userRepo := repository.NewUserRepositoryDBMock()
+	ledgerRepo := repository.NewLedgerRepositoryDBMock()
+
+	userRepo.On(
+		"Deposit",
+		"65c8993c48096b5150cee5d7",
+		"USD",
+		float64(100),
+	).Return("Successfully deposited money", nil)
+	ledgerRepo.On(
+		"Append",
+		mock.MatchedBy(func(transaction LedgerTransaction) bool {
+			return transaction.Currency == "USD" &&
+				assert.ObjectsAreEqual([]LedgerEntry{
+					{Account: "bank:USD", Debit: 100},
+					{Account: "user:65c8993c48096b5150cee5d7:cash:USD", Credit: 100},
+				}, transaction.Entries)
+		}),
+	).Return("Successfully appended ledger transaction", nil)
+	userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+	_, err := userService.DepositBalance("65c8993c48096b5150cee5d7", "usd", 100)
+
+	assert.Empty(t, err)
+	ledgerRepo.AssertExpectations(t)
+
+	_, err = userService.DepositBalance("65c8993c48096b5150cee5d7", "EUR", 100)
+
+	assert.ErrorIs(t, err, errs.ErrCurrency)
+}
+
+func TestDepositBalanceReversal(t *testing.T) {
+	t.Run("Error ledger", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		ledgerRepo.On("Append", mock.Anything).Return("", errs.ErrData)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.DepositBalance("65c8993c48096b5150cee5d7", "THB", 100)
+
+		assert.ErrorIs(t, err, errs.ErrData)
+		userRepo.AssertNotCalled(t, "Deposit", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reverse the ledger when the deposit failed", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		ledgerRepo := repository.NewLedgerRepositoryDBMock()
+		var transactions []LedgerTransaction
+		userRepo.On(
+			"Deposit",
+			"65c8993c48096b5150cee5d7",
+			"THB",
+			float64(100),
+		).Return("", ErrUser)
+		ledgerRepo.On("Append", mock.Anything).Run(func(args mock.Arguments) {
+			transactions = append(transactions, args.Get(0).(LedgerTransaction))
+		}).Return("Successfully appended ledger transaction", nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.DepositBalance("65c8993c48096b5150cee5d7", "THB", 100)
+
+		assert.ErrorIs(t, err, ErrUser)
+		assert.Len(t, transactions, 2)
+		assert.Equal(t, "REVERSAL", transactions[1].Method)
+		assert.Equal(t, transactions[0].ID.Hex(), transactions[1].Reference)
+		assert.Equal(t, []LedgerEntry{
+			{Account: model.LedgerBankAccount, Credit: 100},
+			{Account: "user:65c8993c48096b5150cee5d7:cash", Debit: 100},
+		}, transactions[1].Entries)
 	})
 }
 
+func TestBuyStockRecordFailed(t *testing.T) {
+	userRepo := repository.NewUserRepositoryDBMock()
+	stockRepo := repository.NewStockRepositoryDBMock()
+	orderRequest := OrderRequest{
+		StockId:     "65c39a03dfb8060d99995937",
+		UserId:      "65c8993c48096b5150cee5d6",
+		Price:       100,
+		Amount:      10,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	}
+
+	stockRepo.On("GetStatus", orderRequest.StockId).Return(model.StockActive, nil)
+	stockRepo.On("GetTradingRule", orderRequest.StockId).Return(TradingRule{}, nil)
+	stockRepo.On("GetPrice", orderRequest.StockId).Return(100, nil)
+	stockRepo.On("GetCurrency", orderRequest.StockId).Return(model.BaseCurrency, nil)
+	stockRepo.On("CreateStockOrder", orderRequest.StockId, mock.Anything).Return("", ErrData)
+	userRepo.On("Buy", matchOrder(orderRequest)).Return("Successfully bought stock", nil)
+	userService := service.NewUserService(userRepo, stockRepo, initLedgerRepo(), fxRateProvider, service.FeeSchedule{}, newCache())
+
+	// the money and the stock already moved
+	actual, err := userService.BuyStock(orderRequest)
+
+	assert.Empty(t, err)
+	assert.Equal(t, "Successfully bought stock", actual)
+}
+
 func TestWithdrawBalance(t *testing.T) {
 	expected := "Successfully withdrawed money"
 
 	t.Run("Error invalid user", func(t *testing.T) {
-		userRepo.On(
-			"Withdraw",
-			"",
-			float64(1),
-		).Return(expected, ErrUser)
-		userService := service.NewUserService(userRepo, redisClient)
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", "").Return(UserAccount{}, ErrUser)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.WithdrawBalance(
 			"",
+			"THB",
 			1,
 		)
 
@@ -116,20 +282,28 @@ func TestWithdrawBalance(t *testing.T) {
 	})
 
 	t.Run("Withdraw balance", func(t *testing.T) {
+		cache := newCache()
+		userRepo := repository.NewUserRepositoryDBMock()
+		userRepo.On("GetAccount", "65c8993c48096b5150cee5d6").Return(UserAccount{Balance: 1}, nil)
 		userRepo.On(
 			"Withdraw",
 			"65c8993c48096b5150cee5d6",
+			"THB",
 			float64(1),
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+
+		seedCache(t, cache, "balance:65c8993c48096b5150cee5d6")
 
 		actual, err := userService.WithdrawBalance(
 			"65c8993c48096b5150cee5d6",
+			"THB",
 			1,
 		)
 
 		assert.Empty(t, err)
 		assert.Equal(t, expected, actual)
+		assertCacheInvalidated(t, cache, "balance:65c8993c48096b5150cee5d6")
 	})
 }
 
@@ -141,7 +315,7 @@ func TestBuyStock(t *testing.T) {
 			"Buy",
 			OrderRequest{},
 		).Return(expected, ErrUser)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.BuyStock(OrderRequest{})
 
@@ -149,6 +323,7 @@ func TestBuyStock(t *testing.T) {
 	})
 
 	t.Run("Buy stock", func(t *testing.T) {
+		cache := newCache()
 		orderRequest := OrderRequest{
 			StockId:     "65c39a03dfb8060d99995934",
 			UserId:      "65c8993c48096b5150cee5d6",
@@ -158,17 +333,298 @@ func TestBuyStock(t *testing.T) {
 			OrderMethod: "buy",
 		}
 
+		stockRepo.On("GetStatus", "65c39a03dfb8060d99995934").Return(model.StockActive, nil)
+		stockRepo.On(
+			"GetTradingRule",
+			"65c39a03dfb8060d99995934",
+		).Return(TradingRule{}, nil)
+		stockRepo.On(
+			"GetPrice",
+			"65c39a03dfb8060d99995934",
+		).Return(60, nil)
+		stockRepo.On(
+			"GetCurrency",
+			"65c39a03dfb8060d99995934",
+		).Return(model.BaseCurrency, nil)
 		userRepo.On(
 			"Buy",
-			orderRequest,
+			matchOrder(orderRequest),
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		stockRepo.On(
+			"CreateStockOrder",
+			orderRequest.StockId,
+			mock.Anything,
+		).Return("Successfully created stock order", nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+
+		keys := []string{
+			"balance:65c8993c48096b5150cee5d6",
+			"stockAmount:65c8993c48096b5150cee5d6:65c39a03dfb8060d99995934",
+			"userHistory:65c8993c48096b5150cee5d6",
+			"userStockHistory:65c8993c48096b5150cee5d6:65c39a03dfb8060d99995934",
+			"stockCollection:65c39a03dfb8060d99995934",
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
 
 		actual, err := userService.BuyStock(orderRequest)
 
 		assert.Empty(t, err)
 		assert.Equal(t, expected, actual)
+		assertCacheInvalidated(t, cache, keys...)
 	})
+
+	t.Run("Error trading rule", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		tradingRule := TradingRule{
+			TickSize:    5,
+			LotSize:     100,
+			MinQuantity: 100,
+			MaxQuantity: 1000,
+			MinNotional: 5000,
+		}
+		orderRequest := OrderRequest{
+			StockId:     "65c39a03dfb8060d99995935",
+			UserId:      "65c8993c48096b5150cee5d6",
+			OrderType:   "order",
+			OrderMethod: "buy",
+		}
+
+		// the rule is checked against the fill price, the market price
+		// below the limit
+		cases := []struct {
+			price       float64
+			marketPrice int
+			amount      float64
+			expected    error
+		}{
+			{price: 61, marketPrice: 61, amount: 100, expected: errs.ErrTickSize},
+			{price: 60, marketPrice: 60, amount: 150, expected: errs.ErrLotSize},
+			{price: 60, marketPrice: 60, amount: 0, expected: errs.ErrMinQuantity},
+			{price: 60, marketPrice: 60, amount: 1100, expected: errs.ErrMaxQuantity},
+			{price: 60, marketPrice: 61, amount: 100, expected: errs.ErrLimitPrice},
+			{price: 60, marketPrice: 45, amount: 100, expected: errs.ErrMinNotional},
+		}
+
+		for _, c := range cases {
+			stockRepo := repository.NewStockRepositoryDBMock()
+			stockRepo.On("GetStatus", orderRequest.StockId).Return(model.StockActive, nil)
+			stockRepo.On("GetPrice", orderRequest.StockId).Return(c.marketPrice, nil)
+			stockRepo.On(
+				"GetTradingRule",
+				orderRequest.StockId,
+			).Return(tradingRule, nil)
+			userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+			orderRequest.Price = c.price
+			orderRequest.Amount = c.amount
+
+			_, err := userService.BuyStock(orderRequest)
+
+			assert.ErrorIs(t, err, c.expected)
+		}
+		userRepo.AssertNotCalled(t, "Buy", mock.Anything)
+	})
+
+	t.Run("Fill at market price", func(t *testing.T) {
+		maxSlippage := 0.05
+		userRepo := repository.NewUserRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		orderRequest := OrderRequest{
+			StockId:     "65c39a03dfb8060d99995936",
+			UserId:      "65c8993c48096b5150cee5d6",
+			Price:       0.01,
+			Amount:      8,
+			OrderType:   "auto",
+			OrderMethod: "buy",
+			MaxSlippage: &maxSlippage,
+		}
+
+		stockRepo.On("GetStatus", orderRequest.StockId).Return(model.StockActive, nil)
+		stockRepo.On(
+			"GetTradingRule",
+			orderRequest.StockId,
+		).Return(TradingRule{}, nil)
+		stockRepo.On(
+			"GetPrice",
+			orderRequest.StockId,
+		).Return(60, nil)
+		stockRepo.On(
+			"GetCurrency",
+			orderRequest.StockId,
+		).Return(model.BaseCurrency, nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		_, err := userService.BuyStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrSlippage)
+
+		orderRequest.Price = 58
+		filledRequest := orderRequest
+		filledRequest.Price = 60
+		userRepo.On(
+			"Buy",
+			matchOrder(filledRequest),
+		).Return(expected, nil)
+		stockRepo.On(
+			"CreateStockOrder",
+			orderRequest.StockId,
+			mock.Anything,
+		).Return("Successfully created stock order", nil)
+
+		actual, err := userService.BuyStock(orderRequest)
+
+		assert.Empty(t, err)
+		assert.Equal(t, expected, actual)
+
+		orderRequest.OrderType = "order"
+		_, err = userService.BuyStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrLimitPrice)
+
+		invalidSlippage := 2.0
+		orderRequest.MaxSlippage = &invalidSlippage
+		_, err = userService.BuyStock(orderRequest)
+
+		assert.ErrorIs(t, err, errs.ErrMaxSlippage)
+	})
+}
+
+func TestBuyStockFee(t *testing.T) {
+	userRepo := repository.NewUserRepositoryDBMock()
+	stockRepo := repository.NewStockRepositoryDBMock()
+	ledgerRepo := repository.NewLedgerRepositoryDBMock()
+	feeSchedule := service.FeeSchedule{
+		Flat: 5,
+		Tiers: []model.FeeTier{
+			{MinVolume: 0, Percent: 0.002},
+			{MinVolume: 10000, Percent: 0.001},
+		},
+		TakerRate: 0.0005,
+	}
+	orderRequest := OrderRequest{
+		StockId:     "65c39a03dfb8060d99995934",
+		UserId:      "65c8993c48096b5150cee5d6",
+		Price:       100,
+		Amount:      10,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	}
+
+	stockRepo.On("GetStatus", orderRequest.StockId).Return(model.StockActive, nil)
+	stockRepo.On(
+		"GetTradingRule",
+		orderRequest.StockId,
+	).Return(TradingRule{}, nil)
+	stockRepo.On(
+		"GetPrice",
+		orderRequest.StockId,
+	).Return(100, nil)
+	stockRepo.On(
+		"GetCurrency",
+		orderRequest.StockId,
+	).Return(model.BaseCurrency, nil)
+	stockRepo.On(
+		"CreateStockOrder",
+		orderRequest.StockId,
+		mock.Anything,
+	).Return("Successfully created stock order", nil)
+	userRepo.On(
+		"GetTradingVolume",
+		orderRequest.UserId,
+		mock.Anything,
+	).Return(float64(20000), nil)
+
+	// 5 + 1000 * (0.001 + 0.0005)
+	expectedOrder := orderRequest
+	expectedOrder.Fee = 6.5
+	userRepo.On(
+		"Buy",
+		matchOrder(expectedOrder),
+	).Return("Successfully bought stock", nil)
+	ledgerRepo.On(
+		"Append",
+		mock.MatchedBy(func(transaction LedgerTransaction) bool {
+			userAccount := "user:65c8993c48096b5150cee5d6:cash"
+			return transaction.Method == "BUY" &&
+				assert.ObjectsAreEqual([]LedgerEntry{
+					{Account: userAccount, Debit: 1000},
+					{Account: model.LedgerMarketAccount, Credit: 1000},
+					{Account: userAccount, Debit: 6.5},
+					{Account: model.LedgerFeeAccount, Credit: 6.5},
+				}, transaction.Entries)
+		}),
+	).Return("Successfully appended ledger transaction", nil)
+	userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, feeSchedule, newCache())
+
+	_, err := userService.BuyStock(orderRequest)
+
+	assert.Empty(t, err)
+	userRepo.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
+}
+
+func TestBuyStockFx(t *testing.T) {
+	userRepo := repository.NewUserRepositoryDBMock()
+	stockRepo := repository.NewStockRepositoryDBMock()
+	ledgerRepo := repository.NewLedgerRepositoryDBMock()
+	orderRequest := OrderRequest{
+		StockId:     "65c39a03dfb8060d99995937",
+		UserId:      "65c8993c48096b5150cee5d6",
+		Price:       100,
+		Amount:      10,
+		OrderType:   "auto",
+		OrderMethod: "buy",
+	}
+
+	stockRepo.On("GetStatus", orderRequest.StockId).Return(model.StockActive, nil)
+	stockRepo.On(
+		"GetTradingRule",
+		orderRequest.StockId,
+	).Return(TradingRule{}, nil)
+	stockRepo.On(
+		"GetPrice",
+		orderRequest.StockId,
+	).Return(100, nil)
+	stockRepo.On(
+		"GetCurrency",
+		orderRequest.StockId,
+	).Return("USD", nil)
+	stockRepo.On(
+		"CreateStockOrder",
+		orderRequest.StockId,
+		mock.Anything,
+	).Return("Successfully created stock order", nil)
+
+	expectedOrder := orderRequest
+	expectedOrder.Currency = "THB"
+	expectedOrder.FxRate = 36.5
+	userRepo.On(
+		"Buy",
+		matchOrder(expectedOrder),
+	).Return("Successfully bought stock", nil)
+	ledgerRepo.On(
+		"Append",
+		mock.MatchedBy(func(transaction LedgerTransaction) bool {
+			return transaction.Currency == "THB" &&
+				assert.ObjectsAreEqual([]LedgerEntry{
+					{Account: "user:65c8993c48096b5150cee5d6:cash", Debit: 36500},
+					{Account: model.LedgerMarketAccount, Credit: 36500},
+				}, transaction.Entries)
+		}),
+	).Return("Successfully appended ledger transaction", nil)
+	userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+	_, err := userService.BuyStock(orderRequest)
+
+	assert.Empty(t, err)
+	userRepo.AssertExpectations(t)
+	ledgerRepo.AssertExpectations(t)
+
+	orderRequest.Currency = "EUR"
+	_, err = userService.BuyStock(orderRequest)
+
+	assert.ErrorIs(t, err, errs.ErrCurrency)
 }
 
 func TestSaleStock(t *testing.T) {
@@ -179,7 +635,7 @@ func TestSaleStock(t *testing.T) {
 			"Sale",
 			OrderRequest{},
 		).Return(expected, ErrUser)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.SaleStock(OrderRequest{})
 
@@ -187,6 +643,7 @@ func TestSaleStock(t *testing.T) {
 	})
 
 	t.Run("Sale stock", func(t *testing.T) {
+		cache := newCache()
 		orderRequest := model.OrderRequest{
 			StockId:     "65bf707e040d36a26f4bf523",
 			UserId:      "65c30de7b654c0e7bf938081",
@@ -196,16 +653,99 @@ func TestSaleStock(t *testing.T) {
 			OrderMethod: "sale",
 		}
 
+		stockRepo.On("GetStatus", "65bf707e040d36a26f4bf523").Return(model.StockActive, nil)
+		stockRepo.On(
+			"GetTradingRule",
+			"65bf707e040d36a26f4bf523",
+		).Return(TradingRule{}, nil)
+		stockRepo.On(
+			"GetPrice",
+			"65bf707e040d36a26f4bf523",
+		).Return(10, nil)
+		stockRepo.On(
+			"GetCurrency",
+			"65bf707e040d36a26f4bf523",
+		).Return(model.BaseCurrency, nil)
 		userRepo.On(
 			"Sale",
-			orderRequest,
+			matchOrder(orderRequest),
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		stockRepo.On(
+			"CreateStockOrder",
+			orderRequest.StockId,
+			mock.Anything,
+		).Return("Successfully created stock order", nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, cache)
+
+		keys := []string{
+			"balance:65c30de7b654c0e7bf938081",
+			"stockAmount:65c30de7b654c0e7bf938081:65bf707e040d36a26f4bf523",
+			"userHistory:65c30de7b654c0e7bf938081",
+			"userStockHistory:65c30de7b654c0e7bf938081:65bf707e040d36a26f4bf523",
+			"stockCollection:65bf707e040d36a26f4bf523",
+			"stockCollections",
+			"top10Stock",
+		}
+		seedCache(t, cache, keys...)
 
 		actual, err := userService.SaleStock(orderRequest)
 
 		assert.Empty(t, err)
 		assert.Equal(t, expected, actual)
+		assertCacheInvalidated(t, cache, keys...)
+	})
+	t.Run("Record trade", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
+		stockRepo := repository.NewStockRepositoryDBMock()
+		orderRequest := OrderRequest{
+			StockId:     "65bf707e040d36a26f4bf524",
+			UserId:      "65c30de7b654c0e7bf938081",
+			Price:       10,
+			Amount:      5,
+			OrderType:   "auto",
+			OrderMethod: "sale",
+		}
+
+		var userTradeId string
+		stockRepo.On("GetStatus", orderRequest.StockId).Return(model.StockActive, nil)
+		stockRepo.On(
+			"GetTradingRule",
+			orderRequest.StockId,
+		).Return(TradingRule{}, nil)
+		stockRepo.On(
+			"GetPrice",
+			orderRequest.StockId,
+		).Return(10, nil)
+		stockRepo.On(
+			"GetCurrency",
+			orderRequest.StockId,
+		).Return(model.BaseCurrency, nil)
+		userRepo.On(
+			"Sale",
+			mock.MatchedBy(func(actual OrderRequest) bool {
+				userTradeId = actual.TradeId
+				return len(actual.TradeId) > 0
+			}),
+		).Return(expected, nil)
+		stockRepo.On(
+			"CreateStockOrder",
+			orderRequest.StockId,
+			mock.MatchedBy(func(trade StockHistory) bool {
+				return trade.TradeId == userTradeId &&
+					trade.ID == orderRequest.UserId &&
+					trade.SellerId == orderRequest.UserId &&
+					trade.BuyerId == model.MarketCounterparty &&
+					trade.Amount == orderRequest.Amount &&
+					trade.Price == orderRequest.Price
+			}),
+		).Return("Successfully created stock order", nil)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
+
+		actual, err := userService.SaleStock(orderRequest)
+
+		assert.Empty(t, err)
+		assert.Equal(t, expected, actual)
+		stockRepo.AssertExpectations(t)
 	})
 }
 
@@ -217,7 +757,7 @@ func TestSetFavoriteStock(t *testing.T) {
 			"65c30de7b654c0e7bf938081",
 			"",
 		).Return(expected, ErrInvalidStock)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 		_, err := userService.SetFavoriteStock(
 			"65c30de7b654c0e7bf938081",
 			"",
@@ -232,7 +772,7 @@ func TestSetFavoriteStock(t *testing.T) {
 			"65c30de7b654c0e7bf938081",
 			"65bf707e040d36a26f4bf523",
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.SetFavoriteStock(
 			"65c30de7b654c0e7bf938081",
@@ -258,7 +798,7 @@ func TestGetUserBalanceHistory(t *testing.T) {
 			"",
 			uint(1),
 		).Return(expected, ErrOrderMethod)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.GetUserBalanceHistory(
 			"65c30de7b654c0e7bf938081",
@@ -277,7 +817,7 @@ func TestGetUserBalanceHistory(t *testing.T) {
 				"DEPOSIT",
 				uint(1),
 			).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.GetUserBalanceHistory(
 			"65c30de7b654c0e7bf938081",
@@ -292,11 +832,12 @@ func TestGetUserBalanceHistory(t *testing.T) {
 
 func TestGetUserBalance(t *testing.T) {
 	t.Run("Error invalid user", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
 		userRepo.On(
 			"GetBalance",
 			"65c30de7b654c0e7bf938081",
 		).Return(int(1), ErrUser)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.GetUserBalance("65c30de7b654c0e7bf938081")
 
@@ -304,16 +845,17 @@ func TestGetUserBalance(t *testing.T) {
 	})
 
 	t.Run("Get balance", func(t *testing.T) {
+		userRepo := repository.NewUserRepositoryDBMock()
 		userRepo.On(
 			"GetBalance",
 			"65c30de7b654c0e7bf938081",
 		).Return(int(1), nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.GetUserBalance("65c30de7b654c0e7bf938081")
 
 		assert.Empty(t, err)
-		assert.Equal(t, float64(0), actual)
+		assert.Equal(t, float64(1), actual)
 	})
 }
 
@@ -324,7 +866,7 @@ func GetUserFavoriteStock(t *testing.T) {
 			"GetFavorite",
 			"65c30de7b654c0e7bf938081",
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.GetUserFavoriteStock("65c30de7b654c0e7bf938081")
 
@@ -338,7 +880,7 @@ func GetUserFavoriteStock(t *testing.T) {
 			"",
 		).Return(expected, ErrUser)
 
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.GetUserFavoriteStock("")
 		assert.ErrorIs(t, err, ErrUser)
@@ -364,7 +906,7 @@ func TestGetUserAccount(t *testing.T) {
 			"GetAccount",
 			"65c30de7b654c0e7bf938081",
 		).Return(expetced, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.GetUserAccount("65c30de7b654c0e7bf938081")
 
@@ -377,7 +919,7 @@ func TestGetUserAccount(t *testing.T) {
 			"GetAccount",
 			"",
 		).Return(expetced, ErrUser)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.GetUserAccount("")
 
@@ -402,7 +944,7 @@ func TestGetUserTradingHistories(t *testing.T) {
 			"65c30de7b654c0e7bf938081",
 			uint(0),
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.GetUserTradingHistories(
 			"65c30de7b654c0e7bf938081",
@@ -419,7 +961,7 @@ func TestGetUserTradingHistories(t *testing.T) {
 			"",
 			uint(0),
 		).Return(expected, ErrUser)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.GetUserTradingHistories(
 			"",
@@ -448,7 +990,7 @@ func TestGetUserStockHistory(t *testing.T) {
 			"65c30de7b654c0e7bf938081",
 			uint(0),
 		).Return(expected, nil)
-		userRepo := service.NewUserService(userRepo, redisClient)
+		userRepo := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userRepo.GetUserStockHistory(
 			"65c30de7b654c0e7bf938081",
@@ -467,7 +1009,7 @@ func TestGetUserStockHistory(t *testing.T) {
 			"",
 			uint(0),
 		).Return(expected, ErrInvalidStock)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.GetUserStockHistory(
 			"65c30de7b654c0e7bf938081",
@@ -490,7 +1032,7 @@ func TestGetUserStockAmount(t *testing.T) {
 			"65c30de7b654c0e7bf938081",
 			"65c30de7b654c0e7bf938081",
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.GetUserStockAmount(
 			"65c30de7b654c0e7bf938081",
@@ -507,7 +1049,7 @@ func TestGetUserStockAmount(t *testing.T) {
 			"65c30de7b654c0e7bf938081",
 			"",
 		).Return(expected, ErrInvalidStock)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.GetUserStockAmount(
 			"65c30de7b654c0e7bf938081",
@@ -518,6 +1060,44 @@ func TestGetUserStockAmount(t *testing.T) {
 	})
 }
 
+func TestGetUserFeeSummary(t *testing.T) {
+	userRepo := repository.NewUserRepositoryDBMock()
+	feeSchedule := service.FeeSchedule{
+		Flat:    1,
+		Percent: 0.0015,
+		Tiers: []model.FeeTier{
+			{MinVolume: 0, Percent: 0.0015},
+			{MinVolume: 1000000, Percent: 0.001},
+		},
+		MakerRate: 0,
+		TakerRate: 0.0005,
+	}
+
+	userRepo.On(
+		"GetFeeSummary",
+		"65c8993c48096b5150cee5d6",
+	).Return(service.FeeSummary{TotalFee: 12.5, TradeCount: 3}, nil)
+	userRepo.On(
+		"GetTradingVolume",
+		"65c8993c48096b5150cee5d6",
+		mock.Anything,
+	).Return(float64(2000000), nil)
+	userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, feeSchedule, newCache())
+
+	actual, err := userService.GetUserFeeSummary("65c8993c48096b5150cee5d6")
+
+	assert.Empty(t, err)
+	assert.Equal(t, service.FeeSummary{
+		TotalFee:    12.5,
+		TradeCount:  3,
+		Volume30Day: 2000000,
+		FlatFee:     1,
+		Percent:     0.001,
+		MakerRate:   0,
+		TakerRate:   0.0005,
+	}, actual)
+}
+
 func TestDeleteFavoriteStock(t *testing.T) {
 	expected := "Successfully deleted favorite stock"
 
@@ -527,7 +1107,7 @@ func TestDeleteFavoriteStock(t *testing.T) {
 			"65c30de7b654c0e7bf938081",
 			"65c30de7b654c0e7bf938081",
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.DeleteFavoriteStock(
 			"65c30de7b654c0e7bf938081",
@@ -544,7 +1124,7 @@ func TestDeleteFavoriteStock(t *testing.T) {
 			"65c30de7b654c0e7bf938081",
 			"",
 		).Return(expected, ErrInvalidStock)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.DeleteFavoriteStock(
 			"65c30de7b654c0e7bf938081",
@@ -563,7 +1143,7 @@ func TestDeleteUserAccount(t *testing.T) {
 			"DeleteAccount",
 			"65c30de7b654c0e7bf938081",
 		).Return(expected, nil)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		actual, err := userService.DeleteUserAccount("65c30de7b654c0e7bf938081")
 
@@ -576,7 +1156,7 @@ func TestDeleteUserAccount(t *testing.T) {
 			"DeleteAccount",
 			"",
 		).Return(expected, ErrUser)
-		userService := service.NewUserService(userRepo, redisClient)
+		userService := service.NewUserService(userRepo, stockRepo, ledgerRepo, fxRateProvider, service.FeeSchedule{}, newCache())
 
 		_, err := userService.DeleteUserAccount("")
 
@@ -0,0 +1,23 @@
+package util
+
+import "math"
+
+// AveragePrice is the open price of a position after a signed trade of
+// change (above zero for buy), closing keeps the price and crossing zero
+// opens a new position at the trade price
+func AveragePrice(amount float64, averagePrice float64, change float64, price float64) float64 {
+	newAmount := amount + change
+	if newAmount == 0 {
+		return 0
+	}
+
+	if amount == 0 || math.Signbit(amount) == math.Signbit(change) {
+		return (amount*averagePrice + change*price) / newAmount
+	}
+
+	if math.Signbit(amount) == math.Signbit(newAmount) {
+		return averagePrice
+	}
+
+	return price
+}
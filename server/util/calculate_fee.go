@@ -0,0 +1,50 @@
+package util
+
+import (
+	"math"
+	"server/model"
+)
+
+type FeeSchedule = model.FeeSchedule
+
+func FeePercent(schedule FeeSchedule, volume30Day float64) float64 {
+	if len(schedule.Tiers) == 0 {
+		return schedule.Percent
+	}
+
+	percent := 0.0
+	minVolume := -1.0
+	for _, tier := range schedule.Tiers {
+		if tier.MinVolume <= volume30Day && tier.MinVolume > minVolume {
+			percent = tier.Percent
+			minVolume = tier.MinVolume
+		}
+	}
+
+	return percent
+}
+
+func CalculateFee(schedule FeeSchedule, orderType string, value float64, volume30Day float64) float64 {
+	fee := schedule.Flat + value*orderFeePercent(schedule, orderType, volume30Day)
+
+	return math.Round(fee*100) / 100
+}
+
+// the largest order value that the cash pays for with the fee of the order
+func ValueAfterFee(schedule FeeSchedule, orderType string, cash float64, volume30Day float64) float64 {
+	value := (cash - schedule.Flat) / (1 + orderFeePercent(schedule, orderType, volume30Day))
+	if value < 0 {
+		return 0
+	}
+
+	return value
+}
+
+func orderFeePercent(schedule FeeSchedule, orderType string, volume30Day float64) float64 {
+	percent := FeePercent(schedule, volume30Day)
+	if orderType == "order" {
+		return percent + schedule.MakerRate
+	}
+
+	return percent + schedule.TakerRate
+}
@@ -0,0 +1,166 @@
+package util
+
+import (
+	"math"
+	"server/model"
+	"sort"
+)
+
+type QueuedOrder = model.QueuedOrder
+
+// a buy and a sale matched with each other, the amount of each order is
+// what is left of it before the match
+type AuctionMatch struct {
+	Buy    QueuedOrder
+	Sale   QueuedOrder
+	Amount float64
+}
+
+type auctionFill struct {
+	order  QueuedOrder
+	amount float64
+}
+
+// the price that matches the most volume, ties go to the least imbalance,
+// then to the closest to the reference price and then to the lower price.
+// auto orders take any price, a price is only found among limit prices so
+// an auction of auto orders only is uncrossed at the reference price
+func EquilibriumPrice(orders []QueuedOrder, reference float64) (float64, float64) {
+	prices := []float64{}
+	for _, order := range orders {
+		if isLimitOrder(order) {
+			prices = append(prices, order.Price)
+		}
+	}
+
+	if len(prices) == 0 && reference > 0 {
+		prices = append(prices, reference)
+	}
+
+	bestPrice, bestVolume, bestImbalance := 0.0, 0.0, 0.0
+	for _, price := range prices {
+		demand, supply := auctionDepth(orders, price)
+		volume := math.Min(demand, supply)
+		imbalance := math.Abs(demand - supply)
+
+		better := volume > bestVolume ||
+			volume == bestVolume && imbalance < bestImbalance ||
+			volume == bestVolume && imbalance == bestImbalance && closerPrice(price, bestPrice, reference)
+		if bestPrice == 0 || better {
+			bestPrice, bestVolume, bestImbalance = price, volume, imbalance
+		}
+	}
+
+	if bestVolume == 0 {
+		return 0, 0
+	}
+
+	return bestPrice, bestVolume
+}
+
+// each side is filled up to volume, auto orders first, then the better
+// limit price and then the older order, an order is filled in whole lots.
+// the fills of both sides are paired in that order
+func MatchAuction(orders []QueuedOrder, price float64, volume float64, rule TradingRule) []AuctionMatch {
+	buys, sales := []QueuedOrder{}, []QueuedOrder{}
+	for _, order := range orders {
+		if order.OrderMethod == "buy" && (!isLimitOrder(order) || order.Price >= price) {
+			buys = append(buys, order)
+		}
+
+		if order.OrderMethod == "sale" && (!isLimitOrder(order) || order.Price <= price) {
+			sales = append(sales, order)
+		}
+	}
+
+	sortAuctionOrders(buys, func(a float64, b float64) bool { return a > b })
+	sortAuctionOrders(sales, func(a float64, b float64) bool { return a < b })
+
+	buyFills := allocateSide(buys, volume, rule)
+	saleFills := allocateSide(sales, volume, rule)
+
+	matches := []AuctionMatch{}
+	for i, j := 0, 0; i < len(buyFills) && j < len(saleFills); {
+		buy, sale := &buyFills[i], &saleFills[j]
+		amount := math.Min(buy.amount, sale.amount)
+		matches = append(matches, AuctionMatch{buy.order, sale.order, amount})
+
+		buy.amount -= amount
+		buy.order.Amount -= amount
+		sale.amount -= amount
+		sale.order.Amount -= amount
+		if buy.amount < 1e-9 {
+			i++
+		}
+		if sale.amount < 1e-9 {
+			j++
+		}
+	}
+
+	return matches
+}
+
+func auctionDepth(orders []QueuedOrder, price float64) (float64, float64) {
+	demand, supply := 0.0, 0.0
+	for _, order := range orders {
+		switch order.OrderMethod {
+		case "buy":
+			if !isLimitOrder(order) || order.Price >= price {
+				demand += order.Amount
+			}
+		case "sale":
+			if !isLimitOrder(order) || order.Price <= price {
+				supply += order.Amount
+			}
+		}
+	}
+
+	return demand, supply
+}
+
+func allocateSide(orders []QueuedOrder, volume float64, rule TradingRule) []auctionFill {
+	fills := []auctionFill{}
+	for _, order := range orders {
+		if volume <= 0 {
+			break
+		}
+
+		amount := roundDownLot(rule, math.Min(order.Amount, volume))
+		if amount <= 0 {
+			continue
+		}
+
+		fills = append(fills, auctionFill{order, amount})
+		volume -= amount
+	}
+
+	return fills
+}
+
+func sortAuctionOrders(orders []QueuedOrder, betterPrice func(float64, float64) bool) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		if isLimitOrder(orders[i]) != isLimitOrder(orders[j]) {
+			return !isLimitOrder(orders[i])
+		}
+
+		if orders[i].Price != orders[j].Price && isLimitOrder(orders[i]) {
+			return betterPrice(orders[i].Price, orders[j].Price)
+		}
+
+		return orders[i].CreatedAt < orders[j].CreatedAt
+	})
+}
+
+func closerPrice(price float64, other float64, reference float64) bool {
+	distance := math.Abs(price - reference)
+	otherDistance := math.Abs(other - reference)
+	if distance != otherDistance {
+		return distance < otherDistance
+	}
+
+	return price < other
+}
+
+func isLimitOrder(order QueuedOrder) bool {
+	return order.OrderType == "order"
+}
@@ -0,0 +1,60 @@
+package util
+
+import "server/errs"
+
+const DefaultMaxSlippage = 0.01
+
+// auto order is filled at market price when it moved against the quoted
+// price by no more than maxSlippage (DefaultMaxSlippage when nil, so zero
+// slippage can be asked for), order (limit) is filled at market price when
+// the limit is reached
+func GetFillPrice(
+	orderType string,
+	orderMethod string,
+	orderPrice float64,
+	marketPrice float64,
+	maxSlippage *float64,
+) (float64, error) {
+	if orderPrice <= 0 {
+		return 0, errs.ErrData
+	}
+
+	if marketPrice <= 0 {
+		return 0, errs.ErrPrice
+	}
+
+	slippage := DefaultMaxSlippage
+	if maxSlippage != nil {
+		slippage = *maxSlippage
+	}
+
+	if slippage < 0 || slippage > 1 {
+		return 0, errs.ErrMaxSlippage
+	}
+
+	switch orderType {
+	case "auto":
+		move := (marketPrice - orderPrice) / orderPrice
+		if orderMethod == "sale" {
+			move = -move
+		} else if orderMethod != "buy" && move < 0 {
+			move = -move
+		}
+
+		if move > slippage {
+			return 0, errs.ErrSlippage
+		}
+	case "order":
+		if orderMethod == "buy" && marketPrice > orderPrice {
+			return 0, errs.ErrLimitPrice
+		}
+
+		if orderMethod == "sale" && marketPrice < orderPrice {
+			return 0, errs.ErrLimitPrice
+		}
+	default:
+		return 0, errs.ErrOrderType
+	}
+
+	return marketPrice, nil
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"math"
+	"server/errs"
+	"server/model"
+)
+
+type TradingRule = model.TradingRule
+
+// zero value of each field disables that check, so stocks created
+// before trading rules existed keep accepting any order
+func CheckTradingRule(rule TradingRule, price float64, amount float64) error {
+	if rule.TickSize > 0 && !isMultiple(price, rule.TickSize) {
+		return errs.ErrTickSize
+	}
+
+	if !rule.FractionalShare && rule.LotSize > 0 && !isMultiple(amount, rule.LotSize) {
+		return errs.ErrLotSize
+	}
+
+	if rule.MinQuantity > 0 && amount < rule.MinQuantity {
+		return errs.ErrMinQuantity
+	}
+
+	if rule.MaxQuantity > 0 && amount > rule.MaxQuantity {
+		return errs.ErrMaxQuantity
+	}
+
+	if rule.MinNotional > 0 && price*amount < rule.MinNotional {
+		return errs.ErrMinNotional
+	}
+
+	return nil
+}
+
+func ValidTradingRule(rule TradingRule) bool {
+	if rule.TickSize < 0 ||
+		rule.LotSize < 0 ||
+		rule.MinQuantity < 0 ||
+		rule.MaxQuantity < 0 ||
+		rule.MinNotional < 0 {
+		return false
+	}
+
+	if rule.MaxQuantity > 0 && rule.MaxQuantity < rule.MinQuantity {
+		return false
+	}
+
+	initialMargin, maintenanceMargin := MarginRates(rule)
+	if rule.BorrowRate < 0 ||
+		rule.InitialMargin < 0 ||
+		rule.MaintenanceMargin < 0 ||
+		initialMargin > 1 ||
+		maintenanceMargin > initialMargin {
+		return false
+	}
+
+	if rule.LimitUp < 0 ||
+		rule.LimitDown < 0 ||
+		rule.LimitDown >= 1 ||
+		rule.HaltDuration < 0 {
+		return false
+	}
+
+	return true
+}
+
+// zero initial margin is 1, zero maintenance margin is the initial margin
+func MarginRates(rule TradingRule) (float64, float64) {
+	initialMargin := rule.InitialMargin
+	if initialMargin == 0 {
+		initialMargin = 1
+	}
+
+	maintenanceMargin := rule.MaintenanceMargin
+	if maintenanceMargin == 0 {
+		maintenanceMargin = initialMargin
+	}
+
+	return initialMargin, maintenanceMargin
+}
+
+func isMultiple(value float64, step float64) bool {
+	quotient := value / step
+	return math.Abs(quotient-math.Round(quotient)) < 1e-6
+}
+
+// the amount in whole lots of the trading rule
+func roundDownLot(rule TradingRule, amount float64) float64 {
+	if rule.FractionalShare || rule.LotSize <= 0 {
+		return amount
+	}
+
+	return math.Floor(amount/rule.LotSize+1e-9) * rule.LotSize
+}
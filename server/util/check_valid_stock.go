@@ -11,7 +11,7 @@ import (
 
 type UserStock = model.UserStock
 
-func CheckValidStock(db *mongo.Collection, userId string, stockId string) (bool, UserStock, float64, error) {
+func CheckValidStock(db *mongo.Collection, userId string, stockId string, currency string) (bool, UserStock, float64, error) {
 	filter := bson.M{
 		"uid": userId,
 	}
@@ -21,11 +21,11 @@ func CheckValidStock(db *mongo.Collection, userId string, stockId string) (bool,
 	ctx := context.Background()
 	err := db.FindOne(ctx, filter).Decode(&userAccount)
 	if err != nil {
-		return validStock, UserStock{}, userAccount.Balance, err
+		return validStock, UserStock{}, AccountBalance(userAccount, currency), err
 	}
 
 	if len(userAccount.Stock) == 0 {
-		return validStock, UserStock{}, userAccount.Balance, nil
+		return validStock, UserStock{}, AccountBalance(userAccount, currency), nil
 	}
 
 	var userStock UserStock
@@ -37,5 +37,5 @@ func CheckValidStock(db *mongo.Collection, userId string, stockId string) (bool,
 		}
 	}
 
-	return validStock, userStock, userAccount.Balance, nil
+	return validStock, userStock, AccountBalance(userAccount, currency), nil
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+package util
+
+import "server/model"
+
+var corporateActionTransitions = map[string][]string{
+	model.CorporateActionScheduled: {model.CorporateActionApplying, model.CorporateActionCancelled},
+	model.CorporateActionApplying:  {model.CorporateActionApplied, model.CorporateActionFailed},
+	model.CorporateActionFailed:    {model.CorporateActionApplying},
+}
+
+func CanTransitCorporateAction(from string, to string) bool {
+	for _, status := range corporateActionTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
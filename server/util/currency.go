@@ -0,0 +1,48 @@
+package util
+
+import (
+	"fmt"
+	"server/model"
+	"strings"
+)
+
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) == 0 {
+		return model.BaseCurrency
+	}
+
+	return currency
+}
+
+// ISO 4217 style code
+func ValidCurrency(currency string) bool {
+	if len(currency) != 3 {
+		return false
+	}
+
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// base currency is kept in balance for the existing accounts
+func BalanceField(currency string) string {
+	if currency == model.BaseCurrency {
+		return "balance"
+	}
+
+	return fmt.Sprintf("balances.%s", currency)
+}
+
+func AccountBalance(userAccount UserAccount, currency string) float64 {
+	if currency == model.BaseCurrency {
+		return userAccount.Balance
+	}
+
+	return userAccount.Balances[currency]
+}
@@ -0,0 +1,18 @@
+package util
+
+import "server/model"
+
+var dividendTransitions = map[string][]string{
+	model.DividendDeclared: {model.DividendRecorded, model.DividendCancelled},
+	model.DividendRecorded: {model.DividendPaid},
+}
+
+func CanTransitDividend(from string, to string) bool {
+	for _, status := range dividendTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package util
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"server/errs"
+	"server/model"
+	"slices"
+)
+
+// the image is read up to the size limit and its type and dimensions are
+// checked before it is decoded
+func ReadImage(r io.Reader) ([]byte, image.Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, model.MaxImageBytes+1))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(data) > model.MaxImageBytes {
+		return nil, nil, errs.ErrImageSize
+	}
+
+	if !slices.Contains(model.ImageTypes, http.DetectContentType(data)) {
+		return nil, nil, errs.ErrImageType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, errs.ErrImageType
+	}
+
+	if config.Width < model.MinImageDimension || config.Height < model.MinImageDimension ||
+		config.Width > model.MaxImageDimension || config.Height > model.MaxImageDimension {
+		return nil, nil, errs.ErrImageDimension
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, errs.ErrImageType
+	}
+
+	return data, img, nil
+}
+
+// the center square of the image scaled to size, every pixel is the average
+// of the source pixels it covers
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	left := bounds.Min.X + (bounds.Dx()-side)/2
+	top := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	thumbnail := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		y0, y1 := scaleRange(y, side, size)
+		for x := 0; x < size; x++ {
+			x0, x1 := scaleRange(x, side, size)
+
+			var r, g, b, a, n uint64
+			for sy := top + y0; sy < top+y1; sy++ {
+				for sx := left + x0; sx < left+x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+
+			i := thumbnail.PixOffset(x, y)
+			if a == 0 {
+				continue
+			}
+
+			// nrgba is not premultiplied by alpha
+			thumbnail.Pix[i] = uint8(r * 0xff / a)
+			thumbnail.Pix[i+1] = uint8(g * 0xff / a)
+			thumbnail.Pix[i+2] = uint8(b * 0xff / a)
+			thumbnail.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return thumbnail
+}
+
+func EncodeThumbnail(img image.Image) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// source pixels [from, to) of the target pixel i, at least one
+func scaleRange(i int, source int, target int) (int, int) {
+	from := i * source / target
+	to := (i + 1) * source / target
+	if to <= from {
+		to = from + 1
+	}
+
+	return from, to
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"fmt"
+	"server/model"
+	"strings"
+)
+
+const userCashAccountPrefix = "user:"
+const userCashAccountSuffix = ":cash"
+
+func UserCashAccount(uid string) string {
+	return fmt.Sprintf("%s%s%s", userCashAccountPrefix, uid, userCashAccountSuffix)
+}
+
+// the uid and the currency of a user cash account, "user:<uid>:cash" is in
+// base currency and "user:<uid>:cash:<CCY>" in the suffixed one
+func UserFromCashAccount(account string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(account, userCashAccountPrefix)
+	if !ok {
+		return "", "", false
+	}
+
+	if uid, ok := strings.CutSuffix(rest, userCashAccountSuffix); ok {
+		return uid, model.BaseCurrency, len(uid) > 0
+	}
+
+	index := strings.LastIndex(rest, userCashAccountSuffix+":")
+	if index < 0 {
+		return "", "", false
+	}
+
+	uid := rest[:index]
+	currency := rest[index+len(userCashAccountSuffix)+1:]
+	if len(uid) == 0 || currency == model.BaseCurrency || !ValidCurrency(currency) {
+		return "", "", false
+	}
+
+	return uid, currency, true
+}
+
+// accounts of other currencies are suffixed so each account holds one currency
+func CurrencyAccount(account string, currency string) string {
+	if currency == model.BaseCurrency {
+		return account
+	}
+
+	return fmt.Sprintf("%s:%s", account, currency)
+}
@@ -0,0 +1,128 @@
+package util
+
+import (
+	"server/model"
+	"time"
+)
+
+type MarketCalendar = model.MarketCalendar
+type MarketSchedule = model.MarketSchedule
+
+var queuedOrderTransitions = map[string][]string{
+	model.QueuedOrderQueued:  {model.QueuedOrderFilling, model.QueuedOrderCancelled},
+	model.QueuedOrderFilling: {model.QueuedOrderFilled, model.QueuedOrderFailed},
+}
+
+func CanTransitQueuedOrder(from string, to string) bool {
+	for _, status := range queuedOrderTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// the schedule of the stock with its override applied
+func StockSchedule(calendar MarketCalendar, stockId string) MarketSchedule {
+	schedule := calendar.MarketSchedule
+
+	override, ok := calendar.Stocks[stockId]
+	if !ok {
+		return schedule
+	}
+
+	if len(override.Weekdays) > 0 {
+		schedule.Weekdays = override.Weekdays
+	}
+
+	if len(override.Sessions) > 0 {
+		schedule.Sessions = override.Sessions
+	}
+
+	schedule.Holidays = append(append([]string{}, schedule.Holidays...), override.Holidays...)
+
+	return schedule
+}
+
+// now is in the time zone of the market
+func GetMarketSession(schedule MarketSchedule, now time.Time) string {
+	for _, holiday := range schedule.Holidays {
+		if holiday == now.Format(time.DateOnly) {
+			return model.SessionClosed
+		}
+	}
+
+	if len(schedule.Weekdays) > 0 && !containsWeekday(schedule.Weekdays, now.Weekday()) {
+		return model.SessionClosed
+	}
+
+	if len(schedule.Sessions) == 0 {
+		return model.SessionContinuous
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	for _, session := range schedule.Sessions {
+		start, _ := clockMinute(session.Start)
+		end, _ := clockMinute(session.End)
+		if minute >= start && minute < end {
+			return session.Session
+		}
+	}
+
+	return model.SessionClosed
+}
+
+func ValidMarketSchedule(schedule MarketSchedule) bool {
+	for _, weekday := range schedule.Weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return false
+		}
+	}
+
+	for _, session := range schedule.Sessions {
+		switch session.Session {
+		case model.SessionPreOpen, model.SessionContinuous, model.SessionClosing:
+		default:
+			return false
+		}
+
+		start, startErr := clockMinute(session.Start)
+		end, endErr := clockMinute(session.End)
+		if startErr != nil || endErr != nil || start >= end {
+			return false
+		}
+	}
+
+	for _, holiday := range schedule.Holidays {
+		if _, err := time.Parse(time.DateOnly, holiday); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, day := range weekdays {
+		if day == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// minute of the day of 15:04, 24:00 is the end of the day
+func clockMinute(clock string) (int, error) {
+	if clock == "24:00" {
+		return 24 * 60, nil
+	}
+
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
@@ -0,0 +1,19 @@
+package util
+
+import "server/model"
+
+var paymentTransitions = map[string][]string{
+	model.PaymentRequested: {model.PaymentPending, model.PaymentFailed},
+	model.PaymentPending:   {model.PaymentCompleted, model.PaymentFailed},
+	model.PaymentCompleted: {model.PaymentReversed},
+}
+
+func CanTransitPayment(from string, to string) bool {
+	for _, status := range paymentTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
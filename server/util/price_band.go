@@ -0,0 +1,34 @@
+package util
+
+import "server/errs"
+
+const DefaultHaltDuration = 5 * 60
+
+// a stock without a previous close has no band yet
+func CheckPriceBand(rule TradingRule, previousClose float64, price float64) error {
+	if previousClose <= 0 {
+		return nil
+	}
+
+	if rule.LimitUp > 0 && price > previousClose*(1+rule.LimitUp) {
+		return errs.ErrPriceBand
+	}
+
+	if rule.LimitDown > 0 && price < previousClose*(1-rule.LimitDown) {
+		return errs.ErrPriceBand
+	}
+
+	return nil
+}
+
+func HasPriceBand(rule TradingRule) bool {
+	return rule.LimitUp > 0 || rule.LimitDown > 0
+}
+
+func HaltDuration(rule TradingRule) int64 {
+	if rule.HaltDuration == 0 {
+		return DefaultHaltDuration
+	}
+
+	return rule.HaltDuration
+}
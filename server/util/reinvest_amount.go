@@ -0,0 +1,19 @@
+package util
+
+import "math"
+
+// the largest amount the cash buys at the price that the trading rule
+// accepts, zero when the cash is not enough for one lot
+func ReinvestAmount(rule TradingRule, cash float64, price float64) float64 {
+	if cash <= 0 || price <= 0 {
+		return 0
+	}
+
+	amount := roundDownLot(rule, math.Floor(cash/price*1e6)/1e6)
+
+	if amount <= 0 || CheckTradingRule(rule, price, amount) != nil {
+		return 0
+	}
+
+	return amount
+}
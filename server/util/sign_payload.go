@@ -0,0 +1,18 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+func SignPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyPayload(secret string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(SignPayload(secret, payload)), []byte(signature))
+}
@@ -0,0 +1,28 @@
+package util
+
+import "server/model"
+
+var stockTransitions = map[string][]string{
+	model.StockActive:    {model.StockHalted, model.StockSuspended, model.StockDelisted},
+	model.StockHalted:    {model.StockActive, model.StockSuspended, model.StockDelisted},
+	model.StockSuspended: {model.StockActive, model.StockHalted, model.StockDelisted},
+}
+
+// stocks created before the status existed are active
+func StockStatus(status string) string {
+	if len(status) == 0 {
+		return model.StockActive
+	}
+
+	return status
+}
+
+func CanTransitStock(from string, to string) bool {
+	for _, status := range stockTransitions[StockStatus(from)] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,9 @@
+package util
+
+import "math"
+
+// the whole shares of the amount rounded toward zero, an amount a hair
+// below a whole number after a split keeps that share
+func WholeShares(amount float64) float64 {
+	return math.Copysign(math.Floor(math.Abs(amount)+1e-6), amount)
+}
@@ -22,13 +22,15 @@ func NewStockWebsocket(stockService service.StockService) stockWebsocket {
 }
 
 type connection struct {
-	ws   *websocket.Conn
-	send chan []byte
+	ws     *websocket.Conn
+	send   chan []byte
+	events chan []byte
 }
 
 type subscription struct {
 	conn *connection
 	room string
+	hub  *Hub
 }
 
 var upgrader = websocket.Upgrader{
@@ -53,7 +55,7 @@ var (
 func (s *subscription) readPump() {
 	c := s.conn
 	defer func() {
-		H.unregister <- *s
+		s.hub.unregister <- *s
 		c.ws.Close()
 	}()
 
@@ -72,11 +74,10 @@ func (s *subscription) readPump() {
 			}
 			break
 		}
-		fmt.Println("Received message from client:", string(msg))
 
 		msg = bytes.TrimSpace(bytes.Replace(msg, newline, space, -1))
 		m := message{s.room, msg}
-		H.broadcast <- m
+		s.hub.broadcast <- m
 	}
 }
 
@@ -120,6 +121,11 @@ func (s *subscription) writePrice(h stockWebsocket) {
 				return
 			}
 
+		case data := <-c.events:
+			if err := c.write(websocket.TextMessage, data); err != nil {
+				return
+			}
+
 		case <-ticker.C:
 			if err := c.write(websocket.PingMessage, []byte{}); err != nil {
 				return
@@ -224,8 +230,8 @@ func (h stockWebsocket) ServePriceWs(hub *Hub, w http.ResponseWriter, r *http.Re
 		log.Println(err)
 		return
 	}
-	c := &connection{ws, make(chan []byte, 256)}
-	s := subscription{c, roomId}
+	c := &connection{ws, make(chan []byte, 256), make(chan []byte, 16)}
+	s := subscription{c, roomId, hub}
 
 	s.room = fmt.Sprintf("price-%s", strings.Trim(s.room, " "))
 	hub.register <- s
@@ -254,8 +260,8 @@ func (h stockWebsocket) ServeTransactionWs(hub *Hub, w http.ResponseWriter, r *h
 		log.Println(err)
 		return
 	}
-	c := &connection{ws, make(chan []byte, 256)}
-	s := subscription{c, roomId}
+	c := &connection{ws, make(chan []byte, 256), make(chan []byte, 16)}
+	s := subscription{c, roomId, hub}
 
 	s.room = fmt.Sprintf("tx-%s", strings.Trim(s.room, " "))
 	hub.register <- s
@@ -284,8 +290,8 @@ func (h stockWebsocket) ServeGraphWs(hub *Hub, w http.ResponseWriter, r *http.Re
 		log.Println(err)
 		return
 	}
-	c := &connection{ws, make(chan []byte, 256)}
-	s := subscription{c, roomId}
+	c := &connection{ws, make(chan []byte, 256), make(chan []byte, 16)}
+	s := subscription{c, roomId, hub}
 
 	s.room = fmt.Sprintf("graph-%s", strings.Trim(s.room, " "))
 	hub.register <- s
@@ -1,13 +1,18 @@
 package wshandler
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"server/model"
+	"server/service"
+	"time"
 )
 
 type Hub struct {
 	rooms       map[string]map[*connection]bool
 	broadcast   chan message
+	events      chan message
 	register    chan subscription
 	unregister  chan subscription
 	activeConns map[string]int
@@ -18,12 +23,15 @@ type message struct {
 	Data []byte `json:"data"`
 }
 
-var H = &Hub{
-	broadcast:   make(chan message),
-	register:    make(chan subscription),
-	unregister:  make(chan subscription),
-	rooms:       make(map[string]map[*connection]bool),
-	activeConns: make(map[string]int),
+func NewHub() *Hub {
+	return &Hub{
+		broadcast:   make(chan message),
+		events:      make(chan message),
+		register:    make(chan subscription),
+		unregister:  make(chan subscription),
+		rooms:       make(map[string]map[*connection]bool),
+		activeConns: make(map[string]int),
+	}
 }
 
 func (h *Hub) Run() {
@@ -41,7 +49,6 @@ func (h *Hub) Run() {
 			log.Println("connection", h.activeConns)
 
 		case s := <-h.unregister:
-			fmt.Println(s.room)
 			h.activeConns[s.room]--
 			if h.activeConns[s.room] == 0 {
 				delete(h.rooms, s.room)
@@ -60,6 +67,64 @@ func (h *Hub) Run() {
 					h.activeConns[m.Room]--
 				}
 			}
+
+		case m := <-h.events:
+			for c := range h.rooms[m.Room] {
+				select {
+				case c.events <- m.Data:
+				default:
+				}
+			}
 		}
 	}
-}
\ No newline at end of file
+}
+
+// SubscribeStockEvents forwards every stock status and market session change
+// published by the services to the price channel of the stock
+func (h *Hub) SubscribeStockEvents(cache service.Cache) {
+	messages, unsubscribe := cache.Subscribe(
+		model.StockStatusChannel,
+		model.MarketSessionChannel,
+	)
+	defer unsubscribe()
+
+	for msg := range messages {
+		stockId, event, err := decodeStockEvent(msg)
+		if err != nil {
+			log.Printf("stock event: %v", err)
+			continue
+		}
+
+		room := fmt.Sprintf("price-%s", stockId)
+		data, err := json.Marshal(map[string]interface{}{
+			"time":    time.Now().Format("15:04:05 | 2006-01-02"),
+			"room":    room,
+			event.key: event.value,
+		})
+		if err != nil {
+			log.Printf("stock event: %v", err)
+			continue
+		}
+
+		h.events <- message{room, data}
+	}
+}
+
+type stockEvent struct {
+	key   string
+	value interface{}
+}
+
+func decodeStockEvent(msg model.CacheMessage) (string, stockEvent, error) {
+	if msg.Channel == model.MarketSessionChannel {
+		var sessionMessage model.MarketSessionMessage
+		err := json.Unmarshal([]byte(msg.Payload), &sessionMessage)
+
+		return sessionMessage.StockId, stockEvent{"session", sessionMessage}, err
+	}
+
+	var statusMessage model.StockStatusMessage
+	err := json.Unmarshal([]byte(msg.Payload), &statusMessage)
+
+	return statusMessage.StockId, stockEvent{"status", statusMessage.StockStatusEvent}, err
+}